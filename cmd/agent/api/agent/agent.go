@@ -58,6 +58,8 @@ func SetupHandlers(r *mux.Router) *mux.Router {
 	r.HandleFunc("/status", getStatus).Methods("GET")
 	r.HandleFunc("/stream-logs", streamLogs).Methods("POST")
 	r.HandleFunc("/dogstatsd-stats", getDogstatsdStats).Methods("GET")
+	r.HandleFunc("/dogstatsd-origin-stats", getDogstatsdOriginStats).Methods("GET")
+	r.HandleFunc("/dogstatsd-capture", captureDogstatsdTraffic).Methods("POST")
 	r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
 	r.HandleFunc("/status/health", getHealth).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusGetterHandler).Methods("GET")
@@ -302,6 +304,98 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonStats)
 }
 
+func getDogstatsdOriginStats(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for the Dogstatsd origin stats.")
+
+	if !config.Datadog.GetBool("use_dogstatsd") {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd not enabled in the Agent configuration",
+			"error_type": "no server",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
+	if !config.Datadog.GetBool("dogstatsd_origin_stats_enable") {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd origin stats not enabled in the Agent configuration",
+			"error_type": "not enabled",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
+	// Weird state that should not happen: dogstatsd is enabled
+	// but the server has not been successfully initialized.
+	// Return no data.
+	if common.DSD == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+		return
+	}
+
+	jsonStats, err := common.DSD.GetJSONOriginStats()
+	if err != nil {
+		log.Errorf("Error getting marshalled Dogstatsd origin stats: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonStats)
+}
+
+func captureDogstatsdTraffic(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for a Dogstatsd traffic capture.")
+
+	if !config.Datadog.GetBool("use_dogstatsd") || common.DSD == nil {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd not enabled in the Agent configuration",
+			"error_type": "no server",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
+	var request struct {
+		Duration string `json:"duration"`
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, log.Errorf("Error while reading HTTP request body: %s", err).Error(), 500)
+		return
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, log.Errorf("Error while unmarshaling JSON from request body: %s", err).Error(), 400)
+		return
+	}
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid capture duration: %v", err)})
+		http.Error(w, string(body), 400)
+		return
+	}
+
+	path, err := common.DSD.Capture(duration)
+	if err != nil {
+		log.Errorf("Error starting the Dogstatsd traffic capture: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	body, _ = json.Marshal(map[string]string{"path": path})
+	w.Write(body)
+}
+
 func getFormattedStatus(w http.ResponseWriter, r *http.Request) {
 	log.Info("Got a request for the formatted status. Making formatted status.")
 	s, err := status.GetAndFormatStatus()
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dsdCaptureDuration time.Duration
+	dsdReplayFilePath  string
+	dsdReplaySocket    string
+	dsdReplaySpeed     float64
+)
+
+func init() {
+	AgentCmd.AddCommand(dogstatsdCaptureCmd)
+	dogstatsdCaptureCmd.Flags().DurationVarP(&dsdCaptureDuration, "duration", "d", time.Minute, "duration of the capture")
+
+	AgentCmd.AddCommand(dogstatsdReplayCmd)
+	dogstatsdReplayCmd.Flags().StringVarP(&dsdReplayFilePath, "file", "f", "", "capture file to replay")
+	dogstatsdReplayCmd.Flags().StringVarP(&dsdReplaySocket, "socket", "s", "", "socket to send the packets to, dogstatsd_socket by default")
+	dogstatsdReplayCmd.Flags().Float64Var(&dsdReplaySpeed, "speed", 1, "replay speed, 2 replays the capture twice as fast as it has been recorded, 0 as fast as possible")
+}
+
+var dogstatsdCaptureCmd = &cobra.Command{
+	Use:   "dogstatsd-capture",
+	Short: "Record the packets received by dogstatsd in a capture file",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return requestDogstatsdCapture()
+	},
+}
+
+var dogstatsdReplayCmd = &cobra.Command{
+	Use:   "dogstatsd-replay",
+	Short: "Replay a dogstatsd capture file into a running agent",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return replayDogstatsdCapture()
+	},
+}
+
+func requestDogstatsdCapture() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/dogstatsd-capture", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	if err := util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]string{"duration": dsdCaptureDuration.String()})
+	r, err := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer(body))
+	var resp = make(map[string]string)
+	json.Unmarshal(r, &resp) //nolint:errcheck
+	if err != nil {
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := resp["error"]; found {
+			err = fmt.Errorf(e)
+		}
+
+		if len(resp["error_type"]) > 0 {
+			fmt.Println(err)
+			return nil
+		}
+
+		fmt.Printf("Could not start the capture: %v \nMake sure the agent is running before requesting a dogstatsd capture and contact support if you continue having issues. \n", err)
+		return err
+	}
+
+	fmt.Printf("Capturing the dogstatsd traffic for %s in %s\n", dsdCaptureDuration, resp["path"])
+	return nil
+}
+
+func replayDogstatsdCapture() error {
+	if dsdReplayFilePath == "" {
+		return fmt.Errorf("a capture file must be set with --file")
+	}
+	if dsdReplaySpeed < 0 {
+		return fmt.Errorf("invalid replay speed %v", dsdReplaySpeed)
+	}
+	socketPath := dsdReplaySocket
+	if socketPath == "" {
+		socketPath = config.Datadog.GetString("dogstatsd_socket")
+	}
+	if socketPath == "" {
+		return fmt.Errorf("dogstatsd_socket is not set, the socket to send the packets to must be set with --socket")
+	}
+
+	reader, err := replay.NewTrafficCaptureReader(dsdReplayFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", dsdReplayFilePath, err)
+	}
+	defer reader.Close()
+
+	conn, err := net.Dial("unixgram", socketPath)
+	if err != nil {
+		return fmt.Errorf("unable to connect to %s: %v", socketPath, err)
+	}
+	defer conn.Close()
+
+	fmt.Printf("Replaying %s into %s\n", dsdReplayFilePath, socketPath)
+	start := time.Now()
+	count, err := reader.Replay(func(p *replay.Packet) error {
+		_, err := conn.Write(p.Payload)
+		return err
+	}, dsdReplaySpeed)
+	if err != nil {
+		return fmt.Errorf("replay stopped after %d packets: %v", count, err)
+	}
+
+	fmt.Printf("%d packets replayed in %s\n", count, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	AgentCmd.AddCommand(dogstatsdOriginStatsCmd)
+	dogstatsdOriginStatsCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
+	dogstatsdOriginStatsCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
+}
+
+var dogstatsdOriginStatsCmd = &cobra.Command{
+	Use:   "dogstatsd-origin-stats",
+	Short: "Print statistics on the packets processed by dogstatsd, by origin",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return requestDogstatsdOriginStats()
+	},
+}
+
+func requestDogstatsdOriginStats() error {
+	fmt.Printf("Getting the dogstatsd origin stats from the agent.\n\n")
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/dogstatsd-origin-stats", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	if err := util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	r, err := util.DoGet(c, urlstr)
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap) //nolint:errcheck
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			err = fmt.Errorf(e)
+		}
+
+		if len(errMap["error_type"]) > 0 {
+			fmt.Println(err)
+			return nil
+		}
+
+		fmt.Printf("Could not reach agent: %v \nMake sure the agent is running before requesting the dogstatsd origin stats and contact support if you continue having issues. \n", err)
+		return err
+	}
+
+	// The rendering is done in the client so that the agent has less work to do
+	var s string
+	if prettyPrintJSON {
+		var prettyJSON bytes.Buffer
+		json.Indent(&prettyJSON, r, "", "  ") //nolint:errcheck
+		s = prettyJSON.String()
+	} else if jsonStatus {
+		s = string(r)
+	} else {
+		s, err = dogstatsd.FormatOriginStats(r)
+		if err != nil {
+			fmt.Printf("Could not format the statistics, the data must be inconsistent. You may want to try the JSON output. Contact the support if you continue having issues.\n")
+			return nil
+		}
+	}
+
+	fmt.Println(s)
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/openmetrics"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
@@ -80,6 +81,7 @@ var (
 	pidfilePath string
 
 	orchestratorForwarder *forwarder.DefaultForwarder
+	remoteWriteForwarder  *forwarder.RemoteWriteForwarder
 
 	runCmd = &cobra.Command{
 		Use:   "run",
@@ -345,11 +347,37 @@ func StartAgent() error {
 		orchestratorForwarder.Start() //nolint:errcheck
 	}
 
+	// setup the Prometheus remote-write forwarder
+	if config.Datadog.GetBool("prometheus_remote_write.enabled") {
+		f, err := forwarder.NewRemoteWriteForwarder(
+			options,
+			config.Datadog.GetString("prometheus_remote_write.url"),
+			config.Datadog.GetStringMapString("prometheus_remote_write.headers"))
+		if err != nil {
+			log.Errorf("Could not start the Prometheus remote-write forwarder: %s", err)
+		} else {
+			remoteWriteForwarder = f
+			remoteWriteForwarder.Start() //nolint:errcheck
+		}
+	}
+
 	// setup the aggregator
 	s := serializer.NewSerializer(common.Forwarder, orchestratorForwarder)
+	s.RemoteWriteForwarder = remoteWriteForwarder
 	agg := aggregator.InitAggregator(s, hostname)
 	agg.AddAgentStartupTelemetry(version.AgentVersion)
 
+	// start the OpenMetrics endpoint
+	if store := agg.OpenMetricsStore(); store != nil {
+		host := config.Datadog.GetString("openmetrics_endpoint.bind_host")
+		port := config.Datadog.GetInt("openmetrics_endpoint.port")
+		if err := openmetrics.Serve(common.MainCtx, host, port, store); err != nil {
+			log.Errorf("Could not start the OpenMetrics endpoint: %v", err)
+		} else {
+			log.Debugf("OpenMetrics endpoint listening on %s:%d", host, port)
+		}
+	}
+
 	// start dogstatsd
 	if config.Datadog.GetBool("use_dogstatsd") {
 		var err error
@@ -457,6 +485,9 @@ func StopAgent() {
 	if orchestratorForwarder != nil {
 		orchestratorForwarder.Stop()
 	}
+	if remoteWriteForwarder != nil {
+		remoteWriteForwarder.Stop()
+	}
 
 	logs.Stop()
 	gui.StopGUIServer()
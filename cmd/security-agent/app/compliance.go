@@ -104,7 +104,7 @@ func newComplianceReporter(stopper restart.Stopper, sourceName, sourceType strin
 	stopper.Add(auditor)
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, nil, endpoints, context)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, nil, endpoints, context, nil)
 	pipelineProvider.Start()
 	stopper.Add(pipelineProvider)
 
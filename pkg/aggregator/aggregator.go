@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/openmetrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -23,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/metrics/rewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
@@ -221,7 +224,9 @@ type BufferedAggregator struct {
 	TickerChan         <-chan time.Time // For test/benchmark purposes: it allows the flush to be controlled from the outside
 	stopChan           chan struct{}
 	health             *health.Handle
-	agentName          string // Name of the agent for telemetry metrics
+	agentName          string             // Name of the agent for telemetry metrics
+	openMetrics        *openmetrics.Store // Latest flushed series and sketches, nil when the OpenMetrics endpoint is disabled
+	rewriter           *rewrite.Rewriter  // Rewrites the samples of the checks, nil when no rule is configured
 
 	tlmContainerTagsEnabled bool                                              // Whether we should call the tagger to tag agent telemetry metrics
 	agentTags               func(collectors.TagCardinality) ([]string, error) // This function gets the agent tags from the tagger (defined as a struct field to ease testing)
@@ -274,9 +279,26 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname string, flush
 		agentTags:               tagger.AgentTags,
 	}
 
+	rules, err := config.GetMetricRewriteRules()
+	if err != nil {
+		log.Warnf("Could not parse the metric rewrite rules: %v", err)
+	} else if aggregator.rewriter, err = rewrite.NewRewriter(rules); err != nil {
+		log.Warnf("Could not create the metric rewriter: %v", err)
+	}
+
+	if config.Datadog.GetBool("openmetrics_endpoint.enabled") {
+		aggregator.openMetrics = openmetrics.NewStore(remotewrite.ParseQuantiles("openmetrics_endpoint.quantiles", config.Datadog.GetStringSlice("openmetrics_endpoint.quantiles")))
+	}
+
 	return aggregator
 }
 
+// OpenMetricsStore returns the store exposing the latest flushed series and sketches in the
+// OpenMetrics format, or nil if the OpenMetrics endpoint is disabled.
+func (agg *BufferedAggregator) OpenMetricsStore() *openmetrics.Store {
+	return agg.openMetrics
+}
+
 // AddRecurrentSeries adds a serie to the series that are sent at every flush
 func AddRecurrentSeries(newSerie *metrics.Serie) {
 	recurrentSeriesLock.Lock()
@@ -514,6 +536,10 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 
 	addFlushCount("Series", int64(len(series)))
 
+	if agg.openMetrics != nil {
+		agg.openMetrics.SetSeries(series)
+	}
+
 	// For debug purposes print out all metrics/tag combinations
 	if config.Datadog.GetBool("log_payloads") {
 		log.Debug("Flushing the following metrics:")
@@ -532,6 +558,9 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 func (agg *BufferedAggregator) sendSketches(start time.Time, sketches metrics.SketchSeriesList, waitForSerializer bool) {
 	// Serialize and forward sketches in a separate goroutine
 	addFlushCount("Sketches", int64(len(sketches)))
+	if agg.openMetrics != nil {
+		agg.openMetrics.SetSketches(sketches)
+	}
 	if len(sketches) != 0 {
 		if waitForSerializer {
 			agg.pushSketches(start, sketches)
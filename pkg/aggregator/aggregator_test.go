@@ -9,6 +9,7 @@ package aggregator
 
 import (
 	// stdlib
+	"bytes"
 	"errors"
 	"fmt"
 	"testing"
@@ -16,6 +17,7 @@ import (
 
 	// 3p
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
@@ -267,6 +269,29 @@ func TestRecurentSeries(t *testing.T) {
 
 }
 
+func TestOpenMetricsStore(t *testing.T) {
+	resetAggregator()
+	agg := NewBufferedAggregator(nil, "hostname", DefaultFlushInterval)
+	assert.Nil(t, agg.OpenMetricsStore())
+
+	config.Datadog.Set("openmetrics_endpoint.enabled", true)
+	defer config.Datadog.Set("openmetrics_endpoint.enabled", false)
+
+	s := &serializer.MockSerializer{}
+	s.On("SendServiceChecks", mock.Anything).Return(nil)
+	s.On("SendSeries", mock.Anything).Return(nil)
+	agg = NewBufferedAggregator(s, "hostname", DefaultFlushInterval)
+	require.NotNil(t, agg.OpenMetricsStore())
+
+	start := time.Now()
+	agg.Flush(start, true)
+
+	var buf bytes.Buffer
+	require.NoError(t, agg.OpenMetricsStore().Encode(&buf))
+	assert.Contains(t, buf.String(), fmt.Sprintf("# TYPE datadog_%s_running gauge\n", flavor.GetFlavor()))
+	assert.Contains(t, buf.String(), fmt.Sprintf("datadog_%s_running{host=\"hostname\",version=\"%s\"} 1 %d\n", flavor.GetFlavor(), version.AgentVersion, start.Unix()))
+}
+
 func TestTags(t *testing.T) {
 	tests := []struct {
 		name                    string
@@ -27,6 +27,7 @@ type CheckSampler struct {
 	lastBucketValue map[ckey.ContextKey]int64
 	lastSeenBucket  map[ckey.ContextKey]time.Time
 	bucketExpiry    time.Duration
+	rollups         *rollups
 }
 
 // newCheckSampler returns a newly initialized CheckSampler
@@ -40,12 +41,18 @@ func newCheckSampler() *CheckSampler {
 		lastBucketValue: make(map[ckey.ContextKey]int64),
 		lastSeenBucket:  make(map[ckey.ContextKey]time.Time),
 		bucketExpiry:    1 * time.Minute,
+		rollups:         newRollupsFromConfig(),
 	}
 }
 
 func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
 	contextKey := cs.contextResolver.trackContext(metricSample, metricSample.Timestamp)
 
+	if metricSample.Mtype == metrics.DistributionType {
+		cs.sketchMap.insert(int64(metricSample.Timestamp), contextKey, metricSample.Value, 1)
+		return
+	}
+
 	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1); err != nil {
 		log.Debug("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
 	}
@@ -175,6 +182,11 @@ func (cs *CheckSampler) flush() (metrics.Series, metrics.SketchSeriesList) {
 	sketches := cs.sketches
 	cs.sketches = make(metrics.SketchSeriesList, 0)
 
+	if cs.rollups != nil {
+		series = cs.rollups.rollupSeries(series)
+		sketches = cs.rollups.rollupSketches(sketches)
+	}
+
 	// garbage collect unused bucket deltas
 	now := time.Now()
 	for ctxKey, lastSeenBucket := range cs.lastSeenBucket {
@@ -301,3 +301,38 @@ func TestCheckHistogramBucketInfinityBucket(t *testing.T) {
 		ContextKey: generateContextKey(bucket1),
 	}, flushed[0], .03)
 }
+
+func TestCheckDistributionSampling(t *testing.T) {
+	checkSampler := newCheckSampler()
+
+	mSample1 := metrics.MetricSample{
+		Name:       "my.distribution",
+		Value:      1,
+		Mtype:      metrics.DistributionType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+		Timestamp:  12345.0,
+	}
+	mSample2 := mSample1
+	mSample2.Value = 3
+
+	checkSampler.addSample(&mSample1)
+	checkSampler.addSample(&mSample2)
+
+	checkSampler.commit(12346.0)
+	series, sketches := checkSampler.flush()
+	assert.Equal(t, 0, len(series))
+	require.Equal(t, 1, len(sketches))
+
+	expSketch := &quantile.Sketch{}
+	expSketch.Insert(quantile.Default(), 1, 3)
+
+	metrics.AssertSketchSeriesApproxEqual(t, metrics.SketchSeries{
+		Name: "my.distribution",
+		Tags: []string{"foo", "bar"},
+		Points: []metrics.SketchPoint{
+			{Ts: 12345.0, Sketch: expSketch},
+		},
+		ContextKey: generateContextKey(&mSample1),
+	}, sketches[0], .01)
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"expvar"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// overflowTag is the only tag of the context receiving the samples of a metric once it
+// reached its maximum number of contexts.
+const overflowTag = "metric_tag_limit:overflow"
+
+var (
+	aggregatorContextLimitOverflow = expvar.Map{}
+
+	tlmContextLimitOverflow = telemetry.NewCounter("aggregator", "context_limit_overflow",
+		[]string{"metric_name"}, "Count of the samples sent to the overflow context of their metric")
+)
+
+func init() {
+	aggregatorExpvars.Set("ContextLimitOverflow", &aggregatorContextLimitOverflow)
+}
+
+// metricLimit holds the limits of a metric and the number of its tracked contexts
+type metricLimit struct {
+	maxContexts int
+	includeTags map[string]struct{}
+	excludeTags map[string]struct{}
+	contexts    int
+}
+
+// keep returns whether a tag is kept in the contexts of the metric
+func (l *metricLimit) keep(tag string) bool {
+	key := tag
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		key = tag[:i]
+	}
+	if l.includeTags != nil {
+		if _, found := l.includeTags[key]; !found {
+			return false
+		}
+	}
+	_, found := l.excludeTags[key]
+	return !found
+}
+
+// filterTags removes from tags the tags not kept in the contexts of the metric
+func (l *metricLimit) filterTags(tags *util.TagsBuilder) {
+	if l.includeTags == nil && len(l.excludeTags) == 0 {
+		return
+	}
+	tags.Filter(l.keep)
+}
+
+// full returns whether the metric reached its maximum number of contexts
+func (l *metricLimit) full() bool {
+	return l.maxContexts > 0 && l.contexts >= l.maxContexts
+}
+
+// contextLimiter strips the tags and limits the number of contexts of the metrics configured
+// in `metric_tag_limits`. Each ContextResolver has its own contextLimiter.
+type contextLimiter struct {
+	limits map[string]*metricLimit
+	// metric names of the tracked contexts of the limited metrics
+	keys map[ckey.ContextKey]string
+}
+
+// newContextLimiter returns a contextLimiter, or nil if no metric is limited
+func newContextLimiter(limits []config.MetricTagLimit) *contextLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	cl := &contextLimiter{
+		limits: make(map[string]*metricLimit, len(limits)),
+		keys:   make(map[ckey.ContextKey]string),
+	}
+	for _, limit := range limits {
+		l := &metricLimit{
+			maxContexts: limit.MaxContexts,
+			excludeTags: toSet(limit.ExcludeTags),
+		}
+		if len(limit.IncludeTags) > 0 {
+			l.includeTags = toSet(limit.IncludeTags)
+		}
+		cl.limits[limit.MetricName] = l
+	}
+	return cl
+}
+
+// newContextLimiterFromConfig returns the contextLimiter of the `metric_tag_limits` setting
+func newContextLimiterFromConfig() *contextLimiter {
+	limits, err := config.GetMetricTagLimits()
+	if err != nil {
+		log.Warnf("The metric tag limits are disabled: %v", err)
+		return nil
+	}
+	return newContextLimiter(limits)
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// get returns the limit of a metric, or nil if the metric is not limited
+func (cl *contextLimiter) get(name string) *metricLimit {
+	return cl.limits[name]
+}
+
+// track records a new context of a limited metric
+func (cl *contextLimiter) track(key ckey.ContextKey, name string, l *metricLimit) {
+	cl.keys[key] = name
+	l.contexts++
+}
+
+// overflow records a sample of a metric sent to its overflow context
+func (cl *contextLimiter) overflow(name string) {
+	aggregatorContextLimitOverflow.Add(name, 1)
+	tlmContextLimitOverflow.Inc(name)
+}
+
+// untrack forgets an expired context
+func (cl *contextLimiter) untrack(key ckey.ContextKey) {
+	name, found := cl.keys[key]
+	if !found {
+		return
+	}
+	delete(cl.keys, key)
+	cl.limits[name].contexts--
+}
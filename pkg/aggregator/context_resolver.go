@@ -28,6 +28,9 @@ type ContextResolver struct {
 	// buffer slice allocated once per ContextResolver to combine and sort
 	// tags, origin detection tags and k8s tags.
 	tagsBuffer *util.TagsBuilder
+	// limiter strips the tags and limits the number of contexts of the configured
+	// metrics, nil if no metric is limited.
+	limiter *contextLimiter
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -41,14 +44,35 @@ func newContextResolver() *ContextResolver {
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 		keyGenerator:  ckey.NewKeyGenerator(),
 		tagsBuffer:    util.NewTagsBuilder(),
+		limiter:       newContextLimiterFromConfig(),
 	}
 }
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *ContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
 	metricSampleContext.GetTags(cr.tagsBuffer)
+
+	var limit *metricLimit
+	if cr.limiter != nil {
+		limit = cr.limiter.get(metricSampleContext.GetName())
+	}
+	if limit != nil {
+		limit.filterTags(cr.tagsBuffer)
+	}
 	contextKey := cr.generateContextKey(metricSampleContext, cr.tagsBuffer)
 
+	if _, ok := cr.contextsByKey[contextKey]; !ok && limit != nil {
+		if limit.full() {
+			// the samples of the new contexts are aggregated in the overflow context of the metric
+			cr.limiter.overflow(metricSampleContext.GetName())
+			cr.tagsBuffer.Reset()
+			cr.tagsBuffer.Append(overflowTag)
+			contextKey = cr.generateContextKey(metricSampleContext, cr.tagsBuffer)
+		} else {
+			cr.limiter.track(contextKey, metricSampleContext.GetName(), limit)
+		}
+	}
+
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
 		// making a copy of tags for the context since tagsBuffer
 		// will be reused later. This allow us to allocate one slice
@@ -101,6 +125,9 @@ func (cr *ContextResolver) expireContexts(expireTimestamp float64) []ckey.Contex
 	for _, expiredContextKey := range expiredContextKeys {
 		delete(cr.contextsByKey, expiredContextKey)
 		delete(cr.lastSeenByKey, expiredContextKey)
+		if cr.limiter != nil {
+			cr.limiter.untrack(expiredContextKey)
+		}
 	}
 
 	return expiredContextKeys
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
@@ -134,3 +135,43 @@ func TestExpireContexts(t *testing.T) {
 	_, ok = contextResolver.contextsByKey[contextKey2]
 	assert.True(t, ok)
 }
+
+func TestTrackContextWithLimits(t *testing.T) {
+	sample := func(name string, tags ...string) *metrics.MetricSample {
+		return &metrics.MetricSample{Name: name, Value: 1, Mtype: metrics.GaugeType, Tags: tags, SampleRate: 1}
+	}
+	contextResolver := newContextResolver()
+	contextResolver.limiter = newContextLimiter([]config.MetricTagLimit{
+		{MetricName: "limited", MaxContexts: 2, ExcludeTags: []string{"user_id"}},
+		{MetricName: "allowed", IncludeTags: []string{"env"}},
+	})
+
+	// the excluded tags are stripped before generating the context key
+	contextKey1 := contextResolver.trackContext(sample("limited", "env:prod", "user_id:1"), 1)
+	assert.Equal(t, contextKey1, contextResolver.trackContext(sample("limited", "env:prod", "user_id:2"), 2))
+	context, _ := contextResolver.get(contextKey1)
+	assert.Equal(t, []string{"env:prod"}, context.Tags)
+	contextKey2 := contextResolver.trackContext(sample("limited", "env:staging"), 2)
+	assert.NotEqual(t, contextKey1, contextKey2)
+
+	// the metric reached its limit: the new contexts share the overflow context
+	overflowKey := contextResolver.trackContext(sample("limited", "env:dev"), 3)
+	assert.Equal(t, overflowKey, contextResolver.trackContext(sample("limited", "env:qa"), 3))
+	context, _ = contextResolver.get(overflowKey)
+	assert.Equal(t, []string{overflowTag}, context.Tags)
+	assert.Equal(t, contextKey1, contextResolver.trackContext(sample("limited", "env:prod"), 3))
+
+	// only the included tags are kept, and the other metrics are not limited
+	allowedKey := contextResolver.trackContext(sample("allowed", "env:prod", "user_id:1"), 3)
+	context, _ = contextResolver.get(allowedKey)
+	assert.Equal(t, []string{"env:prod"}, context.Tags)
+	otherKey := contextResolver.trackContext(sample("other", "user_id:1"), 3)
+	context, _ = contextResolver.get(otherKey)
+	assert.Equal(t, []string{"user_id:1"}, context.Tags)
+	assert.Equal(t, 5, contextResolver.length())
+
+	// once a context expired, the metric can have a new context
+	assert.Len(t, contextResolver.expireContexts(3), 1)
+	assert.NotEqual(t, overflowKey, contextResolver.trackContext(sample("limited", "env:dev"), 4))
+	assert.Equal(t, overflowKey, contextResolver.trackContext(sample("limited", "env:qa"), 4))
+}
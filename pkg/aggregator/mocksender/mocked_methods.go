@@ -65,6 +65,11 @@ func (m *MockSender) Event(e metrics.Event) {
 	m.Called(e)
 }
 
+//Distribution adds a distribution type to the mock calls.
+func (m *MockSender) Distribution(metric string, value float64, hostname string, tags []string) {
+	m.Called(metric, value, hostname, tags)
+}
+
 //HistogramBucket enables the histogram bucket mock call.
 func (m *MockSender) HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
 	m.Called(metric, value, lowerBound, upperBound, monotonic, hostname, tags)
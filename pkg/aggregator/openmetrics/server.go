@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Serve starts the http server exposing the metrics of the store on the "/metrics" path.
+// It returns an error if the setup failed, or runs the server in a goroutine.
+// Stop the server by cancelling the passed context.
+func Serve(ctx context.Context, host string, port int, store *Store) error {
+	if port == 0 {
+		return errors.New("port should be non-zero")
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", store)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadTimeout:       defaultTimeout,
+		ReadHeaderTimeout: defaultTimeout,
+		WriteTimeout:      defaultTimeout,
+	}
+
+	go srv.Serve(ln) //nolint:errcheck
+	go closeOnContext(ctx, srv)
+	return nil
+}
+
+func closeOnContext(ctx context.Context, srv *http.Server) {
+	// Wait for the context to be canceled
+	<-ctx.Done()
+
+	// Shutdown the server, it will close the listener
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Shutdown(timeout) //nolint:errcheck
+}
@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package openmetrics exposes the metrics flushed by the aggregator in the OpenMetrics text format,
+// so that they can be scraped by Prometheus.
+package openmetrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ContentType is the content type of the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+const (
+	metricNameLabel = "__name__"
+	quantileLabel   = "quantile"
+
+	gaugeType   = "gauge"
+	summaryType = "summary"
+)
+
+// Store keeps the latest series and sketches flushed by the aggregator. They are converted to the
+// OpenMetrics format when they are scraped: the series are exposed as gauges and the sketches as
+// summaries of the configured quantiles.
+type Store struct {
+	quantiles []float64
+
+	m        sync.RWMutex
+	series   remotewrite.Marshaler
+	sketches remotewrite.Marshaler
+}
+
+// NewStore returns an empty Store which exposes the sketches with the given quantiles.
+func NewStore(quantiles []float64) *Store {
+	return &Store{quantiles: quantiles}
+}
+
+// SetSeries replaces the series of the store. They must not be modified afterwards.
+func (s *Store) SetSeries(series remotewrite.Marshaler) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.series = series
+}
+
+// SetSketches replaces the sketches of the store. They must not be modified afterwards.
+func (s *Store) SetSketches(sketches remotewrite.Marshaler) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.sketches = sketches
+}
+
+// family is a group of time series sharing the same metric name.
+type family struct {
+	name       string
+	metricType string
+	timeseries []remotewrite.TimeSeries
+}
+
+// Encode writes the metrics of the store to w in the OpenMetrics text format. The families are
+// sorted by name and only the latest sample of each time series is written, with its timestamp.
+func (s *Store) Encode(w io.Writer) error {
+	s.m.RLock()
+	series, sketches := s.series, s.sketches
+	s.m.RUnlock()
+
+	families := make(map[string]*family)
+	var names []string
+	add := func(m remotewrite.Marshaler, metricType string) {
+		if m == nil {
+			return
+		}
+		b := remotewrite.NewBuilder(s.quantiles)
+		m.MarshalRemoteWrite(b)
+		for _, ts := range b.TimeSeries() {
+			name := familyName(ts, metricType)
+			f, found := families[name]
+			if !found {
+				if other := collidingFamily(families, name, metricType); other != nil {
+					log.Debugf("Skipping the %s %q in the OpenMetrics endpoint: its samples have the same names as the %s %q", metricType, name, other.metricType, other.name)
+					continue
+				}
+				f = &family{name: name, metricType: metricType}
+				families[name] = f
+				names = append(names, name)
+			} else if f.metricType != metricType {
+				log.Debugf("Skipping the %s %q in the OpenMetrics endpoint: a %s has the same name", metricType, name, f.metricType)
+				continue
+			}
+			f.timeseries = append(f.timeseries, ts)
+		}
+	}
+	add(series, gaugeType)
+	add(sketches, summaryType)
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		bw.WriteString("# TYPE " + f.name + " " + f.metricType + "\n")
+		for _, ts := range f.timeseries {
+			writeTimeSeries(bw, ts)
+		}
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := s.Encode(w); err != nil {
+		log.Debugf("Error writing the OpenMetrics response: %v", err)
+	}
+}
+
+// familyName returns the name of the family of a time series. The "_sum" and "_count" series of
+// the summaries belong to the family of their quantile series.
+func familyName(ts remotewrite.TimeSeries, metricType string) string {
+	name := labelValue(ts, metricNameLabel)
+	if metricType != summaryType || labelValue(ts, quantileLabel) != "" {
+		return name
+	}
+	for _, suffix := range []string{"_sum", "_count"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// collidingFamily returns the family of another metric whose samples have the same names as the
+// samples of the family name, given the "_sum" and "_count" samples of the summaries.
+func collidingFamily(families map[string]*family, name, metricType string) *family {
+	for _, suffix := range []string{"_sum", "_count"} {
+		if metricType == summaryType {
+			if f, found := families[name+suffix]; found {
+				return f
+			}
+		} else if strings.HasSuffix(name, suffix) {
+			if f, found := families[strings.TrimSuffix(name, suffix)]; found && f.metricType == summaryType {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func labelValue(ts remotewrite.TimeSeries, name string) string {
+	for _, l := range ts.Labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// writeTimeSeries writes the latest sample of a time series.
+func writeTimeSeries(w *bufio.Writer, ts remotewrite.TimeSeries) {
+	if len(ts.Samples) == 0 {
+		return
+	}
+	latest := ts.Samples[0]
+	for _, sample := range ts.Samples[1:] {
+		if sample.Timestamp >= latest.Timestamp {
+			latest = sample
+		}
+	}
+
+	w.WriteString(labelValue(ts, metricNameLabel))
+	first := true
+	for _, l := range ts.Labels {
+		if l.Name == metricNameLabel {
+			continue
+		}
+		if first {
+			w.WriteByte('{')
+			first = false
+		} else {
+			w.WriteByte(',')
+		}
+		w.WriteString(l.Name + `="` + escapeLabelValue(l.Value) + `"`)
+	}
+	if !first {
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(latest.Value) + " " + strconv.FormatFloat(float64(latest.Timestamp)/1000, 'f', -1, 64) + "\n")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
+)
+
+type testSeries func(b *remotewrite.Builder)
+
+func (f testSeries) MarshalRemoteWrite(b *remotewrite.Builder) {
+	f(b)
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewStore(nil).Encode(&buf))
+	assert.Equal(t, "# EOF\n", buf.String())
+}
+
+func TestEncode(t *testing.T) {
+	store := NewStore([]float64{1})
+	store.SetSeries(testSeries(func(b *remotewrite.Builder) {
+		b.AddSeries("system.load.1", "host1", []string{"env:prod"}, []remotewrite.Sample{{Value: 1, Timestamp: 10000}, {Value: 2.5, Timestamp: 20000}})
+		b.AddSeries("system.load.1", "host2", nil, []remotewrite.Sample{{Value: math.NaN(), Timestamp: 20500}})
+		b.AddSeries("custom.metric", "", []string{`path:C:\tmp "x"`}, []remotewrite.Sample{{Value: math.Inf(-1), Timestamp: 20000}})
+	}))
+
+	sketch := &quantile.Sketch{}
+	for i := 0; i < 10; i++ {
+		sketch.Insert(quantile.Default(), 4)
+	}
+	store.SetSketches(testSeries(func(b *remotewrite.Builder) {
+		b.AddSketches("http.latency", "host1", nil, []remotewrite.SketchPoint{{Ts: 20, Sketch: sketch}})
+		// a gauge already uses the name of this summary
+		b.AddSketches("custom.metric", "", nil, []remotewrite.SketchPoint{{Ts: 20, Sketch: sketch}})
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, store.Encode(&buf))
+	assert.Equal(t, `# TYPE custom_metric gauge
+custom_metric{path="C:\\tmp \"x\""} -Inf 20
+# TYPE http_latency summary
+http_latency{host="host1",quantile="1"} 4 20
+http_latency_sum{host="host1"} 40 20
+http_latency_count{host="host1"} 10 20
+# TYPE system_load_1 gauge
+system_load_1{env="prod",host="host1"} 2.5 20
+system_load_1{host="host2"} NaN 20.5
+# EOF
+`, buf.String())
+
+	// the latest flush replaces the previous one
+	store.SetSketches(nil)
+	store.SetSeries(testSeries(func(b *remotewrite.Builder) {
+		b.AddSeries("up", "", nil, []remotewrite.Sample{{Value: 1, Timestamp: 30000}})
+	}))
+	buf.Reset()
+	require.NoError(t, store.Encode(&buf))
+	assert.Equal(t, "# TYPE up gauge\nup 1 30\n# EOF\n", buf.String())
+}
+
+func TestEncodeCollidingNames(t *testing.T) {
+	store := NewStore([]float64{1})
+	store.SetSeries(testSeries(func(b *remotewrite.Builder) {
+		b.AddSeries("http.latency.sum", "", nil, []remotewrite.Sample{{Value: 1, Timestamp: 20000}})
+		b.AddSeries("rpc.latency", "", nil, []remotewrite.Sample{{Value: 2, Timestamp: 20000}})
+		b.AddSeries("rpc.latency.count", "", nil, []remotewrite.Sample{{Value: 3, Timestamp: 20000}})
+	}))
+
+	sketch := &quantile.Sketch{}
+	sketch.Insert(quantile.Default(), 4)
+	store.SetSketches(testSeries(func(b *remotewrite.Builder) {
+		// the samples of these summaries have the same names as gauges
+		b.AddSketches("http.latency", "", nil, []remotewrite.SketchPoint{{Ts: 20, Sketch: sketch}})
+		b.AddSketches("rpc.latency.count", "", nil, []remotewrite.SketchPoint{{Ts: 20, Sketch: sketch}})
+		b.AddSketches("db.latency", "", nil, []remotewrite.SketchPoint{{Ts: 20, Sketch: sketch}})
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, store.Encode(&buf))
+	assert.Equal(t, `# TYPE db_latency summary
+db_latency{quantile="1"} 4 20
+db_latency_sum 4 20
+db_latency_count 1 20
+# TYPE http_latency_sum gauge
+http_latency_sum 1 20
+# TYPE rpc_latency gauge
+rpc_latency 2 20
+# TYPE rpc_latency_count gauge
+rpc_latency_count 3 20
+# EOF
+`, buf.String())
+}
+
+func TestServeHTTP(t *testing.T) {
+	store := NewStore(nil)
+	store.SetSeries(testSeries(func(b *remotewrite.Builder) {
+		b.AddSeries("up", "", nil, []remotewrite.Sample{{Value: 1, Timestamp: 30000}})
+	}))
+
+	rec := httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE up gauge\nup 1 30\n# EOF\n", rec.Body.String())
+}
+
+func TestServe(t *testing.T) {
+	assert.Error(t, Serve(context.Background(), "localhost", 0, NewStore(nil)))
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, Serve(ctx, "localhost", port, NewStore(nil)))
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", port))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "# EOF\n", string(body))
+}
@@ -0,0 +1,236 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"math"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// rollup aggregates the series and the sketches of a metric across some of their tags
+type rollup struct {
+	name      string
+	dropTags  map[string]struct{}
+	aggregate func(a, b float64) float64
+}
+
+// metricRollups holds the rollups of a metric
+type metricRollups struct {
+	rollups      []*rollup
+	dropOriginal bool
+}
+
+// rollupSignature holds the elements that allow to know whether two rolled-up series
+// can be merged into one
+type rollupSignature struct {
+	SerieSignature
+	device string
+}
+
+// rolledUpSerie is a rolled-up serie and the index of its points by timestamp
+type rolledUpSerie struct {
+	serie  *metrics.Serie
+	points map[float64]int
+}
+
+// rollups aggregates at flush time the series and the sketches of the metrics configured in
+// `metric_rollups`. Each sampler has its own rollups.
+type rollups struct {
+	metrics      map[string]*metricRollups
+	keyGenerator *ckey.KeyGenerator
+}
+
+// newRollups returns a rollups, or nil if no metric is rolled up
+func newRollups(configRollups []config.MetricRollup) *rollups {
+	if len(configRollups) == 0 {
+		return nil
+	}
+	r := &rollups{
+		metrics:      make(map[string]*metricRollups, len(configRollups)),
+		keyGenerator: ckey.NewKeyGenerator(),
+	}
+	for _, configRollup := range configRollups {
+		ru := &rollup{
+			name:     configRollup.Name,
+			dropTags: toSet(configRollup.DropTags),
+		}
+		if ru.name == "" {
+			if configRollup.DropOriginal {
+				ru.name = configRollup.MetricName
+			} else {
+				ru.name = configRollup.MetricName + ".rollup"
+			}
+		}
+		switch configRollup.Aggregation {
+		case "max":
+			ru.aggregate = math.Max
+		case "min":
+			ru.aggregate = math.Min
+		default:
+			ru.aggregate = func(a, b float64) float64 { return a + b }
+		}
+
+		m, found := r.metrics[configRollup.MetricName]
+		if !found {
+			m = &metricRollups{}
+			r.metrics[configRollup.MetricName] = m
+		}
+		m.rollups = append(m.rollups, ru)
+		m.dropOriginal = m.dropOriginal || configRollup.DropOriginal
+	}
+	return r
+}
+
+// newRollupsFromConfig returns the rollups of the `metric_rollups` setting
+func newRollupsFromConfig() *rollups {
+	configRollups, err := config.GetMetricRollups()
+	if err != nil {
+		log.Warnf("The metric rollups are disabled: %v", err)
+		return nil
+	}
+	return newRollups(configRollups)
+}
+
+// filterTags returns a new slice holding the tags not dropped by the rollup
+func (ru *rollup) filterTags(tags []string) []string {
+	filtered := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		key := tag
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key = tag[:i]
+		}
+		if _, found := ru.dropTags[key]; !found {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
+
+// rollupSeries appends to the series their rollups, and removes the series of the metrics
+// only emitted rolled up. The points of the series merged into a rollup are aggregated
+// by timestamp.
+func (r *rollups) rollupSeries(series metrics.Series) metrics.Series {
+	var rolledUp []*rolledUpSerie
+	bySignature := make(map[rollupSignature]*rolledUpSerie)
+
+	n := 0
+	for _, serie := range series {
+		m, found := r.metrics[serie.Name]
+		if !found {
+			series[n] = serie
+			n++
+			continue
+		}
+		for _, ru := range m.rollups {
+			tags := ru.filterTags(serie.Tags)
+			device := serie.Device
+			if _, found := ru.dropTags["device"]; found {
+				device = ""
+			}
+			signature := rollupSignature{
+				SerieSignature: SerieSignature{serie.MType, r.keyGenerator.Generate(ru.name, serie.Host, tags), ""},
+				device:         device,
+			}
+
+			rs, found := bySignature[signature]
+			if !found {
+				rs = &rolledUpSerie{
+					serie: &metrics.Serie{
+						Name:           ru.name,
+						Tags:           tags,
+						Host:           serie.Host,
+						Device:         device,
+						MType:          serie.MType,
+						Interval:       serie.Interval,
+						SourceTypeName: serie.SourceTypeName,
+						ContextKey:     signature.contextKey,
+					},
+					points: make(map[float64]int),
+				}
+				bySignature[signature] = rs
+				rolledUp = append(rolledUp, rs)
+			}
+			for _, point := range serie.Points {
+				if i, found := rs.points[point.Ts]; found {
+					rs.serie.Points[i].Value = ru.aggregate(rs.serie.Points[i].Value, point.Value)
+				} else {
+					rs.points[point.Ts] = len(rs.serie.Points)
+					rs.serie.Points = append(rs.serie.Points, point)
+				}
+			}
+		}
+		if !m.dropOriginal {
+			series[n] = serie
+			n++
+		}
+	}
+
+	series = series[:n]
+	for _, rs := range rolledUp {
+		series = append(series, rs.serie)
+	}
+	return series
+}
+
+// rollupSketches appends to the sketches their rollups, and removes the sketches of the metrics
+// only emitted rolled up. The sketches merged into a rollup are merged by timestamp.
+func (r *rollups) rollupSketches(sketches metrics.SketchSeriesList) metrics.SketchSeriesList {
+	var rolledUp []*metrics.SketchSeries
+	byKey := make(map[ckey.ContextKey]*metrics.SketchSeries)
+	points := make(map[ckey.ContextKey]map[int64]int)
+
+	n := 0
+	for _, ss := range sketches {
+		m, found := r.metrics[ss.Name]
+		if !found {
+			sketches[n] = ss
+			n++
+			continue
+		}
+		for _, ru := range m.rollups {
+			tags := ru.filterTags(ss.Tags)
+			key := r.keyGenerator.Generate(ru.name, ss.Host, tags)
+
+			rss, found := byKey[key]
+			if !found {
+				rss = &metrics.SketchSeries{
+					Name:       ru.name,
+					Tags:       tags,
+					Host:       ss.Host,
+					Interval:   ss.Interval,
+					ContextKey: key,
+				}
+				byKey[key] = rss
+				points[key] = make(map[int64]int)
+				rolledUp = append(rolledUp, rss)
+			}
+			for _, point := range ss.Points {
+				if i, found := points[key][point.Ts]; found {
+					rss.Points[i].Sketch.Merge(quantile.Default(), point.Sketch)
+				} else {
+					points[key][point.Ts] = len(rss.Points)
+					rss.Points = append(rss.Points, metrics.SketchPoint{Sketch: point.Sketch.Copy(), Ts: point.Ts})
+				}
+			}
+		}
+		if !m.dropOriginal {
+			sketches[n] = ss
+			n++
+		}
+	}
+
+	sketches = sketches[:n]
+	for _, rss := range rolledUp {
+		sketches = append(sketches, *rss)
+	}
+	return sketches
+}
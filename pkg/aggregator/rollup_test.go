@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build test
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+)
+
+func TestNewRollupsEmpty(t *testing.T) {
+	assert.Nil(t, newRollups(nil))
+}
+
+func TestRollupSeries(t *testing.T) {
+	r := newRollups([]config.MetricRollup{
+		{MetricName: "kubernetes.cpu.usage.total", DropTags: []string{"pod_name", "container_id"}},
+		{MetricName: "app.latency.max", DropTags: []string{"pod_name"}, Aggregation: "max", DropOriginal: true},
+	})
+	require.NotNil(t, r)
+
+	cpu1 := &metrics.Serie{
+		Name:     "kubernetes.cpu.usage.total",
+		Tags:     []string{"service:web", "pod_name:web-1", "container_id:abc"},
+		Host:     "node1",
+		MType:    metrics.APIGaugeType,
+		Interval: 10,
+		Points:   []metrics.Point{{Ts: 10, Value: 1}, {Ts: 20, Value: 2}},
+	}
+	cpu2 := &metrics.Serie{
+		Name:     "kubernetes.cpu.usage.total",
+		Tags:     []string{"pod_name:web-2", "service:web"},
+		Host:     "node1",
+		MType:    metrics.APIGaugeType,
+		Interval: 10,
+		Points:   []metrics.Point{{Ts: 20, Value: 3}, {Ts: 30, Value: 4}},
+	}
+	cpu3 := &metrics.Serie{
+		Name:     "kubernetes.cpu.usage.total",
+		Tags:     []string{"pod_name:db-1", "service:db"},
+		Host:     "node1",
+		MType:    metrics.APIGaugeType,
+		Interval: 10,
+		Points:   []metrics.Point{{Ts: 10, Value: 5}},
+	}
+	latency1 := &metrics.Serie{
+		Name:   "app.latency.max",
+		Tags:   []string{"pod_name:web-1", "service:web"},
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: 10, Value: 7}},
+	}
+	latency2 := &metrics.Serie{
+		Name:   "app.latency.max",
+		Tags:   []string{"pod_name:web-2", "service:web"},
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: 10, Value: 9}},
+	}
+	other := &metrics.Serie{
+		Name:   "system.load.1",
+		Tags:   []string{"pod_name:web-1"},
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: 10, Value: 1}},
+	}
+
+	series := r.rollupSeries(metrics.Series{cpu1, latency1, other, cpu2, latency2, cpu3})
+	require.Len(t, series, 7)
+
+	// the originals come first, without the series of the metrics only emitted rolled up
+	assert.Equal(t, metrics.Series{cpu1, other, cpu2, cpu3}, series[:4])
+	assert.Equal(t, []string{"service:web", "pod_name:web-1", "container_id:abc"}, cpu1.Tags)
+
+	assert.Equal(t, "kubernetes.cpu.usage.total.rollup", series[4].Name)
+	assert.Equal(t, []string{"service:web"}, series[4].Tags)
+	assert.Equal(t, "node1", series[4].Host)
+	assert.Equal(t, int64(10), series[4].Interval)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 1}, {Ts: 20, Value: 5}, {Ts: 30, Value: 4}}, series[4].Points)
+
+	assert.Equal(t, "app.latency.max", series[5].Name)
+	assert.Equal(t, []string{"service:web"}, series[5].Tags)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 9}}, series[5].Points)
+
+	assert.Equal(t, "kubernetes.cpu.usage.total.rollup", series[6].Name)
+	assert.Equal(t, []string{"service:db"}, series[6].Tags)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 5}}, series[6].Points)
+}
+
+func TestRollupSeriesKeepsTypes(t *testing.T) {
+	r := newRollups([]config.MetricRollup{
+		{MetricName: "app.requests", DropTags: []string{"pod_name"}, Name: "app.requests.by_service"},
+	})
+
+	series := r.rollupSeries(metrics.Series{
+		{Name: "app.requests", Tags: []string{"pod_name:a"}, MType: metrics.APIRateType, Points: []metrics.Point{{Ts: 10, Value: 1}}},
+		{Name: "app.requests", Tags: []string{"pod_name:b"}, MType: metrics.APICountType, Points: []metrics.Point{{Ts: 10, Value: 2}}},
+		{Name: "app.requests", Tags: []string{"pod_name:c"}, MType: metrics.APIRateType, Points: []metrics.Point{{Ts: 10, Value: 3}}},
+	})
+	require.Len(t, series, 5)
+	assert.Equal(t, "app.requests.by_service", series[3].Name)
+	assert.Equal(t, metrics.APIRateType, series[3].MType)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 4}}, series[3].Points)
+	assert.Equal(t, metrics.APICountType, series[4].MType)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 2}}, series[4].Points)
+}
+
+func TestRollupSketches(t *testing.T) {
+	r := newRollups([]config.MetricRollup{
+		{MetricName: "http.latency", DropTags: []string{"pod_name"}, DropOriginal: true},
+	})
+
+	sketch1 := &quantile.Sketch{}
+	sketch1.Insert(quantile.Default(), 1, 2)
+	sketch2 := &quantile.Sketch{}
+	sketch2.Insert(quantile.Default(), 3)
+	other := metrics.SketchSeries{Name: "other", Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch1}}}
+
+	sketches := r.rollupSketches(metrics.SketchSeriesList{
+		{Name: "http.latency", Tags: []string{"pod_name:a", "env:prod"}, Interval: 10, Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch1}}},
+		other,
+		{Name: "http.latency", Tags: []string{"env:prod", "pod_name:b"}, Interval: 10, Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch2}, {Ts: 20, Sketch: sketch2}}},
+	})
+	require.Len(t, sketches, 2)
+	assert.Equal(t, other, sketches[0])
+
+	expSketch := &quantile.Sketch{}
+	expSketch.Insert(quantile.Default(), 1, 2, 3)
+	metrics.AssertSketchSeriesApproxEqual(t, metrics.SketchSeries{
+		Name:     "http.latency",
+		Tags:     []string{"env:prod"},
+		Interval: 10,
+		Points: []metrics.SketchPoint{
+			{Ts: 10, Sketch: expSketch},
+			{Ts: 20, Sketch: sketch2},
+		},
+		ContextKey: sketches[1].ContextKey,
+	}, sketches[1], .01)
+
+	// the sketches of the original series are not modified
+	assert.Equal(t, int64(2), sketch1.Basic.Cnt)
+}
+
+func TestCheckSamplerRollups(t *testing.T) {
+	checkSampler := newCheckSampler()
+	checkSampler.rollups = newRollups([]config.MetricRollup{
+		{MetricName: "my.gauge", DropTags: []string{"pod_name"}, DropOriginal: true},
+	})
+
+	for i, pod := range []string{"pod_name:a", "pod_name:b"} {
+		checkSampler.addSample(&metrics.MetricSample{
+			Name:       "my.gauge",
+			Value:      float64(i + 1),
+			Mtype:      metrics.GaugeType,
+			Tags:       []string{pod, "service:web"},
+			SampleRate: 1,
+			Timestamp:  12345.0,
+		})
+	}
+	checkSampler.commit(12346.0)
+	series, _ := checkSampler.flush()
+
+	require.Len(t, series, 1)
+	assert.Equal(t, "my.gauge", series[0].Name)
+	assert.Equal(t, []string{"service:web"}, series[0].Tags)
+	assert.Equal(t, []metrics.Point{{Ts: 12346.0, Value: 3}}, series[0].Points)
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/metrics/rewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -32,6 +33,7 @@ type Sender interface {
 	Counter(metric string, value float64, hostname string, tags []string)
 	Histogram(metric string, value float64, hostname string, tags []string)
 	Historate(metric string, value float64, hostname string, tags []string)
+	Distribution(metric string, value float64, hostname string, tags []string)
 	ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string)
 	HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string)
 	Event(e metrics.Event)
@@ -72,6 +74,7 @@ type checkSender struct {
 	orchestratorOut         chan<- senderOrchestratorMetadata
 	checkTags               []string
 	service                 string
+	rewriter                *rewrite.Rewriter
 }
 
 type senderMetricSample struct {
@@ -155,7 +158,9 @@ func GetDefaultSender() (Sender, error) {
 	senderInit.Do(func() {
 		var defaultCheckID check.ID                       // the default value is the zero value
 		aggregatorInstance.registerSender(defaultCheckID) //nolint:errcheck
-		senderInstance = newCheckSender(defaultCheckID, aggregatorInstance.hostname, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.checkHistogramBucketIn, aggregatorInstance.orchestratorMetadataIn)
+		sender := newCheckSender(defaultCheckID, aggregatorInstance.hostname, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.checkHistogramBucketIn, aggregatorInstance.orchestratorMetadataIn)
+		sender.rewriter = aggregatorInstance.rewriter
+		senderInstance = sender
 	})
 
 	return senderInstance, nil
@@ -234,12 +239,25 @@ func (s *checkSender) cyclemetricStats() {
 // SendRawMetricSample sends the raw sample
 // Useful for testing - submitting precomputed samples.
 func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
+	if s.rewriter != nil {
+		var keep bool
+		if sample.Name, sample.Tags, keep = s.rewriter.Rewrite(sample.Name, sample.Tags); !keep {
+			return
+		}
+	}
 	s.smsOut <- senderMetricSample{s.id, sample, false}
 }
 
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType, flushFirstValue bool) {
 	tags = append(tags, s.checkTags...)
 
+	if s.rewriter != nil {
+		var keep bool
+		if metric, tags, keep = s.rewriter.Rewrite(metric, tags); !keep {
+			return
+		}
+	}
+
 	log.Trace(mType.String(), " sample: ", metric, ": ", value, " for hostname: ", hostname, " tags: ", tags)
 
 	metricSample := &metrics.MetricSample{
@@ -303,6 +321,12 @@ func (s *checkSender) Histogram(metric string, value float64, hostname string, t
 	s.sendMetricSample(metric, value, hostname, tags, metrics.HistogramType, false)
 }
 
+// Distribution should be used to track the global statistical distribution of a set of values,
+// the values are aggregated in a sketch instead of being summarized on the agent side
+func (s *checkSender) Distribution(metric string, value float64, hostname string, tags []string) {
+	s.sendMetricSample(metric, value, hostname, tags, metrics.DistributionType, false)
+}
+
 // HistogramBucket should be called to directly send raw buckets to be submitted as distribution metrics
 func (s *checkSender) HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
 	tags = append(tags, s.checkTags...)
@@ -431,6 +455,7 @@ func (sp *checkSenderPool) mkSender(id check.ID) (Sender, error) {
 
 	err := aggregatorInstance.registerSender(id)
 	sender := newCheckSender(id, aggregatorInstance.hostname, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.checkHistogramBucketIn, aggregatorInstance.orchestratorMetadataIn)
+	sender.rewriter = aggregatorInstance.rewriter
 	sp.senders[id] = sender
 	return sender, err
 }
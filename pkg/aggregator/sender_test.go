@@ -20,7 +20,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/metrics/rewrite"
 )
 
 func resetAggregator() {
@@ -170,6 +172,34 @@ func TestGetSenderServiceTagMetrics(t *testing.T) {
 	assert.Equal(t, append(checkTags, "service:service2"), sms.metricSample.Tags)
 }
 
+func TestCheckSenderRewriteRules(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	orchestratorChan := make(chan senderOrchestratorMetadata, 10)
+	checkSender := newCheckSender(checkID1, "", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan, orchestratorChan)
+
+	var err error
+	checkSender.rewriter, err = rewrite.NewRewriter([]config.MetricRewriteRule{
+		{Match: `debug\..*`, Drop: true},
+		{Match: `legacy\.(.*)`, Rename: "app.$1", RemoveTags: []string{"user_id"}},
+	})
+	require.NoError(t, err)
+
+	checkSender.Gauge("debug.metric", 1, "", nil)
+	checkSender.Gauge("legacy.metric", 1, "", []string{"user_id:1", "env:prod"})
+	sms := <-senderMetricSampleChan
+	assert.Equal(t, "app.metric", sms.metricSample.Name)
+	assert.Equal(t, []string{"env:prod"}, sms.metricSample.Tags)
+
+	checkSender.SendRawMetricSample(&metrics.MetricSample{Name: "debug.raw"})
+	checkSender.SendRawMetricSample(&metrics.MetricSample{Name: "legacy.raw"})
+	sms = <-senderMetricSampleChan
+	assert.Equal(t, "app.raw", sms.metricSample.Name)
+	assert.Len(t, senderMetricSampleChan, 0)
+}
+
 func TestGetSenderServiceTagServiceCheck(t *testing.T) {
 	resetAggregator()
 	InitAggregator(nil, "testhostname")
@@ -28,6 +28,7 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	rollups                     *rollups
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
@@ -41,6 +42,7 @@ func NewTimeSampler(interval int64) *TimeSampler {
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		rollups:                     newRollupsFromConfig(),
 	}
 }
 
@@ -181,6 +183,10 @@ func (s *TimeSampler) flush(timestamp float64) (metrics.Series, metrics.SketchSe
 
 	series := s.flushSeries(cutoffTime)
 	sketches := s.flushSketches(cutoffTime)
+	if s.rollups != nil {
+		series = s.rollups.rollupSeries(series)
+		sketches = s.rollups.rollupSketches(sketches)
+	}
 
 	// expiring contexts
 	s.contextResolver.expireContexts(timestamp - config.Datadog.GetFloat64("dogstatsd_context_expiry_seconds"))
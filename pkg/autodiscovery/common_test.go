@@ -62,6 +62,26 @@ func (s *dummyService) GetHostname() (string, error) {
 	return s.Hostname, nil
 }
 
+// GetLabels returns nil
+func (s *dummyService) GetLabels() (map[string]string, error) {
+	return nil, nil
+}
+
+// GetAnnotations returns nil
+func (s *dummyService) GetAnnotations() (map[string]string, error) {
+	return nil, nil
+}
+
+// GetKubeNamespace returns an empty namespace
+func (s *dummyService) GetKubeNamespace() (string, error) {
+	return "", nil
+}
+
+// GetKubePodName returns an empty pod name
+func (s *dummyService) GetKubePodName() (string, error) {
+	return "", nil
+}
+
 // GetCreationTime return a dummy creation time
 func (s *dummyService) GetCreationTime() integration.CreationTime {
 	return s.CreationTime
@@ -22,11 +22,14 @@ import (
 type variableGetter func(key []byte, svc listeners.Service) ([]byte, error)
 
 var templateVariables = map[string]variableGetter{
-	"host":     getHost,
-	"pid":      getPid,
-	"port":     getPort,
-	"hostname": getHostname,
-	"extra":    getExtra,
+	"host":       getHost,
+	"pid":        getPid,
+	"port":       getPort,
+	"hostname":   getHostname,
+	"extra":      getExtra,
+	"label":      getLabel,
+	"annotation": getAnnotation,
+	"kube":       getKube,
 }
 
 // SubstituteTemplateVariables replaces %%VARIABLES%% using the variableGetters passed in
@@ -254,6 +257,56 @@ func getExtra(tplVar []byte, svc listeners.Service) ([]byte, error) {
 	return value, nil
 }
 
+// getLabel returns the value of a label of the service
+func getLabel(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	if len(tplVar) == 0 {
+		return nil, fmt.Errorf("label name is missing for service %s, skipping config", svc.GetEntity())
+	}
+	labels, err := svc.GetLabels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for service %s, skipping config - %s", svc.GetEntity(), err)
+	}
+	value, found := labels[string(tplVar)]
+	if !found {
+		return nil, fmt.Errorf("label %s not found for service %s, skipping config", tplVar, svc.GetEntity())
+	}
+	return []byte(value), nil
+}
+
+// getAnnotation returns the value of an annotation of the service
+func getAnnotation(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	if len(tplVar) == 0 {
+		return nil, fmt.Errorf("annotation name is missing for service %s, skipping config", svc.GetEntity())
+	}
+	annotations, err := svc.GetAnnotations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations for service %s, skipping config - %s", svc.GetEntity(), err)
+	}
+	value, found := annotations[string(tplVar)]
+	if !found {
+		return nil, fmt.Errorf("annotation %s not found for service %s, skipping config", tplVar, svc.GetEntity())
+	}
+	return []byte(value), nil
+}
+
+// getKube returns the kubernetes namespace or pod name of the service
+func getKube(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	var value string
+	var err error
+	switch string(tplVar) {
+	case "namespace":
+		value, err = svc.GetKubeNamespace()
+	case "pod_name":
+		value, err = svc.GetKubePodName()
+	default:
+		return nil, fmt.Errorf("invalid kube variable %q for service %s, skipping config", tplVar, svc.GetEntity())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kube_%s for service %s, skipping config - %s", tplVar, svc.GetEntity(), err)
+	}
+	return []byte(value), nil
+}
+
 // getEnvvar returns a system environment variable if found
 func getEnvvar(envVar []byte) ([]byte, error) {
 	if len(envVar) == 0 {
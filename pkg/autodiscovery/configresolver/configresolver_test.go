@@ -31,6 +31,10 @@ type dummyService struct {
 	CreationTime  integration.CreationTime
 	CheckNames    []string
 	ExtraConfig   map[string]string
+	Labels        map[string]string
+	Annotations   map[string]string
+	KubeNamespace string
+	KubePodName   string
 }
 
 // GetEntity returns the service entity name
@@ -73,6 +77,26 @@ func (s *dummyService) GetHostname() (string, error) {
 	return s.Hostname, nil
 }
 
+// GetLabels returns dummy labels
+func (s *dummyService) GetLabels() (map[string]string, error) {
+	return s.Labels, nil
+}
+
+// GetAnnotations returns dummy annotations
+func (s *dummyService) GetAnnotations() (map[string]string, error) {
+	return s.Annotations, nil
+}
+
+// GetKubeNamespace returns a dummy namespace
+func (s *dummyService) GetKubeNamespace() (string, error) {
+	return s.KubeNamespace, nil
+}
+
+// GetKubePodName returns a dummy pod name
+func (s *dummyService) GetKubePodName() (string, error) {
+	return s.KubePodName, nil
+}
+
 // GetCreationTime return a dummy creation time
 func (s *dummyService) GetCreationTime() integration.CreationTime {
 	return s.CreationTime
@@ -559,6 +583,69 @@ func TestResolve(t *testing.T) {
 				Entity:        "a5901276aed1",
 			},
 		},
+		{
+			testName: "label, annotation and kube template variables",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"postgres"},
+				Labels:        map[string]string{"app.kubernetes.io/name": "billing", "tenant": "acme"},
+				Annotations:   map[string]string{"example.com/db-user": "datadog"},
+				KubeNamespace: "payments",
+				KubePodName:   "billing-6d4cf56db6-2xbt7",
+			},
+			tpl: integration.Config{
+				Name:          "postgres",
+				ADIdentifiers: []string{"postgres"},
+				Instances:     []integration.Data{integration.Data("app: %%label_app.kubernetes.io/name%%\ndbname: %%label_tenant%%\nnamespace: %%kube_namespace%%\npod: %%kube_pod_name%%\nusername: %%annotation_example.com/db-user%%")},
+			},
+			out: integration.Config{
+				Name:          "postgres",
+				ADIdentifiers: []string{"postgres"},
+				Instances:     []integration.Data{integration.Data("app: billing\ndbname: acme\nnamespace: payments\npod: billing-6d4cf56db6-2xbt7\ntags:\n- foo:bar\nusername: datadog\n")},
+				Entity:        "a5901276aed1",
+			},
+		},
+		{
+			testName: "label not found",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"postgres"},
+				Labels:        map[string]string{"app": "billing"},
+			},
+			tpl: integration.Config{
+				Name:          "postgres",
+				ADIdentifiers: []string{"postgres"},
+				Instances:     []integration.Data{integration.Data("dbname: %%label_tenant%%")},
+			},
+			errorString: "label tenant not found for service a5901276aed1, skipping config",
+		},
+		{
+			testName: "annotation not found",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"postgres"},
+			},
+			tpl: integration.Config{
+				Name:          "postgres",
+				ADIdentifiers: []string{"postgres"},
+				Instances:     []integration.Data{integration.Data("username: %%annotation_example.com/db-user%%")},
+			},
+			errorString: "annotation example.com/db-user not found for service a5901276aed1, skipping config",
+		},
+		{
+			testName: "invalid kube template variable",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"postgres"},
+				KubeNamespace: "payments",
+			},
+			tpl: integration.Config{
+				Name:          "postgres",
+				ADIdentifiers: []string{"postgres"},
+				Instances:     []integration.Data{integration.Data("host: %%kube_node%%")},
+			},
+			errorString: "invalid kube variable \"node\" for service a5901276aed1, skipping config",
+		},
 		{
 			testName: "with IgnoreAutodiscoveryTags disabled",
 			svc: &dummyService{
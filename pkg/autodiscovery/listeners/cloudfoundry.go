@@ -241,6 +241,26 @@ func (s *CloudFoundryService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels isn't supported
+func (s *CloudFoundryService) GetLabels() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetAnnotations isn't supported
+func (s *CloudFoundryService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *CloudFoundryService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *CloudFoundryService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime returns the creation time of the container
 func (s *CloudFoundryService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+)
+
+// ContainerService implements and store results from the Service interface for
+// the listeners of container runtimes which are fully resolved at creation time (containerd, podman)
+type ContainerService struct {
+	entity          string
+	taggerEntity    string
+	adIdentifiers   []string
+	hosts           map[string]string
+	ports           []ContainerPort
+	pid             int
+	hostname        string
+	creationTime    integration.CreationTime
+	checkNames      []string
+	metricsExcluded bool
+	logsExcluded    bool
+	labels          map[string]string
+}
+
+// Make sure ContainerService implements the Service interface
+var _ Service = &ContainerService{}
+
+// GetEntity returns the unique entity name linked to that service
+func (s *ContainerService) GetEntity() string {
+	return s.entity
+}
+
+// GetTaggerEntity returns the tagger entity
+func (s *ContainerService) GetTaggerEntity() string {
+	return s.taggerEntity
+}
+
+// GetADIdentifiers returns the AD identifiers computed from the container image and labels
+func (s *ContainerService) GetADIdentifiers() ([]string, error) {
+	return s.adIdentifiers, nil
+}
+
+// GetHosts returns the container's hosts
+func (s *ContainerService) GetHosts() (map[string]string, error) {
+	return s.hosts, nil
+}
+
+// GetPorts returns the container's ports
+func (s *ContainerService) GetPorts() ([]ContainerPort, error) {
+	return s.ports, nil
+}
+
+// GetTags retrieves tags using the Tagger
+func (s *ContainerService) GetTags() ([]string, string, error) {
+	return tagger.TagWithHash(s.GetTaggerEntity(), tagger.ChecksCardinality)
+}
+
+// GetPid returns the pid of the container init process
+func (s *ContainerService) GetPid() (int, error) {
+	return s.pid, nil
+}
+
+// GetHostname returns the hostname of the container
+func (s *ContainerService) GetHostname() (string, error) {
+	if s.hostname == "" {
+		return "", fmt.Errorf("empty hostname for container %s", s.entity)
+	}
+	return s.hostname, nil
+}
+
+// GetLabels returns the labels of the container
+func (s *ContainerService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations isn't supported
+func (s *ContainerService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *ContainerService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *ContainerService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetCreationTime returns the creation time of the container compare to the agent start.
+func (s *ContainerService) GetCreationTime() integration.CreationTime {
+	return s.creationTime
+}
+
+// IsReady returns if the service is ready
+func (s *ContainerService) IsReady() bool {
+	return true
+}
+
+// GetCheckNames returns slice check names defined in container labels
+func (s *ContainerService) GetCheckNames() []string {
+	return s.checkNames
+}
+
+// HasFilter returns true if metrics or logs collection must be excluded for this service
+// no containers.GlobalFilter case here because we don't create services that are globally excluded in AD
+func (s *ContainerService) HasFilter(filter containers.FilterType) bool {
+	switch filter {
+	case containers.MetricsFilter:
+		return s.metricsExcluded
+	case containers.LogsFilter:
+		return s.logsExcluded
+	}
+	return false
+}
+
+// GetExtraConfig isn't supported
+func (s *ContainerService) GetExtraConfig(key []byte) ([]byte, error) {
+	return []byte{}, ErrNotSupported
+}
@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// tcpListenState is the hex code of the LISTEN state in /proc/<pid>/net/tcp
+const tcpListenState = "0A"
+
+// procRoute is a route of /proc/<pid>/net/route
+type procRoute struct {
+	iface   string
+	network net.IPNet
+}
+
+// getContainerNetworkFromProc reads the addresses and listening TCP ports of the network
+// namespace of a container from the `/proc/<pid>/net` directory of its init process.
+// It is used for runtimes which don't expose the network configuration of their containers,
+// the addresses are indexed by the name of the interface they are reachable through.
+func getContainerNetworkFromProc(pid int) (map[string]string, []ContainerPort, error) {
+	if pid <= 0 {
+		return nil, nil, fmt.Errorf("invalid pid %d", pid)
+	}
+	netDir := filepath.Join(config.Datadog.GetString("container_proc_root"), strconv.Itoa(pid), "net")
+
+	hosts, err := readProcHosts(netDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ports, err := readProcListeningPorts(netDir)
+	if err != nil {
+		return hosts, nil, err
+	}
+
+	return hosts, ports, nil
+}
+
+func readProcHosts(netDir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(netDir, "route"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	routes, err := parseProcRoutes(f)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err = os.Open(filepath.Join(netDir, "fib_trie"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	addresses, err := parseProcLocalAddresses(f)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]string)
+	for _, ip := range addresses {
+		// Use the most specific route to find the interface of the address
+		iface, bestSize := "", -1
+		for _, route := range routes {
+			size, _ := route.network.Mask.Size()
+			if route.network.Contains(ip) && size > bestSize {
+				iface, bestSize = route.iface, size
+			}
+		}
+		if iface == "" {
+			continue
+		}
+		if _, found := hosts[iface]; !found {
+			hosts[iface] = ip.String()
+		}
+	}
+	return hosts, nil
+}
+
+func readProcListeningPorts(netDir string) ([]ContainerPort, error) {
+	seen := make(map[int]struct{})
+	for _, file := range []string{"tcp", "tcp6"} {
+		f, err := os.Open(filepath.Join(netDir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// IPv6 can be disabled
+				continue
+			}
+			return nil, err
+		}
+		ports, err := parseProcListeningPorts(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ports {
+			seen[p] = struct{}{}
+		}
+	}
+
+	ports := make([]ContainerPort, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, ContainerPort{p, ""})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Port < ports[j].Port
+	})
+	return ports, nil
+}
+
+// parseProcRoutes parses the IPv4 routing table in the /proc/net/route format
+func parseProcRoutes(r io.Reader) ([]procRoute, error) {
+	var routes []procRoute
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Skip the header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		dest, err := parseProcIP(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseProcIP(fields[7])
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, procRoute{
+			iface:   fields[0],
+			network: net.IPNet{IP: dest, Mask: net.IPMask(mask)},
+		})
+	}
+	return routes, scanner.Err()
+}
+
+// parseProcLocalAddresses returns the non-loopback local IPv4 addresses listed in the /proc/net/fib_trie format
+func parseProcLocalAddresses(r io.Reader) ([]net.IP, error) {
+	var addresses []net.IP
+	seen := make(map[string]struct{})
+	var current string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "|-- "):
+			current = strings.TrimPrefix(line, "|-- ")
+		case strings.HasPrefix(line, "/32 host LOCAL"):
+			if _, found := seen[current]; found {
+				continue
+			}
+			seen[current] = struct{}{}
+			ip := net.ParseIP(current)
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+			addresses = append(addresses, ip.To4())
+		}
+	}
+	return addresses, scanner.Err()
+}
+
+// parseProcListeningPorts returns the TCP ports listened on a non-loopback address
+// in the /proc/net/tcp and /proc/net/tcp6 formats
+func parseProcListeningPorts(r io.Reader) ([]int, error) {
+	var ports []int
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Skip the header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != tcpListenState {
+			continue
+		}
+		parts := strings.Split(fields[1], ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed local address %q", fields[1])
+		}
+		ip, err := parseProcIP(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if ip.IsLoopback() {
+			continue
+		}
+		port, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("malformed local address %q: %v", fields[1], err)
+		}
+		ports = append(ports, int(port))
+	}
+	return ports, scanner.Err()
+}
+
+// parseProcIP parses an hex-encoded IPv4 or IPv6 address of the /proc/net files,
+// which are printed as 32 bits words in host byte order (little endian on the supported architectures).
+func parseProcIP(s string) (net.IP, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, fmt.Errorf("malformed address %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(raw[i:]))
+	}
+	return ip, nil
+}
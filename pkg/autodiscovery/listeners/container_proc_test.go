@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	testProcRoute = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0100580A	0003	0	0	0	00000000	0	0	0
+eth0	0000580A	00000000	0001	0	0	0	0000FFFF	0	0	0
+eth1	0000110A	00000000	0001	0	0	0	0000FFFF	0	0	0
+`
+	testProcFibTrie = `Main:
+  +-- 0.0.0.0/0 3 0 5
+     |-- 0.0.0.0
+        /0 universe UNICAST
+     +-- 10.88.0.0/16 2 0 2
+        +-- 10.88.0.0/30 2 0 2
+           |-- 10.88.0.0
+              /16 link UNICAST
+           |-- 10.88.0.5
+              /32 host LOCAL
+        |-- 10.88.255.255
+           /32 link BROADCAST
+     +-- 10.17.0.0/16 2 0 2
+           |-- 10.17.0.9
+              /32 host LOCAL
+     +-- 127.0.0.0/8 2 0 2
+           |-- 127.0.0.1
+              /32 host LOCAL
+Local:
+  +-- 0.0.0.0/0 3 0 5
+           |-- 10.88.0.5
+              /32 host LOCAL
+           |-- 127.0.0.1
+              /32 host LOCAL
+`
+	testProcTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 662 1 0000000091cb593a 100 0 0 10 0
+   1: 0100007F:BC8F 00000000:0000 0A 00000000:00000000 00:00000000 00000000 65534        0 905 1 00000000c38f3757 100 0 0 10 0
+   2: 0500580A:1F90 0600580A:D2F0 01 00000000:00000000 00:00000000 00000000     0        0 906 1 00000000c38f3757 100 0 0 10 0
+   3: 0500580A:18EB 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 907 1 00000000c38f3757 100 0 0 10 0
+`
+	testProcTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0 100 0 0 10 0
+   1: 00000000000000000000000001000000:0277 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0 100 0 0 10 0
+`
+)
+
+func TestParseProcIP(t *testing.T) {
+	ip, err := parseProcIP("0500580A")
+	require.NoError(t, err)
+	assert.Equal(t, "10.88.0.5", ip.String())
+
+	ip, err = parseProcIP("00000000000000000000000001000000")
+	require.NoError(t, err)
+	assert.True(t, ip.Equal(net.IPv6loopback))
+
+	_, err = parseProcIP("0500580")
+	assert.Error(t, err)
+	_, err = parseProcIP("0500580A00")
+	assert.Error(t, err)
+}
+
+func TestParseProcLocalAddresses(t *testing.T) {
+	addresses, err := parseProcLocalAddresses(strings.NewReader(testProcFibTrie))
+	require.NoError(t, err)
+	require.Len(t, addresses, 2)
+	assert.Equal(t, "10.88.0.5", addresses[0].String())
+	assert.Equal(t, "10.17.0.9", addresses[1].String())
+}
+
+func TestParseProcListeningPorts(t *testing.T) {
+	ports, err := parseProcListeningPorts(strings.NewReader(testProcTCP))
+	require.NoError(t, err)
+	assert.Equal(t, []int{8080, 6379}, ports)
+
+	ports, err = parseProcListeningPorts(strings.NewReader(testProcTCP6))
+	require.NoError(t, err)
+	assert.Equal(t, []int{80}, ports)
+
+	_, err = parseProcListeningPorts(strings.NewReader("header\n 0: 0500580A 00000000:0000 0A\n"))
+	assert.Error(t, err)
+}
+
+func TestGetContainerNetworkFromProc(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	netDir := filepath.Join(procRoot, "42", "net")
+	require.NoError(t, os.MkdirAll(netDir, 0755))
+	for name, content := range map[string]string{
+		"route":    testProcRoute,
+		"fib_trie": testProcFibTrie,
+		"tcp":      testProcTCP,
+		"tcp6":     testProcTCP6,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(netDir, name), []byte(content), 0644))
+	}
+
+	mockConfig := config.Mock()
+	mockConfig.Set("container_proc_root", procRoot)
+
+	hosts, ports, err := getContainerNetworkFromProc(42)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"eth0": "10.88.0.5", "eth1": "10.17.0.9"}, hosts)
+	assert.Equal(t, []ContainerPort{{80, ""}, {6379, ""}, {8080, ""}}, ports)
+
+	// IPv6 can be disabled
+	require.NoError(t, os.Remove(filepath.Join(netDir, "tcp6")))
+	_, ports, err = getContainerNetworkFromProc(42)
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerPort{{6379, ""}, {8080, ""}}, ports)
+
+	_, _, err = getContainerNetworkFromProc(43)
+	assert.Error(t, err)
+	_, _, err = getContainerNetworkFromProc(0)
+	assert.Error(t, err)
+}
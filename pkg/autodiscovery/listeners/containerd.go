@@ -0,0 +1,283 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build containerd
+
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	containerdevents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/events"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	ctrUtil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	containerdTaskStartTopic = "/tasks/start"
+	containerdTaskExitTopic  = "/tasks/exit"
+)
+
+// ContainerdListener implements the ServiceListener interface.
+// It listens for the task events of containerd and reports the running containers
+// of `containerd_namespace` to Auto Discovery. Containers managed by Kubernetes are left
+// to the kubelet listener.
+type ContainerdListener struct {
+	containerdUtil ctrUtil.ContainerdItf
+	filters        *containerFilters
+	services       map[string]Service
+	newService     chan<- Service
+	delService     chan<- Service
+	stop           chan bool
+	health         *health.Handle
+	m              sync.RWMutex
+}
+
+func init() {
+	Register("containerd", NewContainerdListener)
+}
+
+// NewContainerdListener creates a client connection to containerd and instantiate a ContainerdListener with it
+func NewContainerdListener() (ServiceListener, error) {
+	cu, err := ctrUtil.GetContainerdUtil()
+	if err != nil {
+		return nil, err
+	}
+	filters, err := newContainerFilters()
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerdListener{
+		containerdUtil: cu,
+		filters:        filters,
+		services:       make(map[string]Service),
+		stop:           make(chan bool),
+		health:         health.RegisterReadiness("ad-containerdlistener"),
+	}, nil
+}
+
+// Listen streams the task events from containerd and report the running containers as Services.
+func (l *ContainerdListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	// setup the I/O channels
+	l.newService = newSvc
+	l.delService = delSvc
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// subscribe before listing the containers to not miss any event
+	messages, errs := l.subscribe(ctx)
+	l.syncServices(integration.Before)
+
+	go func() {
+		for {
+			select {
+			case <-l.stop:
+				cancel()
+				l.health.Deregister() //nolint:errcheck
+				return
+			case <-l.health.C:
+			case msg := <-messages:
+				l.processEvent(msg)
+			case err := <-errs:
+				// The event stream is interrupted when containerd restarts,
+				// subscribe again and catch up on the events we missed.
+				log.Warnf("containerd listener lost the event stream, subscribing again: %v", err)
+				select {
+				case <-l.stop:
+					cancel()
+					l.health.Deregister() //nolint:errcheck
+					return
+				case <-time.After(5 * time.Second):
+				}
+				messages, errs = l.subscribe(ctx)
+				l.syncServices(integration.After)
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of ContainerdListener
+func (l *ContainerdListener) Stop() {
+	l.stop <- true
+}
+
+func (l *ContainerdListener) subscribe(ctx context.Context) (<-chan *events.Envelope, <-chan error) {
+	ns := l.containerdUtil.Namespace()
+	return l.containerdUtil.GetEvents().Subscribe(ctx,
+		fmt.Sprintf(`topic==%q,namespace==%q`, containerdTaskStartTopic, ns),
+		fmt.Sprintf(`topic==%q,namespace==%q`, containerdTaskExitTopic, ns),
+	)
+}
+
+// syncServices looks at the currently running containers, creates services for the new ones
+// and removes the services of the ones which are not running anymore.
+// It is called at start up and when the event stream is interrupted.
+func (l *ContainerdListener) syncServices(creationTime integration.CreationTime) {
+	ctns, err := l.containerdUtil.Containers()
+	if err != nil {
+		log.Errorf("Couldn't retrieve container list - %s", err)
+		return
+	}
+
+	running := make(map[string]struct{}, len(ctns))
+	for _, ctn := range ctns {
+		pid, err := l.containerdUtil.TaskPid(ctn)
+		if err != nil {
+			log.Debugf("Container %s is not running: %s", ctn.ID(), err)
+			continue
+		}
+		running[ctn.ID()] = struct{}{}
+
+		l.m.RLock()
+		_, found := l.services[ctn.ID()]
+		l.m.RUnlock()
+		if !found {
+			l.createService(ctn, pid, creationTime)
+		}
+	}
+
+	var stopped []string
+	l.m.RLock()
+	for cID := range l.services {
+		if _, found := running[cID]; !found {
+			stopped = append(stopped, cID)
+		}
+	}
+	l.m.RUnlock()
+	for _, cID := range stopped {
+		l.removeService(cID)
+	}
+}
+
+// processEvent takes a containerd event, decodes it and creates or removes
+// the service of the container it is about.
+func (l *ContainerdListener) processEvent(msg *events.Envelope) {
+	if msg == nil || msg.Event == nil {
+		return
+	}
+
+	switch msg.Topic {
+	case containerdTaskStartTopic:
+		e := &containerdevents.TaskStart{}
+		if err := proto.Unmarshal(msg.Event.Value, e); err != nil {
+			log.Errorf("Could not decode %s event: %v", msg.Topic, err)
+			return
+		}
+		ctn, err := l.containerdUtil.Container(e.ContainerID)
+		if err != nil {
+			log.Errorf("Failed to load container %s - %s", e.ContainerID, err)
+			return
+		}
+
+		l.m.RLock()
+		_, found := l.services[e.ContainerID]
+		l.m.RUnlock()
+		if found {
+			// Container restarted with the same ID within 5 seconds.
+			time.AfterFunc(5*time.Second, func() {
+				l.createService(ctn, e.Pid, integration.After)
+			})
+		} else {
+			l.createService(ctn, e.Pid, integration.After)
+		}
+	case containerdTaskExitTopic:
+		e := &containerdevents.TaskExit{}
+		if err := proto.Unmarshal(msg.Event.Value, e); err != nil {
+			log.Errorf("Could not decode %s event: %v", msg.Topic, err)
+			return
+		}
+		// Ignore the exit of the processes executed in the container
+		if e.ID != e.ContainerID {
+			return
+		}
+		l.removeService(e.ContainerID)
+	}
+}
+
+// createService takes a running container, create a service for it in its cache
+// and tells the AutoConfig that this service started.
+func (l *ContainerdListener) createService(ctn containerd.Container, pid uint32, creationTime integration.CreationTime) {
+	cID := ctn.ID()
+	info, err := l.containerdUtil.Info(ctn)
+	if err != nil {
+		log.Errorf("Failed to inspect container %s - %s", cID, err)
+		return
+	}
+	if findKubernetesInLabels(info.Labels) {
+		log.Debugf("container %s is managed by Kubernetes, ignoring it", cID)
+		return
+	}
+	// The container name is not available in containerd, we only rely on image based exclusion
+	if l.filters.IsExcluded(containers.GlobalFilter, "", info.Image, "") {
+		log.Debugf("container %s filtered out: image %q", cID, info.Image)
+		return
+	}
+
+	checkNames, err := getCheckNamesFromLabels(info.Labels)
+	if err != nil {
+		log.Errorf("Error getting check names from labels on container %s: %v", cID, err)
+	}
+
+	entity := containers.BuildEntityName(containers.RuntimeNameContainerd, cID)
+	svc := &ContainerService{
+		entity:          entity,
+		taggerEntity:    containers.BuildTaggerEntityName(cID),
+		adIdentifiers:   ComputeContainerServiceIDs(entity, info.Image, info.Labels),
+		pid:             int(pid),
+		creationTime:    creationTime,
+		checkNames:      checkNames,
+		labels:          info.Labels,
+		metricsExcluded: l.filters.IsExcluded(containers.MetricsFilter, "", info.Image, ""),
+		logsExcluded:    l.filters.IsExcluded(containers.LogsFilter, "", info.Image, ""),
+	}
+
+	spec, err := l.containerdUtil.Spec(ctn)
+	if err != nil {
+		log.Debugf("Failed to get the spec of container %s - %s", cID, err)
+	} else {
+		svc.hostname = spec.Hostname
+	}
+
+	svc.hosts, svc.ports, err = getContainerNetworkFromProc(svc.pid)
+	if err != nil {
+		log.Warnf("Failed to read the network configuration of container %s - %s", cID, err)
+	}
+
+	l.m.Lock()
+	l.services[cID] = svc
+	l.m.Unlock()
+
+	l.newService <- svc
+}
+
+// removeService takes a container ID, removes the related service from its cache
+// and tells the AutoConfig that this service stopped.
+func (l *ContainerdListener) removeService(cID string) {
+	l.m.RLock()
+	svc, ok := l.services[cID]
+	l.m.RUnlock()
+
+	if ok {
+		// delay service removal for short lived service detection
+		time.AfterFunc(5*time.Second, func() {
+			l.m.Lock()
+			delete(l.services, cID)
+			l.m.Unlock()
+			l.delService <- svc
+		})
+	} else {
+		log.Debugf("Container %s not found, not removing", cID)
+	}
+}
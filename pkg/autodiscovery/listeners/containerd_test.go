@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build containerd
+
+package listeners
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/containerd/containerd"
+	containerdevents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/oci"
+	prototypes "github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type fakeContainer struct {
+	containerd.Container
+	id string
+}
+
+func (c *fakeContainer) ID() string {
+	return c.id
+}
+
+// mockContainerdUtil serves containers indexed by ID, the running ones have a pid
+type mockContainerdUtil struct {
+	containers map[string]containers.Container
+	pids       map[string]uint32
+}
+
+func (m *mockContainerdUtil) Container(id string) (containerd.Container, error) {
+	if _, found := m.containers[id]; !found {
+		return nil, fmt.Errorf("container %s not found", id)
+	}
+	return &fakeContainer{id: id}, nil
+}
+
+func (m *mockContainerdUtil) Containers() ([]containerd.Container, error) {
+	var ctns []containerd.Container
+	for id := range m.containers {
+		ctns = append(ctns, &fakeContainer{id: id})
+	}
+	return ctns, nil
+}
+
+func (m *mockContainerdUtil) GetEvents() containerd.EventService {
+	return nil
+}
+
+func (m *mockContainerdUtil) Info(ctn containerd.Container) (containers.Container, error) {
+	return m.containers[ctn.ID()], nil
+}
+
+func (m *mockContainerdUtil) ImageSize(ctn containerd.Container) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockContainerdUtil) Spec(ctn containerd.Container) (*oci.Spec, error) {
+	return &oci.Spec{Hostname: ctn.ID() + "-host"}, nil
+}
+
+func (m *mockContainerdUtil) Metadata() (containerd.Version, error) {
+	return containerd.Version{}, nil
+}
+
+func (m *mockContainerdUtil) Namespace() string {
+	return "default"
+}
+
+func (m *mockContainerdUtil) TaskMetrics(ctn containerd.Container) (*types.Metric, error) {
+	return nil, nil
+}
+
+func (m *mockContainerdUtil) TaskPid(ctn containerd.Container) (uint32, error) {
+	if pid, found := m.pids[ctn.ID()]; found {
+		return pid, nil
+	}
+	return 0, fmt.Errorf("no running task for container %s", ctn.ID())
+}
+
+func (m *mockContainerdUtil) TaskPids(ctn containerd.Container) ([]containerd.ProcessInfo, error) {
+	return nil, nil
+}
+
+func newTestContainerdListener(t *testing.T, cu *mockContainerdUtil) (*ContainerdListener, chan Service) {
+	// The network of the containers is not tested here
+	mockConfig := config.Mock()
+	mockConfig.Set("container_proc_root", "/does/not/exist")
+
+	filters, err := newContainerFilters()
+	require.NoError(t, err)
+	newSvc := make(chan Service, 10)
+	return &ContainerdListener{
+		containerdUtil: cu,
+		filters:        filters,
+		services:       make(map[string]Service),
+		newService:     newSvc,
+	}, newSvc
+}
+
+func newContainerdEnvelope(t *testing.T, topic string, event interface{ Marshal() ([]byte, error) }) *events.Envelope {
+	value, err := event.Marshal()
+	require.NoError(t, err)
+	return &events.Envelope{
+		Topic: topic,
+		Event: &prototypes.Any{Value: value},
+	}
+}
+
+func TestContainerdListenerSyncServices(t *testing.T) {
+	listener, newSvc := newTestContainerdListener(t, &mockContainerdUtil{
+		containers: map[string]containers.Container{
+			"redis": {
+				Image:  "docker.io/library/redis:latest",
+				Labels: map[string]string{"com.datadoghq.ad.check_names": "[\"redisdb\"]"},
+			},
+			"stopped": {Image: "docker.io/library/nginx:latest"},
+			"kube": {
+				Image:  "docker.io/library/nginx:latest",
+				Labels: map[string]string{"io.kubernetes.pod.name": "nginx"},
+			},
+		},
+		pids: map[string]uint32{"redis": 42, "kube": 43},
+	})
+
+	listener.syncServices(integration.Before)
+	require.Len(t, newSvc, 1)
+	svc := (<-newSvc).(*ContainerService)
+
+	assert.Equal(t, "containerd://redis", svc.GetEntity())
+	assert.Equal(t, "container_id://redis", svc.GetTaggerEntity())
+	ids, err := svc.GetADIdentifiers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"containerd://redis", "docker.io/library/redis", "redis"}, ids)
+	pid, err := svc.GetPid()
+	require.NoError(t, err)
+	assert.Equal(t, 42, pid)
+	hostname, err := svc.GetHostname()
+	require.NoError(t, err)
+	assert.Equal(t, "redis-host", hostname)
+	assert.Equal(t, []string{"redisdb"}, svc.GetCheckNames())
+	assert.Equal(t, integration.Before, svc.GetCreationTime())
+
+	// Known containers are not created again
+	listener.syncServices(integration.After)
+	assert.Len(t, newSvc, 0)
+}
+
+func TestContainerdListenerProcessEvent(t *testing.T) {
+	listener, newSvc := newTestContainerdListener(t, &mockContainerdUtil{
+		containers: map[string]containers.Container{
+			"redis": {Image: "docker.io/library/redis:latest"},
+		},
+	})
+
+	listener.processEvent(newContainerdEnvelope(t, containerdTaskStartTopic, &containerdevents.TaskStart{ContainerID: "redis", Pid: 42}))
+	require.Len(t, newSvc, 1)
+	svc := <-newSvc
+	pid, err := svc.GetPid()
+	require.NoError(t, err)
+	assert.Equal(t, 42, pid)
+	assert.Equal(t, integration.After, svc.GetCreationTime())
+
+	// Unknown containers are ignored
+	listener.processEvent(newContainerdEnvelope(t, containerdTaskStartTopic, &containerdevents.TaskStart{ContainerID: "unknown", Pid: 43}))
+	assert.Len(t, newSvc, 0)
+
+	// The exit of an exec'd process doesn't remove the service
+	listener.processEvent(newContainerdEnvelope(t, containerdTaskExitTopic, &containerdevents.TaskExit{ContainerID: "redis", ID: "exec-1", Pid: 44}))
+	listener.m.RLock()
+	assert.Contains(t, listener.services, "redis")
+	listener.m.RUnlock()
+}
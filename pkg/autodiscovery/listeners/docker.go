@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"io"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
@@ -598,22 +597,42 @@ func (s *DockerService) GetHostname() (string, error) {
 	return s.hostname, nil
 }
 
+// GetLabels returns the labels of the container
+func (s *DockerService) GetLabels() (map[string]string, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+	cInspect, err := du.Inspect(s.cID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s", s.cID[:12])
+	}
+	if cInspect.Config == nil {
+		return nil, fmt.Errorf("invalid inspect for container %s", s.cID[:12])
+	}
+	return cInspect.Config.Labels, nil
+}
+
+// GetAnnotations isn't supported
+func (s *DockerService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *DockerService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *DockerService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime returns the creation time of the container compare to the agent start.
 func (s *DockerService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
 }
 
-// findKubernetesInLabels traverses a map of container labels and
-// returns true if a kubernetes label is detected
-func findKubernetesInLabels(labels map[string]string) bool {
-	for name := range labels {
-		if strings.HasPrefix(name, "io.kubernetes.") {
-			return true
-		}
-	}
-	return false
-}
-
 // IsReady returns if the service is ready
 func (s *DockerService) IsReady() bool {
 	return true
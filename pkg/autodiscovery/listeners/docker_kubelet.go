@@ -33,7 +33,7 @@ type DockerKubeletService struct {
 // Make sure DockerKubeletService implements the Service interface
 var _ Service = &DockerKubeletService{}
 
-// getPod wraps KubeUtil init and pod lookup for both public methods.
+// getPod wraps KubeUtil init and pod lookup for the public methods.
 func (s *DockerKubeletService) getPod() (*kubelet.Pod, error) {
 	if s.kubeUtil == nil {
 		var err error
@@ -100,6 +100,43 @@ func (s *DockerKubeletService) GetPorts() ([]ContainerPort, error) {
 	return ports, nil
 }
 
+// GetLabels returns the labels of the container's pod, like the kubelet listener
+// does, instead of the labels of the container
+func (s *DockerKubeletService) GetLabels() (map[string]string, error) {
+	pod, err := s.getPod()
+	if err != nil {
+		return nil, err
+	}
+	return pod.Metadata.Labels, nil
+}
+
+// GetAnnotations returns the annotations of the container's pod
+func (s *DockerKubeletService) GetAnnotations() (map[string]string, error) {
+	pod, err := s.getPod()
+	if err != nil {
+		return nil, err
+	}
+	return pod.Metadata.Annotations, nil
+}
+
+// GetKubeNamespace returns the namespace of the container's pod
+func (s *DockerKubeletService) GetKubeNamespace() (string, error) {
+	pod, err := s.getPod()
+	if err != nil {
+		return "", err
+	}
+	return pod.Metadata.Namespace, nil
+}
+
+// GetKubePodName returns the name of the container's pod
+func (s *DockerKubeletService) GetKubePodName() (string, error) {
+	pod, err := s.getPod()
+	if err != nil {
+		return "", err
+	}
+	return pod.Metadata.Name, nil
+}
+
 // IsReady returns if the service is ready
 func (s *DockerKubeletService) IsReady() bool {
 	pod, err := s.getPod()
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build docker,kubelet
+
+package listeners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+type fakePodKubeUtil struct {
+	kubelet.KubeUtilInterface
+	pod *kubelet.Pod
+}
+
+func (f *fakePodKubeUtil) GetPodForContainerID(containerID string) (*kubelet.Pod, error) {
+	return f.pod, nil
+}
+
+func TestDockerKubeletServicePodMetadata(t *testing.T) {
+	pod := &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:        "redis-0",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "redis"},
+			Annotations: map[string]string{"team": "db"},
+		},
+	}
+	svc := &DockerKubeletService{
+		DockerService: DockerService{cID: "foo"},
+		kubeUtil:      &fakePodKubeUtil{pod: pod},
+	}
+
+	// the labels are the ones of the pod, like for the kubelet listener
+	labels, err := svc.GetLabels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "redis"}, labels)
+
+	annotations, err := svc.GetAnnotations()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "db"}, annotations)
+
+	namespace, err := svc.GetKubeNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+
+	podName, err := svc.GetKubePodName()
+	require.NoError(t, err)
+	assert.Equal(t, "redis-0", podName)
+}
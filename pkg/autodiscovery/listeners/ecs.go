@@ -49,6 +49,7 @@ type ECSService struct {
 	checkNames      []string
 	metricsExcluded bool
 	logsExcluded    bool
+	labels          map[string]string
 }
 
 // Make sure ECSService implements the Service interface
@@ -191,6 +192,7 @@ func (l *ECSListener) createService(c v2.Container, firstRun bool) (ECSService,
 	// ADIdentifiers
 	image := c.Image
 	labels := c.Labels
+	svc.labels = labels
 	svc.ADIdentifiers = ComputeContainerServiceIDs(svc.GetEntity(), image, labels)
 	var err error
 	svc.checkNames, err = getCheckNamesFromLabels(labels)
@@ -266,6 +268,26 @@ func (s *ECSService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels returns the labels of the container
+func (s *ECSService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations isn't supported
+func (s *ECSService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *ECSService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *ECSService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime returns the creation time of the container compare to the agent start.
 func (s *ECSService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
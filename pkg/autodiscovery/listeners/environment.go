@@ -107,6 +107,26 @@ func (s *EnvironmentService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels isn't supported
+func (s *EnvironmentService) GetLabels() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetAnnotations isn't supported
+func (s *EnvironmentService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *EnvironmentService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *EnvironmentService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime is always before for environment service
 func (s *EnvironmentService) GetCreationTime() integration.CreationTime {
 	return integration.Before
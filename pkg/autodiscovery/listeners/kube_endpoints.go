@@ -52,6 +52,10 @@ type KubeEndpointService struct {
 	hosts        map[string]string
 	ports        []ContainerPort
 	creationTime integration.CreationTime
+	labels       map[string]string
+	annotations  map[string]string
+	namespace    string
+	podName      string
 }
 
 // Make sure KubeEndpointService implements the Service interface
@@ -307,6 +311,9 @@ func processEndpoints(kep *v1.Endpoints, alreadyExistingService bool, tags []str
 				creationTime: integration.After,
 				hosts:        map[string]string{"endpoint": host.IP},
 				ports:        ports,
+				labels:       kep.GetLabels(),
+				annotations:  kep.GetAnnotations(),
+				namespace:    kep.Namespace,
 				tags: []string{
 					fmt.Sprintf("kube_service:%s", kep.Name),
 					fmt.Sprintf("kube_namespace:%s", kep.Namespace),
@@ -314,6 +321,9 @@ func processEndpoints(kep *v1.Endpoints, alreadyExistingService bool, tags []str
 				},
 			}
 			ep.tags = append(ep.tags, tags...)
+			if host.TargetRef != nil && host.TargetRef.Kind == "Pod" {
+				ep.podName = host.TargetRef.Name
+			}
 			if alreadyExistingService {
 				ep.creationTime = integration.Before
 			}
@@ -412,6 +422,29 @@ func (s *KubeEndpointService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels returns the labels of the endpoints
+func (s *KubeEndpointService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations returns the annotations of the endpoints
+func (s *KubeEndpointService) GetAnnotations() (map[string]string, error) {
+	return s.annotations, nil
+}
+
+// GetKubeNamespace returns the namespace of the endpoints
+func (s *KubeEndpointService) GetKubeNamespace() (string, error) {
+	return s.namespace, nil
+}
+
+// GetKubePodName returns the name of the pod backing the endpoint
+func (s *KubeEndpointService) GetKubePodName() (string, error) {
+	if s.podName == "" {
+		return "", ErrNotSupported
+	}
+	return s.podName, nil
+}
+
 // GetCreationTime returns the creation time of the endpoint compare to the agent start.
 func (s *KubeEndpointService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
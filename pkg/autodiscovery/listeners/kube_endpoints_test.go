@@ -27,11 +27,12 @@ func TestProcessEndpoints(t *testing.T) {
 			UID:             types.UID("endpoints-uid"),
 			Name:            "myservice",
 			Namespace:       "default",
+			Labels:          map[string]string{"app": "myapp"},
 		},
 		Subsets: []v1.EndpointSubset{
 			{
 				Addresses: []v1.EndpointAddress{
-					{IP: "10.0.0.1"},
+					{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "myapp-1"}},
 					{IP: "10.0.0.2"},
 				},
 				Ports: []v1.EndpointPort{
@@ -77,6 +78,18 @@ func TestProcessEndpoints(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"kube_service:myservice", "kube_namespace:default", "kube_endpoint_ip:10.0.0.1", "foo:bar"}, tags)
 
+	labels, err := eps[0].GetLabels()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "myapp"}, labels)
+
+	namespace, err := eps[0].GetKubeNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+
+	podName, err := eps[0].GetKubePodName()
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp-1", podName)
+
 	assert.Equal(t, "kube_endpoint_uid://default/myservice/10.0.0.2", eps[1].GetEntity())
 	assert.Equal(t, integration.Before, eps[1].GetCreationTime())
 
@@ -96,6 +109,9 @@ func TestProcessEndpoints(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"kube_service:myservice", "kube_namespace:default", "kube_endpoint_ip:10.0.0.2", "foo:bar"}, tags)
 
+	_, err = eps[1].GetKubePodName()
+	assert.Equal(t, ErrNotSupported, err)
+
 	eps = processEndpoints(kep, false, []string{"foo:bar"})
 	assert.Equal(t, integration.After, eps[0].GetCreationTime())
 	assert.Equal(t, integration.After, eps[1].GetCreationTime())
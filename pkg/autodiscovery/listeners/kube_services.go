@@ -47,6 +47,9 @@ type KubeServiceService struct {
 	hosts        map[string]string
 	ports        []ContainerPort
 	creationTime integration.CreationTime
+	labels       map[string]string
+	annotations  map[string]string
+	namespace    string
 }
 
 // Make sure KubeServiceService implements the Service interface
@@ -194,10 +197,20 @@ func (l *KubeServiceListener) createService(ksvc *v1.Service, firstRun bool) {
 	l.newService <- svc
 }
 
+// NewKubeServiceService returns the Service of a Kubernetes service, whether it is
+// annotated or not, to resolve the templates of the config providers selecting the
+// services themselves
+func NewKubeServiceService(ksvc *v1.Service) *KubeServiceService {
+	return processService(ksvc, false)
+}
+
 func processService(ksvc *v1.Service, firstRun bool) *KubeServiceService {
 	svc := &KubeServiceService{
 		entity:       apiserver.EntityForService(ksvc),
 		creationTime: integration.After,
+		labels:       ksvc.GetLabels(),
+		annotations:  ksvc.GetAnnotations(),
+		namespace:    ksvc.Namespace,
 	}
 	if firstRun {
 		svc.creationTime = integration.Before
@@ -292,6 +305,26 @@ func (s *KubeServiceService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels returns the labels of the service
+func (s *KubeServiceService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations returns the annotations of the service
+func (s *KubeServiceService) GetAnnotations() (map[string]string, error) {
+	return s.annotations, nil
+}
+
+// GetKubeNamespace returns the namespace of the service
+func (s *KubeServiceService) GetKubeNamespace() (string, error) {
+	return s.namespace, nil
+}
+
+// GetKubePodName isn't supported
+func (s *KubeServiceService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime returns the creation time of the service compare to the agent start.
 func (s *KubeServiceService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
@@ -76,6 +76,18 @@ func TestProcessService(t *testing.T) {
 	sort.Strings(tags)
 	assert.Equal(t, expectedTags, tags)
 
+	labels, err := svc.GetLabels()
+	assert.NoError(t, err)
+	assert.Equal(t, "my-http-service", labels["tags.datadoghq.com/service"])
+
+	annotations, err := svc.GetAnnotations()
+	assert.NoError(t, err)
+	assert.Equal(t, "[\"http_check\"]", annotations["ad.datadoghq.com/service.check_names"])
+
+	namespace, err := svc.GetKubeNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+
 	svc = processService(ksvc, false)
 	assert.Equal(t, integration.After, svc.GetCreationTime())
 }
@@ -56,6 +56,10 @@ type KubeContainerService struct {
 	checkNames      []string
 	metricsExcluded bool
 	logsExcluded    bool
+	labels          map[string]string
+	annotations     map[string]string
+	namespace       string
+	podName         string
 }
 
 // Make sure KubeContainerService implements the Service interface
@@ -69,6 +73,10 @@ type KubePodService struct {
 	hosts         map[string]string
 	ports         []ContainerPort
 	creationTime  integration.CreationTime
+	labels        map[string]string
+	annotations   map[string]string
+	namespace     string
+	podName       string
 }
 
 // Make sure KubePodService implements the Service interface
@@ -194,6 +202,10 @@ func (l *KubeletListener) createPodService(pod *kubelet.Pod, firstRun bool) {
 		hosts:         map[string]string{"pod": podIP},
 		ports:         ports,
 		creationTime:  crTime,
+		labels:        pod.Metadata.Labels,
+		annotations:   pod.Metadata.Annotations,
+		namespace:     pod.Metadata.Namespace,
+		podName:       pod.Metadata.Name,
 	}
 
 	l.m.Lock()
@@ -214,6 +226,10 @@ func (l *KubeletListener) createService(entity string, pod *kubelet.Pod, firstRu
 		entity:       entity,
 		creationTime: crTime,
 		ready:        kubelet.IsPodReady(pod),
+		labels:       pod.Metadata.Labels,
+		annotations:  pod.Metadata.Annotations,
+		namespace:    pod.Metadata.Namespace,
+		podName:      pod.Metadata.Name,
 	}
 	podName := pod.Metadata.Name
 
@@ -445,6 +461,26 @@ func (s *KubeContainerService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels returns the labels of the pod of the container
+func (s *KubeContainerService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations returns the annotations of the pod of the container
+func (s *KubeContainerService) GetAnnotations() (map[string]string, error) {
+	return s.annotations, nil
+}
+
+// GetKubeNamespace returns the namespace of the pod of the container
+func (s *KubeContainerService) GetKubeNamespace() (string, error) {
+	return s.namespace, nil
+}
+
+// GetKubePodName returns the name of the pod of the container
+func (s *KubeContainerService) GetKubePodName() (string, error) {
+	return s.podName, nil
+}
+
 // GetCreationTime returns the creation time of the container compare to the agent start.
 func (s *KubeContainerService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
@@ -521,6 +557,26 @@ func (s *KubePodService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels returns the labels of the pod
+func (s *KubePodService) GetLabels() (map[string]string, error) {
+	return s.labels, nil
+}
+
+// GetAnnotations returns the annotations of the pod
+func (s *KubePodService) GetAnnotations() (map[string]string, error) {
+	return s.annotations, nil
+}
+
+// GetKubeNamespace returns the namespace of the pod
+func (s *KubePodService) GetKubeNamespace() (string, error) {
+	return s.namespace, nil
+}
+
+// GetKubePodName returns the name of the pod
+func (s *KubePodService) GetKubePodName() (string, error) {
+	return s.podName, nil
+}
+
 // GetCreationTime returns the creation time of the container compare to the agent start.
 func (s *KubePodService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
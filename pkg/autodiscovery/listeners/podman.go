@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/podman"
+)
+
+// PodmanListener implements the ServiceListener interface.
+// It listens for the container events of the podman API socket and
+// reports the running containers to Auto Discovery.
+type PodmanListener struct {
+	client     *podman.Client
+	filters    *containerFilters
+	services   map[string]Service
+	newService chan<- Service
+	delService chan<- Service
+	stop       chan bool
+	health     *health.Handle
+	m          sync.RWMutex
+}
+
+func init() {
+	Register("podman", NewPodmanListener)
+}
+
+// NewPodmanListener creates a client of the podman API and instantiate a PodmanListener with it
+func NewPodmanListener() (ServiceListener, error) {
+	filters, err := newContainerFilters()
+	if err != nil {
+		return nil, err
+	}
+	return &PodmanListener{
+		client:   podman.GetClient(),
+		filters:  filters,
+		services: make(map[string]Service),
+		stop:     make(chan bool),
+		health:   health.RegisterReadiness("ad-podmanlistener"),
+	}, nil
+}
+
+// Listen streams the container events from podman and report the running containers as Services.
+func (l *PodmanListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	// setup the I/O channels
+	l.newService = newSvc
+	l.delService = delSvc
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// subscribe before listing the containers to not miss any event
+	messages, errs := l.client.SubscribeToContainerEvents(ctx, podman.ContainerStartEvent, podman.ContainerDiedEvent)
+	l.syncServices(integration.Before)
+
+	go func() {
+		for {
+			select {
+			case <-l.stop:
+				cancel()
+				l.health.Deregister() //nolint:errcheck
+				return
+			case <-l.health.C:
+			case msg := <-messages:
+				l.processEvent(msg)
+			case err := <-errs:
+				// The event stream is interrupted when the podman service restarts,
+				// subscribe again and catch up on the events we missed.
+				log.Warnf("podman listener lost the event stream, subscribing again: %v", err)
+				select {
+				case <-l.stop:
+					cancel()
+					l.health.Deregister() //nolint:errcheck
+					return
+				case <-time.After(5 * time.Second):
+				}
+				messages, errs = l.client.SubscribeToContainerEvents(ctx, podman.ContainerStartEvent, podman.ContainerDiedEvent)
+				l.syncServices(integration.After)
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of PodmanListener
+func (l *PodmanListener) Stop() {
+	l.stop <- true
+}
+
+// syncServices looks at the currently running containers, creates services for the new ones
+// and removes the services of the ones which are not running anymore.
+// It is called at start up and when the event stream is interrupted.
+func (l *PodmanListener) syncServices(creationTime integration.CreationTime) {
+	ctns, err := l.client.ListContainers()
+	if err != nil {
+		log.Errorf("Couldn't retrieve container list - %s", err)
+		return
+	}
+
+	running := make(map[string]struct{}, len(ctns))
+	for _, ctn := range ctns {
+		running[ctn.ID] = struct{}{}
+
+		l.m.RLock()
+		_, found := l.services[ctn.ID]
+		l.m.RUnlock()
+		if !found {
+			l.createService(ctn.ID, creationTime)
+		}
+	}
+
+	var stopped []string
+	l.m.RLock()
+	for cID := range l.services {
+		if _, found := running[cID]; !found {
+			stopped = append(stopped, cID)
+		}
+	}
+	l.m.RUnlock()
+	for _, cID := range stopped {
+		l.removeService(cID)
+	}
+}
+
+// processEvent takes a podman event and creates or removes
+// the service of the container it is about.
+func (l *PodmanListener) processEvent(e podman.ContainerEvent) {
+	cID := e.Actor.ID
+	if cID == "" {
+		return
+	}
+
+	switch e.Action {
+	case podman.ContainerStartEvent:
+		l.m.RLock()
+		_, found := l.services[cID]
+		l.m.RUnlock()
+		if found {
+			// Container restarted with the same ID within 5 seconds.
+			time.AfterFunc(5*time.Second, func() {
+				l.createService(cID, integration.After)
+			})
+		} else {
+			l.createService(cID, integration.After)
+		}
+	case podman.ContainerDiedEvent:
+		l.removeService(cID)
+	}
+}
+
+// createService takes a container ID, create a service for it in its cache
+// and tells the AutoConfig that this service started.
+func (l *PodmanListener) createService(cID string, creationTime integration.CreationTime) {
+	ctn, err := l.client.InspectContainer(cID)
+	if err != nil {
+		log.Errorf("Failed to inspect container %s - %s", cID, err)
+		return
+	}
+	if !ctn.State.Running {
+		log.Debugf("Container %s is not running, ignoring it", cID)
+		return
+	}
+	name := ctn.Name
+	if l.filters.IsExcluded(containers.GlobalFilter, name, ctn.ImageName, "") {
+		log.Debugf("container %s filtered out: name %q image %q", cID, name, ctn.ImageName)
+		return
+	}
+
+	checkNames, err := getCheckNamesFromLabels(ctn.Config.Labels)
+	if err != nil {
+		log.Errorf("Error getting check names from labels on container %s: %v", cID, err)
+	}
+
+	entity := containers.BuildEntityName(containers.RuntimeNamePodman, cID)
+	svc := &ContainerService{
+		entity:          entity,
+		taggerEntity:    containers.BuildTaggerEntityName(cID),
+		adIdentifiers:   ComputeContainerServiceIDs(entity, ctn.ImageName, ctn.Config.Labels),
+		hosts:           getPodmanHosts(ctn),
+		ports:           getPodmanPorts(ctn),
+		pid:             ctn.State.Pid,
+		hostname:        ctn.Config.Hostname,
+		creationTime:    creationTime,
+		checkNames:      checkNames,
+		labels:          ctn.Config.Labels,
+		metricsExcluded: l.filters.IsExcluded(containers.MetricsFilter, name, ctn.ImageName, ""),
+		logsExcluded:    l.filters.IsExcluded(containers.LogsFilter, name, ctn.ImageName, ""),
+	}
+
+	// Containers on the host network or rootless ones don't have any network
+	// configuration in podman, look at their network namespace instead.
+	if len(svc.hosts) == 0 || len(svc.ports) == 0 {
+		hosts, ports, err := getContainerNetworkFromProc(svc.pid)
+		if err != nil {
+			log.Debugf("Failed to read the network configuration of container %s - %s", cID, err)
+		}
+		if len(svc.hosts) == 0 {
+			svc.hosts = hosts
+		}
+		if len(svc.ports) == 0 {
+			svc.ports = ports
+		}
+	}
+
+	l.m.Lock()
+	l.services[cID] = svc
+	l.m.Unlock()
+
+	l.newService <- svc
+}
+
+// removeService takes a container ID, removes the related service from its cache
+// and tells the AutoConfig that this service stopped.
+func (l *PodmanListener) removeService(cID string) {
+	l.m.RLock()
+	svc, ok := l.services[cID]
+	l.m.RUnlock()
+
+	if ok {
+		// delay service removal for short lived service detection
+		time.AfterFunc(5*time.Second, func() {
+			l.m.Lock()
+			delete(l.services, cID)
+			l.m.Unlock()
+			l.delService <- svc
+		})
+	} else {
+		log.Debugf("Container %s not found, not removing", cID)
+	}
+}
+
+// getPodmanHosts returns the addresses of a container on all its networks
+func getPodmanHosts(ctn *podman.Container) map[string]string {
+	ips := make(map[string]string)
+	for net, settings := range ctn.NetworkSettings.Networks {
+		if len(settings.IPAddress) > 0 {
+			ips[net] = settings.IPAddress
+		}
+	}
+	if len(ips) == 0 && len(ctn.NetworkSettings.IPAddress) > 0 {
+		ips["podman"] = ctn.NetworkSettings.IPAddress
+	}
+	return ips
+}
+
+// getPodmanPorts returns the ports exposed by a container
+func getPodmanPorts(ctn *podman.Container) []ContainerPort {
+	var ports []ContainerPort
+	for p := range ctn.NetworkSettings.Ports {
+		// Ports are formatted as `<port>/<protocol>`
+		port, err := strconv.Atoi(strings.SplitN(p, "/", 2)[0])
+		if err != nil {
+			log.Warnf("failed to extract port from: %s", p)
+			continue
+		}
+		ports = append(ports, ContainerPort{port, ""})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Port < ports[j].Port
+	})
+	return ports
+}
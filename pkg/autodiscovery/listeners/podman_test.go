@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/podman"
+)
+
+func newTestPodmanListener(t *testing.T, responses map[string]string) (*PodmanListener, chan Service, func()) {
+	dir, err := ioutil.TempDir("", "podman")
+	require.NoError(t, err)
+	socketPath := filepath.Join(dir, "podman.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if resp, found := responses[r.URL.Path]; found {
+			w.Write([]byte(resp)) //nolint:errcheck
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"no such container"}`)) //nolint:errcheck
+	}))
+	ts.Listener.Close()
+	ts.Listener = l
+	ts.Start()
+
+	filters, err := newContainerFilters()
+	require.NoError(t, err)
+	newSvc := make(chan Service, 10)
+	listener := &PodmanListener{
+		client:     podman.NewClient(socketPath, time.Second),
+		filters:    filters,
+		services:   make(map[string]Service),
+		newService: newSvc,
+	}
+
+	return listener, newSvc, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPodmanListenerSyncServices(t *testing.T) {
+	listener, newSvc, cleanup := newTestPodmanListener(t, map[string]string{
+		"/v1.0.0/libpod/containers/json": `[{"Id":"abc"},{"Id":"stopped"}]`,
+		"/v1.0.0/libpod/containers/abc/json": `{
+			"Id": "abc",
+			"Name": "redis",
+			"ImageName": "docker.io/library/redis:latest",
+			"State": {"Running": true, "Pid": 1234},
+			"Config": {"Hostname": "redis-host", "Labels": {"com.datadoghq.ad.check_names": "[\"redisdb\"]"}},
+			"NetworkSettings": {
+				"Ports": {"6379/tcp": [{"HostIp": "", "HostPort": "6379"}], "26379/tcp": null},
+				"Networks": {"podman": {"IPAddress": "10.88.0.5"}}
+			}
+		}`,
+		"/v1.0.0/libpod/containers/stopped/json": `{"Id": "stopped", "State": {"Running": false}}`,
+	})
+	defer cleanup()
+
+	listener.syncServices(integration.Before)
+	require.Len(t, newSvc, 1)
+	svc := (<-newSvc).(*ContainerService)
+
+	assert.Equal(t, "podman://abc", svc.GetEntity())
+	assert.Equal(t, "container_id://abc", svc.GetTaggerEntity())
+	ids, err := svc.GetADIdentifiers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"podman://abc", "docker.io/library/redis", "redis"}, ids)
+	hosts, err := svc.GetHosts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"podman": "10.88.0.5"}, hosts)
+	ports, err := svc.GetPorts()
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerPort{{6379, ""}, {26379, ""}}, ports)
+	pid, err := svc.GetPid()
+	require.NoError(t, err)
+	assert.Equal(t, 1234, pid)
+	hostname, err := svc.GetHostname()
+	require.NoError(t, err)
+	assert.Equal(t, "redis-host", hostname)
+	assert.Equal(t, []string{"redisdb"}, svc.GetCheckNames())
+	labels, err := svc.GetLabels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"com.datadoghq.ad.check_names": "[\"redisdb\"]"}, labels)
+	assert.Equal(t, integration.Before, svc.GetCreationTime())
+
+	// Known containers are not created again
+	listener.syncServices(integration.After)
+	assert.Len(t, newSvc, 0)
+}
+
+func TestPodmanListenerNetworkFromProc(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+	netDir := filepath.Join(procRoot, "1234", "net")
+	require.NoError(t, os.MkdirAll(netDir, 0755))
+	for name, content := range map[string]string{
+		"route":    testProcRoute,
+		"fib_trie": testProcFibTrie,
+		"tcp":      testProcTCP,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(netDir, name), []byte(content), 0644))
+	}
+	mockConfig := config.Mock()
+	mockConfig.Set("container_proc_root", procRoot)
+
+	// Rootless container without any network configuration
+	listener, newSvc, cleanup := newTestPodmanListener(t, map[string]string{
+		"/v1.0.0/libpod/containers/abc/json": `{
+			"Id": "abc",
+			"Name": "redis",
+			"ImageName": "docker.io/library/redis:latest",
+			"State": {"Running": true, "Pid": 1234},
+			"NetworkSettings": {"Networks": {}}
+		}`,
+	})
+	defer cleanup()
+
+	listener.processEvent(podman.ContainerEvent{Action: podman.ContainerStartEvent, Actor: podman.EventActor{ID: "abc"}})
+	require.Len(t, newSvc, 1)
+	svc := <-newSvc
+
+	hosts, err := svc.GetHosts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"eth0": "10.88.0.5", "eth1": "10.17.0.9"}, hosts)
+	ports, err := svc.GetPorts()
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerPort{{6379, ""}, {8080, ""}}, ports)
+	assert.Equal(t, integration.After, svc.GetCreationTime())
+}
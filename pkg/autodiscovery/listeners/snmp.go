@@ -368,6 +368,26 @@ func (s *SNMPService) GetHostname() (string, error) {
 	return "", ErrNotSupported
 }
 
+// GetLabels isn't supported
+func (s *SNMPService) GetLabels() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetAnnotations isn't supported
+func (s *SNMPService) GetAnnotations() (map[string]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetKubeNamespace isn't supported
+func (s *SNMPService) GetKubeNamespace() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetKubePodName isn't supported
+func (s *SNMPService) GetKubePodName() (string, error) {
+	return "", ErrNotSupported
+}
+
 // GetCreationTime returns the creation time of the Service
 func (s *SNMPService) GetCreationTime() integration.CreationTime {
 	return s.creationTime
@@ -23,19 +23,23 @@ type ContainerPort struct {
 // It should be matched with a check template by the ConfigResolver using the
 // ADIdentifiers field.
 type Service interface {
-	GetEntity() string                         // unique entity name
-	GetTaggerEntity() string                   // tagger entity name
-	GetADIdentifiers() ([]string, error)       // identifiers on which templates will be matched
-	GetHosts() (map[string]string, error)      // network --> IP address
-	GetPorts() ([]ContainerPort, error)        // network ports
-	GetTags() ([]string, string, error)        // tags and tags hash
-	GetPid() (int, error)                      // process identifier
-	GetHostname() (string, error)              // hostname.domainname for the entity
-	GetCreationTime() integration.CreationTime // created before or after the agent start
-	IsReady() bool                             // is the service ready
-	GetCheckNames() []string                   // slice of check names defined in kubernetes annotations or docker labels
-	HasFilter(containers.FilterType) bool      // whether the service is excluded by metrics or logs exclusion config
-	GetExtraConfig([]byte) ([]byte, error)     // Extra configuration values
+	GetEntity() string                          // unique entity name
+	GetTaggerEntity() string                    // tagger entity name
+	GetADIdentifiers() ([]string, error)        // identifiers on which templates will be matched
+	GetHosts() (map[string]string, error)       // network --> IP address
+	GetPorts() ([]ContainerPort, error)         // network ports
+	GetTags() ([]string, string, error)         // tags and tags hash
+	GetPid() (int, error)                       // process identifier
+	GetHostname() (string, error)               // hostname.domainname for the entity
+	GetLabels() (map[string]string, error)      // container or pod labels
+	GetAnnotations() (map[string]string, error) // pod annotations
+	GetKubeNamespace() (string, error)          // kubernetes namespace of the entity
+	GetKubePodName() (string, error)            // kubernetes pod name of the entity
+	GetCreationTime() integration.CreationTime  // created before or after the agent start
+	IsReady() bool                              // is the service ready
+	GetCheckNames() []string                    // slice of check names defined in kubernetes annotations or docker labels
+	HasFilter(containers.FilterType) bool       // whether the service is excluded by metrics or logs exclusion config
+	GetExtraConfig([]byte) ([]byte, error)      // Extra configuration values
 }
 
 // ServiceListener monitors running services and triggers check (un)scheduling
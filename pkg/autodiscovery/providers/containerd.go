@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build containerd
+
+package providers
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	ctrUtil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ContainerdConfigProvider implements the ConfigProvider interface for the labels
+// of the containers of `containerd_namespace`.
+type ContainerdConfigProvider struct {
+	containerdUtil ctrUtil.ContainerdItf
+	containerIDs   map[string]struct{}
+}
+
+// NewContainerdConfigProvider returns a new ConfigProvider connected to containerd.
+// Connectivity is not checked at this stage to allow for retries, Collect will do it.
+func NewContainerdConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	return &ContainerdConfigProvider{}, nil
+}
+
+// String returns a string representation of the ContainerdConfigProvider
+func (p *ContainerdConfigProvider) String() string {
+	return names.Containerd
+}
+
+// Collect retrieves all the containers and extract AD templates from their labels.
+func (p *ContainerdConfigProvider) Collect() ([]integration.Config, error) {
+	if p.containerdUtil == nil {
+		cu, err := ctrUtil.GetContainerdUtil()
+		if err != nil {
+			return []integration.Config{}, err
+		}
+		p.containerdUtil = cu
+	}
+
+	ctns, err := p.containerdUtil.Containers()
+	if err != nil {
+		return []integration.Config{}, err
+	}
+
+	containerIDs := make(map[string]struct{}, len(ctns))
+	containerLabels := make(map[string]map[string]string, len(ctns))
+	for _, ctn := range ctns {
+		containerIDs[ctn.ID()] = struct{}{}
+		info, err := p.containerdUtil.Info(ctn)
+		if err != nil {
+			log.Debugf("Failed to inspect container %s - %s", ctn.ID(), err)
+			continue
+		}
+		containerLabels[ctn.ID()] = info.Labels
+	}
+	p.containerIDs = containerIDs
+
+	return parseContainerLabels(containers.RuntimeNameContainerd, containerLabels), nil
+}
+
+// IsUpToDate checks whether containers were created or deleted since the last Collect.
+// Container labels cannot change once they are created.
+func (p *ContainerdConfigProvider) IsUpToDate() (bool, error) {
+	if p.containerdUtil == nil {
+		return false, nil
+	}
+
+	ctns, err := p.containerdUtil.Containers()
+	if err != nil {
+		return false, err
+	}
+	ids := make([]string, 0, len(ctns))
+	for _, ctn := range ctns {
+		ids = append(ids, ctn.ID())
+	}
+	return sameContainerIDs(p.containerIDs, ids), nil
+}
+
+func init() {
+	RegisterProvider("containerd", NewContainerdConfigProvider)
+}
@@ -21,8 +21,7 @@ import (
 )
 
 const (
-	dockerADLabelPrefix = "com.datadoghq.ad."
-	delayDuration       = 5 * time.Second
+	delayDuration = 5 * time.Second
 )
 
 // DockerConfigProvider implements the ConfigProvider interface for the docker labels.
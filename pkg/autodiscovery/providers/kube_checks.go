@@ -0,0 +1,386 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/configresolver"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// datadogCheckGVR identifies the DatadogCheck custom resource
+var datadogCheckGVR = schema.GroupVersionResource{
+	Group:    "datadoghq.com",
+	Version:  "v1alpha1",
+	Resource: "datadogchecks",
+}
+
+// datadogCheckSpec is the spec of a DatadogCheck
+type datadogCheckSpec struct {
+	CheckName       string                   `json:"checkName"`
+	InitConfig      map[string]interface{}   `json:"initConfig"`
+	Instances       []map[string]interface{} `json:"instances"`
+	Logs            []map[string]interface{} `json:"logs"`
+	ADIdentifiers   []string                 `json:"adIdentifiers"`
+	ServiceSelector *metav1.LabelSelector    `json:"serviceSelector"`
+}
+
+// datadogCheckStatus is the status of a DatadogCheck, set by the leader Cluster Agent
+type datadogCheckStatus struct {
+	Valid              bool   `json:"valid"`
+	Error              string `json:"error,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+}
+
+// KubeChecksConfigProvider implements the ConfigProvider interface for the DatadogCheck custom resources.
+// A DatadogCheck without AD identifiers nor service selector is a cluster check. A DatadogCheck with
+// AD identifiers is a template resolved by the listeners, and a DatadogCheck with a service selector is
+// resolved by the provider against each selected service, annotated or not, into cluster checks.
+type KubeChecksConfigProvider struct {
+	sync.RWMutex
+	checkLister   cache.GenericLister
+	serviceLister listersv1.ServiceLister
+	client        dynamic.Interface
+	isLeader      func() bool
+	upToDate      bool
+}
+
+// NewKubeChecksConfigProvider returns a new ConfigProvider watching the DatadogCheck resources.
+// Connectivity is not checked at this stage to allow for retries, Collect will do it.
+func NewKubeChecksConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	// Using GetAPIClient() (no retry)
+	ac, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	client, err := apiserver.NewDynamicClient(time.Duration(config.Datadog.GetInt64("kubernetes_apiserver_client_timeout")) * time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get apiserver dynamic client: %s", err)
+	}
+	informerClient, err := apiserver.NewDynamicClient(0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get apiserver dynamic client: %s", err)
+	}
+
+	servicesInformer := ac.InformerFactory.Core().V1().Services()
+	if servicesInformer == nil {
+		return nil, fmt.Errorf("cannot get service informer: %s", err)
+	}
+
+	resyncPeriod := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period")) * time.Second
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(informerClient, resyncPeriod)
+	checksInformer := informerFactory.ForResource(datadogCheckGVR)
+
+	p := &KubeChecksConfigProvider{
+		checkLister:   checksInformer.Lister(),
+		serviceLister: servicesInformer.Lister(),
+		client:        client,
+		isLeader:      isLeader,
+	}
+
+	checksInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    p.invalidate,
+		UpdateFunc: p.invalidateIfChangedCheck,
+		DeleteFunc: p.invalidate,
+	})
+	servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    p.invalidate,
+		UpdateFunc: p.invalidateIfChangedService,
+		DeleteFunc: p.invalidate,
+	})
+
+	// The providers live as long as the Cluster Agent
+	informerFactory.Start(wait.NeverStop)
+	ac.InformerFactory.Start(wait.NeverStop)
+
+	return p, nil
+}
+
+// String returns a string representation of the KubeChecksConfigProvider
+func (k *KubeChecksConfigProvider) String() string {
+	return names.KubeChecks
+}
+
+// Collect retrieves the DatadogCheck resources from the apiserver, builds Config objects and returns them
+func (k *KubeChecksConfigProvider) Collect() ([]integration.Config, error) {
+	objs, err := k.checkLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	k.setUpToDate(true)
+
+	var configs []integration.Config
+	for _, obj := range objs {
+		datadogCheck, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			log.Errorf("Expected an Unstructured type, got: %T", obj)
+			continue
+		}
+
+		checkConfigs, err := parseDatadogCheck(datadogCheck, k.serviceLister)
+		if err != nil {
+			log.Errorf("Cannot parse DatadogCheck %s/%s: %s", datadogCheck.GetNamespace(), datadogCheck.GetName(), err)
+		}
+		if k.isLeader() {
+			k.updateStatus(datadogCheck, err)
+		}
+		configs = append(configs, checkConfigs...)
+	}
+
+	return configs, nil
+}
+
+// IsUpToDate allows to cache configs as long as no changes are detected in the apiserver
+func (k *KubeChecksConfigProvider) IsUpToDate() (bool, error) {
+	k.RLock()
+	defer k.RUnlock()
+	return k.upToDate, nil
+}
+
+// setUpToDate is a thread-safe method to update the upToDate value
+func (k *KubeChecksConfigProvider) setUpToDate(v bool) {
+	k.Lock()
+	defer k.Unlock()
+	k.upToDate = v
+}
+
+func (k *KubeChecksConfigProvider) invalidate(obj interface{}) {
+	if obj != nil {
+		log.Trace("Invalidating configs on new/deleted DatadogCheck or service")
+		k.setUpToDate(false)
+	}
+}
+
+func (k *KubeChecksConfigProvider) invalidateIfChangedCheck(old, obj interface{}) {
+	// Cast the updated object, don't invalidate on casting error.
+	castedObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %T", obj)
+		return
+	}
+	// Cast the old object, invalidate on casting error
+	castedOld, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %T", old)
+		k.setUpToDate(false)
+		return
+	}
+	// Quick exit if resversion did not change
+	if castedObj.GetResourceVersion() == castedOld.GetResourceVersion() {
+		return
+	}
+	// Ignore the status updates
+	if reflect.DeepEqual(castedObj.Object["spec"], castedOld.Object["spec"]) {
+		return
+	}
+	log.Trace("Invalidating configs on DatadogCheck change")
+	k.setUpToDate(false)
+}
+
+func (k *KubeChecksConfigProvider) invalidateIfChangedService(old, obj interface{}) {
+	// Cast the updated object, don't invalidate on casting error.
+	// nil pointers are safely handled by the casting logic.
+	castedObj, ok := obj.(*v1.Service)
+	if !ok {
+		log.Errorf("Expected a Service type, got: %T", obj)
+		return
+	}
+	// Cast the old object, invalidate on casting error
+	castedOld, ok := old.(*v1.Service)
+	if !ok {
+		log.Errorf("Expected a Service type, got: %T", old)
+		k.setUpToDate(false)
+		return
+	}
+	// Quick exit if resversion did not change
+	if castedObj.ResourceVersion == castedOld.ResourceVersion {
+		return
+	}
+	// The labels select the services, the cluster IP and the ports resolve the templates
+	if !reflect.DeepEqual(castedObj.Labels, castedOld.Labels) ||
+		castedObj.Spec.ClusterIP != castedOld.Spec.ClusterIP ||
+		!reflect.DeepEqual(castedObj.Spec.Ports, castedOld.Spec.Ports) {
+		log.Trace("Invalidating configs on service change")
+		k.setUpToDate(false)
+	}
+}
+
+// updateStatus reports the validation result of a DatadogCheck in its status, if it changed
+func (k *KubeChecksConfigProvider) updateStatus(datadogCheck *unstructured.Unstructured, parseErr error) {
+	status := datadogCheckStatus{
+		Valid:              parseErr == nil,
+		ObservedGeneration: datadogCheck.GetGeneration(),
+	}
+	if parseErr != nil {
+		status.Error = parseErr.Error()
+	}
+
+	var current datadogCheckStatus
+	if currentStatus, found := datadogCheck.Object["status"].(map[string]interface{}); found {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(currentStatus, &current); err == nil && current == status {
+			return
+		}
+	}
+
+	newStatus, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		log.Warnf("Cannot build the status of DatadogCheck %s/%s: %s", datadogCheck.GetNamespace(), datadogCheck.GetName(), err)
+		return
+	}
+	updated := datadogCheck.DeepCopy()
+	updated.Object["status"] = newStatus
+
+	_, err = k.client.Resource(datadogCheckGVR).Namespace(updated.GetNamespace()).UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		log.Warnf("Cannot update the status of DatadogCheck %s/%s: %s", datadogCheck.GetNamespace(), datadogCheck.GetName(), err)
+	}
+}
+
+// parseDatadogCheck validates a DatadogCheck and returns the corresponding configs.
+// The template of a DatadogCheck with a serviceSelector is resolved against each selected
+// service, the configs of the services it could be resolved against are returned along
+// with the resolution errors.
+func parseDatadogCheck(datadogCheck *unstructured.Unstructured, serviceLister listersv1.ServiceLister) ([]integration.Config, error) {
+	rawSpec, found := datadogCheck.Object["spec"].(map[string]interface{})
+	if !found {
+		return nil, errors.New("missing spec")
+	}
+	var spec datadogCheckSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err)
+	}
+
+	if spec.CheckName == "" && len(spec.Logs) == 0 {
+		return nil, errors.New("checkName or logs must be set")
+	}
+	if spec.CheckName != "" && len(spec.Instances) == 0 {
+		return nil, errors.New("instances must be set with checkName")
+	}
+	if spec.CheckName == "" && (len(spec.Instances) > 0 || len(spec.InitConfig) > 0) {
+		return nil, errors.New("checkName must be set with instances or initConfig")
+	}
+	if len(spec.ADIdentifiers) > 0 && spec.ServiceSelector != nil {
+		return nil, errors.New("adIdentifiers and serviceSelector are mutually exclusive")
+	}
+
+	conf := integration.Config{
+		Name:          spec.CheckName,
+		ADIdentifiers: spec.ADIdentifiers,
+		ClusterCheck:  true,
+		Source:        "kube_checks:" + datadogCheck.GetNamespace() + "/" + datadogCheck.GetName(),
+	}
+
+	if spec.CheckName != "" {
+		initConfig := spec.InitConfig
+		if initConfig == nil {
+			initConfig = map[string]interface{}{}
+		}
+		data, err := json.Marshal(initConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid initConfig: %s", err)
+		}
+		conf.InitConfig = data
+
+		for i, instance := range spec.Instances {
+			data, err := json.Marshal(instance)
+			if err != nil {
+				return nil, fmt.Errorf("invalid instance %d: %s", i, err)
+			}
+			conf.Instances = append(conf.Instances, data)
+		}
+	}
+
+	if len(spec.Logs) > 0 {
+		data, err := json.Marshal(spec.Logs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logs: %s", err)
+		}
+		conf.LogsConfig = data
+	}
+
+	if spec.ServiceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.ServiceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serviceSelector: %s", err)
+		}
+		services, err := serviceLister.Services(datadogCheck.GetNamespace()).List(selector)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list services: %s", err)
+		}
+		if len(services) == 0 {
+			log.Debugf("No service selected by DatadogCheck %s/%s", datadogCheck.GetNamespace(), datadogCheck.GetName())
+			return nil, nil
+		}
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].Name < services[j].Name
+		})
+
+		var configs []integration.Config
+		var errs []string
+		for _, svc := range services {
+			resolved, _, err := configresolver.Resolve(conf, listeners.NewKubeServiceService(svc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("cannot resolve the template against service %s: %s", svc.Name, err))
+				continue
+			}
+			configs = append(configs, resolved)
+		}
+		if len(errs) > 0 {
+			return configs, errors.New(strings.Join(errs, "; "))
+		}
+		return configs, nil
+	}
+
+	return []integration.Config{conf}, nil
+}
+
+// isLeader returns whether the Cluster Agent is the leader, with no leader election
+// we assume only one Cluster Agent is running.
+func isLeader() bool {
+	if !config.Datadog.GetBool("leader_election") {
+		return true
+	}
+	le, err := leaderelection.GetLeaderEngine()
+	if err != nil {
+		return false
+	}
+	return le.IsLeader()
+}
+
+func init() {
+	RegisterProvider("kube_checks", NewKubeChecksConfigProvider)
+}
@@ -0,0 +1,412 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package providers
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func newDatadogCheck(name string, generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "datadoghq.com/v1alpha1",
+		"kind":       "DatadogCheck",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       "default",
+			"generation":      generation,
+			"resourceVersion": "1",
+		},
+	}}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+func newServiceLister(t *testing.T, services ...*v1.Service) listersv1.ServiceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, svc := range services {
+		require.NoError(t, indexer.Add(svc))
+	}
+	return listersv1.NewServiceLister(indexer)
+}
+
+// sortInstanceTags sorts the tags merged in the instances by the config resolver
+func sortInstanceTags(t *testing.T, cfgs []integration.Config) {
+	for _, cfg := range cfgs {
+		for i, instance := range cfg.Instances {
+			inst := integration.RawMap{}
+			require.NoError(t, yaml.Unmarshal(instance, &inst))
+			tags, found := inst["tags"].([]interface{})
+			if !found {
+				continue
+			}
+			sort.Slice(tags, func(i, j int) bool {
+				return tags[i].(string) < tags[j].(string)
+			})
+			out, err := yaml.Marshal(&inst)
+			require.NoError(t, err)
+			cfg.Instances[i] = out
+		}
+	}
+}
+
+// newRedisService returns a service without any AD annotation
+func newRedisService(name, uid, clusterIP string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid), Labels: map[string]string{"app": "redis"}},
+		Spec: v1.ServiceSpec{
+			ClusterIP: clusterIP,
+			Ports:     []v1.ServicePort{{Name: "redis", Port: 6379}},
+		},
+	}
+}
+
+func TestParseDatadogCheck(t *testing.T) {
+	serviceLister := newServiceLister(t,
+		newRedisService("redis-b", "b", "10.0.0.2"),
+		newRedisService("redis-a", "a", "10.0.0.1"),
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "redis-c", Namespace: "other", UID: types.UID("c"), Labels: map[string]string{"app": "redis"}}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("d"), Labels: map[string]string{"app": "web"}}},
+	)
+
+	for _, tc := range []struct {
+		name        string
+		spec        map[string]interface{}
+		expectedOut []integration.Config
+		expectedErr string
+	}{
+		{
+			name:        "missing spec",
+			expectedErr: "missing spec",
+		},
+		{
+			name: "cluster check",
+			spec: map[string]interface{}{
+				"checkName": "http_check",
+				"instances": []interface{}{
+					map[string]interface{}{"name": "My service", "url": "http://example.com", "timeout": int64(1)},
+				},
+			},
+			expectedOut: []integration.Config{
+				{
+					Name:         "http_check",
+					InitConfig:   integration.Data("{}"),
+					Instances:    []integration.Data{integration.Data("{\"name\":\"My service\",\"timeout\":1,\"url\":\"http://example.com\"}")},
+					ClusterCheck: true,
+					Source:       "kube_checks:default/test",
+				},
+			},
+		},
+		{
+			name: "template with AD identifiers and logs",
+			spec: map[string]interface{}{
+				"checkName":     "http_check",
+				"initConfig":    map[string]interface{}{"service": "web"},
+				"instances":     []interface{}{map[string]interface{}{"url": "http://%%host%%"}},
+				"logs":          []interface{}{map[string]interface{}{"source": "web"}},
+				"adIdentifiers": []interface{}{"kube_endpoint_uid://default/web/"},
+			},
+			expectedOut: []integration.Config{
+				{
+					Name:          "http_check",
+					InitConfig:    integration.Data("{\"service\":\"web\"}"),
+					Instances:     []integration.Data{integration.Data("{\"url\":\"http://%%host%%\"}")},
+					LogsConfig:    integration.Data("[{\"source\":\"web\"}]"),
+					ADIdentifiers: []string{"kube_endpoint_uid://default/web/"},
+					ClusterCheck:  true,
+					Source:        "kube_checks:default/test",
+				},
+			},
+		},
+		{
+			name: "template with service selector",
+			spec: map[string]interface{}{
+				"checkName":       "redisdb",
+				"instances":       []interface{}{map[string]interface{}{"host": "%%host%%", "port": "%%port%%"}},
+				"serviceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "redis"}},
+			},
+			expectedOut: []integration.Config{
+				{
+					Name:         "redisdb",
+					InitConfig:   integration.Data("{}"),
+					Instances:    []integration.Data{integration.Data("host: 10.0.0.1\nport: \"6379\"\ntags:\n- kube_namespace:default\n- kube_service:redis-a\n")},
+					ClusterCheck: true,
+					Entity:       "kube_service_uid://a",
+					CreationTime: integration.After,
+					Source:       "kube_checks:default/test",
+				},
+				{
+					Name:         "redisdb",
+					InitConfig:   integration.Data("{}"),
+					Instances:    []integration.Data{integration.Data("host: 10.0.0.2\nport: \"6379\"\ntags:\n- kube_namespace:default\n- kube_service:redis-b\n")},
+					ClusterCheck: true,
+					Entity:       "kube_service_uid://b",
+					CreationTime: integration.After,
+					Source:       "kube_checks:default/test",
+				},
+			},
+		},
+		{
+			name: "no service selected",
+			spec: map[string]interface{}{
+				"checkName":       "redisdb",
+				"instances":       []interface{}{map[string]interface{}{"host": "%%host%%"}},
+				"serviceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}},
+			},
+			expectedOut: nil,
+		},
+		{
+			name:        "no check nor logs",
+			spec:        map[string]interface{}{"adIdentifiers": []interface{}{"redis"}},
+			expectedErr: "checkName or logs must be set",
+		},
+		{
+			name:        "no instances",
+			spec:        map[string]interface{}{"checkName": "redisdb"},
+			expectedErr: "instances must be set with checkName",
+		},
+		{
+			name: "instances without check",
+			spec: map[string]interface{}{
+				"instances": []interface{}{map[string]interface{}{"host": "%%host%%"}},
+				"logs":      []interface{}{map[string]interface{}{"source": "web"}},
+			},
+			expectedErr: "checkName must be set with instances or initConfig",
+		},
+		{
+			name: "invalid instance",
+			spec: map[string]interface{}{
+				"checkName": "redisdb",
+				"instances": []interface{}{"host"},
+			},
+			expectedErr: "invalid spec",
+		},
+		{
+			name: "AD identifiers and service selector",
+			spec: map[string]interface{}{
+				"checkName":       "redisdb",
+				"instances":       []interface{}{map[string]interface{}{"host": "%%host%%"}},
+				"adIdentifiers":   []interface{}{"redis"},
+				"serviceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "redis"}},
+			},
+			expectedErr: "adIdentifiers and serviceSelector are mutually exclusive",
+		},
+		{
+			name: "invalid service selector",
+			spec: map[string]interface{}{
+				"checkName": "redisdb",
+				"instances": []interface{}{map[string]interface{}{"host": "%%host%%"}},
+				"serviceSelector": map[string]interface{}{"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "app", "operator": "Unknown"},
+				}},
+			},
+			expectedErr: "invalid serviceSelector",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfgs, err := parseDatadogCheck(newDatadogCheck("test", 1, tc.spec), serviceLister)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			sortInstanceTags(t, cfgs)
+			assert.Equal(t, tc.expectedOut, cfgs)
+		})
+	}
+}
+
+func TestKubeChecksCollect(t *testing.T) {
+	valid := newDatadogCheck("valid", 1, map[string]interface{}{
+		"checkName": "http_check",
+		"instances": []interface{}{map[string]interface{}{"url": "http://example.com"}},
+	})
+	invalid := newDatadogCheck("invalid", 2, map[string]interface{}{
+		"checkName": "http_check",
+	})
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), valid, invalid)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(valid))
+	require.NoError(t, indexer.Add(invalid))
+
+	leader := false
+	p := &KubeChecksConfigProvider{
+		checkLister:   cache.NewGenericLister(indexer, datadogCheckGVR.GroupResource()),
+		serviceLister: newServiceLister(t),
+		client:        client,
+		isLeader:      func() bool { return leader },
+	}
+
+	upToDate, err := p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	cfgs, err := p.Collect()
+	require.NoError(t, err)
+	require.Len(t, cfgs, 1)
+	assert.Equal(t, "kube_checks:default/valid", cfgs[0].Source)
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	// Only the leader updates the status
+	obj, err := client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), "invalid", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, obj.Object, "status")
+
+	leader = true
+	_, err = p.Collect()
+	require.NoError(t, err)
+
+	obj, err = client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), "invalid", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"valid":              false,
+		"error":              "instances must be set with checkName",
+		"observedGeneration": int64(2),
+	}, obj.Object["status"])
+
+	obj, err = client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), "valid", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"valid":              true,
+		"observedGeneration": int64(1),
+	}, obj.Object["status"])
+
+	// The status is not updated again if it didn't change
+	for _, name := range []string{"valid", "invalid"} {
+		obj, err = client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.NoError(t, indexer.Update(obj))
+	}
+	client.ClearActions()
+	_, err = p.Collect()
+	require.NoError(t, err)
+	assert.Empty(t, client.Actions())
+}
+
+func TestKubeChecksCollectServiceSelector(t *testing.T) {
+	redis := newDatadogCheck("redis", 1, map[string]interface{}{
+		"checkName":       "redisdb",
+		"instances":       []interface{}{map[string]interface{}{"host": "%%host%%", "port": "%%port_redis%%"}},
+		"serviceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "redis"}},
+	})
+	unresolvable := newDatadogCheck("unresolvable", 1, map[string]interface{}{
+		"checkName":       "redisdb",
+		"instances":       []interface{}{map[string]interface{}{"host": "%%host%%", "port": "%%port_metrics%%"}},
+		"serviceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "redis"}},
+	})
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), redis, unresolvable)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(redis))
+	require.NoError(t, indexer.Add(unresolvable))
+
+	p := &KubeChecksConfigProvider{
+		checkLister: cache.NewGenericLister(indexer, datadogCheckGVR.GroupResource()),
+		// the service has no AD annotation, it is not watched by the kube_services listener
+		serviceLister: newServiceLister(t, newRedisService("redis", "a", "10.0.0.1")),
+		client:        client,
+		isLeader:      func() bool { return true },
+	}
+
+	cfgs, err := p.Collect()
+	require.NoError(t, err)
+	sortInstanceTags(t, cfgs)
+
+	// The config is resolved against the service, it is scheduled as a cluster check
+	// without going through the listeners
+	require.Len(t, cfgs, 1)
+	assert.False(t, cfgs[0].IsTemplate())
+	assert.True(t, cfgs[0].ClusterCheck)
+	assert.Equal(t, "kube_service_uid://a", cfgs[0].Entity)
+	assert.Equal(t, []integration.Data{
+		integration.Data("host: 10.0.0.1\nport: \"6379\"\ntags:\n- kube_namespace:default\n- kube_service:redis\n"),
+	}, cfgs[0].Instances)
+
+	obj, err := client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), "redis", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"valid":              true,
+		"observedGeneration": int64(1),
+	}, obj.Object["status"])
+
+	// The resolution errors are reported in the status
+	obj, err = client.Resource(datadogCheckGVR).Namespace("default").Get(context.TODO(), "unresolvable", metav1.GetOptions{})
+	require.NoError(t, err)
+	status, _ := obj.Object["status"].(map[string]interface{})
+	assert.Equal(t, false, status["valid"])
+	assert.Contains(t, status["error"], "cannot resolve the template against service redis")
+}
+
+func TestKubeChecksInvalidateIfChanged(t *testing.T) {
+	p := &KubeChecksConfigProvider{}
+
+	old := newDatadogCheck("test", 1, map[string]interface{}{"checkName": "http_check"})
+	statusOnly := old.DeepCopy()
+	statusOnly.SetResourceVersion("2")
+	statusOnly.Object["status"] = map[string]interface{}{"valid": true}
+	specChange := old.DeepCopy()
+	specChange.SetResourceVersion("2")
+	specChange.Object["spec"] = map[string]interface{}{"checkName": "redisdb"}
+
+	p.setUpToDate(true)
+	p.invalidateIfChangedCheck(old, statusOnly)
+	upToDate, _ := p.IsUpToDate()
+	assert.True(t, upToDate)
+
+	p.invalidateIfChangedCheck(old, specChange)
+	upToDate, _ = p.IsUpToDate()
+	assert.False(t, upToDate)
+
+	oldSvc := &v1.Service{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1", Labels: map[string]string{"app": "redis"}}}
+	annotationChange := oldSvc.DeepCopy()
+	annotationChange.ResourceVersion = "2"
+	annotationChange.Annotations = map[string]string{"foo": "bar"}
+	labelChange := oldSvc.DeepCopy()
+	labelChange.ResourceVersion = "2"
+	labelChange.Labels["app"] = "web"
+	portChange := oldSvc.DeepCopy()
+	portChange.ResourceVersion = "2"
+	portChange.Spec.Ports = []v1.ServicePort{{Name: "redis", Port: 6380}}
+
+	p.setUpToDate(true)
+	p.invalidateIfChangedService(oldSvc, annotationChange)
+	upToDate, _ = p.IsUpToDate()
+	assert.True(t, upToDate)
+
+	p.invalidateIfChangedService(oldSvc, labelChange)
+	upToDate, _ = p.IsUpToDate()
+	assert.False(t, upToDate)
+
+	// The ports are used to resolve the templates
+	p.setUpToDate(true)
+	p.invalidateIfChangedService(oldSvc, portChange)
+	upToDate, _ = p.IsUpToDate()
+	assert.False(t, upToDate)
+}
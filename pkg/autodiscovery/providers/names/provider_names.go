@@ -10,6 +10,7 @@ const (
 	Consul             = "consul"
 	CloudFoundryBBS    = "cloudfoundry-bbs"
 	ClusterChecks      = "cluster-checks"
+	Containerd         = "containerd"
 	Docker             = "docker"
 	ECS                = "ecs"
 	EndpointsChecks    = "endpoints-checks"
@@ -17,7 +18,9 @@ const (
 	File               = "file"
 	Kubernetes         = "kubernetes"
 	KubeServices       = "kubernetes-services"
+	KubeChecks         = "kubernetes-checks"
 	KubeEndpoints      = "kubernetes-endpoints"
+	Podman             = "podman"
 	PrometheusPods     = "prometheus-pods"
 	PrometheusServices = "prometheus-services"
 	SNMP               = "snmp"
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package providers
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/podman"
+)
+
+// PodmanConfigProvider implements the ConfigProvider interface for the labels of the podman containers.
+type PodmanConfigProvider struct {
+	client       *podman.Client
+	containerIDs map[string]struct{}
+}
+
+// NewPodmanConfigProvider returns a new ConfigProvider connected to the podman API socket.
+// Connectivity is not checked at this stage to allow for retries, Collect will do it.
+func NewPodmanConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	return &PodmanConfigProvider{
+		client: podman.GetClient(),
+	}, nil
+}
+
+// String returns a string representation of the PodmanConfigProvider
+func (p *PodmanConfigProvider) String() string {
+	return names.Podman
+}
+
+// Collect retrieves all running containers and extract AD templates from their labels.
+func (p *PodmanConfigProvider) Collect() ([]integration.Config, error) {
+	ctns, err := p.client.ListContainers()
+	if err != nil {
+		return []integration.Config{}, err
+	}
+
+	containerIDs := make(map[string]struct{}, len(ctns))
+	containerLabels := make(map[string]map[string]string, len(ctns))
+	for _, ctn := range ctns {
+		containerIDs[ctn.ID] = struct{}{}
+		containerLabels[ctn.ID] = ctn.Labels
+	}
+	p.containerIDs = containerIDs
+
+	return parseContainerLabels(containers.RuntimeNamePodman, containerLabels), nil
+}
+
+// IsUpToDate checks whether containers were started or stopped since the last Collect.
+// Container labels cannot change once they are created.
+func (p *PodmanConfigProvider) IsUpToDate() (bool, error) {
+	ctns, err := p.client.ListContainers()
+	if err != nil {
+		return false, err
+	}
+	ids := make([]string, 0, len(ctns))
+	for _, ctn := range ctns {
+		ids = append(ids, ctn.ID)
+	}
+	return sameContainerIDs(p.containerIDs, ids), nil
+}
+
+func init() {
+	RegisterProvider("podman", NewPodmanConfigProvider)
+}
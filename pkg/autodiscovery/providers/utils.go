@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -22,6 +23,9 @@ const (
 	checkNamePath  string = "check_names"
 	initConfigPath string = "init_configs"
 	logsConfigPath string = "logs"
+
+	// Prefix of the AD labels of the docker, containerd and podman containers
+	dockerADLabelPrefix = "com.datadoghq.ad."
 )
 
 func init() {
@@ -213,3 +217,37 @@ func GetPollInterval(cp config.ConfigurationProviders) time.Duration {
 	}
 	return config.Datadog.GetDuration("ad_config_poll_interval") * time.Second
 }
+
+// parseContainerLabels extracts the AD templates from the labels of the containers of a runtime,
+// indexed by container ID. It is used by the runtimes which don't have a dedicated entity format.
+func parseContainerLabels(runtime string, containerLabels map[string]map[string]string) []integration.Config {
+	var configs []integration.Config
+	for cID, labels := range containerLabels {
+		entity := containers.BuildEntityName(runtime, cID)
+		c, errors := extractTemplatesFromMap(entity, labels, dockerADLabelPrefix)
+
+		for _, err := range errors {
+			log.Errorf("Can't parse template for container %s: %s", cID, err)
+		}
+
+		for idx := range c {
+			c[idx].Source = runtime + ":" + entity
+		}
+
+		configs = append(configs, c...)
+	}
+	return configs
+}
+
+// sameContainerIDs returns whether the given container IDs are the ones of the set
+func sameContainerIDs(known map[string]struct{}, ids []string) bool {
+	if known == nil || len(known) != len(ids) {
+		return false
+	}
+	for _, id := range ids {
+		if _, found := known[id]; !found {
+			return false
+		}
+	}
+	return true
+}
@@ -437,3 +437,30 @@ func TestGetPollInterval(t *testing.T) {
 	}
 	assert.Equal(t, GetPollInterval(cp), 1*time.Second)
 }
+
+func TestParseContainerLabels(t *testing.T) {
+	configs := parseContainerLabels("containerd", map[string]map[string]string{
+		"nolabels": {},
+		"3b8efe0c50e8": {
+			"com.datadoghq.ad.check_names":  "[\"redisdb\"]",
+			"com.datadoghq.ad.init_configs": "[{}]",
+			"com.datadoghq.ad.instances":    "[{\"host\": \"%%host%%\"}]",
+		},
+	})
+
+	require.Len(t, configs, 1)
+	assert.Equal(t, "redisdb", configs[0].Name)
+	assert.Equal(t, []string{"containerd://3b8efe0c50e8"}, configs[0].ADIdentifiers)
+	assert.Equal(t, "containerd:containerd://3b8efe0c50e8", configs[0].Source)
+	assert.Equal(t, []integration.Data{integration.Data("{\"host\":\"%%host%%\"}")}, configs[0].Instances)
+}
+
+func TestSameContainerIDs(t *testing.T) {
+	known := map[string]struct{}{"a": {}, "b": {}}
+
+	assert.True(t, sameContainerIDs(known, []string{"b", "a"}))
+	assert.False(t, sameContainerIDs(known, []string{"a"}))
+	assert.False(t, sameContainerIDs(known, []string{"a", "c"}))
+	assert.False(t, sameContainerIDs(nil, []string{}))
+	assert.True(t, sameContainerIDs(map[string]struct{}{}, nil))
+}
@@ -154,7 +154,7 @@ func (w *AutoscalerWatcher) processAutoscalers() {
 	// Or autoscalers referencing inexisting DatadogMetrics (in this case, externalMetric is nil)
 	for datadogMetricID, externalMetric := range datadogMetricReferences {
 		if externalMetric != nil && len(externalMetric.metricName) > 0 {
-			autogenQuery := buildDatadogQueryForExternalMetric(externalMetric.metricName, externalMetric.metricLabels)
+			autogenQuery := buildQueryForExternalMetric(externalMetric.metricName, externalMetric.metricLabels)
 			autogenDatadogMetric := model.NewDatadogMetricInternalFromExternalMetric(
 				datadogMetricID,
 				autogenQuery,
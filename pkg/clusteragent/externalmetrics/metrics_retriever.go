@@ -27,16 +27,16 @@ const (
 type MetricsRetriever struct {
 	refreshPeriod int64
 	metricsMaxAge int64
-	processor     autoscalers.ProcessorInterface
+	backend       autoscalers.MetricsBackend
 	store         *DatadogMetricsInternalStore
 	isLeader      func() bool
 }
 
-func NewMetricsRetriever(refreshPeriod, metricsMaxAge int64, processor autoscalers.ProcessorInterface, isLeader func() bool, store *DatadogMetricsInternalStore) (*MetricsRetriever, error) {
+func NewMetricsRetriever(refreshPeriod, metricsMaxAge int64, backend autoscalers.MetricsBackend, isLeader func() bool, store *DatadogMetricsInternalStore) (*MetricsRetriever, error) {
 	return &MetricsRetriever{
 		refreshPeriod: refreshPeriod,
 		metricsMaxAge: metricsMaxAge,
-		processor:     processor,
+		backend:       backend,
 		store:         store,
 		isLeader:      isLeader,
 	}, nil
@@ -69,12 +69,14 @@ func (mr *MetricsRetriever) retrieveMetricsValues() {
 	queries := getUniqueQueries(datadogMetrics)
 	log.Debugf("Starting refreshing external metrics with: %d queries", len(queries))
 
-	results, err := mr.processor.QueryExternalMetric(queries)
+	results, err := mr.backend.QueryExternalMetric(queries)
 	globalError := false
 	// Check for global failure
 	if len(results) == 0 && err != nil {
 		globalError = true
 		log.Errorf("Unable to fetch external metrics: %v", err)
+	} else if err != nil {
+		log.Warnf("Unable to fetch some external metrics: %v", err)
 	}
 
 	// Update store with current results
@@ -89,7 +91,7 @@ func (mr *MetricsRetriever) retrieveMetricsValues() {
 
 		query := datadogMetric.Query()
 		if queryResult, found := results[query]; found {
-			log.Debugf("QueryResult from DD for %q: %v", query, queryResult)
+			log.Debugf("QueryResult from backend for %q: %v", query, queryResult)
 
 			if queryResult.Valid {
 				datadogMetricFromStore.Value = queryResult.Value
@@ -9,14 +9,18 @@ package externalmetrics
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/custommetrics"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockedProcessor struct {
@@ -562,3 +566,36 @@ func TestRetrieveMetricsNotActive(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrieveMetricsPrometheusOldSample(t *testing.T) {
+	now := time.Now().Unix()
+	// The query is evaluated now, on a sample scraped 4 minutes ago
+	responses := map[string]string{
+		"up":            fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"1"]}]}}`, now),
+		"timestamp(up)": fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"%d"]}]}}`, now, now-240),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[r.FormValue("query")])) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("external_metrics_provider.prometheus.url", ts.URL)
+	backend, err := autoscalers.NewPrometheusClient()
+	require.NoError(t, err)
+
+	store := NewDatadogMetricsInternalStore()
+	datadogMetric := model.DatadogMetricInternal{ID: "metric0", Active: true, Valid: true}
+	datadogMetric.SetQueries("up")
+	store.Set(datadogMetric.ID, datadogMetric, "utest")
+
+	metricsRetriever, err := NewMetricsRetriever(0, 120, backend, getIsLeaderFunction(true), &store)
+	require.NoError(t, err)
+	metricsRetriever.retrieveMetricsValues()
+
+	// The sample is older than the max age, the metric is invalid
+	result := store.Get("metric0")
+	require.NotNil(t, result)
+	assert.False(t, result.Valid)
+	assert.Equal(t, fmt.Errorf(invalidMetricOutdatedErrorMessage, "up"), result.Error)
+}
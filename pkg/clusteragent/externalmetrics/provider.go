@@ -48,7 +48,7 @@ func NewDatadogMetricProvider(ctx context.Context, apiCl *apiserver.APIClient) (
 
 	aggregator := config.Datadog.GetString("external_metrics.aggregator")
 	rollup := config.Datadog.GetInt("external_metrics_provider.rollup")
-	setQueryConfigValues(aggregator, rollup)
+	setQueryConfigValues(aggregator, rollup, autoscalers.GetMetricsBackendName())
 
 	refreshPeriod := config.Datadog.GetInt64("external_metrics_provider.refresh_period")
 	retrieverMetricsMaxAge := int64(math.Max(config.Datadog.GetFloat64("external_metrics_provider.max_age"), float64(3*rollup)))
@@ -61,12 +61,12 @@ func NewDatadogMetricProvider(ctx context.Context, apiCl *apiserver.APIClient) (
 	}
 
 	// Start MetricsRetriever, only leader will do refresh metrics
-	dogCl, err := autoscalers.NewDatadogClient()
+	backend, err := autoscalers.NewMetricsBackend()
 	if err != nil {
-		return nil, fmt.Errorf("Unable to create DatadogMetricProvider as DatadogClient failed with: %v", err)
+		return nil, fmt.Errorf("Unable to create DatadogMetricProvider as metrics backend failed with: %v", err)
 	}
 
-	metricsRetriever, err := NewMetricsRetriever(refreshPeriod, retrieverMetricsMaxAge, autoscalers.NewProcessor(dogCl), le.IsLeader, &provider.store)
+	metricsRetriever, err := NewMetricsRetriever(refreshPeriod, retrieverMetricsMaxAge, backend, le.IsLeader, &provider.store)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to create DatadogMetricProvider as MetricsRetriever failed with: %v", err)
 	}
@@ -16,6 +16,8 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
 )
 
 const (
@@ -31,6 +33,7 @@ var (
 	// These values are set by the provider when starting, here are default values for unit tests
 	queryConfigAggregator string = "avg"
 	queryConfigRollup     int    = 30
+	queryConfigBackend    string = autoscalers.DatadogBackend
 )
 
 // datadogMetric.ID is namespace/name
@@ -48,7 +51,7 @@ func datadogMetricIDToMetricName(datadogMetricID string) string {
 }
 
 func getAutogenDatadogMetricNameFromLabels(metricName string, labels map[string]string) string {
-	return getAutogenDatadogMetricName(buildDatadogQueryForExternalMetric(metricName, labels))
+	return getAutogenDatadogMetricName(buildQueryForExternalMetric(metricName, labels))
 }
 
 func getAutogenDatadogMetricNameFromSelector(metricName string, labels labels.Selector) string {
@@ -63,7 +66,7 @@ func getAutogenDatadogMetricNameFromSelector(metricName string, labels labels.Se
 		mapLabels[kv[0]] = kv[1]
 	}
 
-	return getAutogenDatadogMetricName(buildDatadogQueryForExternalMetric(metricName, mapLabels))
+	return getAutogenDatadogMetricName(buildQueryForExternalMetric(metricName, mapLabels))
 }
 
 // We use query and not metricName + labels as key. It ensures we'll handle changes of config parameters.
@@ -74,6 +77,14 @@ func getAutogenDatadogMetricName(query string) string {
 	return autogenDatadogMetricPrefix + hex.EncodeToString(sum[0:19])
 }
 
+// buildQueryForExternalMetric builds the query of an autogen DatadogMetric in the language of the configured backend
+func buildQueryForExternalMetric(metricName string, labels map[string]string) string {
+	if queryConfigBackend == autoscalers.PrometheusBackend {
+		return buildPrometheusQueryForExternalMetric(metricName, labels)
+	}
+	return buildDatadogQueryForExternalMetric(metricName, labels)
+}
+
 func buildDatadogQueryForExternalMetric(metricName string, labels map[string]string) string {
 	var result string
 
@@ -92,7 +103,22 @@ func buildDatadogQueryForExternalMetric(metricName string, labels map[string]str
 	return fmt.Sprintf("%s:%s.rollup(%d)", queryConfigAggregator, result, queryConfigRollup)
 }
 
-func setQueryConfigValues(aggregator string, rollup int) {
+func buildPrometheusQueryForExternalMetric(metricName string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return fmt.Sprintf("%s(%s)", queryConfigAggregator, metricName)
+	}
+
+	matchers := make([]string, 0, len(labels))
+	for key, val := range labels {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", key, val))
+	}
+	sort.Strings(matchers)
+
+	return fmt.Sprintf("%s(%s{%s})", queryConfigAggregator, metricName, strings.Join(matchers, ","))
+}
+
+func setQueryConfigValues(aggregator string, rollup int, backend string) {
 	queryConfigAggregator = aggregator
 	queryConfigRollup = rollup
+	queryConfigBackend = backend
 }
@@ -13,6 +13,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
 )
 
 func TestMetricNameToDatadogMetricID(t *testing.T) {
@@ -97,3 +99,18 @@ func TestBuildDatadogQueryForExternalMetric(t *testing.T) {
 	testLabels = nil
 	assert.Equal(t, "avg:metricName1{*}.rollup(30)", buildDatadogQueryForExternalMetric(testMetricName, testLabels))
 }
+
+func TestBuildQueryForExternalMetric(t *testing.T) {
+	testMetricName := "metricName1"
+	testLabels := map[string]string{
+		"Zlabel1": "foo",
+		"Alabel2": `b"ar`,
+	}
+
+	assert.Equal(t, "avg:metricName1{Alabel2:b\"ar,Zlabel1:foo}.rollup(30)", buildQueryForExternalMetric(testMetricName, testLabels))
+
+	setQueryConfigValues("sum", 30, autoscalers.PrometheusBackend)
+	defer setQueryConfigValues("avg", 30, autoscalers.DatadogBackend)
+	assert.Equal(t, `sum(metricName1{Alabel2="b\"ar",Zlabel1="foo"})`, buildQueryForExternalMetric(testMetricName, testLabels))
+	assert.Equal(t, "sum(metricName1)", buildQueryForExternalMetric(testMetricName, nil))
+}
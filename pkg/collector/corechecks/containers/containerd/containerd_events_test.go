@@ -28,10 +28,12 @@ import (
 
 type mockItf struct {
 	mockEvents      func() containerd.EventService
-	mockContainer   func() ([]containerd.Container, error)
+	mockContainer   func(id string) (containerd.Container, error)
+	mockContainers  func() ([]containerd.Container, error)
 	mockMetadata    func() (containerd.Version, error)
 	mockImageSize   func(ctn containerd.Container) (int64, error)
 	mockTaskMetrics func(ctn containerd.Container) (*types.Metric, error)
+	mockTaskPid     func(ctn containerd.Container) (uint32, error)
 	mockTaskPids    func(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 	mockInfo        func(ctn containerd.Container) (containers.Container, error)
 	mockNamespace   func() string
@@ -50,6 +52,10 @@ func (m *mockItf) TaskMetrics(ctn containerd.Container) (*types.Metric, error) {
 	return m.mockTaskMetrics(ctn)
 }
 
+func (m *mockItf) TaskPid(ctn containerd.Container) (uint32, error) {
+	return m.mockTaskPid(ctn)
+}
+
 func (m *mockItf) TaskPids(ctn containerd.Container) ([]containerd.ProcessInfo, error) {
 	return m.mockTaskPids(ctn)
 }
@@ -62,8 +68,12 @@ func (m *mockItf) Namespace() string {
 	return m.mockNamespace()
 }
 
+func (m *mockItf) Container(id string) (containerd.Container, error) {
+	return m.mockContainer(id)
+}
+
 func (m *mockItf) Containers() ([]containerd.Container, error) {
-	return m.mockContainer()
+	return m.mockContainers()
 }
 
 func (m *mockItf) GetEvents() containerd.EventService {
@@ -55,6 +55,13 @@ func DiscoverComponentsFromEnv() ([]config.ConfigurationProviders, []config.List
 		log.Info("Adding Kubelet autodiscovery provider and listener from environment")
 	}
 
+	// Containerd running standalone, containers managed by Kubernetes or Docker are covered above
+	if config.IsFeaturePresent(config.Containerd) && !config.IsFeaturePresent(config.Kubernetes) && !config.IsFeaturePresent(config.Docker) {
+		detectedProviders = append(detectedProviders, config.ConfigurationProviders{Name: "containerd", Polling: true})
+		detectedListeners = append(detectedListeners, config.Listeners{Name: "containerd"})
+		log.Info("Adding Containerd autodiscovery provider and listener from environment")
+	}
+
 	if config.IsFeaturePresent(config.ECSFargate) {
 		detectedProviders = append(detectedProviders, config.ConfigurationProviders{Name: "ecs", Polling: true})
 		detectedListeners = append(detectedListeners, config.Listeners{Name: "ecs"})
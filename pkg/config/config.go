@@ -126,6 +126,43 @@ type MappingProfile struct {
 	Mappings []MetricMapping `mapstructure:"mappings" json:"mappings"`
 }
 
+// MetricTagLimit represents the limits applied by the aggregator to the contexts of a metric
+type MetricTagLimit struct {
+	MetricName  string   `mapstructure:"metric_name" json:"metric_name"`
+	MaxContexts int      `mapstructure:"max_contexts" json:"max_contexts"`
+	IncludeTags []string `mapstructure:"include_tags" json:"include_tags"`
+	ExcludeTags []string `mapstructure:"exclude_tags" json:"exclude_tags"`
+}
+
+// MetricRollup represents a metric also emitted, or only emitted, without some of its tags
+type MetricRollup struct {
+	MetricName   string   `mapstructure:"metric_name" json:"metric_name"`
+	DropTags     []string `mapstructure:"drop_tags" json:"drop_tags"`
+	Aggregation  string   `mapstructure:"aggregation" json:"aggregation"`
+	Name         string   `mapstructure:"name" json:"name"`
+	DropOriginal bool     `mapstructure:"drop_original" json:"drop_original"`
+}
+
+// MetricRewriteRule represents a rule rewriting the name and the tags of the metric samples
+type MetricRewriteRule struct {
+	Name           string                 `mapstructure:"name" json:"name"`
+	Match          string                 `mapstructure:"match" json:"match"`
+	MatchTags      map[string]string      `mapstructure:"match_tags" json:"match_tags"`
+	Drop           bool                   `mapstructure:"drop" json:"drop"`
+	Rename         string                 `mapstructure:"rename" json:"rename"`
+	RemoveTags     []string               `mapstructure:"remove_tags" json:"remove_tags"`
+	RenameTags     map[string]string      `mapstructure:"rename_tags" json:"rename_tags"`
+	MergeTagValues []MetricTagValuesMerge `mapstructure:"merge_tag_values" json:"merge_tag_values"`
+	AddTags        []string               `mapstructure:"add_tags" json:"add_tags"`
+}
+
+// MetricTagValuesMerge represents the replacement of the values of a tag matching a pattern by a single value
+type MetricTagValuesMerge struct {
+	Tag   string `mapstructure:"tag" json:"tag"`
+	Match string `mapstructure:"match" json:"match"`
+	Value string `mapstructure:"value" json:"value"`
+}
+
 // MetricMapping represent one mapping rule
 type MetricMapping struct {
 	Match     string            `mapstructure:"match" json:"match"`
@@ -241,6 +278,19 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("secret_backend_output_max_size", secrets.SecretBackendOutputMaxSize)
 	config.BindEnvAndSetDefault("secret_backend_timeout", 5)
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
+	// native secret resolvers, selected by the prefix of the handles
+	config.BindEnvAndSetDefault("secret_backend_resolvers", []string{})
+	config.BindEnvAndSetDefault("secret_backend_k8s_secret.api_server_url", "")
+	config.BindEnvAndSetDefault("secret_backend_k8s_secret.token_path", "/var/run/secrets/kubernetes.io/serviceaccount/token")
+	config.BindEnvAndSetDefault("secret_backend_k8s_secret.ca_path", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt")
+	config.BindEnvAndSetDefault("secret_backend_vault.address", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.auth_method", "token")
+	config.BindEnvAndSetDefault("secret_backend_vault.token", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.token_path", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.kubernetes_role", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.kubernetes_mount", "kubernetes")
+	config.BindEnvAndSetDefault("secret_backend_vault.kubernetes_token_path", "/var/run/secrets/kubernetes.io/serviceaccount/token")
+	config.BindEnvAndSetDefault("secret_backend_vault.ca_path", "")
 
 	// Use to output logs in JSON format
 	config.BindEnvAndSetDefault("log_format_json", false)
@@ -347,10 +397,27 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0) // 0 means disabled. This is a BETA feature.
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.95) // Do not store transactions on disk when the disk usage exceeds 95% of the disk capacity.
 
+	// Prometheus remote-write output of the series and the sketches
+	config.BindEnvAndSetDefault("prometheus_remote_write.enabled", false)
+	config.BindEnvAndSetDefault("prometheus_remote_write.url", "")
+	config.BindEnvAndSetDefault("prometheus_remote_write.headers", map[string]string{})
+	config.BindEnvAndSetDefault("prometheus_remote_write.quantiles", []string{"0.5", "0.75", "0.95", "0.99"})
+	config.BindEnvAndSetDefault("prometheus_remote_write.max_series_per_request", 1000)
+
+	// OpenMetrics endpoint
+	config.BindEnvAndSetDefault("openmetrics_endpoint.enabled", false)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.bind_host", "localhost")
+	config.BindEnvAndSetDefault("openmetrics_endpoint.port", 5004)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.quantiles", []string{"0.5", "0.75", "0.95", "0.99"})
+
 	// Dogstatsd
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
 	config.BindEnvAndSetDefault("dogstatsd_port", 8125)    // Notice: 0 means UDP port closed
 	config.BindEnvAndSetDefault("dogstatsd_pipe_name", "") // experimental and not officially supported for now.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0)   // Notice: 0 means TCP port closed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_max_connections", 1024)
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_cert_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_key_file", "")
 
 	// The following options allow to configure how the dogstatsd intake buffers and queues incoming datagrams.
 	// When a datagram is received it is first added to a datagrams buffer. This buffer fills up until
@@ -363,7 +430,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
-	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_socket", "")        // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_stream_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_stream_socket_framing", "length_prefix")
+	config.BindEnvAndSetDefault("dogstatsd_stream_socket_max_connections", 1024)
+	config.BindEnvAndSetDefault("dogstatsd_stream_max_frame_size", 1024*1024)
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
 	config.BindEnvAndSetDefault("dogstatsd_stats_enable", false)
 	config.BindEnvAndSetDefault("dogstatsd_stats_buffer", 10)
@@ -377,6 +448,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
+	config.BindEnvAndSetDefault("dogstatsd_origin_stats_enable", false)
+	// Directory of the traffic captures, empty means <run_path>/dsd_capture
+	config.BindEnvAndSetDefault("dogstatsd_capture_path", "")
 	config.BindEnvAndSetDefault("dogstatsd_tags", []string{})
 	config.BindEnvAndSetDefault("dogstatsd_mapper_cache_size", 1000)
 	config.BindEnvAndSetDefault("dogstatsd_string_interner_size", 4096)
@@ -394,6 +468,33 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	_ = config.BindEnv("metric_rewrite_rules")
+	config.SetEnvKeyTransformer("metric_rewrite_rules", func(in string) interface{} {
+		var rules []MetricRewriteRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"metric_rewrite_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
+	_ = config.BindEnv("metric_tag_limits")
+	config.SetEnvKeyTransformer("metric_tag_limits", func(in string) interface{} {
+		var limits []MetricTagLimit
+		if err := json.Unmarshal([]byte(in), &limits); err != nil {
+			log.Errorf(`"metric_tag_limits" can not be parsed: %v`, err)
+		}
+		return limits
+	})
+
+	_ = config.BindEnv("metric_rollups")
+	config.SetEnvKeyTransformer("metric_rollups", func(in string) interface{} {
+		var rollups []MetricRollup
+		if err := json.Unmarshal([]byte(in), &rollups); err != nil {
+			log.Errorf(`"metric_rollups" can not be parsed: %v`, err)
+		}
+		return rollups
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -435,9 +536,13 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cri_query_timeout", int64(5))      // in seconds
 
 	// Containerd
-	// We only support containerd in Kubernetes. By default containerd cri uses `k8s.io` https://github.com/containerd/cri/blob/release/1.2/pkg/constants/constants.go#L22-L23
+	// The containerd check only supports Kubernetes. By default containerd cri uses `k8s.io` https://github.com/containerd/cri/blob/release/1.2/pkg/constants/constants.go#L22-L23
 	config.BindEnvAndSetDefault("containerd_namespace", "k8s.io")
 
+	// Podman
+	config.BindEnvAndSetDefault("podman_socket_path", "/run/podman/podman.sock")
+	config.BindEnvAndSetDefault("podman_query_timeout", int64(5)) // in seconds
+
 	// Kubernetes
 	config.BindEnvAndSetDefault("kubernetes_kubelet_host", "")
 	config.BindEnvAndSetDefault("kubernetes_kubelet_nodename", "")
@@ -642,12 +747,23 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.stop_grace_period", 30)
 	config.BindEnvAndSetDefault("logs_config.close_timeout", 60)
 	config.BindEnvAndSetDefault("logs_config.auditor_ttl", DefaultAuditorTTL) // in hours
+	// Payloads that can not be sent because of an intake outage are stored on disk
+	// and replayed in order once the intake recovers. 0 means disabled.
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_max_size_in_bytes", 0)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_max_age", 86400) // in seconds
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_path", "")       // defaults to <run_path>/disk_buffer
 	// Timeout in milliseonds used when performing agreggation operations,
 	// including multi-line log processing rules and chunked line reaggregation.
 	// It may be useful to increase it when logs writing is slowed down, that
 	// could happen while serializing large objects on log lines.
 	config.BindEnvAndSetDefault("logs_config.aggregation_timeout", 1000)
 	config.BindEnv("logs_config.additional_endpoints") //nolint:errcheck
+	// Sources without multi-line processing rule sample their first lines to detect
+	// if they contain multi-line logs, and aggregate them with the best matching pattern.
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_detection", false)
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_sample_size", 500)
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_timeout", 30) // in seconds
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_threshold", 0.48)
 
 	// The cardinality of tags to send for checks and dogstatsd respectively.
 	// Choices are: low, orchestrator, high.
@@ -682,6 +798,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("kubernetes_informers_resync_period", 60*5)               // value in seconds. Default to 5 minutes
 	config.BindEnvAndSetDefault("external_metrics_provider.config", map[string]string{})  // list of options that can be used to configure the external metrics server
 	config.BindEnvAndSetDefault("external_metrics_provider.local_copy_refresh_rate", 30)  // value in seconds
+	// External metrics provider backend, only used with the DatadogMetric CRD
+	config.BindEnvAndSetDefault("external_metrics_provider.backend", "datadog")               // Backend evaluating the DatadogMetric queries. Choose from [datadog,prometheus]
+	config.BindEnvAndSetDefault("external_metrics_provider.prometheus.url", "")               // Base URL of the PromQL-compatible HTTP API used by the prometheus backend
+	config.BindEnvAndSetDefault("external_metrics_provider.prometheus.bearer_token_file", "") // File holding the bearer token sent to the prometheus backend
+	config.BindEnvAndSetDefault("external_metrics_provider.prometheus.timeout", 10)           // value in seconds. Timeout of the queries sent to the prometheus backend
 	// Cluster check Autodiscovery
 	config.BindEnvAndSetDefault("cluster_checks.enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.node_expiration_timeout", 30) // value in seconds
@@ -1033,9 +1154,27 @@ func ResolveSecrets(config Config, origin string) error {
 		config.GetInt("secret_backend_timeout"),
 		config.GetInt("secret_backend_output_max_size"),
 		config.GetBool("secret_backend_command_allow_group_exec_perm"),
+		secrets.NativeResolversConfig{
+			Enabled: config.GetStringSlice("secret_backend_resolvers"),
+			K8sSecret: secrets.K8sSecretResolverConfig{
+				APIServerURL: config.GetString("secret_backend_k8s_secret.api_server_url"),
+				TokenPath:    config.GetString("secret_backend_k8s_secret.token_path"),
+				CAPath:       config.GetString("secret_backend_k8s_secret.ca_path"),
+			},
+			Vault: secrets.VaultResolverConfig{
+				Address:             config.GetString("secret_backend_vault.address"),
+				AuthMethod:          config.GetString("secret_backend_vault.auth_method"),
+				Token:               config.GetString("secret_backend_vault.token"),
+				TokenPath:           config.GetString("secret_backend_vault.token_path"),
+				KubernetesRole:      config.GetString("secret_backend_vault.kubernetes_role"),
+				KubernetesMount:     config.GetString("secret_backend_vault.kubernetes_mount"),
+				KubernetesTokenPath: config.GetString("secret_backend_vault.kubernetes_token_path"),
+				CAPath:              config.GetString("secret_backend_vault.ca_path"),
+			},
+		},
 	)
 
-	if config.GetString("secret_backend_command") != "" {
+	if config.GetString("secret_backend_command") != "" || len(config.GetStringSlice("secret_backend_resolvers")) != 0 {
 		// Viper doesn't expose the final location of the file it
 		// loads. Since we are searching for 'datadog.yaml' in multiple
 		// locations we let viper determine the one to use before
@@ -1310,6 +1449,72 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetMetricRewriteRules returns the rules rewriting the metric samples of the checks and DogStatsD
+func GetMetricRewriteRules() ([]MetricRewriteRule, error) {
+	return getMetricRewriteRulesConfig(Datadog)
+}
+
+func getMetricRewriteRulesConfig(config Config) ([]MetricRewriteRule, error) {
+	var rules []MetricRewriteRule
+	if config.IsSet("metric_rewrite_rules") {
+		err := config.UnmarshalKey("metric_rewrite_rules", &rules)
+		if err != nil {
+			return []MetricRewriteRule{}, log.Errorf("Could not parse metric_rewrite_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
+// GetMetricTagLimits returns the limits applied by the aggregator to the contexts of the metrics
+func GetMetricTagLimits() ([]MetricTagLimit, error) {
+	return getMetricTagLimitsConfig(Datadog)
+}
+
+func getMetricTagLimitsConfig(config Config) ([]MetricTagLimit, error) {
+	var limits []MetricTagLimit
+	if config.IsSet("metric_tag_limits") {
+		err := config.UnmarshalKey("metric_tag_limits", &limits)
+		if err != nil {
+			return []MetricTagLimit{}, log.Errorf("Could not parse metric_tag_limits: %v", err)
+		}
+	}
+	for _, limit := range limits {
+		if limit.MetricName == "" {
+			return []MetricTagLimit{}, log.Errorf("Could not parse metric_tag_limits: metric_name is required")
+		}
+	}
+	return limits, nil
+}
+
+// GetMetricRollups returns the metrics aggregated across some of their tags at flush time
+func GetMetricRollups() ([]MetricRollup, error) {
+	return getMetricRollupsConfig(Datadog)
+}
+
+func getMetricRollupsConfig(config Config) ([]MetricRollup, error) {
+	var rollups []MetricRollup
+	if config.IsSet("metric_rollups") {
+		err := config.UnmarshalKey("metric_rollups", &rollups)
+		if err != nil {
+			return []MetricRollup{}, log.Errorf("Could not parse metric_rollups: %v", err)
+		}
+	}
+	for _, rollup := range rollups {
+		if rollup.MetricName == "" {
+			return []MetricRollup{}, log.Errorf("Could not parse metric_rollups: metric_name is required")
+		}
+		if len(rollup.DropTags) == 0 {
+			return []MetricRollup{}, log.Errorf("Could not parse metric_rollups: drop_tags of %s is empty", rollup.MetricName)
+		}
+		switch rollup.Aggregation {
+		case "", "sum", "max", "min":
+		default:
+			return []MetricRollup{}, log.Errorf("Could not parse metric_rollups: unknown aggregation %q of %s", rollup.Aggregation, rollup.MetricName)
+		}
+	}
+	return rollups, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
@@ -907,6 +907,170 @@ dogstatsd_mapper_profiles:
 	assert.Empty(t, profiles)
 }
 
+func TestMetricRewriteRules(t *testing.T) {
+	datadogYaml := `
+metric_rewrite_rules:
+  - name: "legacy"
+    match: 'legacy\.(.*)'
+    match_tags:
+      env: "prod.*"
+    rename: "app.$1"
+    remove_tags: ["user_id"]
+    rename_tags:
+      svc: service
+    merge_tag_values:
+      - tag: status_code
+        match: '2\d\d'
+        value: 2xx
+    add_tags: ["team:platform"]
+  - match: 'debug\..*'
+    drop: true
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getMetricRewriteRulesConfig(testConfig)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []MetricRewriteRule{
+		{
+			Name:           "legacy",
+			Match:          `legacy\.(.*)`,
+			MatchTags:      map[string]string{"env": "prod.*"},
+			Rename:         "app.$1",
+			RemoveTags:     []string{"user_id"},
+			RenameTags:     map[string]string{"svc": "service"},
+			MergeTagValues: []MetricTagValuesMerge{{Tag: "status_code", Match: `2\d\d`, Value: "2xx"}},
+			AddTags:        []string{"team:platform"},
+		},
+		{Match: `debug\..*`, Drop: true},
+	}, rules)
+}
+
+func TestMetricRewriteRulesError(t *testing.T) {
+	testConfig := setupConfFromYAML(`
+metric_rewrite_rules:
+  - abc
+`)
+	rules, err := getMetricRewriteRulesConfig(testConfig)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Could not parse metric_rewrite_rules")
+	assert.Empty(t, rules)
+}
+
+func TestMetricTagLimits(t *testing.T) {
+	datadogYaml := `
+metric_tag_limits:
+  - metric_name: "app.requests"
+    max_contexts: 1000
+    exclude_tags: ["user_id", "request_id"]
+  - metric_name: "app.latency"
+    include_tags: ["env", "service"]
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	limits, err := getMetricTagLimitsConfig(testConfig)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []MetricTagLimit{
+		{MetricName: "app.requests", MaxContexts: 1000, ExcludeTags: []string{"user_id", "request_id"}},
+		{MetricName: "app.latency", IncludeTags: []string{"env", "service"}},
+	}, limits)
+
+	limits, err = getMetricTagLimitsConfig(setupConfFromYAML(""))
+	assert.Nil(t, err)
+	assert.Empty(t, limits)
+}
+
+func TestMetricTagLimitsError(t *testing.T) {
+	for _, datadogYaml := range []string{`
+metric_tag_limits:
+  - abc
+`, `
+metric_tag_limits:
+  - max_contexts: 10
+`} {
+		testConfig := setupConfFromYAML(datadogYaml)
+		limits, err := getMetricTagLimitsConfig(testConfig)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Could not parse metric_tag_limits")
+		assert.Empty(t, limits)
+	}
+}
+
+func TestMetricTagLimitsEnv(t *testing.T) {
+	env := "DD_METRIC_TAG_LIMITS"
+	err := os.Setenv(env, `[{"metric_name":"app.requests","max_contexts":10,"exclude_tags":["user_id"]}]`)
+	assert.Nil(t, err)
+	defer os.Unsetenv(env)
+
+	testConfig := setupConfFromYAML("")
+	limits, err := getMetricTagLimitsConfig(testConfig)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []MetricTagLimit{{MetricName: "app.requests", MaxContexts: 10, ExcludeTags: []string{"user_id"}}}, limits)
+}
+
+func TestMetricRollups(t *testing.T) {
+	datadogYaml := `
+metric_rollups:
+  - metric_name: "kubernetes.cpu.usage.total"
+    drop_tags: ["pod_name", "container_id"]
+  - metric_name: "app.latency"
+    drop_tags: ["pod_name"]
+    aggregation: max
+    name: "app.latency.by_service"
+    drop_original: true
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rollups, err := getMetricRollupsConfig(testConfig)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []MetricRollup{
+		{MetricName: "kubernetes.cpu.usage.total", DropTags: []string{"pod_name", "container_id"}},
+		{MetricName: "app.latency", DropTags: []string{"pod_name"}, Aggregation: "max", Name: "app.latency.by_service", DropOriginal: true},
+	}, rollups)
+
+	rollups, err = getMetricRollupsConfig(setupConfFromYAML(""))
+	assert.Nil(t, err)
+	assert.Empty(t, rollups)
+}
+
+func TestMetricRollupsError(t *testing.T) {
+	for _, datadogYaml := range []string{`
+metric_rollups:
+  - abc
+`, `
+metric_rollups:
+  - drop_tags: ["pod_name"]
+`, `
+metric_rollups:
+  - metric_name: "app.requests"
+`, `
+metric_rollups:
+  - metric_name: "app.requests"
+    drop_tags: ["pod_name"]
+    aggregation: avg
+`} {
+		testConfig := setupConfFromYAML(datadogYaml)
+		rollups, err := getMetricRollupsConfig(testConfig)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Could not parse metric_rollups")
+		assert.Empty(t, rollups)
+	}
+}
+
+func TestMetricRollupsEnv(t *testing.T) {
+	env := "DD_METRIC_ROLLUPS"
+	err := os.Setenv(env, `[{"metric_name":"app.requests","drop_tags":["pod_name"],"drop_original":true}]`)
+	assert.Nil(t, err)
+	defer os.Unsetenv(env)
+
+	testConfig := setupConfFromYAML("")
+	rollups, err := getMetricRollupsConfig(testConfig)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []MetricRollup{{MetricName: "app.requests", DropTags: []string{"pod_name"}, DropOriginal: true}}, rollups)
+}
+
 func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	env := "DD_DOGSTATSD_MAPPER_PROFILES"
 	err := os.Setenv(env, `[{"name":"another_profile","prefix":"abcd","mappings":[{"match":"airflow\\.dag_processing\\.last_runtime\\.(.*)","match_type":"regex","name":"foo","tags":{"a":"$1","b":"$2"}}]},{"name":"some_other_profile","prefix":"some_other_profile.","mappings":[{"match":"some_other_profile.*","name":"some_other_profile.abc","tags":{"a":"$1"}}]}]`)
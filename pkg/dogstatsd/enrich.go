@@ -2,6 +2,7 @@ package dogstatsd
 
 import (
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
@@ -98,6 +99,13 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 
 	mtype := enrichMetricType(ddSample.metricType)
 
+	// the samples with a timestamp are sent to the aggregator with their timestamp
+	// in nanoseconds, the other ones are timestamped when they are aggregated
+	var timestamp float64
+	if ddSample.timestamp > 0 {
+		timestamp = float64(ddSample.timestamp) * float64(time.Second)
+	}
+
 	// if 'ddSample.values' contains values we're enriching a multi-value
 	// dogstatsd message and will create a MetricSample per value. If not
 	// we will use 'ddSample.value'and return a single MetricSample
@@ -115,6 +123,7 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 					OriginID:    originID,
 					K8sOriginID: k8sOriginID,
 					Cardinality: cardinality,
+					Timestamp:   timestamp,
 				})
 		}
 		return metricSamples
@@ -132,6 +141,7 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 		OriginID:    originID,
 		K8sOriginID: k8sOriginID,
 		Cardinality: cardinality,
+		Timestamp:   timestamp,
 	})
 }
 
@@ -3,6 +3,7 @@ package dogstatsd
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/stretchr/testify/assert"
@@ -94,6 +95,23 @@ func TestConvertParseMultiple(t *testing.T) {
 	}
 }
 
+func TestConvertParseWithTimestamp(t *testing.T) {
+	parsed, err := parseAndEnrichMultipleMetricMessage([]byte("daemon:666:777|d|T1657100430"), "", nil, "default-hostname")
+
+	assert.NoError(t, err)
+	require.Len(t, parsed, 2)
+	for _, sample := range parsed {
+		assert.Equal(t, "daemon", sample.Name)
+		assert.Equal(t, metrics.DistributionType, sample.Mtype)
+		assert.Equal(t, float64(1657100430*time.Second), sample.Timestamp)
+	}
+
+	parsed, err = parseAndEnrichMultipleMetricMessage([]byte("daemon:666|g"), "", nil, "default-hostname")
+	assert.NoError(t, err)
+	require.Len(t, parsed, 1)
+	assert.Zero(t, parsed[0].Timestamp)
+}
+
 func TestConvertParseSingle(t *testing.T) {
 	for metricSymbol, metricType := range symbolToType {
 
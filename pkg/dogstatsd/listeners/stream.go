@@ -0,0 +1,372 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+// streamFraming is the way the messages are delimited in a stream
+type streamFraming int
+
+const (
+	// newlineFraming separates the messages by newlines
+	newlineFraming streamFraming = iota
+	// lengthPrefixFraming prefixes each frame by its length, as a 32 bits little
+	// endian integer. A frame can hold several messages separated by newlines, and
+	// can be larger than the reader buffer, up to the maximum frame size.
+	lengthPrefixFraming
+)
+
+// streamTelemetry holds the expvars and the telemetry of a stream listener
+type streamTelemetry struct {
+	connections           expvar.Int
+	rejectedConnections   expvar.Int
+	activeConnections     expvar.Int
+	originDetectionErrors expvar.Int
+	readingErrors         expvar.Int
+	messages              expvar.Int
+	tooLongMessages       expvar.Int
+	bytes                 expvar.Int
+
+	tlmConnections          telemetry.Counter
+	tlmActiveConnections    telemetry.Gauge
+	tlmMessages             telemetry.Counter
+	tlmBytes                telemetry.Counter
+	tlmOriginDetectionError telemetry.Counter
+}
+
+// newStreamTelemetry registers the expvars and the telemetry of a stream listener,
+// prefixed by the given name
+func newStreamTelemetry(name string, description string) *streamTelemetry {
+	t := &streamTelemetry{
+		tlmConnections: telemetry.NewCounter("dogstatsd", name+"_connections",
+			[]string{"state"}, fmt.Sprintf("Dogstatsd %s connections count", description)),
+		tlmActiveConnections: telemetry.NewGauge("dogstatsd", name+"_active_connections",
+			nil, fmt.Sprintf("Dogstatsd %s open connections", description)),
+		tlmMessages: telemetry.NewCounter("dogstatsd", name+"_messages",
+			[]string{"state"}, fmt.Sprintf("Dogstatsd %s messages count", description)),
+		tlmBytes: telemetry.NewCounter("dogstatsd", name+"_bytes",
+			nil, fmt.Sprintf("Dogstatsd %s bytes count", description)),
+		tlmOriginDetectionError: telemetry.NewCounter("dogstatsd", name+"_origin_detection_error",
+			nil, fmt.Sprintf("Dogstatsd %s origin detection error count", description)),
+	}
+
+	expvars := expvar.NewMap("dogstatsd-" + name)
+	expvars.Set("Connections", &t.connections)
+	expvars.Set("RejectedConnections", &t.rejectedConnections)
+	expvars.Set("ActiveConnections", &t.activeConnections)
+	expvars.Set("OriginDetectionErrors", &t.originDetectionErrors)
+	expvars.Set("ReadingErrors", &t.readingErrors)
+	expvars.Set("Messages", &t.messages)
+	expvars.Set("TooLongMessages", &t.tooLongMessages)
+	expvars.Set("Bytes", &t.bytes)
+	return t
+}
+
+// streamListener reads the messages of the connections accepted by a stream listener.
+// Each connection has its own packet assembler, so that a packet only holds the
+// messages of a single client, tagged with the origin of the connection. When the
+// packets channel is full, the connections are not read anymore, which applies
+// backpressure to the clients instead of dropping their messages.
+type streamListener struct {
+	logPrefix        string
+	listener         net.Listener
+	framing          streamFraming
+	getOrigin        func(conn net.Conn) (string, error)
+	telemetry        *streamTelemetry
+	packetsBuffer    *packetsBuffer
+	sharedPacketPool *PacketPool
+	trafficCapture   *replay.TrafficCapture
+	flushTimeout     time.Duration
+	bufferSize       int
+	maxFrameSize     int
+	maxConnections   int
+
+	m       sync.Mutex
+	conns   map[net.Conn]struct{}
+	stopped chan struct{}
+}
+
+// newStreamListener returns an idle streamListener. getOrigin is nil if origin detection is disabled.
+func newStreamListener(logPrefix string, listener net.Listener, framing streamFraming, getOrigin func(net.Conn) (string, error),
+	t *streamTelemetry, maxConnections int, packetOut chan Packets, sharedPacketPool *PacketPool, capture *replay.TrafficCapture) *streamListener {
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+	return &streamListener{
+		logPrefix: logPrefix,
+		listener:  listener,
+		framing:   framing,
+		getOrigin: getOrigin,
+		telemetry: t,
+		packetsBuffer: newPacketsBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+			flushTimeout, packetOut),
+		sharedPacketPool: sharedPacketPool,
+		trafficCapture:   capture,
+		flushTimeout:     flushTimeout,
+		bufferSize:       config.Datadog.GetInt("dogstatsd_buffer_size"),
+		maxFrameSize:     config.Datadog.GetInt("dogstatsd_stream_max_frame_size"),
+		maxConnections:   maxConnections,
+		conns:            make(map[net.Conn]struct{}),
+		stopped:          make(chan struct{}),
+	}
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *streamListener) Listen() {
+	log.Infof("%s: starting to listen on %s", l.logPrefix, l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			select {
+			case <-l.stopped:
+				// listener has been closed
+				return
+			default:
+			}
+
+			log.Errorf("%s: error accepting connection: %v", l.logPrefix, err)
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return
+		}
+
+		if !l.track(conn) {
+			log.Warnf("%s: rejecting a connection: %d connections are already open", l.logPrefix, l.maxConnections)
+			l.telemetry.rejectedConnections.Add(1)
+			l.telemetry.tlmConnections.Inc("rejected")
+			conn.Close()
+			continue
+		}
+		l.telemetry.connections.Add(1)
+		l.telemetry.tlmConnections.Inc("accepted")
+
+		go l.handleConnection(conn)
+	}
+}
+
+// track registers a new connection, it returns false if the connection is rejected
+func (l *streamListener) track(conn net.Conn) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+	select {
+	case <-l.stopped:
+		return false
+	default:
+	}
+	if l.maxConnections > 0 && len(l.conns) >= l.maxConnections {
+		return false
+	}
+	l.conns[conn] = struct{}{}
+	l.telemetry.activeConnections.Add(1)
+	l.telemetry.tlmActiveConnections.Inc()
+	return true
+}
+
+// untrack closes and forgets a connection
+func (l *streamListener) untrack(conn net.Conn) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	conn.Close()
+	if _, found := l.conns[conn]; found {
+		delete(l.conns, conn)
+		l.telemetry.activeConnections.Add(-1)
+		l.telemetry.tlmActiveConnections.Dec()
+	}
+}
+
+// handleConnection reads the messages of a connection until it is closed
+func (l *streamListener) handleConnection(conn net.Conn) {
+	defer l.untrack(conn)
+
+	remote := conn.RemoteAddr()
+	origin := NoOrigin
+	if l.getOrigin != nil {
+		var err error
+		// the origin of the connection is resolved once, when the connection is accepted
+		origin, err = l.getOrigin(conn)
+		if err != nil {
+			log.Warnf("%s: error processing the origin of a connection, data will not be tagged : %v", l.logPrefix, err)
+			l.telemetry.originDetectionErrors.Add(1)
+			l.telemetry.tlmOriginDetectionError.Inc()
+		}
+	}
+	log.Debugf("%s: new connection from %s (origin: %q)", l.logPrefix, remote, origin)
+
+	packetAssembler := newPacketAssembler(l.flushTimeout, l.packetsBuffer, l.sharedPacketPool)
+	packetAssembler.origin = origin
+
+	var messages, bytes int64
+	// each message read fits in a packet
+	reader := bufio.NewReaderSize(conn, l.bufferSize)
+	err := readStream(reader, l.framing, l.maxFrameSize, func(message []byte) {
+		messages++
+		bytes += int64(len(message))
+		l.telemetry.messages.Add(1)
+		l.telemetry.bytes.Add(int64(len(message)))
+		l.telemetry.tlmMessages.Inc("ok")
+		l.telemetry.tlmBytes.Add(float64(len(message)))
+		// the messages are captured one by one, to be replayed as datagrams
+		l.trafficCapture.Capture(message, nil)
+
+		// packetAssembler merges multiple messages together and sends them when its buffer is full
+		packetAssembler.addMessage(message)
+	}, func() {
+		log.Debugf("%s: dropping a message from %s longer than the %d bytes buffer or a frame longer than %d bytes",
+			l.logPrefix, remote, l.bufferSize, l.maxFrameSize)
+		l.telemetry.tooLongMessages.Add(1)
+		l.telemetry.tlmMessages.Inc("too_long")
+	})
+
+	select {
+	case <-l.stopped:
+		packetAssembler.close()
+		return
+	default:
+	}
+	if err != io.EOF {
+		log.Errorf("%s: error reading from %s: %v", l.logPrefix, remote, err)
+		l.telemetry.readingErrors.Add(1)
+		l.telemetry.tlmMessages.Inc("error")
+	}
+
+	// send the last messages of the connection right away
+	packetAssembler.Lock()
+	packetAssembler.flush()
+	packetAssembler.Unlock()
+	packetAssembler.close()
+	log.Debugf("%s: connection from %s closed after %d messages (%d bytes)", l.logPrefix, remote, messages, bytes)
+}
+
+// Stop closes the listener and its connections and stops listening
+func (l *streamListener) Stop() {
+	l.m.Lock()
+	close(l.stopped)
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.m.Unlock()
+
+	l.listener.Close()
+	l.packetsBuffer.close()
+}
+
+// readStream reads the messages of a stream until an error occurs, and passes them to
+// addMessage. The messages which do not fit in the reader buffer, and the frames longer
+// than maxFrameSize, are skipped and reported to tooLong. It returns io.EOF when the
+// stream has been closed by the client.
+func readStream(r *bufio.Reader, framing streamFraming, maxFrameSize int, addMessage func([]byte), tooLong func()) error {
+	if framing == lengthPrefixFraming {
+		return readLengthPrefixedStream(r, maxFrameSize, addMessage, tooLong)
+	}
+	return readNewlineStream(r, addMessage, tooLong)
+}
+
+func readNewlineStream(r *bufio.Reader, addMessage func([]byte), tooLong func()) error {
+	for {
+		message, err := r.ReadSlice(messageSeparator)
+		if err == bufio.ErrBufferFull {
+			// the message is too long for the packet buffers, skip it
+			for err == bufio.ErrBufferFull {
+				_, err = r.ReadSlice(messageSeparator)
+			}
+			tooLong()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err == nil {
+			message = message[:len(message)-1]
+		}
+		// the last message of the stream does not need a newline
+		if len(message) > 0 {
+			addMessage(message)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readLengthPrefixedStream(r *bufio.Reader, maxFrameSize int, addMessage func([]byte), tooLong func()) error {
+	for {
+		header, err := r.Peek(4)
+		if err != nil {
+			if err == io.EOF && len(header) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		size := int(binary.LittleEndian.Uint32(header))
+		r.Discard(4) //nolint:errcheck
+
+		switch {
+		case size <= r.Size():
+			// the frame is read from the reader buffer
+			frame, err := r.Peek(size)
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			splitFrame(frame, r.Size(), addMessage, tooLong)
+			r.Discard(size) //nolint:errcheck
+		case size <= maxFrameSize:
+			// the frame is larger than the reader buffer, it is read in its own buffer
+			// which is released once its messages are added to the packets
+			frame := make([]byte, size)
+			if _, err := io.ReadFull(r, frame); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			splitFrame(frame, r.Size(), addMessage, tooLong)
+		default:
+			// the frame is too long, skip it
+			tooLong()
+			if _, err := r.Discard(size); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// splitFrame passes the newline separated messages of a frame to addMessage, the
+// messages longer than maxMessageSize do not fit in the packet buffers and are
+// reported to tooLong
+func splitFrame(frame []byte, maxMessageSize int, addMessage func([]byte), tooLong func()) {
+	for len(frame) > 0 {
+		message := frame
+		if i := bytes.IndexByte(frame, messageSeparator); i >= 0 {
+			message, frame = frame[:i], frame[i+1:]
+		} else {
+			frame = nil
+		}
+		if len(message) > maxMessageSize {
+			tooLong()
+		} else if len(message) > 0 {
+			addMessage(message)
+		}
+	}
+}
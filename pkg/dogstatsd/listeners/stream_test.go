@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lengthPrefixed(frames ...string) []byte {
+	var buf bytes.Buffer
+	for _, frame := range frames {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(frame))) //nolint:errcheck
+		buf.WriteString(frame)
+	}
+	return buf.Bytes()
+}
+
+func readTestStream(t *testing.T, framing streamFraming, data []byte) ([]string, int, error) {
+	var messages []string
+	tooLong := 0
+	r := bufio.NewReaderSize(bytes.NewReader(data), 16)
+	err := readStream(r, framing, 64, func(message []byte) {
+		messages = append(messages, string(message))
+	}, func() {
+		tooLong++
+	})
+	return messages, tooLong, err
+}
+
+func TestReadNewlineStream(t *testing.T) {
+	messages, tooLong, err := readTestStream(t, newlineFraming, []byte("a:1|c\n\nb:2|c\nabcdefghijklmnopqrstuvwxyz\nc:3|c"))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a:1|c", "b:2|c", "c:3|c"}, messages)
+	assert.Equal(t, 1, tooLong)
+
+	messages, tooLong, err = readTestStream(t, newlineFraming, []byte("a:1|c\nabcdefghijklmnopqrstuvwxyz"))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a:1|c"}, messages)
+	assert.Equal(t, 1, tooLong)
+}
+
+func TestReadLengthPrefixedStream(t *testing.T) {
+	messages, tooLong, err := readTestStream(t, lengthPrefixFraming,
+		lengthPrefixed("a:1|c", "", "b:2|c\nc:3|c\n", "abcdefghijklmnopqrstuvwxyz", "0123456789abcdef"))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a:1|c", "b:2|c", "c:3|c", "0123456789abcdef"}, messages)
+	assert.Equal(t, 1, tooLong)
+
+	// the frames larger than the reader buffer are split into their messages
+	messages, tooLong, err = readTestStream(t, lengthPrefixFraming,
+		lengthPrefixed("a:1|c\nb:2|c\nc:3|c\nd:4|c\ne:5|c", "abcdefghijklmnopqrstuvwxyz\nf:6|c", "g:7|c"))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a:1|c", "b:2|c", "c:3|c", "d:4|c", "e:5|c", "f:6|c", "g:7|c"}, messages)
+	assert.Equal(t, 1, tooLong)
+
+	// the frames larger than the maximum frame size are skipped
+	messages, tooLong, err = readTestStream(t, lengthPrefixFraming,
+		lengthPrefixed("a:1|c", strings.Repeat("b:2|c\n", 20), "c:3|c"))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a:1|c", "c:3|c"}, messages)
+	assert.Equal(t, 1, tooLong)
+
+	// truncated frame
+	data := lengthPrefixed("a:1|c", "b:2|c")
+	messages, _, err = readTestStream(t, lengthPrefixFraming, data[:len(data)-1])
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, []string{"a:1|c"}, messages)
+
+	// truncated frame larger than the reader buffer
+	data = lengthPrefixed("a:1|c", "b:2|c\nc:3|c\nd:4|c\ne:5|c")
+	messages, _, err = readTestStream(t, lengthPrefixFraming, data[:len(data)-1])
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, []string{"a:1|c"}, messages)
+
+	// truncated header
+	messages, _, err = readTestStream(t, lengthPrefixFraming, data[:11])
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, []string{"a:1|c"}, messages)
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+var tcpTelemetry = newStreamTelemetry("tcp", "TCP")
+
+// TCPListener implements the StatsdListener interface for TCP protocol.
+// It accepts connections on a given TCP address, optionally over TLS, and
+// sends back packets ready to be processed. The messages are newline framed.
+// Each connection has its own packet assembler, so that a packet only holds
+// the messages of a single client, tagged with the origin of the connection
+// when origin detection is enabled (Linux only, for loopback connections).
+type TCPListener struct {
+	*streamListener
+	OriginDetection bool
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan Packets, sharedPacketPool *PacketPool, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	var tlsConfig *tls.Config
+	certFile := config.Datadog.GetString("dogstatsd_tcp_tls_cert_file")
+	keyFile := config.Datadog.GetString("dogstatsd_tcp_tls_key_file")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("dogstatsd-tcp: can't load the TLS certificate: %s", err)
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	originDetection := config.Datadog.GetBool("dogstatsd_origin_detection")
+	var getOrigin func(net.Conn) (string, error)
+	if originDetection {
+		getOrigin = func(conn net.Conn) (string, error) {
+			return getTCPOrigin(conn.RemoteAddr())
+		}
+	}
+
+	l := &TCPListener{
+		streamListener: newStreamListener("dogstatsd-tcp", listener, newlineFraming, getOrigin, tcpTelemetry,
+			config.Datadog.GetInt("dogstatsd_tcp_max_connections"), packetOut, sharedPacketPool, capture),
+		OriginDetection: originDetection,
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized (TLS: %t)", listener.Addr(), tlsConfig != nil)
+	return l, nil
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// getTCPOrigin returns the container entity of the process at the other end of a
+// TCP connection. Only the loopback connections can be resolved: the socket of the
+// client is looked up in /proc/net/tcp, then its process in the file descriptors of
+// the processes, which needs the agent to run in the host PID namespace.
+func getTCPOrigin(addr net.Addr) (string, error) {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsLoopback() {
+		return NoOrigin, nil
+	}
+
+	path := "/proc/net/tcp6"
+	if tcpAddr.IP.To4() != nil {
+		path = "/proc/net/tcp"
+	}
+	inode, err := findTCPSocketInode(path, formatProcNetAddr(tcpAddr))
+	if err != nil {
+		return NoOrigin, err
+	}
+	pid, err := findPIDForSocketInode(config.Datadog.GetString("container_proc_root"), inode)
+	if err != nil {
+		return NoOrigin, err
+	}
+	return getEntityForPID(pid)
+}
+
+// formatProcNetAddr formats an address like the addresses of /proc/net/tcp and
+// /proc/net/tcp6: the IP is written as 32 bits words in host (little endian) order.
+func formatProcNetAddr(addr *net.TCPAddr) string {
+	ip := addr.IP.To4()
+	if ip == nil {
+		ip = addr.IP.To16()
+	}
+	var b strings.Builder
+	for i := 0; i+4 <= len(ip); i += 4 {
+		fmt.Fprintf(&b, "%02X%02X%02X%02X", ip[i+3], ip[i+2], ip[i+1], ip[i])
+	}
+	fmt.Fprintf(&b, ":%04X", addr.Port)
+	return b.String()
+}
+
+// findTCPSocketInode returns the inode of the socket bound to a local address
+func findTCPSocketInode(path string, localAddress string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 10 && fields[1] == localAddress {
+			return fields[9], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no socket bound to %s in %s", localAddress, path)
+}
+
+// findPIDForSocketInode returns the PID of the process holding a socket
+func findPIDForSocketInode(procRoot string, inode string) (int32, error) {
+	target := "socket:[" + inode + "]"
+
+	d, err := os.Open(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range names {
+		pid, err := strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, name, "fd")
+		fd, err := os.Open(fdDir)
+		if err != nil {
+			continue
+		}
+		fds, _ := fd.Readdirnames(-1)
+		fd.Close()
+		for _, fdName := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fdName)); err == nil && link == target {
+				return int32(pid), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no process holds the socket %s", inode)
+}
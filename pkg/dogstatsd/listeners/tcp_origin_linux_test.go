@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatProcNetAddr(t *testing.T) {
+	assert.Equal(t, "0100007F:1F90", formatProcNetAddr(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8080}))
+	assert.Equal(t, "00000000000000000000000001000000:0035", formatProcNetAddr(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 53}))
+}
+
+func TestFindTCPSocketInode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tcp")
+	require.NoError(t, ioutil.WriteFile(path, []byte(
+		`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1FBD 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21436 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:D431 0100007F:1FBD 01 00000000:00000000 00:00000000 00000000  1000        0 88213 1 0000000000000000 20 4 30 10 -1
+`), 0644))
+
+	inode, err := findTCPSocketInode(path, "0100007F:D431")
+	assert.NoError(t, err)
+	assert.Equal(t, "88213", inode)
+
+	_, err = findTCPSocketInode(path, "0100007F:0001")
+	assert.Error(t, err)
+}
+
+func TestFindPIDForSocketInode(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	inode, err := findTCPSocketInode("/proc/net/tcp", formatProcNetAddr(ln.Addr().(*net.TCPAddr)))
+	require.NoError(t, err)
+	pid, err := findPIDForSocketInode("/proc", inode)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(os.Getpid()), pid)
+}
+
+func TestGetTCPOriginNotLoopback(t *testing.T) {
+	origin, err := getTCPOrigin(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234})
+	assert.NoError(t, err)
+	assert.Equal(t, NoOrigin, origin)
+}
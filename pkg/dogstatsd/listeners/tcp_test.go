@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var packetPoolTCP = NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+
+func newTestTCPListener(t *testing.T, packetChannel chan Packets, pool *PacketPool) (*TCPListener, int) {
+	port, err := getAvailableTCPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+
+	s, err := NewTCPListener(packetChannel, pool, nil)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	go s.Listen()
+	return s, port
+}
+
+// receiveMessages reads the packets until the expected number of messages is received
+func receiveMessages(t *testing.T, packetChannel chan Packets, expected int) [][]byte {
+	var messages [][]byte
+	for len(messages) < expected {
+		select {
+		case packets := <-packetChannel:
+			for _, packet := range packets {
+				assert.Equal(t, NoOrigin, packet.Origin)
+				messages = append(messages, bytes.Split(packet.Contents, []byte{messageSeparator})...)
+			}
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "Timeout on receive channel")
+		}
+	}
+	return messages
+}
+
+func TestStartStopTCPListener(t *testing.T) {
+	s, port := newTestTCPListener(t, nil, packetPoolTCP)
+
+	// Local port should be unavailable
+	_, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.Error(t, err)
+
+	s.Stop()
+
+	// check that the port can be bound, try for 100 ms
+	for i := 0; i < 10; i++ {
+		var ln net.Listener
+		ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			ln.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err, "port is not available, it should be")
+}
+
+func TestNewTCPListenerInvalidTLS(t *testing.T) {
+	config.Datadog.Set("dogstatsd_tcp_tls_cert_file", "/does/not/exist.crt")
+	config.Datadog.Set("dogstatsd_tcp_tls_key_file", "/does/not/exist.key")
+	defer config.Datadog.Set("dogstatsd_tcp_tls_cert_file", "")
+	defer config.Datadog.Set("dogstatsd_tcp_tls_key_file", "")
+
+	s, err := NewTCPListener(nil, packetPoolTCP, nil)
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestTCPReceive(t *testing.T) {
+	packetChannel := make(chan Packets)
+	s, port := newTestTCPListener(t, packetChannel, packetPoolTCP)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\ndaemon:1|c\n\ndaem"))
+	require.NoError(t, err)
+	// a message can span several writes, the last one does not need a newline
+	_, err = conn.Write([]byte("on:2|c"))
+	require.NoError(t, err)
+	conn.Close()
+
+	messages := receiveMessages(t, packetChannel, 3)
+	assert.Equal(t, [][]byte{
+		[]byte("daemon:666|g|#sometag1:somevalue1"),
+		[]byte("daemon:1|c"),
+		[]byte("daemon:2|c"),
+	}, messages)
+}
+
+func TestTCPTooLongMessage(t *testing.T) {
+	config.Datadog.Set("dogstatsd_buffer_size", 16)
+	defer config.Datadog.Set("dogstatsd_buffer_size", 1024*8)
+
+	packetChannel := make(chan Packets)
+	s, port := newTestTCPListener(t, packetChannel, NewPacketPool(16))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("daemon:1|c|#sometag1:somevalue1\ndaemon:2|c\n"))
+	require.NoError(t, err)
+
+	messages := receiveMessages(t, packetChannel, 1)
+	assert.Equal(t, [][]byte{[]byte("daemon:2|c")}, messages)
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	config.Datadog.Set("dogstatsd_tcp_max_connections", 1)
+	defer config.Datadog.Set("dogstatsd_tcp_max_connections", 1024)
+
+	packetChannel := make(chan Packets)
+	s, port := newTestTCPListener(t, packetChannel, packetPoolTCP)
+	defer s.Stop()
+
+	conn1, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	_, err = conn1.Write([]byte("daemon:1|c\n"))
+	require.NoError(t, err)
+	receiveMessages(t, packetChannel, 1)
+
+	// the second connection is closed by the listener
+	conn2, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn2.Close()
+	conn2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn2.Read(make([]byte, 1))
+	assert.Error(t, err)
+	if ne, ok := err.(net.Error); ok {
+		assert.False(t, ne.Timeout(), "the connection should have been closed")
+	}
+
+	// once the first connection is closed, a new one is accepted
+	conn1.Close()
+	assert.Eventually(t, func() bool {
+		s.m.Lock()
+		defer s.m.Unlock()
+		return len(s.conns) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	conn3, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn3.Close()
+	_, err = conn3.Write([]byte("daemon:3|c\n"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("daemon:3|c")}, receiveMessages(t, packetChannel, 1))
+}
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
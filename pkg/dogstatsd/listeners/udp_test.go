@@ -8,7 +8,10 @@ package listeners
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -17,12 +20,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 )
 
 var packetPoolUDP = NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
 
 func TestNewUDPListener(t *testing.T) {
-	s, err := NewUDPListener(nil, packetPoolUDP)
+	s, err := NewUDPListener(nil, packetPoolUDP, nil)
 	assert.NotNil(t, s)
 	assert.Nil(t, err)
 
@@ -34,7 +38,7 @@ func TestStartStopUDPListener(t *testing.T) {
 	require.Nil(t, err)
 	config.Datadog.SetDefault("dogstatsd_port", port)
 	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
-	s, err := NewUDPListener(nil, packetPoolUDP)
+	s, err := NewUDPListener(nil, packetPoolUDP, nil)
 	require.NotNil(t, s)
 
 	assert.Nil(t, err)
@@ -65,7 +69,7 @@ func TestUDPNonLocal(t *testing.T) {
 	require.Nil(t, err)
 	config.Datadog.SetDefault("dogstatsd_port", port)
 	config.Datadog.SetDefault("dogstatsd_non_local_traffic", true)
-	s, err := NewUDPListener(nil, packetPoolUDP)
+	s, err := NewUDPListener(nil, packetPoolUDP, nil)
 	assert.Nil(t, err)
 	require.NotNil(t, s)
 
@@ -89,7 +93,7 @@ func TestUDPLocalOnly(t *testing.T) {
 	require.Nil(t, err)
 	config.Datadog.SetDefault("dogstatsd_port", port)
 	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
-	s, err := NewUDPListener(nil, packetPoolUDP)
+	s, err := NewUDPListener(nil, packetPoolUDP, nil)
 	assert.Nil(t, err)
 	require.NotNil(t, s)
 
@@ -117,7 +121,7 @@ func TestUDPReceive(t *testing.T) {
 	config.Datadog.SetDefault("dogstatsd_port", port)
 
 	packetChannel := make(chan Packets)
-	s, err := NewUDPListener(packetChannel, packetPoolUDP)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP, nil)
 	require.NotNil(t, s)
 	assert.Nil(t, err)
 
@@ -141,6 +145,48 @@ func TestUDPReceive(t *testing.T) {
 	}
 }
 
+func TestUDPCapture(t *testing.T) {
+	var contents = []byte("daemon:666|g|#sometag1:somevalue1")
+	port, err := getAvailableUDPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	capture := replay.NewTrafficCapture()
+	path, err := capture.Start(dir, time.Minute)
+	require.NoError(t, err)
+
+	packetChannel := make(chan Packets)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP, capture)
+	require.NoError(t, err)
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write(contents)
+
+	select {
+	case packets := <-packetChannel:
+		assert.Equal(t, contents, packets[0].Contents)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+	capture.Stop()
+
+	reader, err := replay.NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	p, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, contents, p.Payload)
+	assert.Nil(t, p.Credentials)
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
 // Reproducer for https://github.com/DataDog/datadog-agent/issues/6803
 func TestNewUDPListenerWhenBusyWithSoRcvBufSet(t *testing.T) {
 	port, err := getAvailableUDPPort()
@@ -153,7 +199,7 @@ func TestNewUDPListenerWhenBusyWithSoRcvBufSet(t *testing.T) {
 	config.Datadog.SetDefault("dogstatsd_so_rcvbuf", 1)
 	config.Datadog.SetDefault("dogstatsd_port", port)
 	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
-	s, err := NewUDPListener(nil, packetPoolUDP)
+	s, err := NewUDPListener(nil, packetPoolUDP, nil)
 	assert.Nil(t, s)
 	assert.NotNil(t, err)
 }
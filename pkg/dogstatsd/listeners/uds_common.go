@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 )
 
 var (
@@ -52,11 +53,12 @@ type UDSListener struct {
 	packetsBuffer    *packetsBuffer
 	sharedPacketPool *PacketPool
 	oobPool          *sync.Pool // For origin detection ancilary data
+	trafficCapture   *replay.TrafficCapture
 	OriginDetection  bool
 }
 
 // NewUDSListener returns an idle UDS Statsd listener
-func NewUDSListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDSListener, error) {
+func NewUDSListener(packetOut chan Packets, sharedPacketPool *PacketPool, capture *replay.TrafficCapture) (*UDSListener, error) {
 	socketPath := config.Datadog.GetString("dogstatsd_socket")
 	originDetection := config.Datadog.GetBool("dogstatsd_origin_detection")
 
@@ -64,17 +66,8 @@ func NewUDSListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDSL
 	if addrErr != nil {
 		return nil, fmt.Errorf("dogstatsd-uds: can't ResolveUnixAddr: %v", addrErr)
 	}
-	fileInfo, err := os.Stat(socketPath)
-	// Socket file already exists
-	if err == nil {
-		// Make sure it's a UNIX socket
-		if fileInfo.Mode()&os.ModeSocket == 0 {
-			return nil, fmt.Errorf("dogstatsd-uds: cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
-		}
-		err = os.Remove(socketPath)
-		if err != nil {
-			return nil, fmt.Errorf("dogstatsd-usd: cannot remove stale UNIX socket: %v", err)
-		}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds: %v", err)
 	}
 
 	conn, err := net.ListenUnixgram("unixgram", address)
@@ -109,6 +102,7 @@ func NewUDSListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDSL
 		packetsBuffer: newPacketsBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
 			config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
 		sharedPacketPool: sharedPacketPool,
+		trafficCapture:   capture,
 	}
 
 	// Init the oob buffer pool if origin detection is enabled
@@ -124,6 +118,23 @@ func NewUDSListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDSL
 	return listener, nil
 }
 
+// removeStaleSocket removes the UNIX socket left at a socket path by a previous run
+func removeStaleSocket(socketPath string) error {
+	fileInfo, err := os.Stat(socketPath)
+	// Socket file already exists
+	if err == nil {
+		// Make sure it's a UNIX socket
+		if fileInfo.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
+		}
+		err = os.Remove(socketPath)
+		if err != nil {
+			return fmt.Errorf("cannot remove stale UNIX socket: %v", err)
+		}
+	}
+	return nil
+}
+
 // Listen runs the intake loop. Should be called in its own goroutine
 func (l *UDSListener) Listen() {
 	log.Infof("dogstatsd-uds: starting to listen on %s", l.conn.LocalAddr())
@@ -132,15 +143,18 @@ func (l *UDSListener) Listen() {
 		var err error
 		// retrieve an available packet from the packet pool,
 		// which will be pushed back by the server when processed.
+		var creds *replay.Credentials
 		packet := l.sharedPacketPool.Get()
 		udsPackets.Add(1)
 		if l.OriginDetection {
 			// Read datagram + credentials in ancilary data
 			oob := l.oobPool.Get().([]byte)
 			var oobn int
+			var container string
+			var taggingErr error
 			n, oobn, _, _, err = l.conn.ReadMsgUnix(packet.buffer, oob)
 			// Extract container id from credentials
-			container, taggingErr := processUDSOrigin(oob[:oobn])
+			creds, container, taggingErr = processUDSOrigin(oob[:oobn])
 			if taggingErr != nil {
 				log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", taggingErr)
 				udsOriginDetectionErrors.Add(1)
@@ -171,6 +185,7 @@ func (l *UDSListener) Listen() {
 		udsBytes.Add(int64(n))
 		tlmUDSPacketsBytes.Add(float64(n))
 		packet.Contents = packet.buffer[:n]
+		l.trafficCapture.Capture(packet.Contents, creds)
 
 		// packetsBuffer handles the forwarding of the packets to the dogstatsd server intake channel
 		l.packetsBuffer.append(packet)
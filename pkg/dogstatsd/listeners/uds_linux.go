@@ -14,6 +14,7 @@ import (
 
 	"golang.org/x/sys/unix"
 
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
@@ -48,32 +49,65 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 }
 
 // processUDSOrigin reads ancillary data to determine a packet's origin,
-// it returns a string identifying the source.
+// it returns the credentials of the sender, nil if they can't be read, and
+// a string identifying the source.
 // PID is added to ancillary data by the Linux kernel if we added the
 // SO_PASSCRED to the socket, see enableUDSPassCred.
-func processUDSOrigin(ancillary []byte) (string, error) {
+func processUDSOrigin(ancillary []byte) (*replay.Credentials, string, error) {
 	messages, err := unix.ParseSocketControlMessage(ancillary)
 	if err != nil {
-		return NoOrigin, err
+		return nil, NoOrigin, err
 	}
 	if len(messages) == 0 {
-		return NoOrigin, fmt.Errorf("ancillary data empty")
+		return nil, NoOrigin, fmt.Errorf("ancillary data empty")
 	}
 	cred, err := unix.ParseUnixCredentials(&messages[0])
 	if err != nil {
-		return NoOrigin, err
+		return nil, NoOrigin, err
 	}
+	creds := &replay.Credentials{Pid: cred.Pid, UID: cred.Uid, GID: cred.Gid}
 
 	if cred.Pid == 0 {
-		return NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
+		return creds, NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
 			"probably to another namespace. Is the agent in host PID mode?")
 	}
 
 	entity, err := getEntityForPID(cred.Pid)
+	if err != nil {
+		return creds, NoOrigin, err
+	}
+	return creds, entity, nil
+}
+
+// getUDSPeerOrigin returns the origin of the client of a stream connection, from its
+// credentials read with SO_PEERCRED.
+func getUDSPeerOrigin(conn net.Conn) (string, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return NoOrigin, fmt.Errorf("not a UNIX socket connection")
+	}
+	rawconn, err := unixConn.SyscallConn()
 	if err != nil {
 		return NoOrigin, err
 	}
-	return entity, nil
+
+	var cred *unix.Ucred
+	var credErr error
+	err = rawconn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return NoOrigin, err
+	}
+	if credErr != nil {
+		return NoOrigin, credErr
+	}
+
+	if cred.Pid == 0 {
+		return NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
+			"probably to another namespace. Is the agent in host PID mode?")
+	}
+	return getEntityForPID(cred.Pid)
 }
 
 // getEntityForPID returns the container entity name and caches the value for future lookups
@@ -13,13 +13,19 @@ package listeners
 
 import (
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"golang.org/x/sys/unix"
 )
 
@@ -33,7 +39,7 @@ func TestUDSPassCred(t *testing.T) {
 	mockConfig.Set("dogstatsd_socket", socketPath)
 	mockConfig.Set("dogstatsd_origin_detection", true)
 
-	s, err := NewUDSListener(nil, NewPacketPool(512))
+	s, err := NewUDSListener(nil, NewPacketPool(512), nil)
 	defer s.Stop()
 
 	assert.Nil(t, err)
@@ -48,3 +54,60 @@ func TestUDSPassCred(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, enabled, 1)
 }
+
+func TestGetUDSPeerOriginNotUnix(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+	defer conn2.Close()
+
+	origin, err := getUDSPeerOrigin(conn1)
+	assert.Error(t, err)
+	assert.Equal(t, NoOrigin, origin)
+}
+
+func TestUDSCaptureCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+	socketPath := filepath.Join(dir, "dsd.socket")
+
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_socket", socketPath)
+	mockConfig.Set("dogstatsd_origin_detection", true)
+
+	// the test process is not in a container
+	key := cache.BuildAgentKey(pidToEntityCacheKeyPrefix, strconv.Itoa(os.Getpid()))
+	cache.Cache.Set(key, NoOrigin, time.Minute)
+	defer cache.Cache.Delete(key)
+
+	capture := replay.NewTrafficCapture()
+	path, err := capture.Start(dir, time.Minute)
+	require.NoError(t, err)
+
+	packetsChannel := make(chan Packets)
+	s, err := NewUDSListener(packetsChannel, NewPacketPool(512), capture)
+	require.NoError(t, err)
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("unixgram", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g")) //nolint:errcheck
+
+	select {
+	case packets := <-packetsChannel:
+		assert.Equal(t, "daemon:666|g", string(packets[0].Contents))
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+	capture.Stop()
+
+	reader, err := replay.NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	p, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "daemon:666|g", string(p.Payload))
+	assert.Equal(t, &replay.Credentials{Pid: int32(os.Getpid()), UID: uint32(os.Getuid()), GID: uint32(os.Getgid())}, p.Credentials)
+}
@@ -10,6 +10,8 @@ package listeners
 import (
 	"errors"
 	"net"
+
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 )
 
 // ErrLinuxOnly is emitted on non-linux platforms
@@ -26,6 +28,11 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 }
 
 // processUDSOrigin returns a "not implemented" error on non-linux hosts
-func processUDSOrigin(oob []byte) (string, error) {
+func processUDSOrigin(oob []byte) (*replay.Credentials, string, error) {
+	return nil, NoOrigin, ErrLinuxOnly
+}
+
+// getUDSPeerOrigin returns a "not implemented" error on non-linux hosts
+func getUDSPeerOrigin(conn net.Conn) (string, error) {
 	return NoOrigin, ErrLinuxOnly
 }
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+var udsStreamTelemetry = newStreamTelemetry("uds_stream", "UDS stream")
+
+// UDSStreamListener implements the StatsdListener interface for Unix Domain
+// Socket stream protocol. It accepts connections on a given socket path and
+// sends back packets ready to be processed. The messages are length prefixed
+// or newline framed. A length prefixed frame holds newline separated messages
+// and can be larger than dogstatsd_buffer_size, up to dogstatsd_stream_max_frame_size,
+// but each message must fit in dogstatsd_buffer_size. The clients are slowed
+// down instead of having their messages dropped when the intake is full.
+// Origin detection reads the credentials of the client once per connection.
+type UDSStreamListener struct {
+	*streamListener
+	socketPath      string
+	OriginDetection bool
+}
+
+// NewUDSStreamListener returns an idle UDS stream Statsd listener
+func NewUDSStreamListener(packetOut chan Packets, sharedPacketPool *PacketPool, capture *replay.TrafficCapture) (*UDSStreamListener, error) {
+	socketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+	originDetection := config.Datadog.GetBool("dogstatsd_origin_detection")
+
+	var framing streamFraming
+	switch f := config.Datadog.GetString("dogstatsd_stream_socket_framing"); f {
+	case "length_prefix":
+		framing = lengthPrefixFraming
+	case "newline":
+		framing = newlineFraming
+	default:
+		return nil, fmt.Errorf("dogstatsd-uds-stream: unknown framing %q, must be length_prefix or newline", f)
+	}
+
+	address, addrErr := net.ResolveUnixAddr("unix", socketPath)
+	if addrErr != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: can't ResolveUnixAddr: %v", addrErr)
+	}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: %v", err)
+	}
+
+	listener, err := net.ListenUnix("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	// the socket file is removed by Stop
+	listener.SetUnlinkOnClose(false)
+	err = os.Chmod(socketPath, 0722)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("can't set the socket at write only: %s", err)
+	}
+
+	var getOrigin func(net.Conn) (string, error)
+	if originDetection {
+		getOrigin = getUDSPeerOrigin
+		log.Debugf("dogstatsd-uds-stream: enabling origin detection on %s", listener.Addr())
+	}
+
+	l := &UDSStreamListener{
+		streamListener: newStreamListener("dogstatsd-uds-stream", listener, framing, getOrigin, udsStreamTelemetry,
+			config.Datadog.GetInt("dogstatsd_stream_socket_max_connections"), packetOut, sharedPacketPool, capture),
+		socketPath:      socketPath,
+		OriginDetection: originDetection,
+	}
+	log.Debugf("dogstatsd-uds-stream: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// Stop closes the UDS listener and its connections and stops listening
+func (l *UDSStreamListener) Stop() {
+	l.streamListener.Stop()
+
+	// Socket cleanup on exit
+	err := os.Remove(l.socketPath)
+	if err != nil {
+		log.Infof("dogstatsd-uds-stream: error removing socket file: %s", err)
+	}
+}
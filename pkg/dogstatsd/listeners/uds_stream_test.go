@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build !windows
+// UDS won't work in windows
+
+package listeners
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestNewUDSStreamListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+	socketPath := filepath.Join(dir, "dsd-stream.socket")
+
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_stream_socket", socketPath)
+
+	// a stale socket is replaced
+	address, err := net.ResolveUnixAddr("unix", socketPath)
+	require.NoError(t, err)
+	stale, err := net.ListenUnix("unix", address)
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	s, err := NewUDSStreamListener(nil, packetPoolUDS, nil)
+	require.NoError(t, err)
+	fi, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Srwx-w--w-", fi.Mode().String())
+
+	s.Stop()
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+
+	mockConfig.Set("dogstatsd_stream_socket_framing", "unknown")
+	defer mockConfig.Set("dogstatsd_stream_socket_framing", "length_prefix")
+	_, err = NewUDSStreamListener(nil, packetPoolUDS, nil)
+	assert.Error(t, err)
+}
+
+func TestUDSStreamReceive(t *testing.T) {
+	for _, test := range []struct {
+		framing string
+		data    []byte
+	}{
+		{"length_prefix", lengthPrefixed("daemon:666|g|#sometag1:somevalue1", "daemon:1|c\ndaemon:2|c")},
+		{"newline", []byte("daemon:666|g|#sometag1:somevalue1\ndaemon:1|c\ndaemon:2|c\n")},
+	} {
+		t.Run(test.framing, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "dd-test-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir) // clean up
+			socketPath := filepath.Join(dir, "dsd-stream.socket")
+
+			mockConfig := config.Mock()
+			mockConfig.Set("dogstatsd_stream_socket", socketPath)
+			mockConfig.Set("dogstatsd_stream_socket_framing", test.framing)
+			defer mockConfig.Set("dogstatsd_stream_socket_framing", "length_prefix")
+
+			packetChannel := make(chan Packets)
+			s, err := NewUDSStreamListener(packetChannel, packetPoolUDS, nil)
+			require.NoError(t, err)
+			go s.Listen()
+			defer s.Stop()
+
+			conn, err := net.Dial("unix", socketPath)
+			require.NoError(t, err)
+			_, err = conn.Write(test.data)
+			require.NoError(t, err)
+			conn.Close()
+
+			messages := receiveMessages(t, packetChannel, 3)
+			assert.Equal(t, [][]byte{
+				[]byte("daemon:666|g|#sometag1:somevalue1"),
+				[]byte("daemon:1|c"),
+				[]byte("daemon:2|c"),
+			}, messages)
+		})
+	}
+}
+
+func TestUDSStreamReceiveFrameLargerThanBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+	socketPath := filepath.Join(dir, "dsd-stream.socket")
+
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_stream_socket", socketPath)
+
+	packetChannel := make(chan Packets)
+	s, err := NewUDSStreamListener(packetChannel, packetPoolUDS, nil)
+	require.NoError(t, err)
+	go s.Listen()
+	defer s.Stop()
+
+	// a single frame of messages larger than the buffer
+	var expected [][]byte
+	var frame bytes.Buffer
+	for i := 0; frame.Len() <= 4*config.Datadog.GetInt("dogstatsd_buffer_size"); i++ {
+		message := fmt.Sprintf("daemon.histogram:%d|h|#sometag1:somevalue1", i)
+		expected = append(expected, []byte(message))
+		frame.WriteString(message + "\n")
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	_, err = conn.Write(lengthPrefixed(frame.String()))
+	require.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, expected, receiveMessages(t, packetChannel, len(expected)))
+}
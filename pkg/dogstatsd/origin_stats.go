@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+const (
+	// maxOrigins is the number of origins above which the stats of the new
+	// origins are merged in the otherOrigins stats
+	maxOrigins = 1024
+	// originStatsExpiry is the duration after which the stats of an origin
+	// are forgotten if no packet has been received from it
+	originStatsExpiry = 10 * time.Minute
+	// statusOrigins is the number of origins shown in the status page
+	statusOrigins = 10
+
+	otherOrigins = "other"
+)
+
+var (
+	// statusOriginStats holds the origin stats published for the status page
+	statusOriginStats = struct {
+		sync.RWMutex
+		stats *originStats
+	}{}
+)
+
+func init() {
+	expvar.Publish("dogstatsd-origins", expvar.Func(func() interface{} {
+		statusOriginStats.RLock()
+		defer statusOriginStats.RUnlock()
+		if statusOriginStats.stats == nil {
+			return nil
+		}
+		return statusOriginStats.stats.top(statusOrigins, false)
+	}))
+}
+
+// originStat holds the telemetry of the packets received from an origin
+type originStat struct {
+	Origin        string    `json:"origin"`
+	Tags          string    `json:"tags"`
+	Packets       uint64    `json:"packets"`
+	Samples       uint64    `json:"samples"`
+	Events        uint64    `json:"events"`
+	ServiceChecks uint64    `json:"service_checks"`
+	ParseErrors   uint64    `json:"parse_errors"`
+	Drops         uint64    `json:"drops"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// packetStat counts the messages of a packet, before being added to the stats
+// of its origin
+type packetStat struct {
+	samples       uint64
+	events        uint64
+	serviceChecks uint64
+	parseErrors   uint64
+	drops         uint64
+}
+
+// originStats breaks down the telemetry of the server by origin, the origin being
+// the tagger entity of the container which has sent the packets. The origins which
+// haven't sent any packet for a while are forgotten.
+type originStats struct {
+	sync.Mutex
+	stats map[string]*originStat
+}
+
+func newOriginStats() *originStats {
+	return &originStats{
+		stats: make(map[string]*originStat),
+	}
+}
+
+// messageOrigin returns the origin of a message received in a packet without origin, e.g. over
+// UDP, given by its entity ID tag. The messages of a packet are expected to share their origin.
+func messageOrigin(originID, k8sOriginID string) string {
+	if originID != "" {
+		return originID
+	}
+	return k8sOriginID
+}
+
+// add adds the messages of a packet to the stats of its origin
+func (o *originStats) add(origin string, p *packetStat) {
+	now := time.Now()
+	o.Lock()
+	defer o.Unlock()
+
+	stat, found := o.stats[origin]
+	if !found {
+		if len(o.stats) >= maxOrigins {
+			o.expire(now)
+		}
+		if len(o.stats) >= maxOrigins {
+			origin = otherOrigins
+			stat = o.stats[origin]
+		}
+		if stat == nil {
+			stat = &originStat{Origin: origin}
+			o.stats[origin] = stat
+		}
+	}
+
+	stat.Packets++
+	stat.Samples += p.samples
+	stat.Events += p.events
+	stat.ServiceChecks += p.serviceChecks
+	stat.ParseErrors += p.parseErrors
+	stat.Drops += p.drops
+	stat.LastSeen = now
+}
+
+// expire forgets the origins which haven't sent any packet for originStatsExpiry
+func (o *originStats) expire(now time.Time) {
+	for origin, stat := range o.stats {
+		if origin != otherOrigins && now.Sub(stat.LastSeen) > originStatsExpiry {
+			delete(o.stats, origin)
+		}
+	}
+}
+
+// top returns a copy of the stats of the n origins having sent the most packets,
+// or of all the origins if n is 0. withTags sets the tagger tags of the origins.
+func (o *originStats) top(n int, withTags bool) []originStat {
+	o.Lock()
+	stats := make([]originStat, 0, len(o.stats))
+	for _, stat := range o.stats {
+		stats = append(stats, *stat)
+	}
+	o.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Packets != stats[j].Packets {
+			return stats[i].Packets > stats[j].Packets
+		}
+		return stats[i].Origin < stats[j].Origin
+	})
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+
+	if withTags {
+		for i := range stats {
+			if stats[i].Origin == "" || stats[i].Origin == otherOrigins {
+				continue
+			}
+			// the tags are only a hint to identify the origin, an origin unknown
+			// to the tagger (e.g. a container that has exited) has no tags.
+			if tags, err := tagger.Tag(stats[i].Origin, collectors.LowCardinality); err == nil {
+				stats[i].Tags = strings.Join(tags, " ")
+			}
+		}
+	}
+	return stats
+}
+
+// GetJSONOriginStats returns the jsonified telemetry of the server by origin, the origins
+// having sent the most packets first.
+func (s *Server) GetJSONOriginStats() ([]byte, error) {
+	if s.originStats == nil {
+		return json.Marshal([]originStat{})
+	}
+	return json.Marshal(s.originStats.top(0, true))
+}
+
+// FormatOriginStats returns a printable version of the origin stats.
+func FormatOriginStats(stats []byte) (string, error) {
+	var originStats []originStat
+	if err := json.Unmarshal(stats, &originStats); err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	header := fmt.Sprintf("%-60s | %-30s | %-10s | %-10s | %-10s | %-14s | %-12s | %-10s | %-20s\n",
+		"Origin", "Tags", "Packets", "Samples", "Events", "Service Checks", "Parse Errors", "Drops", "Last Seen")
+	buf.Write([]byte(header))
+	buf.Write([]byte(strings.Repeat("-", len(header)) + "\n"))
+
+	for _, stat := range originStats {
+		origin := stat.Origin
+		if origin == "" {
+			origin = "no origin"
+		}
+		buf.Write([]byte(fmt.Sprintf("%-60s | %-30s | %-10d | %-10d | %-10d | %-14d | %-12d | %-10d | %-20v\n",
+			origin, stat.Tags, stat.Packets, stat.Samples, stat.Events, stat.ServiceChecks, stat.ParseErrors, stat.Drops, stat.LastSeen)))
+	}
+
+	if len(originStats) == 0 {
+		buf.Write([]byte("No packets processed yet."))
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestOriginStatsAdd(t *testing.T) {
+	o := newOriginStats()
+	o.add("container_id://a", &packetStat{samples: 2, parseErrors: 1})
+	o.add("container_id://b", &packetStat{events: 1})
+	o.add("container_id://a", &packetStat{samples: 1, drops: 1})
+	o.add("", &packetStat{serviceChecks: 1})
+
+	stats := o.top(0, false)
+	require.Len(t, stats, 3)
+	assert.Equal(t, "container_id://a", stats[0].Origin)
+	assert.Equal(t, uint64(2), stats[0].Packets)
+	assert.Equal(t, uint64(3), stats[0].Samples)
+	assert.Equal(t, uint64(1), stats[0].ParseErrors)
+	assert.Equal(t, uint64(1), stats[0].Drops)
+	assert.WithinDuration(t, time.Now(), stats[0].LastSeen, time.Minute)
+	// same number of packets, sorted by origin
+	assert.Equal(t, "", stats[1].Origin)
+	assert.Equal(t, uint64(1), stats[1].ServiceChecks)
+	assert.Equal(t, "container_id://b", stats[2].Origin)
+	assert.Equal(t, uint64(1), stats[2].Events)
+
+	stats = o.top(1, false)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "container_id://a", stats[0].Origin)
+}
+
+func TestOriginStatsMaxOrigins(t *testing.T) {
+	o := newOriginStats()
+	for i := 0; i < maxOrigins; i++ {
+		o.add(fmt.Sprintf("container_id://%d", i), &packetStat{samples: 1})
+	}
+	o.add("container_id://new", &packetStat{samples: 1})
+	o.add("container_id://newer", &packetStat{samples: 1})
+	assert.Len(t, o.stats, maxOrigins+1)
+	assert.Equal(t, uint64(2), o.stats[otherOrigins].Packets)
+	assert.NotContains(t, o.stats, "container_id://new")
+
+	// the origins which haven't sent anything for a while are forgotten
+	o.stats["container_id://0"].LastSeen = time.Now().Add(-2 * originStatsExpiry)
+	o.add("container_id://new", &packetStat{samples: 1})
+	assert.Contains(t, o.stats, "container_id://new")
+	assert.NotContains(t, o.stats, "container_id://0")
+}
+
+func TestFormatOriginStats(t *testing.T) {
+	o := newOriginStats()
+	o.add("container_id://a", &packetStat{samples: 2})
+	o.add("", &packetStat{samples: 1})
+	data, err := json.Marshal(o.top(0, false))
+	require.NoError(t, err)
+
+	formatted, err := FormatOriginStats(data)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "container_id://a")
+	assert.Contains(t, formatted, "no origin")
+
+	formatted, err = FormatOriginStats([]byte("[]"))
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "No packets processed yet.")
+
+	_, err = FormatOriginStats([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestParsePacketsOriginStats(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	defaultPort := config.Datadog.GetInt("dogstatsd_port")
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	defer config.Datadog.SetDefault("dogstatsd_port", defaultPort)
+	config.Datadog.Set("dogstatsd_origin_stats_enable", true)
+	defer config.Datadog.Set("dogstatsd_origin_stats_enable", false)
+	config.Datadog.Set("dogstatsd_eol_required", true)
+	defer config.Datadog.Set("dogstatsd_eol_required", false)
+
+	agg := mockAggregator()
+	s, err := NewServer(agg, nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+	require.NotNil(t, s.originStats)
+
+	batcher := newBatcher(agg)
+	parser := newParser(newFloat64ListPool())
+	samples := make([]metrics.MetricSample, 0, 512)
+	packets := listeners.Packets{
+		{Contents: []byte("daemon:666|g\ndaemon:1:2|d\n_e{5,4}:title|text\nnot a metric\n_sc|agent.up|0\nunterminated:1|c"), Origin: "container_id://a"},
+		{Contents: []byte("daemon:666|g\n"), Origin: listeners.NoOrigin},
+		// the origin of the packets received over UDP is given by their entity ID tag
+		{Contents: []byte("daemon:666|g|#dd.internal.entity_id:foo\n_sc|agent.up|0|#dd.internal.entity_id:foo\n"), Origin: listeners.NoOrigin},
+	}
+	s.parsePackets(batcher, parser, packets, samples)
+
+	data, err := s.GetJSONOriginStats()
+	require.NoError(t, err)
+	var stats []originStat
+	require.NoError(t, json.Unmarshal(data, &stats))
+	require.Len(t, stats, 3)
+	statsByOrigin := make(map[string]originStat)
+	for _, stat := range stats {
+		statsByOrigin[stat.Origin] = stat
+	}
+	assert.Equal(t, originStat{
+		Origin:        "container_id://a",
+		Packets:       1,
+		Samples:       3,
+		Events:        1,
+		ServiceChecks: 1,
+		ParseErrors:   1,
+		Drops:         1,
+		LastSeen:      statsByOrigin["container_id://a"].LastSeen,
+	}, statsByOrigin["container_id://a"])
+	assert.Equal(t, uint64(1), statsByOrigin[""].Samples)
+	assert.Equal(t, uint64(1), statsByOrigin["kubernetes_pod_uid://foo"].Samples)
+	assert.Equal(t, uint64(1), statsByOrigin["kubernetes_pod_uid://foo"].ServiceChecks)
+
+	// the origins are published for the status page
+	var statusStats []originStat
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("dogstatsd-origins").String()), &statusStats))
+	assert.Len(t, statusStats, 3)
+}
@@ -126,6 +126,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 	}
 
 	sampleRate := 1.0
+	var timestamp int64
 	var tags []string
 	var optionalField []byte
 	for message != nil {
@@ -137,6 +138,11 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 			if err != nil {
 				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd sample rate %q", optionalField)
 			}
+		} else if bytes.HasPrefix(optionalField, timestampFieldPrefix) {
+			timestamp, err = parseMetricSampleTimestamp(optionalField[1:])
+			if err != nil {
+				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd timestamp %q", optionalField)
+			}
 		}
 	}
 
@@ -148,6 +154,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 		metricType: metricType,
 		sampleRate: sampleRate,
 		tags:       tags,
+		timestamp:  timestamp,
 	}, nil
 }
 
@@ -26,6 +26,7 @@ var (
 
 	tagsFieldPrefix       = []byte("#")
 	sampleRateFieldPrefix = []byte("@")
+	timestampFieldPrefix  = []byte("T")
 )
 
 type dogstatsdMetricSample struct {
@@ -39,6 +40,8 @@ type dogstatsdMetricSample struct {
 	metricType metricType
 	sampleRate float64
 	tags       []string
+	// unix timestamp in seconds supplied by the client, 0 if none
+	timestamp int64
 }
 
 // sanity checks a given message against the metric sample format
@@ -47,7 +50,7 @@ func hasMetricSampleFormat(message []byte) bool {
 		return false
 	}
 	separatorCount := bytes.Count(message, fieldSeparator)
-	if separatorCount < 1 || separatorCount > 3 {
+	if separatorCount < 1 || separatorCount > 4 {
 		return false
 	}
 	return true
@@ -87,3 +90,14 @@ func parseMetricSampleMetricType(rawMetricType []byte) (metricType, error) {
 func parseMetricSampleSampleRate(rawSampleRate []byte) (float64, error) {
 	return parseFloat64(rawSampleRate)
 }
+
+func parseMetricSampleTimestamp(rawTimestamp []byte) (int64, error) {
+	timestamp, err := parseInt64(rawTimestamp)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp <= 0 {
+		return 0, fmt.Errorf("invalid timestamp: %d", timestamp)
+	}
+	return timestamp, nil
+}
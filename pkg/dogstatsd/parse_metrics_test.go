@@ -268,6 +268,32 @@ func TestParseGaugeWithUnicode(t *testing.T) {
 	assert.InEpsilon(t, 1.0, sample.sampleRate, epsilon)
 }
 
+func TestParseGaugeWithTimestamp(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:666|g|#sometag1:somevalue1|T1657100430"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.InEpsilon(t, 666.0, sample.value, epsilon)
+	assert.Equal(t, gaugeType, sample.metricType)
+	assert.Equal(t, []string{"sometag1:somevalue1"}, sample.tags)
+	assert.InEpsilon(t, 1.0, sample.sampleRate, epsilon)
+	assert.Equal(t, int64(1657100430), sample.timestamp)
+}
+
+func TestParseDistributionMultipleWithTimestamp(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:1:2:3|d|@0.5|#sometag1:somevalue1|T1657100430"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.Equal(t, []float64{1, 2, 3}, sample.values)
+	assert.Equal(t, distributionType, sample.metricType)
+	assert.Equal(t, []string{"sometag1:somevalue1"}, sample.tags)
+	assert.InEpsilon(t, 0.5, sample.sampleRate, epsilon)
+	assert.Equal(t, int64(1657100430), sample.timestamp)
+}
+
 func TestParseMetricError(t *testing.T) {
 	// not enough information
 	_, err := parseMetricSample([]byte("daemon:666"))
@@ -300,4 +326,11 @@ func TestParseMetricError(t *testing.T) {
 	// invalid sample rate
 	_, err = parseMetricSample([]byte("daemon:666|g|@abc"))
 	assert.Error(t, err)
+
+	// invalid timestamp
+	_, err = parseMetricSample([]byte("daemon:666|g|Tabc"))
+	assert.Error(t, err)
+
+	_, err = parseMetricSample([]byte("daemon:666|g|T-1"))
+	assert.Error(t, err)
 }
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// captureDepth is the number of packets waiting to be written in the capture file
+// above which the packets are not captured anymore
+const captureDepth = 4096
+
+var (
+	captureExpvars        = expvar.NewMap("dogstatsd-capture")
+	capturedPackets       = expvar.Int{}
+	droppedCapturePackets = expvar.Int{}
+
+	tlmCapturedPackets = telemetry.NewCounter("dogstatsd", "capture_packets",
+		[]string{"state"}, "Dogstatsd traffic capture packets count")
+)
+
+func init() {
+	captureExpvars.Set("Packets", &capturedPackets)
+	captureExpvars.Set("DroppedPackets", &droppedCapturePackets)
+}
+
+// TrafficCapture records the packets read by the DogStatsD listeners in a capture file.
+// A single capture can be ongoing at a time. The packets are written by a dedicated
+// goroutine, so that the listeners are never slowed down by the capture: the packets
+// are dropped from the capture when the writer does not keep up.
+type TrafficCapture struct {
+	// ongoing is checked without locking by the listeners, for every packet
+	ongoing int32
+
+	m        sync.RWMutex
+	packets  chan *Packet
+	stop     chan struct{}
+	finished chan struct{}
+}
+
+// NewTrafficCapture returns an idle TrafficCapture
+func NewTrafficCapture() *TrafficCapture {
+	return &TrafficCapture{}
+}
+
+// IsOngoing returns whether a capture is ongoing
+func (tc *TrafficCapture) IsOngoing() bool {
+	return tc != nil && atomic.LoadInt32(&tc.ongoing) == 1
+}
+
+// Start starts a capture of the given duration in a new file of the directory dir,
+// and returns the path of that file.
+func (tc *TrafficCapture) Start(dir string, duration time.Duration) (string, error) {
+	if duration <= 0 {
+		return "", fmt.Errorf("invalid capture duration %s", duration)
+	}
+
+	tc.m.Lock()
+	defer tc.m.Unlock()
+	if tc.IsOngoing() {
+		return "", fmt.Errorf("a capture is already ongoing")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("can't create the capture directory: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("dogstatsd-capture-%d.gz", time.Now().UnixNano()))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("can't create the capture file: %v", err)
+	}
+	writer, err := newCaptureWriter(f)
+	if err != nil {
+		f.Close()
+		os.Remove(path) //nolint:errcheck
+		return "", fmt.Errorf("can't write the capture file: %v", err)
+	}
+
+	tc.packets = make(chan *Packet, captureDepth)
+	tc.stop = make(chan struct{})
+	tc.finished = make(chan struct{})
+	atomic.StoreInt32(&tc.ongoing, 1)
+	go tc.run(path, f, writer, duration, tc.packets, tc.stop, tc.finished)
+
+	log.Infof("dogstatsd-capture: capturing the traffic for %s in %s", duration, path)
+	return path, nil
+}
+
+// Capture adds a copy of a packet to the ongoing capture, if any. creds are the
+// credentials of the sender, nil if unknown.
+func (tc *TrafficCapture) Capture(payload []byte, creds *Credentials) {
+	if !tc.IsOngoing() {
+		return
+	}
+
+	p := &Packet{
+		Timestamp:   time.Now(),
+		Credentials: creds,
+		Payload:     append([]byte(nil), payload...),
+	}
+
+	tc.m.RLock()
+	defer tc.m.RUnlock()
+	// the capture may have ended since the first check
+	if !tc.IsOngoing() {
+		return
+	}
+	select {
+	case tc.packets <- p:
+		capturedPackets.Add(1)
+		tlmCapturedPackets.Inc("ok")
+	default:
+		droppedCapturePackets.Add(1)
+		tlmCapturedPackets.Inc("dropped")
+	}
+}
+
+// Stop stops the ongoing capture, if any, and waits for its file to be written
+func (tc *TrafficCapture) Stop() {
+	if tc == nil {
+		return
+	}
+	tc.m.RLock()
+	stop, finished := tc.stop, tc.finished
+	tc.m.RUnlock()
+
+	tc.end(stop)
+	if finished != nil {
+		<-finished
+	}
+}
+
+// end marks the capture identified by its stop channel as ended, if it is still
+// ongoing. Once it returns, no packet can be added to the capture anymore.
+func (tc *TrafficCapture) end(stop chan struct{}) {
+	tc.m.Lock()
+	defer tc.m.Unlock()
+	if tc.IsOngoing() && tc.stop == stop {
+		atomic.StoreInt32(&tc.ongoing, 0)
+		close(tc.stop)
+	}
+}
+
+// run writes the packets of a capture in its file until the end of the capture
+func (tc *TrafficCapture) run(path string, f *os.File, writer *captureWriter, duration time.Duration,
+	packets chan *Packet, stop chan struct{}, finished chan struct{}) {
+	defer close(finished)
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	var count int
+	var err error
+	write := func(p *Packet) {
+		if err != nil {
+			return
+		}
+		if err = writer.write(p); err != nil {
+			log.Errorf("dogstatsd-capture: error writing in %s, stopping the capture: %v", path, err)
+			tc.end(stop)
+			return
+		}
+		count++
+	}
+
+loop:
+	for {
+		select {
+		case p := <-packets:
+			write(p)
+		case <-timer.C:
+			tc.end(stop)
+			break loop
+		case <-stop:
+			break loop
+		}
+	}
+
+	// no packet can be captured anymore, write the remaining ones
+	for {
+		select {
+		case p := <-packets:
+			write(p)
+			continue
+		default:
+		}
+		break
+	}
+
+	if closeErr := writer.close(); closeErr != nil && err == nil {
+		log.Errorf("dogstatsd-capture: error writing in %s: %v", path, closeErr)
+	}
+	if closeErr := f.Close(); closeErr != nil && err == nil {
+		log.Errorf("dogstatsd-capture: error closing %s: %v", path, closeErr)
+	}
+	log.Infof("dogstatsd-capture: capture ended, %d packets written in %s", count, path)
+}
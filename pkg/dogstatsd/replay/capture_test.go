@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureFileRoundTrip(t *testing.T) {
+	packets := []*Packet{
+		{Timestamp: time.Unix(1600000000, 1), Payload: []byte("daemon:666|g")},
+		{Timestamp: time.Unix(1600000000, 500), Credentials: &Credentials{Pid: 42, UID: 1000, GID: 0}, Payload: []byte("daemon:1|c|#sometag\ndaemon:2|c")},
+		{Timestamp: time.Unix(1600000001, 0), Payload: []byte{}},
+	}
+
+	var buf bytes.Buffer
+	writer, err := newCaptureWriter(&buf)
+	require.NoError(t, err)
+	for _, p := range packets {
+		require.NoError(t, writer.write(p))
+	}
+	require.NoError(t, writer.close())
+
+	reader, err := newCaptureReader(&buf)
+	require.NoError(t, err)
+	for _, expected := range packets {
+		p, err := reader.read()
+		require.NoError(t, err)
+		assert.True(t, expected.Timestamp.Equal(p.Timestamp))
+		assert.Equal(t, expected.Credentials, p.Credentials)
+		assert.Equal(t, expected.Payload, p.Payload)
+	}
+	_, err = reader.read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCaptureFileInvalid(t *testing.T) {
+	_, err := newCaptureReader(bytes.NewBufferString("daemon:666|g"))
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("DSDCAP\x09")) //nolint:errcheck
+	gz.Close()
+	_, err = newCaptureReader(&buf)
+	assert.EqualError(t, err, "unsupported capture file version 9")
+
+	buf.Reset()
+	writer, err := newCaptureWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.write(&Packet{Timestamp: time.Now(), Payload: []byte("daemon:666|g")}))
+	require.NoError(t, writer.close())
+	uncompressed, err := ioutil.ReadAll(mustGzipReader(t, &buf))
+	require.NoError(t, err)
+
+	// drop the end of the payload
+	buf.Reset()
+	gz = gzip.NewWriter(&buf)
+	gz.Write(uncompressed[:len(uncompressed)-3]) //nolint:errcheck
+	gz.Close()
+	reader, err := newCaptureReader(&buf)
+	require.NoError(t, err)
+	_, err = reader.read()
+	assert.EqualError(t, err, "truncated capture file")
+}
+
+func mustGzipReader(t *testing.T, r io.Reader) io.Reader {
+	gz, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	return gz
+}
+
+func TestTrafficCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tc := NewTrafficCapture()
+	assert.False(t, tc.IsOngoing())
+	// nothing is captured when no capture is ongoing
+	tc.Capture([]byte("daemon:0|g"), nil)
+
+	path, err := tc.Start(filepath.Join(dir, "captures"), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, tc.IsOngoing())
+	_, err = tc.Start(dir, time.Minute)
+	assert.EqualError(t, err, "a capture is already ongoing")
+
+	payload := []byte("daemon:1|g")
+	tc.Capture(payload, nil)
+	// the packets are copied, the listeners reuse their buffers
+	payload[7] = '2'
+	tc.Capture(payload, &Credentials{Pid: 1234, UID: 1, GID: 2})
+
+	tc.Stop()
+	assert.False(t, tc.IsOngoing())
+	tc.Capture([]byte("daemon:3|g"), nil)
+	tc.Stop()
+
+	reader, err := NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	p, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "daemon:1|g", string(p.Payload))
+	assert.Nil(t, p.Credentials)
+	assert.WithinDuration(t, time.Now(), p.Timestamp, time.Minute)
+
+	p, err = reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "daemon:2|g", string(p.Payload))
+	assert.Equal(t, &Credentials{Pid: 1234, UID: 1, GID: 2}, p.Credentials)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTrafficCaptureDuration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tc := NewTrafficCapture()
+	_, err = tc.Start(dir, 0)
+	assert.Error(t, err)
+
+	path, err := tc.Start(dir, 50*time.Millisecond)
+	require.NoError(t, err)
+	tc.Capture([]byte("daemon:1|g"), nil)
+	require.Eventually(t, func() bool { return !tc.IsOngoing() }, 2*time.Second, 10*time.Millisecond)
+	tc.Stop()
+
+	reader, err := NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	count, err := reader.Replay(func(*Packet) error { return nil }, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// a new capture can be started once the previous one has ended
+	_, err = tc.Start(dir, time.Minute)
+	require.NoError(t, err)
+	tc.Stop()
+}
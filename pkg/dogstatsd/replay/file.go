@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A capture file is a gzip compressed stream starting with a header, the magic
+// `DSDCAP` followed by the version of the format, and then holding the packets one
+// after the other. The integers are little endian. A packet is made of:
+//   - its reception timestamp, in nanoseconds since the epoch (int64)
+//   - flags (uint8), flagCredentials is set when the credentials of the sender are known
+//   - the pid (int32), uid (uint32) and gid (uint32) of the sender, if flagCredentials is set
+//   - the size of its payload (uint32)
+//   - its payload
+const (
+	fileMagic   = "DSDCAP"
+	fileVersion = byte(1)
+
+	flagCredentials = byte(1)
+
+	// maxPayloadSize is the size above which a payload is considered as corrupted
+	maxPayloadSize = 1 << 24
+)
+
+// Credentials are the credentials of the process which has sent a packet on the UDS
+// socket, as read in the ancillary data of the packet
+type Credentials struct {
+	Pid int32
+	UID uint32
+	GID uint32
+}
+
+// Packet is a packet read by a DogStatsD listener
+type Packet struct {
+	// Timestamp is the time the packet has been received
+	Timestamp time.Time
+	// Credentials are the credentials of the sender, nil if unknown
+	Credentials *Credentials
+	// Payload holds the raw messages of the packet
+	Payload []byte
+}
+
+// captureWriter writes packets in a capture file
+type captureWriter struct {
+	buffered *bufio.Writer
+	gz       *gzip.Writer
+	header   [25]byte
+}
+
+// newCaptureWriter writes the header of a capture file in w, and returns a captureWriter
+func newCaptureWriter(w io.Writer) (*captureWriter, error) {
+	gz := gzip.NewWriter(w)
+	cw := &captureWriter{
+		buffered: bufio.NewWriter(gz),
+		gz:       gz,
+	}
+	if _, err := cw.buffered.WriteString(fileMagic); err != nil {
+		return nil, err
+	}
+	if err := cw.buffered.WriteByte(fileVersion); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// write appends a packet to the capture
+func (cw *captureWriter) write(p *Packet) error {
+	binary.LittleEndian.PutUint64(cw.header[0:], uint64(p.Timestamp.UnixNano()))
+	n := 9
+	if p.Credentials != nil {
+		cw.header[8] = flagCredentials
+		binary.LittleEndian.PutUint32(cw.header[9:], uint32(p.Credentials.Pid))
+		binary.LittleEndian.PutUint32(cw.header[13:], p.Credentials.UID)
+		binary.LittleEndian.PutUint32(cw.header[17:], p.Credentials.GID)
+		n = 21
+	} else {
+		cw.header[8] = 0
+	}
+	binary.LittleEndian.PutUint32(cw.header[n:], uint32(len(p.Payload)))
+	n += 4
+
+	if _, err := cw.buffered.Write(cw.header[:n]); err != nil {
+		return err
+	}
+	_, err := cw.buffered.Write(p.Payload)
+	return err
+}
+
+// close flushes the capture, it does not close the underlying writer
+func (cw *captureWriter) close() error {
+	if err := cw.buffered.Flush(); err != nil {
+		return err
+	}
+	return cw.gz.Close()
+}
+
+// captureReader reads the packets of a capture file
+type captureReader struct {
+	buffered *bufio.Reader
+	gz       *gzip.Reader
+	header   [25]byte
+}
+
+// newCaptureReader reads and checks the header of the capture file read from r,
+// and returns a captureReader
+func newCaptureReader(r io.Reader) (*captureReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a capture file: %v", err)
+	}
+	cr := &captureReader{
+		buffered: bufio.NewReader(gz),
+		gz:       gz,
+	}
+
+	header := cr.header[:len(fileMagic)+1]
+	if _, err := io.ReadFull(cr.buffered, header); err != nil {
+		return nil, fmt.Errorf("not a capture file: %v", err)
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, fmt.Errorf("not a capture file: unknown header")
+	}
+	if version := header[len(fileMagic)]; version != fileVersion {
+		return nil, fmt.Errorf("unsupported capture file version %d", version)
+	}
+	return cr, nil
+}
+
+// read returns the next packet of the capture, or io.EOF at the end of the capture
+func (cr *captureReader) read() (*Packet, error) {
+	if _, err := io.ReadFull(cr.buffered, cr.header[:9]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated capture file")
+		}
+		return nil, err
+	}
+	p := &Packet{
+		Timestamp: time.Unix(0, int64(binary.LittleEndian.Uint64(cr.header[0:]))),
+	}
+	n := 9
+	if cr.header[8]&flagCredentials != 0 {
+		if _, err := io.ReadFull(cr.buffered, cr.header[9:21]); err != nil {
+			return nil, fmt.Errorf("truncated capture file")
+		}
+		p.Credentials = &Credentials{
+			Pid: int32(binary.LittleEndian.Uint32(cr.header[9:])),
+			UID: binary.LittleEndian.Uint32(cr.header[13:]),
+			GID: binary.LittleEndian.Uint32(cr.header[17:]),
+		}
+		n = 21
+	}
+	if _, err := io.ReadFull(cr.buffered, cr.header[n:n+4]); err != nil {
+		return nil, fmt.Errorf("truncated capture file")
+	}
+
+	size := binary.LittleEndian.Uint32(cr.header[n:])
+	if size > maxPayloadSize {
+		return nil, fmt.Errorf("corrupted capture file: packet of %d bytes", size)
+	}
+	p.Payload = make([]byte, size)
+	if _, err := io.ReadFull(cr.buffered, p.Payload); err != nil {
+		return nil, fmt.Errorf("truncated capture file")
+	}
+	return p, nil
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// TrafficCaptureReader reads the packets of a capture file
+type TrafficCaptureReader struct {
+	f      *os.File
+	reader *captureReader
+}
+
+// NewTrafficCaptureReader opens a capture file
+func NewTrafficCaptureReader(path string) (*TrafficCaptureReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := newCaptureReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &TrafficCaptureReader{
+		f:      f,
+		reader: reader,
+	}, nil
+}
+
+// Read returns the next packet of the capture, or io.EOF once all the packets have been read
+func (tcr *TrafficCaptureReader) Read() (*Packet, error) {
+	return tcr.reader.read()
+}
+
+// Close closes the capture file
+func (tcr *TrafficCaptureReader) Close() error {
+	return tcr.f.Close()
+}
+
+// Replay sends the packets of the capture with send, reproducing the delays between the
+// packets divided by speed: a speed of 2 replays the capture twice as fast as it has been
+// recorded. The packets are sent without any delay when speed is 0. It returns the number
+// of packets sent.
+func (tcr *TrafficCaptureReader) Replay(send func(*Packet) error, speed float64) (int, error) {
+	var count int
+	var start, first time.Time
+	for {
+		p, err := tcr.Read()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if speed > 0 {
+			if count == 0 {
+				start, first = time.Now(), p.Timestamp
+			} else {
+				// the delays are computed from the start of the replay so that the
+				// time spent sending the packets doesn't slow the replay down
+				target := time.Duration(float64(p.Timestamp.Sub(first)) / speed)
+				if wait := target - time.Since(start); wait > 0 {
+					time.Sleep(wait)
+				}
+			}
+		}
+
+		if err := send(p); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
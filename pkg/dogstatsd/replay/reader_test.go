@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCaptureFile(t *testing.T, packets []*Packet) string {
+	f, err := ioutil.TempFile("", "dsd-capture")
+	require.NoError(t, err)
+	defer f.Close()
+
+	writer, err := newCaptureWriter(f)
+	require.NoError(t, err)
+	for _, p := range packets {
+		require.NoError(t, writer.write(p))
+	}
+	require.NoError(t, writer.close())
+	return f.Name()
+}
+
+func TestNewTrafficCaptureReaderError(t *testing.T) {
+	_, err := NewTrafficCaptureReader("/does/not/exist")
+	assert.Error(t, err)
+
+	f, err := ioutil.TempFile("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("daemon:666|g") //nolint:errcheck
+	f.Close()
+	_, err = NewTrafficCaptureReader(f.Name())
+	assert.Error(t, err)
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	path := writeCaptureFile(t, []*Packet{
+		{Timestamp: start, Payload: []byte("daemon:1|c")},
+		{Timestamp: start.Add(200 * time.Millisecond), Payload: []byte("daemon:2|c")},
+		{Timestamp: start.Add(400 * time.Millisecond), Payload: []byte("daemon:3|c")},
+	})
+	defer os.Remove(path)
+
+	for _, tc := range []struct {
+		speed   float64
+		minimum time.Duration
+		maximum time.Duration
+	}{
+		{speed: 1, minimum: 400 * time.Millisecond, maximum: 2 * time.Second},
+		{speed: 4, minimum: 100 * time.Millisecond, maximum: 390 * time.Millisecond},
+		{speed: 0, minimum: 0, maximum: 100 * time.Millisecond},
+	} {
+		reader, err := NewTrafficCaptureReader(path)
+		require.NoError(t, err)
+
+		var payloads []string
+		replayStart := time.Now()
+		count, err := reader.Replay(func(p *Packet) error {
+			payloads = append(payloads, string(p.Payload))
+			return nil
+		}, tc.speed)
+		elapsed := time.Since(replayStart)
+		reader.Close()
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, []string{"daemon:1|c", "daemon:2|c", "daemon:3|c"}, payloads)
+		assert.True(t, elapsed >= tc.minimum, "speed %v: replay took %s", tc.speed, elapsed)
+		assert.True(t, elapsed <= tc.maximum, "speed %v: replay took %s", tc.speed, elapsed)
+	}
+}
+
+func TestReplaySendError(t *testing.T) {
+	path := writeCaptureFile(t, []*Packet{
+		{Timestamp: time.Now(), Payload: []byte("daemon:1|c")},
+		{Timestamp: time.Now(), Payload: []byte("daemon:2|c")},
+	})
+	defer os.Remove(path)
+
+	reader, err := NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	count, err := reader.Replay(func(p *Packet) error {
+		return errors.New("connection refused")
+	}, 0)
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 0, count)
+}
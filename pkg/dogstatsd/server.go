@@ -11,6 +11,7 @@ import (
 	"expvar"
 	"fmt"
 	"net"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -23,7 +24,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/metrics/rewrite"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	telemetry_utils "github.com/DataDog/datadog-agent/pkg/telemetry/utils"
@@ -60,7 +63,7 @@ func init() {
 
 // Server represent a Dogstatsd server
 type Server struct {
-	// listeners are the instantiated socket listener (UDS or UDP or both)
+	// listeners are the instantiated socket listeners (UDS, UDP, TCP or named pipe)
 	listeners []listeners.StatsdListener
 	// aggregator is a pointer to the aggregator that the dogstatsd daemon
 	// will send the metrics samples, events and service checks to.
@@ -83,7 +86,9 @@ type Server struct {
 	histToDistPrefix          string
 	extraTags                 []string
 	Debug                     *dsdServerDebug
+	originStats               *originStats
 	mapper                    *mapper.MetricMapper
+	rewriter                  *rewrite.Rewriter
 	eolTerminationEnabled     bool
 	telemetryEnabled          bool
 	entityIDPrecedenceEnabled bool
@@ -96,6 +101,9 @@ type Server struct {
 	// ServerlessMode is set to true if we're running in a serverless environment.
 	ServerlessMode     bool
 	UdsListenerRunning bool
+
+	// TCapture records the packets read by the listeners when a capture is ongoing
+	TCapture *replay.TrafficCapture
 }
 
 // metricStat holds how many times a metric has been
@@ -157,9 +165,12 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 
 	udsListenerRunning := false
 
+	// the capture is started on demand, through the agent API
+	capture := replay.NewTrafficCapture()
+
 	socketPath := config.Datadog.GetString("dogstatsd_socket")
 	if len(socketPath) > 0 {
-		unixListener, err := listeners.NewUDSListener(packetsChannel, sharedPacketPool)
+		unixListener, err := listeners.NewUDSListener(packetsChannel, sharedPacketPool, capture)
 		if err != nil {
 			log.Errorf(err.Error())
 		} else {
@@ -167,8 +178,17 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 			udsListenerRunning = true
 		}
 	}
+	streamSocketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+	if len(streamSocketPath) > 0 {
+		unixStreamListener, err := listeners.NewUDSStreamListener(packetsChannel, sharedPacketPool, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, unixStreamListener)
+		}
+	}
 	if config.Datadog.GetInt("dogstatsd_port") > 0 {
-		udpListener, err := listeners.NewUDPListener(packetsChannel, sharedPacketPool)
+		udpListener, err := listeners.NewUDPListener(packetsChannel, sharedPacketPool, capture)
 		if err != nil {
 			log.Errorf(err.Error())
 		} else {
@@ -176,6 +196,15 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 		}
 	}
 
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPool, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPool)
@@ -187,7 +216,7 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
+		return nil, fmt.Errorf("listening on neither udp, tcp nor socket, please check your configuration")
 	}
 
 	// check configuration for custom namespace
@@ -241,6 +270,7 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 			keyGen: ckey.NewKeyGenerator(),
 		},
 		UdsListenerRunning: udsListenerRunning,
+		TCapture:           capture,
 	}
 
 	// packets forwarding
@@ -271,6 +301,14 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 		s.EnableMetricsStats()
 	}
 
+	if config.Datadog.GetBool("dogstatsd_origin_stats_enable") {
+		log.Info("Dogstatsd: origin statistics will be stored.")
+		s.originStats = newOriginStats()
+		statusOriginStats.Lock()
+		statusOriginStats.stats = s.originStats
+		statusOriginStats.Unlock()
+	}
+
 	// map some metric name
 	// ----------------------
 
@@ -287,6 +325,16 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 			s.mapper = mapperInstance
 		}
 	}
+
+	// rewrite the metric samples
+	// ----------------------
+
+	rules, err := config.GetMetricRewriteRules()
+	if err != nil {
+		log.Warnf("Could not parse the metric rewrite rules: %v", err)
+	} else if s.rewriter, err = rewrite.NewRewriter(rules); err != nil {
+		log.Warnf("Could not create the metric rewriter: %v", err)
+	}
 	return s, nil
 }
 
@@ -392,9 +440,15 @@ func nextMessage(packet *[]byte, eolTermination bool) (message []byte) {
 func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*listeners.Packet, samples []metrics.MetricSample) []metrics.MetricSample {
 	for _, packet := range packets {
 		log.Tracef("Dogstatsd receive: %q", packet.Contents)
+		var stat packetStat
+		origin := packet.Origin
 		for {
 			message := nextMessage(&packet.Contents, s.eolTerminationEnabled)
 			if message == nil {
+				if len(packet.Contents) > 0 {
+					// the last message is not newline terminated
+					stat.drops++
+				}
 				break
 			}
 			if len(message) == 0 {
@@ -410,15 +464,25 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 				serviceCheck, err := s.parseServiceCheckMessage(parser, message, packet.Origin)
 				if err != nil {
 					s.errLog("Dogstatsd: error parsing service check '%q': %s", message, err)
+					stat.parseErrors++
 					continue
 				}
+				stat.serviceChecks++
+				if origin == "" {
+					origin = messageOrigin(serviceCheck.OriginID, serviceCheck.K8sOriginID)
+				}
 				batcher.appendServiceCheck(serviceCheck)
 			case eventType:
 				event, err := s.parseEventMessage(parser, message, packet.Origin)
 				if err != nil {
 					s.errLog("Dogstatsd: error parsing event '%q': %s", message, err)
+					stat.parseErrors++
 					continue
 				}
+				stat.events++
+				if origin == "" {
+					origin = messageOrigin(event.OriginID, event.K8sOriginID)
+				}
 				batcher.appendEvent(event)
 			case metricSampleType:
 				var err error
@@ -427,8 +491,17 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 				samples, err = s.parseMetricMessage(samples, parser, message, packet.Origin)
 				if err != nil {
 					s.errLog("Dogstatsd: error parsing metric message '%q': %s", message, err)
+					stat.parseErrors++
 					continue
 				}
+				if len(samples) == 0 {
+					// dropped by the rewrite rules
+					stat.drops++
+				}
+				stat.samples += uint64(len(samples))
+				if origin == "" && len(samples) > 0 {
+					origin = messageOrigin(samples[0].OriginID, samples[0].K8sOriginID)
+				}
 				for idx := range samples {
 					if atomic.LoadUint64(&s.Debug.Enabled) == 1 {
 						s.storeMetricStats(samples[idx])
@@ -443,6 +516,9 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 				}
 			}
 		}
+		if s.originStats != nil {
+			s.originStats.add(origin, &stat)
+		}
 		s.sharedPacketPool.Put(packet)
 	}
 	batcher.flush()
@@ -489,6 +565,18 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		dogstatsdMetricPackets.Add(1)
 		tlmProcessed.IncWithTags(tlmProcessedOkTags)
 	}
+
+	if s.rewriter != nil && len(metricSamples) > 0 {
+		// All metricSamples share the same name and tags, they are rewritten once.
+		name, tags, keep := s.rewriter.Rewrite(metricSamples[0].Name, metricSamples[0].Tags)
+		if !keep {
+			return metricSamples[:0], nil
+		}
+		for idx := range metricSamples {
+			metricSamples[idx].Name = name
+			metricSamples[idx].Tags = tags
+		}
+	}
 	return metricSamples, nil
 }
 
@@ -526,6 +614,14 @@ func (s *Server) Stop() {
 	for _, l := range s.listeners {
 		l.Stop()
 	}
+	s.TCapture.Stop()
+	if s.originStats != nil {
+		statusOriginStats.Lock()
+		if statusOriginStats.stats == s.originStats {
+			statusOriginStats.stats = nil
+		}
+		statusOriginStats.Unlock()
+	}
 	if s.Statistics != nil {
 		s.Statistics.Stop()
 	}
@@ -533,6 +629,16 @@ func (s *Server) Stop() {
 	s.Started = false
 }
 
+// Capture starts a capture of the packets read by the listeners for the given
+// duration, and returns the path of the capture file.
+func (s *Server) Capture(duration time.Duration) (string, error) {
+	dir := config.Datadog.GetString("dogstatsd_capture_path")
+	if dir == "" {
+		dir = filepath.Join(config.Datadog.GetString("run_path"), "dsd_capture")
+	}
+	return s.TCapture.Start(dir, duration)
+}
+
 func (s *Server) storeMetricStats(sample metrics.MetricSample) {
 	now := time.Now()
 	s.Debug.Lock()
@@ -8,8 +8,10 @@ package dogstatsd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,6 +23,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
@@ -414,6 +417,94 @@ func TestHistToDist(t *testing.T) {
 	}
 }
 
+func TestTimestampedSamples(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	defaultPort := config.Datadog.GetInt("dogstatsd_port")
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	defer config.Datadog.SetDefault("dogstatsd_port", defaultPort)
+
+	agg := mockAggregator()
+	metricOut, _, _ := agg.GetBufferedChannels()
+	metricWithTsOut := agg.GetBufferedMetricsWithTsChannel()
+	s, err := NewServer(agg, nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	url := fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_port"))
+	conn, err := net.Dial("udp", url)
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\ndaemon:1:2|d|#sometag1:somevalue1|T1657100430"))
+	select {
+	case res := <-metricOut:
+		require.Equal(t, 1, len(res))
+		assert.EqualValues(t, 666.0, res[0].Value)
+		assert.Zero(t, res[0].Timestamp)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+	select {
+	case res := <-metricWithTsOut:
+		require.Equal(t, 2, len(res))
+		for i, sample := range res {
+			assert.Equal(t, "daemon", sample.Name)
+			assert.EqualValues(t, i+1, sample.Value)
+			assert.Equal(t, metrics.DistributionType, sample.Mtype)
+			assert.Equal(t, float64(1657100430*time.Second), sample.Timestamp)
+		}
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func TestCapture(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	defaultPort := config.Datadog.GetInt("dogstatsd_port")
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	defer config.Datadog.SetDefault("dogstatsd_port", defaultPort)
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config.Datadog.Set("dogstatsd_capture_path", dir)
+	defer config.Datadog.Set("dogstatsd_capture_path", "")
+
+	agg := mockAggregator()
+	metricOut, _, _ := agg.GetBufferedChannels()
+	s, err := NewServer(agg, nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	path, err := s.Capture(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	_, err = s.Capture(time.Minute)
+	assert.Error(t, err)
+
+	url := fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_port"))
+	conn, err := net.Dial("udp", url)
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1"))
+	select {
+	case res := <-metricOut:
+		require.Equal(t, 1, len(res))
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+	s.TCapture.Stop()
+
+	reader, err := replay.NewTrafficCaptureReader(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	p, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "daemon:666|g|#sometag1:somevalue1", string(p.Payload))
+}
+
 func TestScanLines(t *testing.T) {
 
 	messages := []string{"foo", "bar", "baz", "quz", "hax", ""}
@@ -814,6 +905,43 @@ dogstatsd_mapper_profiles:
 	}
 }
 
+func TestRewriteRules(t *testing.T) {
+	datadogYaml := `
+metric_rewrite_rules:
+  - match: 'debug\..*'
+    drop: true
+  - match: 'legacy\.(.*)'
+    rename: 'app.$1'
+    remove_tags: ["user_id"]
+`
+	config.Datadog.SetConfigType("yaml")
+	err := config.Datadog.ReadConfig(strings.NewReader(datadogYaml))
+	require.NoError(t, err)
+	defer config.Datadog.ReadConfig(strings.NewReader("")) //nolint:errcheck
+
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+
+	s, err := NewServer(mockAggregator(), nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+	require.NotNil(t, s.rewriter)
+
+	parser := newParser(newFloat64ListPool())
+	samples, err := s.parseMetricMessage(nil, parser, []byte("debug.metric:1|g|#user_id:1"), "")
+	assert.NoError(t, err)
+	assert.Len(t, samples, 0)
+
+	samples, err = s.parseMetricMessage(nil, parser, []byte("legacy.requests:1:2|c|#user_id:1,env:prod"), "")
+	assert.NoError(t, err)
+	require.Len(t, samples, 2)
+	for _, sample := range samples {
+		assert.Equal(t, "app.requests", sample.Name)
+		assert.Equal(t, []string{"env:prod"}, sample.Tags)
+	}
+}
+
 func TestNewServerExtraTags(t *testing.T) {
 	require := require.New(t)
 	port, err := getAvailableUDPPort()
@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// remoteWriteEndpointName is the name of the remote-write endpoint in the telemetry of the transactions.
+	remoteWriteEndpointName = "prometheus_remote_write"
+	// remoteWriteStorageFolder is the folder of the remote-write transactions stored on disk. It is
+	// not under the folder of the DefaultForwarder, which removes the folders of the unknown domains.
+	remoteWriteStorageFolder = "remote_write"
+	// remoteWriteVersion is the version of the remote-write protocol.
+	remoteWriteVersion = "0.1.0"
+)
+
+// RemoteWriteForwarder sends Prometheus remote-write payloads to a single endpoint. Its
+// transactions go through a domainForwarder, so the failed ones are retried and flushed to disk
+// like the transactions of the DefaultForwarder.
+type RemoteWriteForwarder struct {
+	domain          string
+	endpoint        endpoint
+	headers         http.Header
+	domainForwarder *domainForwarder
+	internalState   uint32
+	m               sync.Mutex // To control Start/Stop races
+}
+
+// NewRemoteWriteForwarder returns a RemoteWriteForwarder sending the payloads to rawURL with the
+// extra headers. It uses the workers and the retry queue settings of options, the transactions
+// being stored on disk when the core features are enabled. The values of the headers and the
+// credentials of the URL are replaced by placeholders in the stored transactions.
+func NewRemoteWriteForwarder(options *Options, rawURL string, headers map[string]string) (*RemoteWriteForwarder, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote-write URL: %s", log.SanitizeURL(err.Error()))
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid remote-write URL %q: an absolute http or https URL is expected", log.SanitizeURL(rawURL))
+	}
+
+	f := &RemoteWriteForwarder{
+		domain:        u.Scheme + "://" + u.Host,
+		endpoint:      endpoint{u.RequestURI(), remoteWriteEndpointName},
+		headers:       make(http.Header),
+		internalState: Stopped,
+	}
+	var secrets []string
+	for k, v := range headers {
+		f.headers.Set(k, v)
+		if v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(u.User.Username()+":"+password))
+		f.headers.Set("Authorization", auth)
+		secrets = append(secrets, auth)
+	}
+
+	storageMaxSize := config.Datadog.GetInt64("forwarder_storage_max_size_in_bytes")
+	var domainFolderPath string
+	if storageMaxSize == 0 {
+		log.Infof("Retry queue storage on disk is disabled for the remote-write forwarder")
+	} else if agentFolder := getAgentFolder(options); agentFolder != "" {
+		domainFolderPath = registerRemoteWriteStorage(agentFolder, f.domain)
+	}
+
+	transactionContainer := buildTransactionContainer(
+		options.RetryQueuePayloadsTotalMaxSize,
+		config.Datadog.GetFloat64("forwarder_flush_to_disk_mem_ratio"),
+		domainFolderPath,
+		storageMaxSize,
+		sortByCreatedTimeAndPriority{highPriorityFirst: false},
+		f.domain,
+		secrets)
+	f.domainForwarder = newDomainForwarder(
+		f.domain,
+		transactionContainer,
+		options.NumberOfWorkers,
+		options.ConnectionResetInterval,
+		sortByCreatedTimeAndPriority{highPriorityFirst: true})
+	return f, nil
+}
+
+// registerRemoteWriteStorage prepares the folder of the remote-write transactions stored on disk,
+// removing the outdated files and the folders of the previous domains, and returns the folder of
+// the domain. It returns an empty path if the storage cannot be used.
+func registerRemoteWriteStorage(agentFolder string, domain string) string {
+	storagePath := path.Join(config.Datadog.GetString("forwarder_storage_path"), remoteWriteStorageFolder, agentFolder)
+	outdatedFileInDays := config.Datadog.GetInt("forwarder_outdated_file_in_days")
+	removalPolicy, err := newFailedTransactionRemovalPolicy(storagePath, outdatedFileInDays, failedTransactionRemovalPolicyTelemetry{})
+	if err != nil {
+		log.Errorf("Error when initializing the removal policy of the remote-write forwarder: %v", err)
+		return ""
+	}
+	domainFolderPath, err := removalPolicy.registerDomain(domain)
+	if err != nil {
+		log.Errorf("Retry queue storage on disk disabled. Cannot register the remote-write domain '%v': %v", domain, err)
+		return ""
+	}
+	for _, remove := range []func() ([]string, error){removalPolicy.removeOutdatedFiles, removalPolicy.removeUnknownDomains} {
+		filesRemoved, err := remove()
+		if err != nil {
+			log.Errorf("Error when removing outdated files: %v", err)
+		}
+		log.Debugf("Outdated files removed: %v", strings.Join(filesRemoved, ", "))
+	}
+	return domainFolderPath
+}
+
+// Start starts the remote-write forwarder.
+func (f *RemoteWriteForwarder) Start() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.internalState == Started {
+		return fmt.Errorf("the remote-write forwarder is already started")
+	}
+	if err := f.domainForwarder.Start(); err != nil {
+		return err
+	}
+	log.Infof("Remote-write forwarder started, sending to %q with %v worker(s)",
+		log.SanitizeURL(f.domain+f.endpoint.route), f.domainForwarder.numberOfWorkers)
+	f.internalState = Started
+	return nil
+}
+
+// Stop stops the remote-write forwarder.
+func (f *RemoteWriteForwarder) Stop() {
+	log.Infof("stopping the remote-write forwarder")
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.internalState == Stopped {
+		log.Warnf("the remote-write forwarder is already stopped")
+		return
+	}
+	f.internalState = Stopped
+
+	purgeTimeout := config.Datadog.GetDuration("forwarder_stop_timeout") * time.Second
+	if purgeTimeout <= 0 {
+		f.domainForwarder.Stop(false)
+		return
+	}
+	donePurging := make(chan struct{})
+	go func() {
+		f.domainForwarder.Stop(true)
+		close(donePurging)
+	}()
+	select {
+	case <-donePurging:
+	case <-time.After(purgeTimeout):
+		log.Warnf("Timeout emptying new transactions before stopping the remote-write forwarder %v", purgeTimeout)
+	}
+}
+
+// SubmitRemoteWrite sends snappy compressed remote-write requests.
+func (f *RemoteWriteForwarder) SubmitRemoteWrite(payloads Payloads) error {
+	if atomic.LoadUint32(&f.internalState) == Stopped {
+		return fmt.Errorf("the remote-write forwarder is not started")
+	}
+
+	for _, payload := range payloads {
+		t := NewHTTPTransaction()
+		t.Domain = f.domain
+		t.Endpoint = f.endpoint
+		t.Payload = payload
+		t.priority = TransactionPriorityNormal
+		for key := range f.headers {
+			t.Headers.Set(key, f.headers.Get(key))
+		}
+		t.Headers.Set("Content-Type", "application/x-protobuf")
+		t.Headers.Set("Content-Encoding", "snappy")
+		t.Headers.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+		t.Headers.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+		tlmTxInputCount.Inc(f.domain, remoteWriteEndpointName)
+		tlmTxInputBytes.Add(float64(t.GetPayloadSize()), f.domain, remoteWriteEndpointName)
+		transactionsInputCountByEndpoint.Add(remoteWriteEndpointName, 1)
+		transactionsInputBytesByEndpoint.Add(remoteWriteEndpointName, int64(t.GetPayloadSize()))
+
+		if err := f.domainForwarder.sendHTTPTransactions(t); err != nil {
+			log.Errorf(err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRemoteWriteForwarderInvalidURL(t *testing.T) {
+	for _, u := range []string{"", "localhost:9090/api/v1/write", "ftp://host/write", "http://%zz"} {
+		_, err := NewRemoteWriteForwarder(NewOptions(nil), u, nil)
+		assert.Error(t, err, u)
+	}
+}
+
+func TestRemoteWriteForwarder(t *testing.T) {
+	type request struct {
+		uri     string
+		headers http.Header
+		body    string
+	}
+	requests := make(chan request, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- request{r.URL.RequestURI(), r.Header, string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	url := strings.Replace(ts.URL, "http://", "http://user:secret@", 1) + "/api/v1/write?tenant=a"
+	f, err := NewRemoteWriteForwarder(NewOptions(nil), url, map[string]string{"X-Scope-OrgID": "team-a"})
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL, f.domain)
+
+	data := []byte("payload")
+	assert.Error(t, f.SubmitRemoteWrite(Payloads{&data}))
+
+	require.NoError(t, f.Start())
+	defer f.Stop()
+	assert.Error(t, f.Start())
+	require.NoError(t, f.SubmitRemoteWrite(Payloads{&data}))
+
+	select {
+	case r := <-requests:
+		assert.Equal(t, "/api/v1/write?tenant=a", r.uri)
+		assert.Equal(t, "payload", r.body)
+		assert.Equal(t, "snappy", r.headers.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.headers.Get("Content-Type"))
+		assert.Equal(t, remoteWriteVersion, r.headers.Get("X-Prometheus-Remote-Write-Version"))
+		assert.Equal(t, "team-a", r.headers.Get("X-Scope-OrgID"))
+		assert.Equal(t, "Basic dXNlcjpzZWNyZXQ=", r.headers.Get("Authorization"))
+		assert.Empty(t, r.headers.Get(apiHTTPHeaderKey))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+	}
+}
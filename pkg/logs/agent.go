@@ -23,9 +23,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/input/file"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/journald"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/listener"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/traps"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/windowsevent"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 )
@@ -44,10 +46,11 @@ type Agent struct {
 	inputs                    []restart.Restartable
 	health                    *health.Handle
 	diagnosticMessageReceiver *diagnostic.BufferedMessageReceiver
+	metricCommitter           *processor.MetricCommitter
 }
 
-// NewAgent returns a new Logs Agent
-func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, endpoints *config.Endpoints) *Agent {
+// NewAgent returns a new Logs Agent, metricSender is optional and receives the metrics generated from logs.
+func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, metricSender processor.MetricSender) *Agent {
 	health := health.RegisterLiveness("logs-agent")
 
 	// setup the auditor
@@ -59,7 +62,13 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 	diagnosticMessageReceiver := diagnostic.NewBufferedMessageReceiver()
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, endpoints, destinationsCtx)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, endpoints, destinationsCtx, metricSender)
+
+	// the metrics generated from logs are committed periodically to be flushed by the aggregator
+	var metricCommitter *processor.MetricCommitter
+	if metricSender != nil {
+		metricCommitter = processor.NewMetricCommitter(metricSender, processor.MetricCommitInterval)
+	}
 
 	// setup the inputs
 	inputs := []restart.Restartable{
@@ -75,6 +84,7 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 		journald.NewLauncher(sources, pipelineProvider, auditor),
 		windowsevent.NewLauncher(sources, pipelineProvider),
 		traps.NewLauncher(sources, pipelineProvider),
+		syslog.NewLauncher(sources, pipelineProvider),
 	}
 
 	return &Agent{
@@ -84,6 +94,7 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 		inputs:                    inputs,
 		health:                    health,
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		metricCommitter:           metricCommitter,
 	}
 }
 
@@ -121,6 +132,9 @@ func NewServerless(sources *config.LogSources, services *service.Services, proce
 // in the right order to prevent data loss
 func (a *Agent) Start() {
 	starter := restart.NewStarter(a.destinationsCtx, a.auditor, a.pipelineProvider, a.diagnosticMessageReceiver)
+	if a.metricCommitter != nil {
+		starter.Add(a.metricCommitter)
+	}
 	for _, input := range a.inputs {
 		starter.Add(input)
 	}
@@ -146,6 +160,10 @@ func (a *Agent) Stop() {
 		a.destinationsCtx,
 		a.diagnosticMessageReceiver,
 	)
+	if a.metricCommitter != nil {
+		// the last metrics are committed once the pipelines are flushed
+		stopper.Add(a.metricCommitter)
+	}
 
 	// This will try to stop everything in order, including the potentially blocking
 	// parts like the sender. After StopTimeout it will just stop the last part of the
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -106,13 +107,38 @@ func BuildEndpoints(httpConnectivity HTTPConnectivity) (*Endpoints, error) {
 	if coreConfig.Datadog.GetBool("logs_config.dev_mode_no_ssl") {
 		log.Warnf("Use of illegal configuration parameter, if you need to send your logs to a proxy, please use 'logs_config.logs_dd_url' and 'logs_config.logs_no_ssl' instead")
 	}
+	var endpoints *Endpoints
+	var err error
 	if isForceHTTPUse() || (bool(httpConnectivity) && !(isForceTCPUse() || isSocks5ProxySet() || hasAdditionalEndpoints())) {
-		return BuildHTTPEndpoints()
+		endpoints, err = BuildHTTPEndpoints()
+	} else {
+		log.Warn("You are currently sending Logs to Datadog through TCP (either because logs_config.use_tcp or logs_config.socks5_proxy_address is set or the HTTP connectivity test has failed) " +
+			"To benefit from increased reliability and better network performances, " +
+			"we strongly encourage switching over to compressed HTTPS which is now the default protocol.")
+		endpoints, err = buildTCPEndpoints()
+	}
+	if err != nil {
+		return nil, err
+	}
+	endpoints.DiskBuffer = buildDiskBuffer()
+	return endpoints, nil
+}
+
+// buildDiskBuffer returns the settings of the on-disk buffer, nil when it is disabled.
+func buildDiskBuffer() *DiskBuffer {
+	maxSize := coreConfig.Datadog.GetInt64("logs_config.disk_buffer_max_size_in_bytes")
+	if maxSize <= 0 {
+		return nil
+	}
+	path := coreConfig.Datadog.GetString("logs_config.disk_buffer_path")
+	if path == "" {
+		path = filepath.Join(coreConfig.Datadog.GetString("logs_config.run_path"), "disk_buffer")
+	}
+	return &DiskBuffer{
+		Path:    path,
+		MaxSize: maxSize,
+		MaxAge:  time.Duration(coreConfig.Datadog.GetInt("logs_config.disk_buffer_max_age")) * time.Second,
 	}
-	log.Warn("You are currently sending Logs to Datadog through TCP (either because logs_config.use_tcp or logs_config.socks5_proxy_address is set or the HTTP connectivity test has failed) " +
-		"To benefit from increased reliability and better network performances, " +
-		"we strongly encourage switching over to compressed HTTPS which is now the default protocol.")
-	return buildTCPEndpoints()
 }
 
 // ExpectedTagsDuration returns a duration of the time expected tags will be submitted for.
@@ -327,3 +353,32 @@ func TaggerWarmupDuration() time.Duration {
 func AggregationTimeout() time.Duration {
 	return coreConfig.Datadog.GetDuration("logs_config.aggregation_timeout") * time.Millisecond
 }
+
+// AutoMultiLineEnabled returns true if the multi-line logs of the source should be detected automatically.
+func AutoMultiLineEnabled(source *LogsConfig) bool {
+	if source.AutoMultiLine != nil {
+		return *source.AutoMultiLine
+	}
+	return coreConfig.Datadog.GetBool("logs_config.auto_multi_line_detection")
+}
+
+// AutoMultiLineSampleSize returns the number of lines sampled to detect the multi-line pattern of the source.
+func AutoMultiLineSampleSize(source *LogsConfig) int {
+	if source.AutoMultiLineSampleSize > 0 {
+		return source.AutoMultiLineSampleSize
+	}
+	return coreConfig.Datadog.GetInt("logs_config.auto_multi_line_default_sample_size")
+}
+
+// AutoMultiLineMatchThreshold returns the minimum ratio of sampled lines a multi-line pattern has to match.
+func AutoMultiLineMatchThreshold(source *LogsConfig) float64 {
+	if source.AutoMultiLineMatchThreshold > 0 {
+		return source.AutoMultiLineMatchThreshold
+	}
+	return coreConfig.Datadog.GetFloat64("logs_config.auto_multi_line_default_match_threshold")
+}
+
+// AutoMultiLineMatchTimeout returns the maximum time spent sampling lines to detect a multi-line pattern.
+func AutoMultiLineMatchTimeout() time.Duration {
+	return coreConfig.Datadog.GetDuration("logs_config.auto_multi_line_default_match_timeout") * time.Second
+}
@@ -45,6 +45,26 @@ func (suite *ConfigTestSuite) TestDefaultDatadogConfig() {
 	suite.Equal(false, suite.config.GetBool("logs_config.k8s_container_use_file"))
 }
 
+func (suite *ConfigTestSuite) TestDiskBuffer() {
+	suite.Nil(buildDiskBuffer())
+
+	suite.config.Set("logs_config.run_path", "/opt/datadog-agent/run")
+	suite.config.Set("logs_config.disk_buffer_max_size_in_bytes", 1024)
+	suite.Equal(&DiskBuffer{
+		Path:    "/opt/datadog-agent/run/disk_buffer",
+		MaxSize: 1024,
+		MaxAge:  24 * time.Hour,
+	}, buildDiskBuffer())
+
+	suite.config.Set("logs_config.disk_buffer_path", "/var/lib/logs")
+	suite.config.Set("logs_config.disk_buffer_max_age", 60)
+	suite.Equal(&DiskBuffer{
+		Path:    "/var/lib/logs",
+		MaxSize: 1024,
+		MaxAge:  time.Minute,
+	}, buildDiskBuffer())
+}
+
 func (suite *ConfigTestSuite) TestDefaultSources() {
 	// container collect all source
 
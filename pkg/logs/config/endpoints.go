@@ -28,6 +28,17 @@ type Endpoints struct {
 	UseProto    bool
 	UseHTTP     bool
 	BatchWait   time.Duration
+	// Optional. Payloads that could not be sent to the main endpoint
+	// are stored on disk when set.
+	DiskBuffer *DiskBuffer
+}
+
+// DiskBuffer holds the settings of the on-disk buffer of the payloads
+// that could not be sent because of an intake outage.
+type DiskBuffer struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
 }
 
 // NewEndpoints returns a new endpoints composite.
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Capture types of the parse_pattern rule
+const (
+	StringCapture = "string"
+	IntCapture    = "int"
+	FloatCapture  = "float"
+)
+
+// PatternCapture describes the attribute extracted by a capture group of a parse_pattern rule.
+type PatternCapture struct {
+	Name string
+	Type string
+}
+
+// grokPatterns is the library of patterns that can be referenced with %{NAME} or %{NAME:attribute}
+// in the pattern of a parse_pattern rule, all of them are RE2 compatible.
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"POSINT":            `\b[1-9]\d*\b`,
+	"NONNEGINT":         `\b\d+\b`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[A-Fa-f0-9]*:[A-Fa-f0-9:]*(?:%{IPV4})?`,
+	"IP":                `(?:%{IPV4}|%{IPV6})`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z\-_]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z\-_]{0,62})*\.?\b`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"USER":              `[a-zA-Z0-9._-]+`,
+	"PATH":              `(?:/[^\s?#]*)+`,
+	"URIPATHPARAM":      `\S+`,
+	"JAVACLASS":         `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+	"LOGLEVEL":          `(?i:alert|trace|debug|notice|info(?:rmation)?|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?|panic)`,
+	"HTTPDATE":          `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"SYSLOGTIMESTAMP":   `\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+}
+
+// maxGrokDepth bounds the nesting of pattern references to detect cycles.
+const maxGrokDepth = 16
+
+var grokReference = regexp.MustCompile(`%{(\w+)(?::([\w.@-]+))?(?::(\w+))?}`)
+
+// compilePattern expands the pattern references of a parse_pattern rule and compiles
+// the resulting regular expression. Returns the compiled expression along with the
+// attributes extracted by its capture groups, indexed by group name.
+func compilePattern(pattern string) (*regexp.Regexp, map[string]PatternCapture, error) {
+	captures := make(map[string]PatternCapture)
+	expanded, err := expandPattern(pattern, captures, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(re.SubexpNames()) < 2 {
+		return nil, nil, fmt.Errorf("pattern %s does not capture any attribute", pattern)
+	}
+	return re, captures, nil
+}
+
+// expandPattern replaces recursively the references to the pattern library with their definitions,
+// named references become capture groups and are registered in captures.
+func expandPattern(pattern string, captures map[string]PatternCapture, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("too many nested pattern references")
+	}
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+		submatches := grokReference.FindStringSubmatch(reference)
+		name, attribute, captureType := submatches[1], submatches[2], submatches[3]
+
+		definition, exists := grokPatterns[name]
+		if !exists {
+			err = fmt.Errorf("unknown pattern %s", name)
+			return ""
+		}
+		definition, err = expandPattern(definition, captures, depth+1)
+		if err != nil || attribute == "" {
+			return "(?:" + definition + ")"
+		}
+
+		switch captureType {
+		case "":
+			captureType = StringCapture
+		case StringCapture, IntCapture, FloatCapture:
+		default:
+			err = fmt.Errorf("unsupported type %s for attribute %s", captureType, attribute)
+			return ""
+		}
+		// attributes can contain characters which are not allowed in group names
+		group := fmt.Sprintf("capture%d", len(captures))
+		captures[group] = PatternCapture{Name: attribute, Type: captureType}
+		return "(?P<" + group + ">" + definition + ")"
+	})
+	if err != nil {
+		return "", err
+	}
+	if depth == 0 && strings.Contains(expanded, "%{") {
+		return "", fmt.Errorf("invalid pattern reference in %s", pattern)
+	}
+	return expanded, nil
+}
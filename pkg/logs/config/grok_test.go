@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePatternShouldExpandReferences(t *testing.T) {
+	re, captures, err := compilePattern(`^%{IPORHOST:network.client.ip} - %{USER} \[%{HTTPDATE:date}\] "%{WORD:http.method} %{NOTSPACE:http.url}" %{INT:http.status_code:int}`)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]PatternCapture{
+		"capture0": {Name: "network.client.ip", Type: StringCapture},
+		"capture1": {Name: "date", Type: StringCapture},
+		"capture2": {Name: "http.method", Type: StringCapture},
+		"capture3": {Name: "http.url", Type: StringCapture},
+		"capture4": {Name: "http.status_code", Type: IntCapture},
+	}, captures)
+
+	submatches := re.FindStringSubmatch(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif" 200`)
+	assert.Equal(t, []string{"127.0.0.1", "10/Oct/2000:13:55:36 -0700", "GET", "/apache_pb.gif", "200"}, submatches[1:])
+}
+
+func TestCompilePatternShouldKeepNamedGroups(t *testing.T) {
+	re, captures, err := compilePattern(`duration=(?P<duration>\d+)ms`)
+	assert.Nil(t, err)
+	assert.Empty(t, captures)
+	assert.Equal(t, []string{"", "duration"}, re.SubexpNames())
+}
+
+func TestCompilePatternShouldFailWithInvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{
+		`%{UNKNOWN:foo}`,
+		`%{INT:foo:bool}`,
+		`%{INT}`,
+		`no capture`,
+		`(?P<foo>(?=abf))`,
+	} {
+		_, _, err := compilePattern(pattern)
+		assert.NotNil(t, err, pattern)
+	}
+}
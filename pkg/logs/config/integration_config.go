@@ -21,6 +21,7 @@ const (
 	JournaldType      = "journald"
 	WindowsEventType  = "windows_event"
 	SnmpTrapsType     = "snmp_traps"
+	SyslogType        = "syslog"
 	StringChannelType = "string_channel"
 
 	// UTF16BE for UTF-16 Big endian encoding
@@ -37,6 +38,13 @@ type LogsConfig struct {
 	Port int    // Network
 	Path string // File, Journald
 
+	Protocol    string `mapstructure:"protocol" json:"protocol"`           // Syslog
+	TLSCertFile string `mapstructure:"tls_cert_file" json:"tls_cert_file"` // Syslog
+	TLSKeyFile  string `mapstructure:"tls_key_file" json:"tls_key_file"`   // Syslog
+	TLSCAFile   string `mapstructure:"tls_ca_file" json:"tls_ca_file"`     // Syslog
+	// IdleTimeout is the number of seconds after which an idle TCP connection is closed, 0 keeps it open
+	IdleTimeout int `mapstructure:"idle_timeout" json:"idle_timeout"` // Syslog
+
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
 	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
@@ -66,6 +74,11 @@ type LogsConfig struct {
 	SourceCategory  string
 	Tags            []string
 	ProcessingRules []*ProcessingRule `mapstructure:"log_processing_rules" json:"log_processing_rules"`
+
+	// AutoMultiLine overrides logs_config.auto_multi_line_detection for this source when set.
+	AutoMultiLine               *bool   `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
+	AutoMultiLineSampleSize     int     `mapstructure:"auto_multi_line_sample_size" json:"auto_multi_line_sample_size"`
+	AutoMultiLineMatchThreshold float64 `mapstructure:"auto_multi_line_match_threshold" json:"auto_multi_line_match_threshold"`
 }
 
 // TailingMode type
@@ -129,8 +142,17 @@ func (c *LogsConfig) Validate() error {
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case c.Type == SyslogType:
+		err := c.validateSyslog()
+		if err != nil {
+			return err
+		}
 	}
-	err := ValidateProcessingRules(c.ProcessingRules)
+	err := c.validateAutoMultiLine()
+	if err != nil {
+		return err
+	}
+	err = ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
 	}
@@ -148,6 +170,36 @@ func (c *LogsConfig) validateTailingMode() error {
 	return nil
 }
 
+// validateAutoMultiLine applies to every source type, as the multi-line detection
+// happens in the decoder
+func (c *LogsConfig) validateAutoMultiLine() error {
+	switch {
+	case c.AutoMultiLineSampleSize < 0:
+		return fmt.Errorf("auto_multi_line_sample_size must be positive")
+	case c.AutoMultiLineMatchThreshold < 0 || c.AutoMultiLineMatchThreshold > 1:
+		return fmt.Errorf("auto_multi_line_match_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+func (c *LogsConfig) validateSyslog() error {
+	switch {
+	case c.Port == 0:
+		return fmt.Errorf("syslog source must have a port")
+	case c.Protocol != "" && c.Protocol != TCPType && c.Protocol != UDPType:
+		return fmt.Errorf("invalid protocol '%v' for syslog source, must be tcp or udp", c.Protocol)
+	case (c.TLSCertFile != "") != (c.TLSKeyFile != ""):
+		return fmt.Errorf("syslog source must have both a tls_cert_file and a tls_key_file")
+	case c.TLSCAFile != "" && c.TLSCertFile == "":
+		return fmt.Errorf("syslog source must have a tls_cert_file to verify client certificates")
+	case c.TLSCertFile != "" && c.Protocol != TCPType:
+		return fmt.Errorf("TLS is only supported by syslog sources using the tcp protocol")
+	case c.IdleTimeout < 0:
+		return fmt.Errorf("idle_timeout of syslog source must be positive")
+	}
+	return nil
+}
+
 // ContainsWildcard returns true if the path contains any wildcard character
 func ContainsWildcard(path string) bool {
 	return strings.ContainsAny(path, "*?[")
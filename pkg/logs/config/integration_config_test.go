@@ -20,7 +20,18 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: UDPType, Port: 5678},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParseJSONLog}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParseKeyValueLog, Pattern: "^time="}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RemapAttributes, Drop: []string{"password"}}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParsePattern, Pattern: "%{LOGLEVEL:level} %{GREEDYDATA:msg}"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: "ERROR", MetricName: "app.errors"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: `took (?P<value>\d+)ms`, MetricName: "app.latency", MetricType: MetricTypeDistribution}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: `(?P<route>/\w+) (?P<ms>\d+)`, MetricName: "app.latency", MetricType: MetricTypeGauge, ValueCapture: "ms", TagCaptures: []string{"route"}}}},
 		{Type: SnmpTrapsType},
+		{Type: SyslogType, Port: 514},
+		{Type: SyslogType, Port: 6514, Protocol: TCPType, TLSCertFile: "/etc/cert.pem", TLSKeyFile: "/etc/key.pem", TLSCAFile: "/etc/ca.pem"},
+		{Type: SyslogType, Port: 514, Protocol: TCPType, IdleTimeout: 60},
+		{Type: FileType, Path: "/var/log/app.log", AutoMultiLineSampleSize: 500, AutoMultiLineMatchThreshold: 0.9},
 	}
 
 	for _, config := range validConfigs {
@@ -42,6 +53,28 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParseJSONLog, Pattern: "(?=abf)"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: RemapAttributes}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParsePattern}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ParsePattern, Pattern: "%{FOO:bar}"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, MetricName: "app.errors"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: "ERROR"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: "ERROR", MetricName: "app.errors", MetricType: "rate"}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: `took \d+ms`, MetricName: "app.latency", MetricType: MetricTypeGauge}}},
+		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: GenerateMetric, Pattern: `took (?P<value>\d+)ms`, MetricName: "app.latency", TagCaptures: []string{"route"}}}},
+		{Type: DockerType, AutoMultiLineSampleSize: -1},
+		{Type: SyslogType},
+		{Type: SyslogType, Port: 514, Protocol: "tls"},
+		{Type: SyslogType, Port: 6514, Protocol: TCPType, TLSCertFile: "/etc/cert.pem"},
+		{Type: SyslogType, Port: 6514, Protocol: TCPType, TLSCAFile: "/etc/ca.pem"},
+		{Type: SyslogType, Port: 6514, Protocol: UDPType, TLSCertFile: "/etc/cert.pem", TLSKeyFile: "/etc/key.pem"},
+		{Type: SyslogType, Port: 514, Protocol: TCPType, IdleTimeout: -1},
+		{Type: DockerType, AutoMultiLineMatchThreshold: 1.5},
+		{Type: FileType, Path: "/var/log/app.log", AutoMultiLineSampleSize: -1},
+		{Type: FileType, Path: "/var/log/app.log", AutoMultiLineMatchThreshold: 1.5},
+		{Type: FileType, Path: "/var/log/app.log", AutoMultiLineMatchThreshold: -0.1},
+		{Type: SyslogType, Port: 514, AutoMultiLineMatchThreshold: 1.5},
+		{Type: TCPType, Port: 10514, AutoMultiLineSampleSize: -1},
 	}
 
 	for _, config := range invalidConfigs {
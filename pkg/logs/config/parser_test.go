@@ -105,6 +105,40 @@ logs:
 	assert.Equal(t, "^[0-9]+$", rule.Pattern)
 }
 
+func TestParseYAMLWithStructuredLogRulesShouldSucceed(t *testing.T) {
+	data := []byte(`
+logs:
+  - type: docker
+    log_processing_rules:
+      - type: parse_json
+        name: app_logs
+        status_attribute: log.level
+        timestamp_format: "2006-01-02 15:04:05"
+      - type: remap_attributes
+        name: cleanup
+        rename:
+          trace_id: dd.trace_id
+        drop:
+          - password
+`)
+
+	configs, err := ParseYAML(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(configs))
+	assert.Equal(t, 2, len(configs[0].ProcessingRules))
+
+	rule := configs[0].ProcessingRules[0]
+	assert.Equal(t, ParseJSONLog, rule.Type)
+	assert.Equal(t, "log.level", rule.StatusAttribute)
+	assert.Equal(t, "2006-01-02 15:04:05", rule.TimestampFormat)
+
+	rule = configs[0].ProcessingRules[1]
+	assert.Equal(t, RemapAttributes, rule.Type)
+	assert.Equal(t, map[string]string{"trace_id": "dd.trace_id"}, rule.Rename)
+	assert.Equal(t, []string{"password"}, rule.Drop)
+	assert.Nil(t, configs[0].Validate())
+}
+
 func TestParseYAMLWithInvalidFormatShouldFail(t *testing.T) {
 	invalidFormats := []string{`
 foo:
@@ -16,8 +16,27 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+
+	// Structured log rules
+	ParseJSONLog     = "parse_json"
+	ParseKeyValueLog = "parse_key_value"
+	ParsePattern     = "parse_pattern"
+	RemapAttributes  = "remap_attributes"
+
+	// Metric rules
+	GenerateMetric = "generate_metric"
+)
+
+// Metric types supported by the generate_metric rule
+const (
+	MetricTypeCount        = "count"
+	MetricTypeGauge        = "gauge"
+	MetricTypeDistribution = "distribution"
 )
 
+// defaultValueCapture is the capture group holding the value of gauges and distributions when not set.
+const defaultValueCapture = "value"
+
 // ProcessingRule defines an exclusion or a masking rule to
 // be applied on log lines
 type ProcessingRule struct {
@@ -25,16 +44,34 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Attributes promoted to the message by the parse_json, parse_key_value and parse_pattern rules,
+	// well-known attribute names are used when they are not set.
+	MessageAttribute   string `mapstructure:"message_attribute" json:"message_attribute"`
+	StatusAttribute    string `mapstructure:"status_attribute" json:"status_attribute"`
+	TimestampAttribute string `mapstructure:"timestamp_attribute" json:"timestamp_attribute"`
+	TimestampFormat    string `mapstructure:"timestamp_format" json:"timestamp_format"`
+	// Attributes sent as tags instead of attributes
+	TagAttributes []string `mapstructure:"tag_attributes" json:"tag_attributes"`
+	// Attributes renamed or dropped by the remap_attributes rule
+	Rename map[string]string
+	Drop   []string
+	// Metric submitted by the generate_metric rule for each matching log, counts are incremented
+	// by one unless a value capture is set, gauges and distributions take the value of the capture.
+	MetricName   string   `mapstructure:"metric_name" json:"metric_name"`
+	MetricType   string   `mapstructure:"metric_type" json:"metric_type"`
+	ValueCapture string   `mapstructure:"value_capture" json:"value_capture"`
+	TagCaptures  []string `mapstructure:"tag_captures" json:"tag_captures"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
+	Captures    map[string]PatternCapture
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
 // - a valid type
-// - a valid pattern that compiles
+// - a valid pattern that compiles, the pattern is optional for structured log rules
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -43,16 +80,34 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+			if rule.Pattern == "" {
+				return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+			}
+		case ParseJSONLog, ParseKeyValueLog:
 			break
+		case ParsePattern:
+			if rule.Pattern == "" {
+				return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+			}
+			if _, _, err := compilePattern(rule.Pattern); err != nil {
+				return fmt.Errorf("invalid pattern %s for processing rule: %s: %v", rule.Pattern, rule.Name, err)
+			}
+			continue
+		case RemapAttributes:
+			if len(rule.Rename) == 0 && len(rule.Drop) == 0 {
+				return fmt.Errorf("no attribute to rename or drop provided for processing rule: %s", rule.Name)
+			}
+		case GenerateMetric:
+			if err := validateMetricRule(rule); err != nil {
+				return err
+			}
+			continue
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
 			return fmt.Errorf("type %s is not supported for processing rule `%s`", rule.Type, rule.Name)
 		}
 
-		if rule.Pattern == "" {
-			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
-		}
 		_, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
@@ -64,13 +119,30 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		if rule.Type == ParsePattern {
+			re, captures, err := compilePattern(rule.Pattern)
+			if err != nil {
+				return err
+			}
+			rule.Regex = re
+			rule.Captures = captures
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, ParseJSONLog, ParseKeyValueLog:
 			rule.Regex = re
+		case GenerateMetric:
+			rule.Regex = re
+			if rule.MetricType == "" {
+				rule.MetricType = MetricTypeCount
+			}
+			if rule.ValueCapture == "" && rule.MetricType != MetricTypeCount {
+				rule.ValueCapture = defaultValueCapture
+			}
 		case MaskSequences:
 			rule.Regex = re
 			rule.Placeholder = []byte(rule.ReplacePlaceholder)
@@ -83,3 +155,45 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 	}
 	return nil
 }
+
+// validateMetricRule validates a generate_metric rule, the captures it refers to must be defined by its pattern.
+func validateMetricRule(rule *ProcessingRule) error {
+	if rule.Pattern == "" {
+		return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+	}
+	if rule.MetricName == "" {
+		return fmt.Errorf("no metric name provided for processing rule: %s", rule.Name)
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+	}
+	valueCapture := rule.ValueCapture
+	switch rule.MetricType {
+	case "", MetricTypeCount:
+		break
+	case MetricTypeGauge, MetricTypeDistribution:
+		if valueCapture == "" {
+			valueCapture = defaultValueCapture
+		}
+	default:
+		return fmt.Errorf("metric type %s is not supported for processing rule: %s", rule.MetricType, rule.Name)
+	}
+	captures := append([]string{valueCapture}, rule.TagCaptures...)
+	for _, capture := range captures {
+		if capture != "" && subexpIndex(re, capture) < 0 {
+			return fmt.Errorf("capture %s is not defined by the pattern of processing rule: %s", capture, rule.Name)
+		}
+	}
+	return nil
+}
+
+// subexpIndex returns the index of the first capture group with the given name, or -1 if there is none.
+func subexpIndex(re *regexp.Regexp, name string) int {
+	for i, subexpName := range re.SubexpNames() {
+		if i > 0 && subexpName == name {
+			return i
+		}
+	}
+	return -1
+}
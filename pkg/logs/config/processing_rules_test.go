@@ -21,6 +21,19 @@ func TestCompileShouldSucceedWithValidRules(t *testing.T) {
 	assert.True(t, rules[0].Regex.MatchString("abcde"))
 }
 
+func TestCompileShouldSetMetricRuleDefaults(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: GenerateMetric, Pattern: "ERROR"},
+		{Type: GenerateMetric, Pattern: `(?P<value>\d+)ms`, MetricType: MetricTypeDistribution},
+	}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+	assert.NotNil(t, rules[0].Regex)
+	assert.Equal(t, MetricTypeCount, rules[0].MetricType)
+	assert.Equal(t, "", rules[0].ValueCapture)
+	assert.Equal(t, "value", rules[1].ValueCapture)
+}
+
 func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 	invalidRules := []*ProcessingRule{
 		{Type: IncludeAtMatch, Pattern: "(?=abf)"},
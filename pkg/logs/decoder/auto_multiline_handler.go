@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// autoMultiLineInfoKey is the key of the status page info reporting the detected pattern.
+const autoMultiLineInfoKey = "Auto multi-line detection"
+
+// autoMultiLinePattern is a pattern the first line of a multi-line log is expected to start with.
+type autoMultiLinePattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// autoMultiLinePatterns is the library of patterns scored by the AutoMultiLineHandler,
+// the first one wins in case of a tie so the most specific patterns come first.
+var autoMultiLinePatterns = []autoMultiLinePattern{
+	// 2021-03-01T10:00:00.000Z, 2021-03-01 10:00:00,000
+	{"iso8601", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`)},
+	// [2021-03-01 10:00:00]
+	{"bracketed_iso8601", regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`)},
+	// 2021/03/01 10:00:00
+	{"slashed_date", regexp.MustCompile(`^\d{4}/\d{2}/\d{2}[T ]\d{2}:\d{2}:\d{2}`)},
+	// 01/03/2021 10:00:00
+	{"day_first_date", regexp.MustCompile(`^\d{2}/\d{2}/\d{4}[T ]\d{2}:\d{2}:\d{2}`)},
+	// 01-Mar-2021 10:00:00
+	{"tomcat", regexp.MustCompile(`^\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}:\d{2}`)},
+	// Mon Mar  1 10:00:00 2021
+	{"ansic", regexp.MustCompile(`^[A-Z][a-z]{2} [A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}`)},
+	// Mar  1 10:00:00
+	{"syslog", regexp.MustCompile(`^[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}`)},
+	// Mar 1, 2021 10:00:00 AM
+	{"java_util_logging", regexp.MustCompile(`^[A-Z][a-z]{2} \d{1,2}, \d{4} \d{1,2}:\d{2}:\d{2} [AP]M`)},
+	// I0301 10:00:00.000000
+	{"glog", regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}`)},
+	// ERROR: ..., [WARN] ...
+	{"level", regexp.MustCompile(`^\[?(?:TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERROR|CRIT(?:ICAL)?|FATAL|SEVERE)(?:\]|:|\s)`)},
+}
+
+// AutoMultiLineHandler detects whether a source contains multi-line logs.
+// It sends the lines as single lines while sampling them, once enough lines are sampled
+// or the sampling timed out, it aggregates the following lines with the pattern of the library
+// matching the most sampled lines if it matches at least the threshold, or keeps sending
+// single lines otherwise.
+type AutoMultiLineHandler struct {
+	inputChan         chan *Message
+	outputChan        chan *Message
+	singleLineHandler *SingleLineHandler
+	multiLineHandler  *MultiLineHandler
+	source            *config.LogSource
+	flushTimeout      time.Duration
+	lineLimit         int
+	sampleSize        int
+	matchThreshold    float64
+	matchTimeout      time.Duration
+	scores            []int
+	linesSampled      int
+	sampleDeadline    time.Time
+	sampleTimer       *time.Timer
+	detecting         bool
+	info              *config.MappedInfo
+}
+
+// NewAutoMultiLineHandler returns a new AutoMultiLineHandler.
+func NewAutoMultiLineHandler(outputChan chan *Message, source *config.LogSource, sampleSize int, matchThreshold float64, matchTimeout time.Duration, flushTimeout time.Duration, lineLimit int) *AutoMultiLineHandler {
+	h := &AutoMultiLineHandler{
+		inputChan:         make(chan *Message),
+		outputChan:        outputChan,
+		singleLineHandler: NewSingleLineHandler(outputChan, lineLimit),
+		source:            source,
+		flushTimeout:      flushTimeout,
+		lineLimit:         lineLimit,
+		sampleSize:        sampleSize,
+		matchThreshold:    matchThreshold,
+		matchTimeout:      matchTimeout,
+		scores:            make([]int, len(autoMultiLinePatterns)),
+		detecting:         true,
+	}
+
+	// all the decoders of a source report their detection in the same info
+	if existingInfo, ok := source.GetInfo(autoMultiLineInfoKey).(*config.MappedInfo); ok {
+		h.info = existingInfo
+	} else {
+		h.info = config.NewMappedInfo(autoMultiLineInfoKey)
+		source.RegisterInfo(h.info)
+	}
+	h.info.SetMessage("sampling", "Sampling lines")
+	return h
+}
+
+// Handle forward lines to lineChan to process them.
+func (h *AutoMultiLineHandler) Handle(input *Message) {
+	h.inputChan <- input
+}
+
+// Stop stops the handler.
+func (h *AutoMultiLineHandler) Stop() {
+	close(h.inputChan)
+}
+
+// Start starts the handler.
+func (h *AutoMultiLineHandler) Start() {
+	go h.run()
+}
+
+// run processes new lines from the channel, runs the detection when the sampling timed out
+// even if no new line is received and makes sure the content aggregated once a pattern
+// is detected is sent when it stayed for too long in the buffer.
+func (h *AutoMultiLineHandler) run() {
+	flushTimer := time.NewTimer(h.flushTimeout)
+	defer func() {
+		flushTimer.Stop()
+		if h.sampleTimer != nil {
+			h.sampleTimer.Stop()
+		}
+		if h.multiLineHandler != nil {
+			h.multiLineHandler.sendBuffer()
+		}
+		close(h.outputChan)
+	}()
+	for {
+		// a nil channel blocks forever, until the sampling starts
+		var sampleTimeout <-chan time.Time
+		if h.detecting && h.sampleTimer != nil {
+			sampleTimeout = h.sampleTimer.C
+		}
+		select {
+		case message, isOpen := <-h.inputChan:
+			if !isOpen {
+				// lineChan has been closed, no more lines are expected
+				return
+			}
+			if !flushTimer.Stop() {
+				select {
+				case <-flushTimer.C:
+				default:
+				}
+			}
+			h.process(message)
+			flushTimer.Reset(h.flushTimeout)
+		case <-sampleTimeout:
+			h.detect()
+		case <-flushTimer.C:
+			if h.multiLineHandler != nil {
+				h.multiLineHandler.sendBuffer()
+			}
+		}
+	}
+}
+
+// process samples the line until the detection is over and forwards it to the handler in use.
+func (h *AutoMultiLineHandler) process(message *Message) {
+	if h.multiLineHandler != nil {
+		h.multiLineHandler.process(message)
+		return
+	}
+	if h.detecting {
+		h.sample(message.Content)
+	}
+	h.singleLineHandler.process(message)
+}
+
+// sample scores the line against the patterns of the library and runs the detection
+// once the sample is complete or the sampling timed out.
+func (h *AutoMultiLineHandler) sample(content []byte) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		// empty lines are neither the start nor the continuation of a log
+		return
+	}
+	if h.linesSampled == 0 {
+		h.sampleDeadline = time.Now().Add(h.matchTimeout)
+		h.sampleTimer = time.NewTimer(h.matchTimeout)
+	}
+	for i, pattern := range autoMultiLinePatterns {
+		if pattern.re.Match(content) {
+			h.scores[i]++
+		}
+	}
+	h.linesSampled++
+	if h.linesSampled >= h.sampleSize || time.Now().After(h.sampleDeadline) {
+		h.detect()
+	}
+}
+
+// detect switches to multi-line aggregation if the best pattern matches enough sampled lines.
+func (h *AutoMultiLineHandler) detect() {
+	h.detecting = false
+	h.sampleTimer.Stop()
+	h.info.RemoveMessage("sampling")
+
+	best := 0
+	for i, score := range h.scores {
+		if score > h.scores[best] {
+			best = i
+		}
+	}
+	pattern := autoMultiLinePatterns[best]
+	score := h.scores[best]
+	if float64(score) < h.matchThreshold*float64(h.linesSampled) {
+		log.Debugf("No multi-line pattern detected for source %s, the best pattern %s matched %d of %d lines", h.source.Name, pattern.name, score, h.linesSampled)
+		h.info.SetMessage("none", "No multi-line pattern detected")
+		return
+	}
+
+	log.Infof("Detected multi-line pattern %s for source %s, it matched %d of %d lines", pattern.name, h.source.Name, score, h.linesSampled)
+	metrics.TlmAutoMultiLineDetected.Inc(pattern.name)
+	h.info.SetMessage(pattern.name, fmt.Sprintf("Detected pattern %s: %s, matched %d of %d sampled lines", pattern.name, pattern.re.String(), score, h.linesSampled))
+
+	h.multiLineHandler = NewMultiLineHandler(h.outputChan, pattern.re, h.flushTimeout, h.lineLimit)
+	shareCountInfo(h.source, h.multiLineHandler)
+	// the next line may be the remainder of a line truncated by the single line handler
+	h.multiLineHandler.shouldTruncate = h.singleLineHandler.shouldTruncate
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func newAutoMultiLineSource() *config.LogSource {
+	return config.NewLogSource("config", &config.LogsConfig{})
+}
+
+func TestAutoMultiLineHandlerDetectsPattern(t *testing.T) {
+	source := newAutoMultiLineSource()
+	outputChan := make(chan *Message, 10)
+	h := NewAutoMultiLineHandler(outputChan, source, 3, 0.5, time.Minute, 10*time.Millisecond, 100)
+	h.Start()
+
+	// lines are sent as single lines while sampling
+	for _, line := range []string{"2021-03-01 10:00:00 INFO starting", "2021-03-01 10:00:01 ERROR failed", "\tat Main.main(Main.java:3)"} {
+		h.Handle(getDummyMessageWithLF(line))
+		output := <-outputChan
+		assert.Equal(t, strings.TrimSpace(line), string(output.Content))
+	}
+	assert.Equal(t, []string{"Detected pattern iso8601: " + autoMultiLinePatterns[0].re.String() + ", matched 2 of 3 sampled lines"}, source.GetInfo(autoMultiLineInfoKey).Info())
+
+	// the following lines are aggregated
+	h.Handle(getDummyMessageWithLF("2021-03-01 10:00:02 ERROR failed"))
+	h.Handle(getDummyMessageWithLF("java.lang.NullPointerException"))
+	h.Handle(getDummyMessageWithLF("\tat Main.main(Main.java:3)"))
+	h.Handle(getDummyMessageWithLF("2021-03-01 10:00:03 INFO stopping"))
+
+	output := <-outputChan
+	assert.Equal(t, "2021-03-01 10:00:02 ERROR failed\\njava.lang.NullPointerException\\n\tat Main.main(Main.java:3)", string(output.Content))
+	output = <-outputChan
+	assert.Equal(t, "2021-03-01 10:00:03 INFO stopping", string(output.Content))
+	assert.Equal(t, []string{"2"}, source.GetInfo("MultiLine matches").Info())
+
+	h.Stop()
+}
+
+func TestAutoMultiLineHandlerKeepsSingleLines(t *testing.T) {
+	source := newAutoMultiLineSource()
+	outputChan := make(chan *Message, 10)
+	h := NewAutoMultiLineHandler(outputChan, source, 4, 0.5, time.Minute, 10*time.Millisecond, 100)
+	h.Start()
+
+	lines := []string{"2021-03-01 10:00:00 starting", "", "hello", "world", "foo", "2021-03-01 10:00:01 stopping"}
+	for _, line := range lines {
+		h.Handle(getDummyMessageWithLF(line))
+	}
+	for _, line := range lines {
+		output := <-outputChan
+		assert.Equal(t, line, string(output.Content))
+	}
+	assert.Equal(t, []string{"No multi-line pattern detected"}, source.GetInfo(autoMultiLineInfoKey).Info())
+	assert.Nil(t, source.GetInfo("MultiLine matches"))
+
+	h.Stop()
+}
+
+func TestAutoMultiLineHandlerDetectsAfterTimeout(t *testing.T) {
+	source := newAutoMultiLineSource()
+	outputChan := make(chan *Message, 10)
+	h := NewAutoMultiLineHandler(outputChan, source, 500, 0.5, 10*time.Millisecond, time.Minute, 100)
+	h.Start()
+
+	h.Handle(getDummyMessageWithLF("[ERROR] failed"))
+	<-outputChan
+	assert.Equal(t, []string{"Sampling lines"}, source.GetInfo(autoMultiLineInfoKey).Info())
+
+	// the detection runs once the sampling timed out, even if the source stays quiet
+	expected := []string{"Detected pattern level: " + autoMultiLinePatterns[len(autoMultiLinePatterns)-1].re.String() + ", matched 1 of 1 sampled lines"}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, source.GetInfo(autoMultiLineInfoKey).Info())
+	}, time.Second, 5*time.Millisecond)
+
+	// the aggregated lines are flushed when the handler stops
+	h.Handle(getDummyMessageWithLF("[WARN] retrying"))
+	h.Handle(getDummyMessageWithLF("  connection refused"))
+	h.Stop()
+	output := <-outputChan
+	assert.Equal(t, `[WARN] retrying\n  connection refused`, string(output.Content))
+}
+
+func TestAutoMultiLinePatterns(t *testing.T) {
+	lines := map[string]string{
+		"iso8601":           "2021-03-01T10:00:00.000Z INFO hello",
+		"bracketed_iso8601": "[2021-03-01 10:00:00,000] INFO hello",
+		"slashed_date":      "2021/03/01 10:00:00 hello",
+		"day_first_date":    "01/03/2021 10:00:00 hello",
+		"tomcat":            "01-Mar-2021 10:00:00.000 INFO hello",
+		"ansic":             "Mon Mar  1 10:00:00 2021 hello",
+		"syslog":            "Mar  1 10:00:00 host app[1]: hello",
+		"java_util_logging": "Mar 1, 2021 10:00:00 AM com.example.Main main",
+		"glog":              "I0301 10:00:00.000000 1 main.go:1] hello",
+		"level":             "WARNING: hello",
+	}
+	for _, pattern := range autoMultiLinePatterns {
+		line, exists := lines[pattern.name]
+		assert.True(t, exists, pattern.name)
+		assert.True(t, pattern.re.MatchString(line), pattern.name)
+		assert.False(t, pattern.re.MatchString("\tat "+line), pattern.name)
+	}
+}
@@ -92,20 +92,14 @@ func NewDecoderWithEndLineMatcher(source *config.LogSource, parser parser.Parser
 	for _, rule := range source.Config.ProcessingRules {
 		if rule.Type == config.MultiLine {
 			lh := NewMultiLineHandler(outputChan, rule.Regex, config.AggregationTimeout(), lineLimit)
-
-			// Since a single source can have multiple file tailers - each with their own decoder instance,
-			// Make sure we keep track of the multiline match count info from all of the decoders so the
-			// status page displays it correctly.
-			if existingInfo, ok := source.GetInfo(lh.countInfo.InfoKey()).(*config.CountInfo); ok {
-				// override the new decoders info to the instance we are already using
-				lh.countInfo = existingInfo
-			} else {
-				// this is the first decoder we have seen for this source - use it's count info
-				source.RegisterInfo(lh.countInfo)
-			}
+			shareCountInfo(source, lh)
 			lineHandler = lh
 		}
 	}
+	if lineHandler == nil && config.AutoMultiLineEnabled(source.Config) {
+		lineHandler = NewAutoMultiLineHandler(outputChan, source, config.AutoMultiLineSampleSize(source.Config), config.AutoMultiLineMatchThreshold(source.Config),
+			config.AutoMultiLineMatchTimeout(), config.AggregationTimeout(), lineLimit)
+	}
 	if lineHandler == nil {
 		lineHandler = NewSingleLineHandler(outputChan, lineLimit)
 	}
@@ -119,6 +113,20 @@ func NewDecoderWithEndLineMatcher(source *config.LogSource, parser parser.Parser
 	return New(inputChan, outputChan, lineParser, lineLimit, matcher)
 }
 
+// shareCountInfo makes the handler report its matches in the count info of the source.
+// Since a single source can have multiple file tailers - each with their own decoder instance,
+// Make sure we keep track of the multiline match count info from all of the decoders so the
+// status page displays it correctly.
+func shareCountInfo(source *config.LogSource, lh *MultiLineHandler) {
+	if existingInfo, ok := source.GetInfo(lh.countInfo.InfoKey()).(*config.CountInfo); ok {
+		// override the new decoders info to the instance we are already using
+		lh.countInfo = existingInfo
+	} else {
+		// this is the first decoder we have seen for this source - use it's count info
+		source.RegisterInfo(lh.countInfo)
+	}
+}
+
 // New returns an initialized Decoder
 func New(InputChan chan *Input, OutputChan chan *Message, lineParser LineParser, contentLenLimit int, matcher EndLineMatcher) *Decoder {
 	var lineBuffer bytes.Buffer
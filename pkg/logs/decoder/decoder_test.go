@@ -6,6 +6,7 @@
 package decoder
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 
@@ -303,3 +304,20 @@ func TestDecoderWithDecodingParser(t *testing.T) {
 
 	d.Stop()
 }
+
+func TestDecoderWithAutoMultiLine(t *testing.T) {
+	enabled := true
+	source := config.NewLogSource("config", &config.LogsConfig{AutoMultiLine: &enabled})
+	d := InitializeDecoder(source, parser.NoopParser)
+	assert.IsType(t, &AutoMultiLineHandler{}, d.lineParser.(*SingleLineParser).lineHandler)
+
+	// multi_line rules take precedence over the detection
+	source.Config.ProcessingRules = []*config.ProcessingRule{{Type: config.MultiLine, Regex: regexp.MustCompile(`^\d`)}}
+	d = InitializeDecoder(source, parser.NoopParser)
+	assert.IsType(t, &MultiLineHandler{}, d.lineParser.(*SingleLineParser).lineHandler)
+
+	enabled = false
+	source.Config.ProcessingRules = nil
+	d = InitializeDecoder(source, parser.NoopParser)
+	assert.IsType(t, &SingleLineHandler{}, d.lineParser.(*SingleLineParser).lineHandler)
+}
@@ -9,8 +9,8 @@ import (
 	"strings"
 )
 
-// isConnClosedError returns true if the error is related to a closed connection,
+// IsClosedConnError returns true if the error is related to a closed connection,
 // for more details, see: https://golang.org/src/internal/poll/fd.go#L18.
-func isClosedConnError(err error) bool {
+func IsClosedConnError(err error) bool {
 	return strings.Contains(err.Error(), "use of closed network connection")
 }
@@ -14,6 +14,6 @@ import (
 )
 
 func TestIsConnectionClosedError(t *testing.T) {
-	assert.True(t, isClosedConnError(errors.New("use of closed network connection")))
-	assert.False(t, isClosedConnError(io.EOF))
+	assert.True(t, IsClosedConnError(errors.New("use of closed network connection")))
+	assert.False(t, IsClosedConnError(io.EOF))
 }
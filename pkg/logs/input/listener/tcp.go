@@ -81,7 +81,7 @@ func (l *TCPListener) run() {
 		default:
 			conn, err := l.listener.Accept()
 			switch {
-			case err != nil && isClosedConnError(err):
+			case err != nil && IsClosedConnError(err):
 				return
 			case err != nil:
 				// an error occurred, restart the listener.
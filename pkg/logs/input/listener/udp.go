@@ -87,7 +87,7 @@ func (l *UDPListener) read(tailer *Tailer) ([]byte, error) {
 	frame := make([]byte, l.frameSize+1)
 	n, err := tailer.conn.Read(frame)
 	switch {
-	case err != nil && isClosedConnError(err):
+	case err != nil && IsClosedConnError(err):
 		return nil, err
 	case err != nil:
 		go l.resetTailer()
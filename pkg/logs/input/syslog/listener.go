@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/listener"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// A TCPListener accepts syslog TCP connections, optionally over TLS, and delegates the read operations to a tailer.
+type TCPListener struct {
+	pipelineProvider pipeline.Provider
+	source           *config.LogSource
+	listener         net.Listener
+	tailers          map[*Tailer]struct{}
+	mu               sync.Mutex
+	done             chan struct{}
+}
+
+// NewTCPListener returns an initialized TCPListener
+func NewTCPListener(pipelineProvider pipeline.Provider, source *config.LogSource) *TCPListener {
+	return &TCPListener{
+		pipelineProvider: pipelineProvider,
+		source:           source,
+		tailers:          make(map[*Tailer]struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+// Start starts the listener to accept new incoming connections.
+func (l *TCPListener) Start() {
+	log.Infof("Starting syslog TCP listener on port %d", l.source.Config.Port)
+	listener, err := l.newListener()
+	if err != nil {
+		log.Errorf("Can't start syslog TCP listener on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		close(l.done)
+		return
+	}
+	l.listener = listener
+	l.source.Status.Success()
+	go l.run()
+}
+
+// Stop stops the listener from accepting new connections and all the active tailers.
+func (l *TCPListener) Stop() {
+	log.Infof("Stopping syslog TCP listener on port %d", l.source.Config.Port)
+	if l.listener != nil {
+		l.listener.Close()
+	}
+	<-l.done
+	l.mu.Lock()
+	stopper := restart.NewParallelStopper()
+	for tailer := range l.tailers {
+		stopper.Add(tailer)
+	}
+	l.mu.Unlock()
+	stopper.Stop()
+}
+
+// run accepts new connections and creates a dedicated tailer for each.
+func (l *TCPListener) run() {
+	defer close(l.done)
+	for {
+		conn, err := l.listener.Accept()
+		switch {
+		case err != nil && listener.IsClosedConnError(err):
+			return
+		case err != nil:
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				log.Warnf("Can't accept syslog connection on port %d: %v", l.source.Config.Port, err)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			log.Errorf("Can't accept syslog connections on port %d anymore: %v", l.source.Config.Port, err)
+			l.source.Status.Error(err)
+			return
+		default:
+			l.startTailer(conn)
+		}
+	}
+}
+
+// newListener listens on the port of the source, over TLS when a certificate is configured.
+func (l *TCPListener) newListener() (net.Listener, error) {
+	var tlsConfig *tls.Config
+	if l.source.Config.TLSCertFile != "" {
+		var err error
+		tlsConfig, err = buildTLSConfig(l.source.Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", l.source.Config.Port))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	return listener, nil
+}
+
+// startTailer creates and starts a new tailer that reads from the connection,
+// the tailer is forgotten once the connection is closed.
+func (l *TCPListener) startTailer(conn net.Conn) {
+	timeout := time.Duration(l.source.Config.IdleTimeout) * time.Second
+	tailer := NewTailer(l.source, conn, newStreamReader(conn, timeout), l.pipelineProvider.NextPipelineChan())
+	l.mu.Lock()
+	l.tailers[tailer] = struct{}{}
+	l.mu.Unlock()
+	tailer.Start()
+	go func() {
+		<-tailer.done
+		l.mu.Lock()
+		delete(l.tailers, tailer)
+		l.mu.Unlock()
+	}()
+}
+
+// buildTLSConfig returns the TLS configuration of the listener, client certificates
+// are required and verified when a CA is configured.
+func buildTLSConfig(c *config.LogsConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the TLS certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.TLSCAFile != "" {
+		ca, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the TLS CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificate found in %s", c.TLSCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// A UDPListener opens a UDP connection and delegates the read operations to a tailer.
+type UDPListener struct {
+	pipelineProvider pipeline.Provider
+	source           *config.LogSource
+	tailer           *Tailer
+}
+
+// NewUDPListener returns an initialized UDPListener
+func NewUDPListener(pipelineProvider pipeline.Provider, source *config.LogSource) *UDPListener {
+	return &UDPListener{
+		pipelineProvider: pipelineProvider,
+		source:           source,
+	}
+}
+
+// Start opens a new UDP connection and starts a tailer.
+func (l *UDPListener) Start() {
+	log.Infof("Starting syslog UDP listener on port %d", l.source.Config.Port)
+	udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", l.source.Config.Port))
+	if err != nil {
+		log.Errorf("Can't start syslog UDP listener on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		log.Errorf("Can't start syslog UDP listener on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	l.tailer = NewTailer(l.source, conn, newDatagramReader(conn), l.pipelineProvider.NextPipelineChan())
+	l.tailer.Start()
+	l.source.Status.Success()
+}
+
+// Stop stops the tailer.
+func (l *UDPListener) Stop() {
+	log.Infof("Stopping syslog UDP listener on port %d", l.source.Config.Port)
+	if l.tailer != nil {
+		l.tailer.Stop()
+	}
+}
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+func TestTCPListenerReceivesMessages(t *testing.T) {
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Protocol: config.TCPType, Service: "appliance"})
+	listener := NewTCPListener(pp, source)
+	listener.Start()
+
+	conn, err := net.Dial("tcp", listener.listener.Addr().String())
+	require.Nil(t, err)
+
+	fmt.Fprintf(conn, "<11>1 2021-03-01T10:00:00Z host app - - - disk failure\n")
+	msg := <-msgChan
+	assert.Equal(t, "disk failure", string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, "appliance", msg.Origin.Service())
+	assert.Equal(t, config.SyslogType, msg.Origin.Source())
+	assert.Contains(t, msg.Origin.Tags(), "syslog_hostname:host")
+
+	// lines which are not syslog lines are forwarded as is
+	fmt.Fprintf(conn, "hello world\n")
+	msg = <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+
+	// the tailer is forgotten once the client closes the connection
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		listener.mu.Lock()
+		defer listener.mu.Unlock()
+		return len(listener.tailers) == 0
+	}, time.Second, 10*time.Millisecond)
+	listener.Stop()
+}
+
+func TestTCPListenerWithTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	serverCert := generateCertificate(t, dir, "server")
+	clientCert := generateCertificate(t, dir, "client")
+
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:        config.SyslogType,
+		Protocol:    config.TCPType,
+		TLSCertFile: filepath.Join(dir, "server.crt"),
+		TLSKeyFile:  filepath.Join(dir, "server.key"),
+		TLSCAFile:   filepath.Join(dir, "client.crt"),
+	})
+	listener := NewTCPListener(pp, source)
+	listener.Start()
+	defer listener.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.Leaf)
+
+	// a client without certificate is rejected
+	conn, err := tls.Dial("tcp", listener.listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err == nil {
+		// with TLS 1.3 the client certificate is verified after the handshake completed on the client side
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	assert.NotNil(t, err)
+
+	conn, err = tls.Dial("tcp", listener.listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{clientCert}})
+	require.Nil(t, err)
+	defer conn.Close()
+	fmt.Fprintf(conn, "17 <13>1 - - - - - -")
+	fmt.Fprintf(conn, "<13>Mar  1 10:00:00 host app: hello\n")
+	msg := <-msgChan
+	assert.Equal(t, "", string(msg.Content))
+	msg = <-msgChan
+	assert.Equal(t, "hello", string(msg.Content))
+	assert.Equal(t, message.StatusNotice, msg.GetStatus())
+}
+
+func TestTCPListenerClosesIdleConnections(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Protocol: config.TCPType, IdleTimeout: 1})
+	listener := NewTCPListener(pp, source)
+	listener.Start()
+	defer listener.Stop()
+
+	conn, err := net.Dial("tcp", listener.listener.Addr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	// the connection is closed by the listener once it stayed idle for too long
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second)) //nolint:errcheck
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTCPListenerKeepsIdleConnectionsByDefault(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Protocol: config.TCPType})
+	listener := NewTCPListener(pp, source)
+	listener.Start()
+	defer listener.Stop()
+
+	conn, err := net.Dial("tcp", listener.listener.Addr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)) //nolint:errcheck
+	_, err = conn.Read(make([]byte, 1))
+	netErr, ok := err.(net.Error)
+	assert.True(t, ok && netErr.Timeout(), "%v", err)
+}
+
+func TestTCPListenerFailsWithInvalidCertificate(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Protocol: config.TCPType, TLSCertFile: "/does/not/exist", TLSKeyFile: "/does/not/exist"})
+	listener := NewTCPListener(pp, source)
+	listener.Start()
+	assert.True(t, source.Status.IsError())
+	listener.Stop()
+}
+
+func TestUDPListenerReceivesMessages(t *testing.T) {
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	listener := NewUDPListener(pp, config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType}))
+	listener.Start()
+
+	conn, err := net.Dial("udp", listener.tailer.conn.LocalAddr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "<12>Mar  1 10:00:00 switch01 ifmgr[7]: port 3 down")
+	msg := <-msgChan
+	assert.Equal(t, "port 3 down", string(msg.Content))
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, []string{"syslog_facility:user", "syslog_severity:warning", "syslog_hostname:switch01", "syslog_appname:ifmgr", "syslog_procid:7"}, msg.Origin.Tags())
+
+	listener.Stop()
+}
+
+// generateCertificate writes a self-signed certificate for localhost and its key to <dir>/<name>.crt and <dir>/<name>.key.
+func generateCertificate(t *testing.T, dir string, name string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600))
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.Nil(t, err)
+	certificate.Leaf, err = x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return certificate
+}
@@ -0,0 +1,318 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// nilValue is the value of the RFC 5424 header fields which are not set.
+const nilValue = "-"
+
+// facilities are the names of the syslog facilities, indexed by code.
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// severities are the names of the syslog severities, indexed by code.
+var severities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// statuses are the statuses of the syslog severities, indexed by code.
+var statuses = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// utf8BOM may prefix the message of a RFC 5424 line.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// syslogMessage is a parsed RFC 5424 or RFC 3164 syslog line.
+type syslogMessage struct {
+	facility       int
+	severity       int
+	timestamp      time.Time
+	hostname       string
+	appName        string
+	procID         string
+	msgID          string
+	structuredData []structuredDataElement
+	content        []byte
+}
+
+// structuredDataElement is a RFC 5424 structured data element, e.g. [exampleSDID@32473 iut="3"].
+type structuredDataElement struct {
+	id     string
+	params []structuredDataParam
+}
+
+type structuredDataParam struct {
+	name  string
+	value string
+}
+
+// status returns the status of the message.
+func (m *syslogMessage) status() string {
+	return statuses[m.severity]
+}
+
+// tags returns the tags of the message, structured data parameters are
+// sent as <SD-ID>.<PARAM-NAME>:<PARAM-VALUE>.
+func (m *syslogMessage) tags() []string {
+	tags := []string{
+		"syslog_facility:" + facilities[m.facility],
+		"syslog_severity:" + severities[m.severity],
+	}
+	for _, field := range []struct{ name, value string }{
+		{"syslog_hostname", m.hostname},
+		{"syslog_appname", m.appName},
+		{"syslog_procid", m.procID},
+		{"syslog_msgid", m.msgID},
+	} {
+		if field.value != "" {
+			tags = append(tags, field.name+":"+field.value)
+		}
+	}
+	for _, element := range m.structuredData {
+		for _, param := range element.params {
+			tags = append(tags, element.id+"."+param.name+":"+param.value)
+		}
+	}
+	return tags
+}
+
+// parse parses a RFC 5424 or a RFC 3164 syslog line,
+// returns an error if the line does not start with a valid priority.
+func parse(line []byte, now time.Time) (*syslogMessage, error) {
+	priority, rest, err := parsePriority(line)
+	if err != nil {
+		return nil, err
+	}
+	msg := &syslogMessage{
+		facility: priority / 8,
+		severity: priority % 8,
+	}
+	if len(rest) > 1 && rest[0] == '1' && rest[1] == ' ' {
+		if err := parseRFC5424(msg, rest[2:]); err == nil {
+			return msg, nil
+		}
+		// fall back on RFC 3164 with a fresh message
+		msg = &syslogMessage{facility: msg.facility, severity: msg.severity}
+	}
+	parseRFC3164(msg, rest, now)
+	return msg, nil
+}
+
+// parsePriority parses the <PRI> prefix of a syslog line.
+func parsePriority(line []byte) (int, []byte, error) {
+	if len(line) < 3 || line[0] != '<' {
+		return 0, nil, fmt.Errorf("missing priority")
+	}
+	end := bytes.IndexByte(line[:min(len(line), 5)], '>')
+	if end < 2 {
+		return 0, nil, fmt.Errorf("invalid priority")
+	}
+	priority, err := strconv.Atoi(string(line[1:end]))
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, nil, fmt.Errorf("invalid priority %q", line[1:end])
+	}
+	return priority, line[end+1:], nil
+}
+
+// parseRFC5424 parses the fields following the version of a RFC 5424 line:
+// TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(msg *syslogMessage, rest []byte) error {
+	var fields [5]string
+	for i := range fields {
+		end := bytes.IndexByte(rest, ' ')
+		if end <= 0 {
+			return fmt.Errorf("missing header field")
+		}
+		fields[i] = string(rest[:end])
+		rest = rest[end+1:]
+	}
+	if fields[0] != nilValue {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return err
+		}
+		msg.timestamp = timestamp
+	}
+	msg.hostname = nilToEmpty(fields[1])
+	msg.appName = nilToEmpty(fields[2])
+	msg.procID = nilToEmpty(fields[3])
+	msg.msgID = nilToEmpty(fields[4])
+
+	structuredData, rest, err := parseStructuredData(rest)
+	if err != nil {
+		return err
+	}
+	msg.structuredData = structuredData
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return fmt.Errorf("missing space before the message")
+		}
+		rest = bytes.TrimPrefix(rest[1:], utf8BOM)
+	}
+	msg.content = rest
+	return nil
+}
+
+// parseStructuredData parses the RFC 5424 structured data elements starting rest.
+func parseStructuredData(rest []byte) ([]structuredDataElement, []byte, error) {
+	if len(rest) > 0 && rest[0] == '-' {
+		return nil, rest[1:], nil
+	}
+	var elements []structuredDataElement
+	for len(rest) > 0 && rest[0] == '[' {
+		end := bytes.IndexAny(rest, " ]")
+		if end <= 1 {
+			return nil, nil, fmt.Errorf("invalid structured data element id")
+		}
+		element := structuredDataElement{id: string(rest[1:end])}
+		rest = rest[end:]
+		for len(rest) > 0 && rest[0] == ' ' {
+			eq := bytes.IndexByte(rest, '=')
+			if eq <= 1 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return nil, nil, fmt.Errorf("invalid structured data parameter")
+			}
+			name := string(rest[1:eq])
+			value, n, err := parseParamValue(rest[eq+2:])
+			if err != nil {
+				return nil, nil, err
+			}
+			element.params = append(element.params, structuredDataParam{name: name, value: value})
+			rest = rest[eq+2+n:]
+		}
+		if len(rest) == 0 || rest[0] != ']' {
+			return nil, nil, fmt.Errorf("unterminated structured data element")
+		}
+		rest = rest[1:]
+		elements = append(elements, element)
+	}
+	if elements == nil {
+		return nil, nil, fmt.Errorf("missing structured data")
+	}
+	return elements, rest, nil
+}
+
+// parseParamValue parses a quoted parameter value, the opening quote excluded,
+// returns the unescaped value and the number of bytes read, closing quote included.
+func parseParamValue(rest []byte) (string, int, error) {
+	var value []byte
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			if i+1 < len(rest) && (rest[i+1] == '"' || rest[i+1] == '\\' || rest[i+1] == ']') {
+				i++
+			}
+			value = append(value, rest[i])
+		case '"':
+			return string(value), i + 1, nil
+		default:
+			value = append(value, rest[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated structured data parameter value")
+}
+
+// parseRFC3164 parses the fields following the priority of a RFC 3164 line: TIMESTAMP HOSTNAME TAG[PID]: MSG,
+// all the fields are optional, the fields which can not be parsed are left in the content.
+func parseRFC3164(msg *syslogMessage, rest []byte, now time.Time) {
+	if timestamp, n, ok := parseRFC3164Timestamp(rest, now); ok {
+		msg.timestamp = timestamp
+		rest = rest[n:]
+		if len(rest) > 0 && rest[0] == ' ' {
+			rest = rest[1:]
+		}
+		// the hostname is followed by the tag unless it is the tag itself
+		if end := bytes.IndexByte(rest, ' '); end > 0 && !isTag(rest[:end]) {
+			msg.hostname = string(rest[:end])
+			rest = rest[end+1:]
+		}
+	}
+	if end := bytes.IndexByte(rest, ' '); end > 0 && isTag(rest[:end]) {
+		tag := bytes.TrimSuffix(rest[:end], []byte(":"))
+		if start := bytes.IndexByte(tag, '['); start > 0 && tag[len(tag)-1] == ']' {
+			msg.procID = string(tag[start+1 : len(tag)-1])
+			tag = tag[:start]
+		}
+		msg.appName = string(tag)
+		rest = rest[end+1:]
+	}
+	msg.content = rest
+}
+
+// isTag returns true if the token is a RFC 3164 tag, e.g. "sshd:" or "sshd[123]:".
+func isTag(token []byte) bool {
+	return len(token) > 1 && token[len(token)-1] == ':'
+}
+
+// rfc3164TimestampLayouts are the timestamp layouts supported in RFC 3164 lines, fractional
+// seconds are accepted by both. The year and the time zone are not part of the RFC 3164 timestamp,
+// many senders use a RFC 3339 timestamp instead.
+var rfc3164TimestampLayouts = []string{time.Stamp, time.RFC3339Nano}
+
+// parseRFC3164Timestamp parses the timestamp starting rest, returns the number of bytes read.
+func parseRFC3164Timestamp(rest []byte, now time.Time) (time.Time, int, bool) {
+	// "Jan _2 15:04:05" contains two spaces, RFC3339 timestamps none
+	end := 0
+	for spaces := 0; end < len(rest); end++ {
+		if rest[end] == ' ' && end > 0 && rest[end-1] != ' ' {
+			spaces++
+			if spaces == 3 {
+				break
+			}
+		}
+	}
+	for _, layout := range rfc3164TimestampLayouts {
+		candidate := rest[:end]
+		if layout == time.RFC3339Nano {
+			if i := bytes.IndexByte(candidate, ' '); i >= 0 {
+				candidate = candidate[:i]
+			}
+		}
+		timestamp, err := time.ParseInLocation(layout, string(candidate), now.Location())
+		if err != nil {
+			continue
+		}
+		if layout != time.RFC3339Nano {
+			// assume the line was sent less than a day in the future, at the turn of the year
+			timestamp = timestamp.AddDate(now.Year(), 0, 0)
+			if timestamp.After(now.AddDate(0, 0, 1)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+		}
+		return timestamp, len(candidate), true
+	}
+	return time.Time{}, 0, false
+}
+
+func nilToEmpty(field string) string {
+	if field == nilValue {
+		return ""
+	}
+	return field
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
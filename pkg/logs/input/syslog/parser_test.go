@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+var now = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestParseRFC5424(t *testing.T) {
+	msg, err := parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application \"A\" [1\]"][examplePriority@32473 class="high"] `+"\xEF\xBB\xBF"+`An application event log entry`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "An application event log entry", string(msg.content))
+	assert.Equal(t, message.StatusNotice, msg.status())
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), msg.timestamp)
+	assert.Equal(t, []string{
+		"syslog_facility:local4",
+		"syslog_severity:notice",
+		"syslog_hostname:mymachine.example.com",
+		"syslog_appname:evntslog",
+		"syslog_procid:1234",
+		"syslog_msgid:ID47",
+		"exampleSDID@32473.iut:3",
+		`exampleSDID@32473.eventSource:Application "A" [1]`,
+		"examplePriority@32473.class:high",
+	}, msg.tags())
+
+	msg, err = parse([]byte(`<34>1 - - - - - -`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(msg.content))
+	assert.Equal(t, message.StatusCritical, msg.status())
+	assert.True(t, msg.timestamp.IsZero())
+	assert.Equal(t, []string{"syslog_facility:auth", "syslog_severity:crit"}, msg.tags())
+}
+
+func TestParseRFC3164(t *testing.T) {
+	msg, err := parse([]byte(`<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick on /dev/pts/8`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", string(msg.content))
+	assert.Equal(t, message.StatusCritical, msg.status())
+	// the timestamp is assumed to be from the previous year since it would be in the future otherwise
+	assert.Equal(t, time.Date(2020, 10, 11, 22, 14, 15, 0, time.UTC), msg.timestamp)
+	assert.Equal(t, []string{"syslog_facility:auth", "syslog_severity:crit", "syslog_hostname:mymachine", "syslog_appname:su", "syslog_procid:42"}, msg.tags())
+
+	// no hostname
+	msg, err = parse([]byte(`<13>Mar  1 11:59:00 cron: job done`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "job done", string(msg.content))
+	assert.Equal(t, message.StatusNotice, msg.status())
+	assert.Equal(t, time.Date(2021, 3, 1, 11, 59, 0, 0, time.UTC), msg.timestamp)
+	assert.Equal(t, []string{"syslog_facility:user", "syslog_severity:notice", "syslog_appname:cron"}, msg.tags())
+
+	// RFC 3339 timestamp
+	msg, err = parse([]byte(`<30>2021-03-01T11:00:00+01:00 router dhcpd: lease renewed`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "lease renewed", string(msg.content))
+	assert.True(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC).Equal(msg.timestamp))
+	assert.Equal(t, []string{"syslog_facility:daemon", "syslog_severity:info", "syslog_hostname:router", "syslog_appname:dhcpd"}, msg.tags())
+
+	// no header
+	msg, err = parse([]byte(`<7>link down`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "link down", string(msg.content))
+	assert.Equal(t, message.StatusDebug, msg.status())
+	assert.True(t, msg.timestamp.IsZero())
+
+	// invalid RFC 5424 lines are parsed as RFC 3164 lines
+	msg, err = parse([]byte(`<14>1 apple a day`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "1 apple a day", string(msg.content))
+}
+
+func TestParseInvalidPriority(t *testing.T) {
+	for _, line := range []string{"", "hello world", "<>", "<192>overflow", "<abc>text", "<1234>too long"} {
+		_, err := parse([]byte(line), now)
+		assert.NotNil(t, err, line)
+	}
+}
@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// maxFrameLen is the maximum length of a syslog frame, longer frames are truncated.
+const maxFrameLen = 256 * 1000
+
+// maxFrameLenDigits is the maximum number of digits of the length prefixing a frame.
+const maxFrameLenDigits = 9
+
+// maxDatagramLen is the maximum size of a UDP datagram.
+const maxDatagramLen = 65535
+
+// frameReader returns the syslog lines received on a connection one at a time.
+type frameReader interface {
+	ReadFrame() ([]byte, error)
+}
+
+// streamReader splits a TCP stream into syslog lines, both octet-counting
+// (RFC 6587 3.4.1, "<LEN> <LINE>") and line feed delimited framings are supported.
+type streamReader struct {
+	reader *bufio.Reader
+}
+
+// newStreamReader returns a new streamReader, the connection is closed if no data
+// is received before the timeout, a zero timeout keeps idle connections open.
+func newStreamReader(conn net.Conn, timeout time.Duration) *streamReader {
+	var reader io.Reader = conn
+	if timeout > 0 {
+		reader = &timeoutReader{conn: conn, timeout: timeout}
+	}
+	return &streamReader{
+		reader: bufio.NewReader(reader),
+	}
+}
+
+// ReadFrame returns the next syslog line of the stream.
+func (r *streamReader) ReadFrame() ([]byte, error) {
+	for {
+		first, err := r.reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case first[0] >= '1' && first[0] <= '9':
+			if length, n, ok := r.peekFrameLength(); ok {
+				return r.readOctetCountedFrame(length, n)
+			}
+			return r.readDelimitedFrame()
+		case first[0] == '\n' || first[0] == '\r' || first[0] == 0:
+			// skip the empty lines and the trailers left by some senders
+			r.reader.ReadByte() //nolint:errcheck
+		default:
+			return r.readDelimitedFrame()
+		}
+	}
+}
+
+// peekFrameLength returns the length prefixing the next frame and the size of the prefix,
+// returns false if the frame is not prefixed by its length.
+func (r *streamReader) peekFrameLength() (int, int, bool) {
+	for n := 1; n <= maxFrameLenDigits+1; n++ {
+		prefix, err := r.reader.Peek(n)
+		if err != nil {
+			return 0, 0, false
+		}
+		switch c := prefix[n-1]; {
+		case c == ' ' && n > 1:
+			length, err := strconv.Atoi(string(prefix[:n-1]))
+			return length, n, err == nil
+		case c < '0' || c > '9':
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+// readOctetCountedFrame reads a frame prefixed by its length.
+func (r *streamReader) readOctetCountedFrame(length int, prefixLen int) ([]byte, error) {
+	if _, err := r.reader.Discard(prefixLen); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, min(length, maxFrameLen))
+	if _, err := io.ReadFull(r.reader, frame); err != nil {
+		return nil, err
+	}
+	if length > maxFrameLen {
+		if _, err := r.reader.Discard(length - maxFrameLen); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.TrimRight(frame, "\r\n"), nil
+}
+
+// readDelimitedFrame reads a frame terminated by a line feed.
+func (r *streamReader) readDelimitedFrame() ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if len(frame) < maxFrameLen {
+			frame = append(frame, chunk[:min(len(chunk), maxFrameLen-len(frame))]...)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(frame) > 0:
+			// the last line of the stream does not need to be terminated
+			return bytes.TrimRight(frame, "\r\n"), nil
+		case err != nil:
+			return nil, err
+		}
+		return bytes.TrimRight(frame, "\r\n"), nil
+	}
+}
+
+// timeoutReader sets a read deadline on the connection before each read.
+type timeoutReader struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (r *timeoutReader) Read(p []byte) (int, error) {
+	r.conn.SetReadDeadline(time.Now().Add(r.timeout)) //nolint:errcheck
+	return r.conn.Read(p)
+}
+
+// datagramReader reads syslog lines from UDP datagrams, a datagram can contain
+// several lines delimited by line feeds.
+type datagramReader struct {
+	conn    net.Conn
+	buffer  []byte
+	pending [][]byte
+}
+
+func newDatagramReader(conn net.Conn) *datagramReader {
+	return &datagramReader{
+		conn:   conn,
+		buffer: make([]byte, maxDatagramLen),
+	}
+}
+
+// ReadFrame returns the next syslog line received.
+func (r *datagramReader) ReadFrame() ([]byte, error) {
+	for len(r.pending) == 0 {
+		n, err := r.conn.Read(r.buffer)
+		if err != nil {
+			return nil, err
+		}
+		// the lines outlive the buffer which is reused by the next read
+		datagram := make([]byte, n)
+		copy(datagram, r.buffer[:n])
+		for _, line := range bytes.Split(datagram, []byte("\n")) {
+			line = bytes.TrimRight(line, "\r\x00")
+			if len(line) > 0 {
+				r.pending = append(r.pending, line)
+			}
+		}
+	}
+	frame := r.pending[0]
+	r.pending = r.pending[1:]
+	return frame, nil
+}
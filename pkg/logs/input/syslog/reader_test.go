@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamReader(t *testing.T) {
+	r, w := net.Pipe()
+	reader := newStreamReader(r, time.Minute)
+	go func() {
+		io.WriteString(w, "<34>1 - - - - - - first\n")
+		io.WriteString(w, "25 <34>1 - - - - - - sec\nond")
+		io.WriteString(w, "\r\n\x00<13>third\r\n")
+		io.WriteString(w, "2021-03-01 not a frame length\n")
+		io.WriteString(w, "3 "+strings.Repeat("a", 3)+"<13>last")
+		w.Close()
+	}()
+
+	for _, expected := range []string{
+		"<34>1 - - - - - - first",
+		"<34>1 - - - - - - sec\nond",
+		"<13>third",
+		"2021-03-01 not a frame length",
+		"aaa",
+		"<13>last",
+	} {
+		frame, err := reader.ReadFrame()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, string(frame))
+	}
+	_, err := reader.ReadFrame()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamReaderTruncatesLongFrames(t *testing.T) {
+	r, w := net.Pipe()
+	reader := newStreamReader(r, time.Minute)
+	go func() {
+		io.WriteString(w, strings.Repeat("a", maxFrameLen+10)+"\n")
+		io.WriteString(w, "256010 "+strings.Repeat("b", maxFrameLen+10))
+		io.WriteString(w, "<13>next\n")
+		w.Close()
+	}()
+
+	frame, err := reader.ReadFrame()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("a", maxFrameLen), string(frame))
+	frame, err = reader.ReadFrame()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("b", maxFrameLen), string(frame))
+	frame, err = reader.ReadFrame()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>next", string(frame))
+}
+
+func TestDatagramReader(t *testing.T) {
+	r, w := net.Pipe()
+	reader := newDatagramReader(r)
+	go func() {
+		io.WriteString(w, "<13>first\n<13>second\n")
+		io.WriteString(w, "<13>third")
+	}()
+
+	var frames []string
+	for i := 0; i < 3; i++ {
+		frame, err := reader.ReadFrame()
+		assert.Nil(t, err)
+		frames = append(frames, string(frame))
+	}
+	// the frames are not overwritten by the next datagrams
+	assert.Equal(t, []string{"<13>first", "<13>second", "<13>third"}, frames)
+	r.Close()
+}
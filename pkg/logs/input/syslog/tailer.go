@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/listener"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Tailer reads syslog lines from a connection and forwards them as log messages.
+type Tailer struct {
+	source     *config.LogSource
+	conn       net.Conn
+	reader     frameReader
+	outputChan chan *message.Message
+	done       chan struct{}
+}
+
+// NewTailer returns a new Tailer
+func NewTailer(source *config.LogSource, conn net.Conn, reader frameReader, outputChan chan *message.Message) *Tailer {
+	return &Tailer{
+		source:     source,
+		conn:       conn,
+		reader:     reader,
+		outputChan: outputChan,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts reading the lines from the connection.
+func (t *Tailer) Start() {
+	go t.run()
+}
+
+// Stop closes the connection and waits for the lines read to be forwarded.
+func (t *Tailer) Stop() {
+	t.conn.Close()
+	<-t.done
+}
+
+// run reads and forwards the lines until the connection is closed.
+func (t *Tailer) run() {
+	defer func() {
+		t.conn.Close()
+		close(t.done)
+	}()
+	for {
+		frame, err := t.reader.ReadFrame()
+		if err != nil {
+			switch {
+			case err == io.EOF || listener.IsClosedConnError(err):
+				// the connection has been closed by the client or the listener
+			case isTimeoutError(err):
+				log.Debugf("Closing idle syslog connection from %s", t.conn.RemoteAddr())
+			default:
+				log.Warnf("Couldn't read syslog message from connection: %v", err)
+			}
+			return
+		}
+		t.source.BytesRead.Add(int64(len(frame)))
+		t.outputChan <- t.toMessage(frame, time.Now())
+	}
+}
+
+// toMessage returns the log message of a syslog line, the lines which are not
+// valid syslog lines are forwarded as is.
+func (t *Tailer) toMessage(frame []byte, now time.Time) *message.Message {
+	origin := message.NewOrigin(t.source)
+	origin.SetSource(config.SyslogType)
+	msg, err := parse(frame, now)
+	if err != nil {
+		return message.NewMessage(frame, origin, message.StatusInfo, now.UnixNano())
+	}
+	origin.SetTags(msg.tags())
+	if msg.appName != "" {
+		origin.SetService(msg.appName)
+	}
+	output := message.NewMessage(msg.content, origin, msg.status(), now.UnixNano())
+	if !msg.timestamp.IsZero() {
+		output.Timestamp = msg.timestamp.UTC()
+	}
+	return output
+}
@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
@@ -22,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
@@ -98,7 +100,14 @@ func start(getAC func() *autodiscovery.AutoConfig, serverless bool, logsChan cha
 	if !serverless {
 		// regular logs agent
 		log.Info("Starting logs-agent...")
-		agent = NewAgent(sources, services, processingRules, endpoints)
+		// the metrics generated from logs are submitted through the default sender of the aggregator
+		var metricSender processor.MetricSender
+		if sender, err := aggregator.GetDefaultSender(); err == nil {
+			metricSender = sender
+		} else {
+			log.Debugf("Metrics can't be generated from logs: %v", err)
+		}
+		agent = NewAgent(sources, services, processingRules, endpoints, metricSender)
 	} else {
 		// serverless logs agent
 		log.Info("Starting a serverless logs-agent...")
@@ -88,6 +88,12 @@ func (o *Origin) SetTags(tags []string) {
 	o.tags = tags
 }
 
+// AddTags appends tags to the tags of the origin,
+// the tags previously set are left untouched as they can be shared between origins.
+func (o *Origin) AddTags(tags ...string) {
+	o.tags = append(o.tags[:len(o.tags):len(o.tags)], tags...)
+}
+
 // SetSource sets the source of the origin.
 func (o *Origin) SetSource(source string) {
 	o.source = source
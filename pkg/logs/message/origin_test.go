@@ -62,6 +62,23 @@ func TestSetTagsWithConfigTags(t *testing.T) {
 	assert.Equal(t, "[dd ddsource=\"a\"][dd ddsourcecategory=\"b\"][dd ddtags=\"c:d,e,foo:bar,baz\"]", string(origin.TagsPayload()))
 }
 
+func TestAddTags(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	tags := make([]string, 1, 2)
+	tags[0] = "foo:bar"
+
+	origin := NewOrigin(source)
+	origin.SetTags(tags)
+	origin.AddTags("baz")
+	assert.Equal(t, []string{"foo:bar", "baz"}, origin.Tags())
+
+	other := NewOrigin(source)
+	other.SetTags(tags)
+	other.AddTags("qux")
+	assert.Equal(t, []string{"foo:bar", "qux"}, other.Tags())
+	assert.Equal(t, []string{"foo:bar", "baz"}, origin.Tags())
+}
+
 func TestDefaultSourceValueIsSourceFromConfig(t *testing.T) {
 	var cfg *config.LogsConfig
 	var source *config.LogSource
@@ -5,6 +5,8 @@
 
 package message
 
+import "strings"
+
 // Status values
 const (
 	StatusEmergency = "emergency"
@@ -41,6 +43,34 @@ var statusSeverityMapping = map[string][]byte{
 	StatusDebug:     SevDebug,
 }
 
+// levelStatusMapping maps the log levels commonly found in structured logs to statuses.
+var levelStatusMapping = map[string]string{
+	"emerg":       StatusEmergency,
+	"emergency":   StatusEmergency,
+	"panic":       StatusEmergency,
+	"alert":       StatusAlert,
+	"crit":        StatusCritical,
+	"critical":    StatusCritical,
+	"fatal":       StatusCritical,
+	"err":         StatusError,
+	"error":       StatusError,
+	"severe":      StatusError,
+	"warn":        StatusWarning,
+	"warning":     StatusWarning,
+	"notice":      StatusNotice,
+	"info":        StatusInfo,
+	"information": StatusInfo,
+	"debug":       StatusDebug,
+	"trace":       StatusDebug,
+}
+
+// LevelToStatus transforms a log level into a status,
+// returns false if the level is not recognized.
+func LevelToStatus(level string) (string, bool) {
+	status, exists := levelStatusMapping[strings.ToLower(strings.TrimSpace(level))]
+	return status, exists
+}
+
 // StatusToSeverity transforms a severity into a status.
 func StatusToSeverity(status string) []byte {
 	if sev, exists := statusSeverityMapping[status]; exists {
@@ -33,3 +33,21 @@ func TestStatusToSeverity(t *testing.T) {
 	// default value should be "info"
 	assert.Equal(t, 0, bytes.Compare(SevInfo, StatusToSeverity("foo")))
 }
+
+func TestLevelToStatus(t *testing.T) {
+	for level, expected := range map[string]string{
+		"ERROR":   StatusError,
+		"warning": StatusWarning,
+		" Info ":  StatusInfo,
+		"fatal":   StatusCritical,
+		"SEVERE":  StatusError,
+		"trace":   StatusDebug,
+	} {
+		status, ok := LevelToStatus(level)
+		assert.True(t, ok)
+		assert.Equal(t, expected, status)
+	}
+
+	_, ok := LevelToStatus("foo")
+	assert.False(t, ok)
+}
@@ -51,6 +51,25 @@ var (
 	// TlmEncodedBytesSent is the total number of sent bytes after encoding if any
 	TlmEncodedBytesSent = telemetry.NewCounter("logs", "encoded_bytes_sent",
 		nil, "Total number of sent bytes after encoding if any")
+
+	// DiskBufferPayloads is the number of payloads stored in the disk buffers
+	DiskBufferPayloads = expvar.Int{}
+	// TlmDiskBufferPayloads is the number of payloads stored in the disk buffers
+	TlmDiskBufferPayloads = telemetry.NewGauge("logs", "disk_buffer_payloads",
+		nil, "Number of payloads stored in the disk buffers")
+	// DiskBufferBytes is the size of the payloads stored in the disk buffers
+	DiskBufferBytes = expvar.Int{}
+	// TlmDiskBufferBytes is the size of the payloads stored in the disk buffers
+	TlmDiskBufferBytes = telemetry.NewGauge("logs", "disk_buffer_bytes",
+		nil, "Size of the payloads stored in the disk buffers")
+	// DiskBufferPayloadsDropped is the total number of payloads dropped from the disk buffers
+	DiskBufferPayloadsDropped = expvar.Int{}
+	// TlmDiskBufferPayloadsDropped is the total number of payloads dropped from the disk buffers
+	TlmDiskBufferPayloadsDropped = telemetry.NewCounter("logs", "disk_buffer_payloads_dropped",
+		nil, "Total number of payloads dropped from the disk buffers because they were outdated or the buffers were full")
+	// TlmAutoMultiLineDetected is the total number of sources for which a multi-line pattern was detected, per pattern
+	TlmAutoMultiLineDetected = telemetry.NewCounter("logs", "auto_multi_line_detected",
+		[]string{"pattern"}, "Total number of sources for which a multi-line pattern was automatically detected, per pattern")
 	// TODO: Add LogsCollected for the total number of collected logs.
 
 )
@@ -64,4 +83,7 @@ func init() {
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
 	LogsExpvars.Set("BytesSent", &BytesSent)
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
+	LogsExpvars.Set("DiskBufferPayloads", &DiskBufferPayloads)
+	LogsExpvars.Set("DiskBufferBytes", &DiskBufferBytes)
+	LogsExpvars.Set("DiskBufferPayloadsDropped", &DiskBufferPayloadsDropped)
 }
@@ -25,8 +25,8 @@ type Pipeline struct {
 	sender    *sender.Sender
 }
 
-// NewPipeline returns a new Pipeline
-func NewPipeline(outputChan chan *message.Message, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diagnosticMessageReceiver diagnostic.MessageReceiver, serverless bool) *Pipeline {
+// NewPipeline returns a new Pipeline, diskBuffer and metricSender are optional.
+func NewPipeline(outputChan chan *message.Message, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diagnosticMessageReceiver diagnostic.MessageReceiver, serverless bool, diskBuffer *sender.DiskBuffer, metricSender processor.MetricSender) *Pipeline {
 	var destinations *client.Destinations
 	if endpoints.UseHTTP {
 		main := http.NewDestination(endpoints.Main, http.JSONContentType, destinationsContext)
@@ -52,7 +52,7 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 	} else {
 		strategy = sender.StreamStrategy
 	}
-	sender := sender.NewSender(senderChan, outputChan, destinations, strategy)
+	sender := sender.NewSenderWithDiskBuffer(senderChan, outputChan, destinations, strategy, diskBuffer)
 
 	var encoder processor.Encoder
 	if serverless {
@@ -66,7 +66,7 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, senderChan, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, senderChan, processingRules, encoder, diagnosticMessageReceiver, metricSender)
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -7,15 +7,21 @@ package pipeline
 
 import (
 	"context"
+	"path/filepath"
+	"strconv"
 	"sync/atomic"
 
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 )
 
 // Provider provides message channels
@@ -35,6 +41,7 @@ type provider struct {
 	outputChan                chan *message.Message
 	processingRules           []*config.ProcessingRule
 	endpoints                 *config.Endpoints
+	metricSender              processor.MetricSender
 
 	pipelines            []*Pipeline
 	currentPipelineIndex int32
@@ -43,23 +50,24 @@ type provider struct {
 	serverless bool
 }
 
-// NewProvider returns a new Provider
-func NewProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
-	return newProvider(numberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, endpoints, destinationsContext, false)
+// NewProvider returns a new Provider, metricSender is optional and only used by the generate_metric rules.
+func NewProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, metricSender processor.MetricSender) Provider {
+	return newProvider(numberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, endpoints, destinationsContext, metricSender, false)
 }
 
 // NewServerlessProvider returns a new Provider in serverless mode
 func NewServerlessProvider(numberOfPipelines int, auditor auditor.Auditor, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
-	return newProvider(numberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, processingRules, endpoints, destinationsContext, true)
+	return newProvider(numberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, processingRules, endpoints, destinationsContext, nil, true)
 }
 
-func newProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, serverless bool) Provider {
+func newProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, metricSender processor.MetricSender, serverless bool) Provider {
 	return &provider{
 		numberOfPipelines:         numberOfPipelines,
 		auditor:                   auditor,
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
 		processingRules:           processingRules,
 		endpoints:                 endpoints,
+		metricSender:              metricSender,
 		pipelines:                 []*Pipeline{},
 		destinationsContext:       destinationsContext,
 		serverless:                serverless,
@@ -72,12 +80,28 @@ func (p *provider) Start() {
 	p.outputChan = p.auditor.Channel()
 
 	for i := 0; i < p.numberOfPipelines; i++ {
-		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext, p.diagnosticMessageReceiver, p.serverless)
+		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext, p.diagnosticMessageReceiver, p.serverless, p.newDiskBuffer(i), p.metricSender)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
 }
 
+// newDiskBuffer returns the disk buffer of a pipeline, each of them gets a share of the configured size.
+// Returns nil when disk buffering is disabled or the buffer can not be created.
+func (p *provider) newDiskBuffer(pipelineID int) *sender.DiskBuffer {
+	settings := p.endpoints.DiskBuffer
+	if settings == nil {
+		return nil
+	}
+	path := filepath.Join(settings.Path, strconv.Itoa(pipelineID))
+	diskBuffer, err := sender.NewDiskBuffer(path, settings.MaxSize/int64(p.numberOfPipelines), settings.MaxAge)
+	if err != nil {
+		log.Warnf("Could not create the logs disk buffer %s, payloads will be retried in memory: %v", path, err)
+		return nil
+	}
+	return diskBuffer
+}
+
 // Stop stops all pipelines in parallel,
 // this call blocks until all pipelines are stopped
 func (p *provider) Stop() {
@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"encoding/json"
 	"unicode"
 	"unicode/utf8"
 
@@ -33,3 +34,23 @@ func toValidUtf8(msg []byte) string {
 	}
 	return string(str)
 }
+
+// renderContent returns the content to encode, when the processing rules extracted
+// structured attributes from the message, they are sent as a JSON object along with the message.
+func renderContent(msg *message.Message, redactedMsg []byte) []byte {
+	if len(msg.Attributes) == 0 {
+		return redactedMsg
+	}
+	object := make(map[string]interface{}, len(msg.Attributes)+1)
+	for key, value := range msg.Attributes {
+		object[key] = value
+	}
+	if len(redactedMsg) > 0 {
+		object["message"] = toValidUtf8(redactedMsg)
+	}
+	content, err := json.Marshal(object)
+	if err != nil {
+		return redactedMsg
+	}
+	return content
+}
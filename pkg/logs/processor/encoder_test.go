@@ -221,6 +221,46 @@ func TestJsonEncoder(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 }
 
+func TestJsonEncoderWithAttributes(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	msg := newMessage([]byte("message"), source, "")
+	msg.Attributes = map[string]interface{}{"trace_id": json.Number("123"), "user": "foo"}
+	msg.Timestamp = time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	jsonMessage, err := JSONEncoder.Encode(msg, []byte("redacted"))
+	assert.Nil(t, err)
+
+	log := &jsonPayload{}
+	err = json.Unmarshal(jsonMessage, log)
+	assert.Nil(t, err)
+
+	assert.Equal(t, `{"message":"redacted","trace_id":123,"user":"foo"}`, log.Message)
+	assert.Equal(t, msg.Timestamp.UnixNano()/nanoToMillis, log.Timestamp)
+}
+
+func TestJSONEncoderWithParsedNumbers(t *testing.T) {
+	rule := &config.ProcessingRule{
+		Type:    config.ParsePattern,
+		Name:    "test",
+		Pattern: `^%{NOTSPACE:count:int} %{NOTSPACE:ratio:float} %{NOTSPACE:other:float} %{GREEDYDATA:msg}`,
+	}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{rule}))
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}})
+	p := &Processor{}
+
+	msg := newMessage([]byte("+5 .5 NaN hello"), source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+
+	jsonMessage, err := JSONEncoder.Encode(msg, redactedMessage)
+	assert.Nil(t, err)
+
+	log := &jsonPayload{}
+	err = json.Unmarshal(jsonMessage, log)
+	assert.Nil(t, err)
+
+	assert.Equal(t, `{"count":5,"message":"hello","ratio":0.5}`, log.Message)
+}
+
 func TestEncoderToValidUTF8(t *testing.T) {
 	assert.Equal(t, "a�z", toValidUtf8([]byte("a\xfez")))
 	assert.Equal(t, "a��z", toValidUtf8([]byte("a\xc0\xafz")))
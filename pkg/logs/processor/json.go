@@ -38,7 +38,7 @@ func (j *jsonEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte,
 		ts = msg.Timestamp
 	}
 	return json.Marshal(jsonPayload{
-		Message:   toValidUtf8(redactedMsg),
+		Message:   toValidUtf8(renderContent(msg, redactedMsg)),
 		Status:    msg.GetStatus(),
 		Timestamp: ts.UnixNano() / nanoToMillis,
 		Hostname:  getHostname(),
@@ -59,7 +59,7 @@ func (j *jsonServerlessEncoder) Encode(msg *message.Message, redactedMsg []byte)
 
 	return json.Marshal(jsonServerlessPayload{
 		Message: jsonServerlessMessage{
-			Message: toValidUtf8(redactedMsg),
+			Message: toValidUtf8(renderContent(msg, redactedMsg)),
 			Lambda:  lambdaPart,
 		},
 		Status:    msg.GetStatus(),
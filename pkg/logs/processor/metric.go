@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// MetricCommitInterval is the interval at which the metrics generated from logs are committed to the aggregator.
+const MetricCommitInterval = 15 * time.Second
+
+// MetricSender submits the metrics generated by the generate_metric rules,
+// it is implemented by the aggregator sender.
+type MetricSender interface {
+	Count(metric string, value float64, hostname string, tags []string)
+	Gauge(metric string, value float64, hostname string, tags []string)
+	Distribution(metric string, value float64, hostname string, tags []string)
+	Commit()
+}
+
+// applyMetricRule submits the metric of a generate_metric rule when the content matches its pattern,
+// the metric is tagged with the tags of the origin and the tag captures of the rule.
+func applyMetricRule(rule *config.ProcessingRule, sender MetricSender, msg *message.Message, content []byte) {
+	submatches := rule.Regex.FindSubmatch(content)
+	if submatches == nil {
+		return
+	}
+
+	value := 1.0
+	var tags []string
+	for i, name := range rule.Regex.SubexpNames() {
+		if i == 0 || name == "" || submatches[i] == nil {
+			continue
+		}
+		if name == rule.ValueCapture {
+			var err error
+			value, err = strconv.ParseFloat(string(submatches[i]), 64)
+			if err != nil {
+				log.Debugf("Can't submit metric %s for processing rule %s, %q is not a number", rule.MetricName, rule.Name, submatches[i])
+				return
+			}
+		}
+		for _, tagCapture := range rule.TagCaptures {
+			if name == tagCapture && len(submatches[i]) > 0 {
+				tags = append(tags, name+":"+string(submatches[i]))
+			}
+		}
+	}
+	tags = append(tags, metricTags(msg.Origin)...)
+
+	switch rule.MetricType {
+	case config.MetricTypeGauge:
+		sender.Gauge(rule.MetricName, value, "", tags)
+	case config.MetricTypeDistribution:
+		sender.Distribution(rule.MetricName, value, "", tags)
+	default:
+		sender.Count(rule.MetricName, value, "", tags)
+	}
+}
+
+// metricTags returns the tags of the origin along with its service and source.
+func metricTags(origin *message.Origin) []string {
+	tags := origin.Tags()
+	if service := origin.Service(); service != "" {
+		tags = append(tags, "service:"+service)
+	}
+	if source := origin.Source(); source != "" {
+		tags = append(tags, "source:"+source)
+	}
+	return tags
+}
+
+// A MetricCommitter periodically commits the metrics generated from logs,
+// metrics submitted to the aggregator are only flushed once committed.
+type MetricCommitter struct {
+	sender   MetricSender
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewMetricCommitter returns a new MetricCommitter.
+func NewMetricCommitter(sender MetricSender, interval time.Duration) *MetricCommitter {
+	return &MetricCommitter{
+		sender:   sender,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts the committer.
+func (c *MetricCommitter) Start() {
+	go c.run()
+}
+
+// Stop stops the committer after a last commit.
+func (c *MetricCommitter) Stop() {
+	close(c.stop)
+	<-c.done
+}
+
+func (c *MetricCommitter) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.sender.Commit()
+		case <-c.stop:
+			c.sender.Commit()
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+type metricSample struct {
+	metricType string
+	name       string
+	value      float64
+	tags       []string
+}
+
+type fakeMetricSender struct {
+	mu      sync.Mutex
+	samples []metricSample
+	commits int
+}
+
+func (s *fakeMetricSender) add(metricType, metric string, value float64, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, metricSample{metricType, metric, value, tags})
+}
+
+func (s *fakeMetricSender) Count(metric string, value float64, hostname string, tags []string) {
+	s.add(config.MetricTypeCount, metric, value, tags)
+}
+
+func (s *fakeMetricSender) Gauge(metric string, value float64, hostname string, tags []string) {
+	s.add(config.MetricTypeGauge, metric, value, tags)
+}
+
+func (s *fakeMetricSender) Distribution(metric string, value float64, hostname string, tags []string) {
+	s.add(config.MetricTypeDistribution, metric, value, tags)
+}
+
+func (s *fakeMetricSender) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+}
+
+func newMetricRule(t *testing.T, rule *config.ProcessingRule) *config.ProcessingRule {
+	rule.Name = "test"
+	rule.Type = config.GenerateMetric
+	require.Nil(t, config.ValidateProcessingRules([]*config.ProcessingRule{rule}))
+	require.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{rule}))
+	return rule
+}
+
+func TestGenerateCountMetric(t *testing.T) {
+	sender := &fakeMetricSender{}
+	rule := newMetricRule(t, &config.ProcessingRule{Pattern: `ERROR (?P<code>E\d+)`, MetricName: "app.errors", TagCaptures: []string{"code"}})
+	source := config.NewLogSource("", &config.LogsConfig{Service: "billing", Source: "java", Tags: []string{"env:prod"}, ProcessingRules: []*config.ProcessingRule{rule}})
+	p := &Processor{metricSender: sender}
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("INFO all good"), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Len(t, sender.samples, 0)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("ERROR E42 payment refused"), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []metricSample{
+		{config.MetricTypeCount, "app.errors", 1, []string{"code:E42", "env:prod", "service:billing", "source:java"}},
+	}, sender.samples)
+}
+
+func TestGenerateMetricFromValueCapture(t *testing.T) {
+	sender := &fakeMetricSender{}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{
+		newMetricRule(t, &config.ProcessingRule{Pattern: `took (?P<value>[\d.]+)ms`, MetricName: "app.latency", MetricType: config.MetricTypeDistribution}),
+		newMetricRule(t, &config.ProcessingRule{Pattern: `queue=(?P<size>\d+)`, MetricName: "app.queue", MetricType: config.MetricTypeGauge, ValueCapture: "size"}),
+		newMetricRule(t, &config.ProcessingRule{Pattern: `sent (?P<bytes>\d+) bytes`, MetricName: "app.bytes", ValueCapture: "bytes"}),
+	}})
+	p := &Processor{metricSender: sender}
+
+	p.applyRedactingRules(newMessage([]byte("request took 12.5ms queue=3"), source, ""))
+	p.applyRedactingRules(newMessage([]byte("sent 512 bytes"), source, ""))
+	assert.Equal(t, []metricSample{
+		{config.MetricTypeDistribution, "app.latency", 12.5, nil},
+		{config.MetricTypeGauge, "app.queue", 3, nil},
+		{config.MetricTypeCount, "app.bytes", 512, nil},
+	}, sender.samples)
+}
+
+func TestGenerateMetricBeforeExclusion(t *testing.T) {
+	sender := &fakeMetricSender{}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{
+		newMetricRule(t, &config.ProcessingRule{Pattern: `DEBUG`, MetricName: "app.debug"}),
+		newProcessingRule(config.ExcludeAtMatch, "", "DEBUG"),
+	}})
+	p := &Processor{metricSender: sender}
+
+	// the log is counted but not sent
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("DEBUG cache miss"), source, ""))
+	assert.False(t, shouldProcess)
+	assert.Len(t, sender.samples, 1)
+
+	// metrics are not generated without a sender
+	p = &Processor{}
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("DEBUG cache miss"), source, ""))
+	assert.False(t, shouldProcess)
+}
+
+func TestMetricCommitter(t *testing.T) {
+	sender := &fakeMetricSender{}
+	committer := NewMetricCommitter(sender, time.Millisecond)
+	committer.Start()
+	assert.Eventually(t, func() bool {
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+		return sender.commits > 0
+	}, time.Second, time.Millisecond)
+	committer.Stop()
+
+	// the metrics are committed a last time when the committer stops
+	sender = &fakeMetricSender{}
+	committer = NewMetricCommitter(sender, time.Hour)
+	committer.Start()
+	committer.Stop()
+	assert.Equal(t, 1, sender.commits)
+}
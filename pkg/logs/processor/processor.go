@@ -26,11 +26,12 @@ type Processor struct {
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	metricSender              MetricSender
 	mu                        sync.Mutex
 }
 
-// New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+// New returns an initialized Processor, metricSender is optional.
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, metricSender MetricSender) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
@@ -38,6 +39,7 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		metricSender:              metricSender,
 	}
 }
 
@@ -120,6 +122,18 @@ func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+		case config.ParseJSONLog, config.ParseKeyValueLog:
+			if rule.Regex.Match(content) {
+				content = applyParseRule(rule, msg, content)
+			}
+		case config.ParsePattern:
+			content = applyParseRule(rule, msg, content)
+		case config.RemapAttributes:
+			applyRemapRule(rule, msg)
+		case config.GenerateMetric:
+			if p.metricSender != nil {
+				applyMetricRule(rule, p.metricSender, msg, content)
+			}
 		}
 	}
 	return true, content
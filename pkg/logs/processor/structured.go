@@ -0,0 +1,293 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Well-known attributes looked up when a structured log rule does not define them.
+var (
+	defaultMessageAttributes   = []string{"message", "msg"}
+	defaultStatusAttributes    = []string{"status", "level", "severity", "log.level"}
+	defaultTimestampAttributes = []string{"timestamp", "@timestamp", "time", "ts"}
+)
+
+// applyParseRule parses the content as a structured log, stores its attributes on the message
+// and promotes the message, status and timestamp attributes.
+// Returns the new content of the message, the content is left untouched if it could not be parsed.
+func applyParseRule(rule *config.ProcessingRule, msg *message.Message, content []byte) []byte {
+	var attributes map[string]interface{}
+	switch rule.Type {
+	case config.ParseJSONLog:
+		attributes = parseJSON(content)
+	case config.ParseKeyValueLog:
+		attributes = parseKeyValue(content)
+	case config.ParsePattern:
+		attributes = parsePattern(rule, content)
+	}
+	if attributes == nil {
+		return content
+	}
+
+	if msg.Attributes == nil {
+		msg.Attributes = attributes
+	} else {
+		for key, value := range attributes {
+			msg.Attributes[key] = value
+		}
+	}
+
+	if parent, key, found := lookupAttributes(msg.Attributes, rule.StatusAttribute, defaultStatusAttributes); found {
+		if level, ok := parent[key].(string); ok {
+			if status, ok := message.LevelToStatus(level); ok {
+				msg.SetStatus(status)
+				delete(parent, key)
+			}
+		}
+	}
+
+	if parent, key, found := lookupAttributes(msg.Attributes, rule.TimestampAttribute, defaultTimestampAttributes); found {
+		if timestamp, ok := parseTimestamp(parent[key], rule.TimestampFormat); ok {
+			msg.Timestamp = timestamp.UTC()
+			delete(parent, key)
+		}
+	}
+
+	for _, path := range rule.TagAttributes {
+		if parent, key, found := lookupAttribute(msg.Attributes, path); found {
+			switch value := parent[key].(type) {
+			case string, json.Number, bool:
+				msg.Origin.AddTags(fmt.Sprintf("%s:%v", path, value))
+				delete(parent, key)
+			}
+		}
+	}
+
+	if parent, key, found := lookupAttributes(msg.Attributes, rule.MessageAttribute, defaultMessageAttributes); found {
+		if value, ok := parent[key].(string); ok {
+			delete(parent, key)
+			return []byte(value)
+		}
+	}
+	if rule.Type == config.ParsePattern {
+		// the attributes are extracted from the line which remains the message
+		return content
+	}
+	return nil
+}
+
+// applyRemapRule renames and drops the attributes of the message.
+func applyRemapRule(rule *config.ProcessingRule, msg *message.Message) {
+	if msg.Attributes == nil {
+		return
+	}
+	for from, to := range rule.Rename {
+		if parent, key, found := lookupAttribute(msg.Attributes, from); found {
+			value := parent[key]
+			delete(parent, key)
+			msg.Attributes[to] = value
+		}
+	}
+	for _, path := range rule.Drop {
+		if parent, key, found := lookupAttribute(msg.Attributes, path); found {
+			delete(parent, key)
+		}
+	}
+}
+
+// parseJSON returns the attributes of a JSON object, nil if the content is not a JSON object.
+func parseJSON(content []byte) map[string]interface{} {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	var attributes map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	// keep the exact representation of large numbers like trace ids
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
+		return nil
+	}
+	return attributes
+}
+
+// parsePattern returns the attributes captured by the pattern of the rule, nil if the content does not match.
+func parsePattern(rule *config.ProcessingRule, content []byte) map[string]interface{} {
+	submatches := rule.Regex.FindSubmatch(content)
+	if submatches == nil {
+		return nil
+	}
+	attributes := make(map[string]interface{})
+	for i, group := range rule.Regex.SubexpNames() {
+		if group == "" || submatches[i] == nil {
+			continue
+		}
+		capture, exists := rule.Captures[group]
+		if !exists {
+			capture = config.PatternCapture{Name: group, Type: config.StringCapture}
+		}
+		value := string(submatches[i])
+		switch capture.Type {
+		// numbers are re-formatted as the captured text may not be valid JSON (e.g. "+5", ".5")
+		case config.IntCapture:
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			attributes[capture.Name] = json.Number(strconv.FormatInt(i, 10))
+		case config.FloatCapture:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				continue
+			}
+			attributes[capture.Name] = json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+		default:
+			attributes[capture.Name] = value
+		}
+	}
+	return attributes
+}
+
+// parseKeyValue returns the attributes of a logfmt formatted line (`key=value key="quoted value"`),
+// nil if one of the fields of the content is not a key/value pair.
+func parseKeyValue(content []byte) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	s := string(bytes.TrimSpace(content))
+	for len(s) > 0 {
+		i := strings.IndexFunc(s, func(r rune) bool { return r == '=' || unicode.IsSpace(r) })
+		if i <= 0 || s[i] != '=' {
+			return nil
+		}
+		key := s[:i]
+		s = s[i+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := closingQuote(s)
+			if end < 0 {
+				return nil
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil
+			}
+			value = unquoted
+			s = s[end+1:]
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		attributes[key] = value
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// closingQuote returns the index of the quote closing the quoted string s starts with, -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// lookupAttributes looks up the attribute at path if set, the first default attribute found otherwise.
+func lookupAttributes(attributes map[string]interface{}, path string, defaults []string) (map[string]interface{}, string, bool) {
+	if path != "" {
+		return lookupAttribute(attributes, path)
+	}
+	for _, path := range defaults {
+		if parent, key, found := lookupAttribute(attributes, path); found {
+			return parent, key, found
+		}
+	}
+	return nil, "", false
+}
+
+// lookupAttribute returns the object holding the attribute at the dotted path and its key,
+// keys containing dots take precedence over nested objects.
+func lookupAttribute(attributes map[string]interface{}, path string) (map[string]interface{}, string, bool) {
+	if _, exists := attributes[path]; exists {
+		return attributes, path, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if child, ok := attributes[path[:i]].(map[string]interface{}); ok {
+			if parent, key, found := lookupAttribute(child, path[i+1:]); found {
+				return parent, key, found
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// parseTimestamp parses a timestamp either with the given layout, as a RFC3339 date
+// or as a unix epoch in seconds, milliseconds, microseconds or nanoseconds.
+func parseTimestamp(value interface{}, layout string) (time.Time, bool) {
+	var epoch string
+	switch v := value.(type) {
+	case json.Number:
+		epoch = v.String()
+	case string:
+		if layout != "" {
+			t, err := time.Parse(layout, v)
+			return t, err == nil
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		epoch = v
+	default:
+		return time.Time{}, false
+	}
+
+	// keep integer epochs exact, fractional ones are only expected in seconds
+	if i, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+		switch {
+		case i <= 0:
+			return time.Time{}, false
+		case i < 1e11:
+			return time.Unix(i, 0), true
+		case i < 1e14:
+			return time.Unix(0, i*int64(time.Millisecond)), true
+		case i < 1e17:
+			return time.Unix(0, i*int64(time.Microsecond)), true
+		default:
+			return time.Unix(0, i), true
+		}
+	}
+	f, err := strconv.ParseFloat(epoch, 64)
+	if err != nil || f <= 0 || f >= 1e11 {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newStructuredRule(ruleType string) *config.ProcessingRule {
+	return &config.ProcessingRule{
+		Type:  ruleType,
+		Name:  "test",
+		Regex: regexp.MustCompile(""),
+	}
+}
+
+func TestParseJSONLog(t *testing.T) {
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newStructuredRule(config.ParseJSONLog)}}}
+	p := &Processor{}
+
+	msg := newMessage([]byte(`{"level":"WARNING","msg":"disk almost full","timestamp":"2021-03-01T10:00:00.5Z","trace_id":1234567890123456789012,"disk":{"used":97}}`), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("disk almost full"), redactedMessage)
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 500000000, time.UTC), msg.Timestamp)
+	assert.Equal(t, map[string]interface{}{
+		"trace_id": json.Number("1234567890123456789012"),
+		"disk":     map[string]interface{}{"used": json.Number("97")},
+	}, msg.Attributes)
+
+	// lines which are not JSON objects are left untouched
+	msg = newMessage([]byte("disk almost full"), &source, "")
+	shouldProcess, redactedMessage = p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("disk almost full"), redactedMessage)
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	assert.Nil(t, msg.Attributes)
+}
+
+func TestParseJSONLogWithCustomAttributes(t *testing.T) {
+	rule := newStructuredRule(config.ParseJSONLog)
+	rule.MessageAttribute = "event"
+	rule.StatusAttribute = "log.severity"
+	rule.TimestampAttribute = "date"
+	rule.TimestampFormat = "2006-01-02 15:04:05"
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+	p := &Processor{}
+
+	msg := newMessage([]byte(`{"log":{"severity":"err"},"event":"user logged in","msg":"kept","date":"2021-03-01 10:00:00"}`), &source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, []byte("user logged in"), redactedMessage)
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, map[string]interface{}{
+		"log": map[string]interface{}{},
+		"msg": "kept",
+	}, msg.Attributes)
+}
+
+func TestParseKeyValueLog(t *testing.T) {
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newStructuredRule(config.ParseKeyValueLog)}}}
+	p := &Processor{}
+
+	msg := newMessage([]byte(`ts=1614592800123 level=debug msg="request \"/\" served" status_code=200 empty=`), &source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, []byte(`request "/" served`), redactedMessage)
+	assert.Equal(t, message.StatusDebug, msg.GetStatus())
+	assert.Equal(t, time.Unix(0, 1614592800123000000).UTC(), msg.Timestamp)
+	assert.Equal(t, map[string]interface{}{"status_code": "200", "empty": ""}, msg.Attributes)
+
+	for _, content := range []string{"hello world", "foo=bar baz", `foo="bar`, "=bar"} {
+		msg = newMessage([]byte(content), &source, "")
+		_, redactedMessage = p.applyRedactingRules(msg)
+		assert.Equal(t, []byte(content), redactedMessage)
+		assert.Nil(t, msg.Attributes)
+	}
+}
+
+func TestRemapAttributes(t *testing.T) {
+	remap := newStructuredRule(config.RemapAttributes)
+	remap.Rename = map[string]string{"trace_id": "dd.trace_id", "http.code": "status_code"}
+	remap.Drop = []string{"password", "kubernetes.labels"}
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newStructuredRule(config.ParseJSONLog), remap}}}
+	p := &Processor{}
+
+	msg := newMessage([]byte(`{"msg":"hello","trace_id":"123","password":"secret","http":{"code":500},"kubernetes":{"labels":{"app":"foo"},"pod":"bar"}}`), &source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, []byte("hello"), redactedMessage)
+	assert.Equal(t, map[string]interface{}{
+		"dd.trace_id": "123",
+		"status_code": json.Number("500"),
+		"http":        map[string]interface{}{},
+		"kubernetes":  map[string]interface{}{"pod": "bar"},
+	}, msg.Attributes)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, value := range []interface{}{
+		json.Number("1614592800"),
+		json.Number("1614592800000"),
+		json.Number("1614592800000000"),
+		json.Number("1614592800000000000"),
+		"1614592800",
+		"2021-03-01T11:00:00+01:00",
+	} {
+		timestamp, ok := parseTimestamp(value, "")
+		assert.True(t, ok)
+		assert.True(t, expected.Equal(timestamp), "%v", value)
+	}
+
+	for _, value := range []interface{}{"yesterday", json.Number("-1"), true, nil} {
+		_, ok := parseTimestamp(value, "")
+		assert.False(t, ok)
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	rule := &config.ProcessingRule{
+		Type:          config.ParsePattern,
+		Name:          "test",
+		Pattern:       `^%{IPORHOST:network.client.ip} - %{USER:user} \[%{HTTPDATE:date}\] "%{WORD:http.method} %{NOTSPACE:http.url}" %{INT:http.status_code:int} %{NUMBER:duration:float}`,
+		TagAttributes: []string{"http.method"},
+	}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{rule}))
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+	p := &Processor{}
+
+	content := []byte(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif" 200 0.25`)
+	msg := newMessage(content, &source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, content, redactedMessage)
+	assert.Equal(t, map[string]interface{}{
+		"network.client.ip": "127.0.0.1",
+		"user":              "frank",
+		"date":              "10/Oct/2000:13:55:36 -0700",
+		"http.url":          "/apache_pb.gif",
+		"http.status_code":  json.Number("200"),
+		"duration":          json.Number("0.25"),
+	}, msg.Attributes)
+	assert.Equal(t, []string{"http.method:GET"}, msg.Origin.Tags())
+
+	// lines which do not match are left untouched
+	msg = newMessage([]byte("hello world"), &source, "")
+	_, redactedMessage = p.applyRedactingRules(msg)
+	assert.Equal(t, []byte("hello world"), redactedMessage)
+	assert.Nil(t, msg.Attributes)
+}
+
+func TestParsePatternPromotesAttributes(t *testing.T) {
+	rule := &config.ProcessingRule{
+		Type:            config.ParsePattern,
+		Name:            "test",
+		Pattern:         `^%{TIMESTAMP_ISO8601:timestamp} \[%{LOGLEVEL:level}\] (?P<msg>.*)`,
+		TimestampFormat: "2006-01-02 15:04:05",
+	}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{rule}))
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+	p := &Processor{}
+
+	msg := newMessage([]byte("2021-03-01 10:00:00 [ERROR] connection refused"), &source, "")
+	_, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, []byte("connection refused"), redactedMessage)
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	assert.Empty(t, msg.Attributes)
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+const (
+	diskBufferExtension    = ".payload"
+	diskBufferTmpExtension = ".tmp"
+)
+
+// DiskBuffer stores on disk the payloads that could not be sent to the main destination,
+// they are replayed in the order they were stored once the destination recovers.
+// The oldest payloads are dropped when the buffer exceeds its maximum size or age.
+type DiskBuffer struct {
+	path        string
+	maxSize     int64
+	maxAge      time.Duration
+	mu          sync.Mutex
+	files       []diskBufferFile
+	currentSize int64
+	nextID      uint64
+	notify      chan struct{}
+}
+
+type diskBufferFile struct {
+	name      string
+	size      int64
+	createdAt time.Time
+}
+
+// NewDiskBuffer returns a new DiskBuffer storing payloads in path,
+// the payloads stored by a previous run of the agent are reloaded.
+func NewDiskBuffer(path string, maxSize int64, maxAge time.Duration) (*DiskBuffer, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	b := &DiskBuffer{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		notify:  make(chan struct{}, 1),
+	}
+	if err := b.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Store writes the payload to disk, the call returns once the payload is durably stored.
+func (b *DiskBuffer) Store(payload []byte) error {
+	size := int64(len(payload))
+	if size > b.maxSize {
+		return fmt.Errorf("the payload size (%d bytes) exceeds the disk buffer maximum size (%d bytes)", size, b.maxSize)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.makeRoomFor(size)
+
+	name := fmt.Sprintf("%020d%s", b.nextID, diskBufferExtension)
+	if err := writeFileSync(filepath.Join(b.path, name), payload); err != nil {
+		return err
+	}
+	b.nextID++
+	b.files = append(b.files, diskBufferFile{name: name, size: size, createdAt: time.Now()})
+	b.currentSize += size
+	updateDiskBufferTelemetry(1, size)
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// IsEmpty returns true if no payload is waiting to be replayed.
+func (b *DiskBuffer) IsEmpty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.files) == 0
+}
+
+// Notify returns a channel receiving a value when a payload is stored.
+func (b *DiskBuffer) Notify() <-chan struct{} {
+	return b.notify
+}
+
+// Peek returns the oldest payload along with the name to remove it with once it is sent,
+// outdated payloads are dropped. Returns false if the buffer is empty.
+func (b *DiskBuffer) Peek() ([]byte, string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.files) > 0 {
+		file := b.files[0]
+		if b.maxAge > 0 && time.Since(file.createdAt) > b.maxAge {
+			log.Warnf("Dropping outdated logs payload %s from the disk buffer", file.name)
+			b.drop(0)
+			continue
+		}
+		payload, err := ioutil.ReadFile(filepath.Join(b.path, file.name))
+		if err != nil {
+			log.Warnf("Could not read logs payload %s from the disk buffer, dropping it: %v", file.name, err)
+			b.drop(0)
+			continue
+		}
+		return payload, file.name, true
+	}
+	return nil, "", false
+}
+
+// Remove removes the payload returned by Peek.
+func (b *DiskBuffer) Remove(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, file := range b.files {
+		if file.name == name {
+			b.remove(i)
+			return
+		}
+	}
+}
+
+// makeRoomFor drops the oldest payloads until a payload of the given size fits in the buffer.
+func (b *DiskBuffer) makeRoomFor(size int64) {
+	for len(b.files) > 0 && b.currentSize+size > b.maxSize {
+		log.Warnf("The logs disk buffer is full, dropping payload %s", b.files[0].name)
+		b.drop(0)
+	}
+}
+
+// drop removes a payload that could not be sent.
+func (b *DiskBuffer) drop(index int) {
+	metrics.DiskBufferPayloadsDropped.Add(1)
+	metrics.TlmDiskBufferPayloadsDropped.Inc()
+	b.remove(index)
+}
+
+func (b *DiskBuffer) remove(index int) {
+	file := b.files[index]
+	if err := os.Remove(filepath.Join(b.path, file.name)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove logs payload %s from the disk buffer: %v", file.name, err)
+	}
+	b.files = append(b.files[:index], b.files[index+1:]...)
+	b.currentSize -= file.size
+	updateDiskBufferTelemetry(-1, -file.size)
+}
+
+// updateDiskBufferTelemetry reports the payloads added to or removed from a buffer,
+// the metrics are shared by the buffers of all pipelines.
+func updateDiskBufferTelemetry(payloads int64, bytes int64) {
+	metrics.DiskBufferPayloads.Add(payloads)
+	metrics.TlmDiskBufferPayloads.Add(float64(payloads))
+	metrics.DiskBufferBytes.Add(bytes)
+	metrics.TlmDiskBufferBytes.Add(float64(bytes))
+}
+
+// reloadExistingFiles loads the payloads stored by a previous run, oldest first.
+func (b *DiskBuffer) reloadExistingFiles() error {
+	entries, err := ioutil.ReadDir(b.path)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, diskBufferTmpExtension) {
+			// the agent stopped while writing this payload
+			os.Remove(filepath.Join(b.path, name)) //nolint:errcheck
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, diskBufferExtension), 10, 64)
+		if entry.IsDir() || !strings.HasSuffix(name, diskBufferExtension) || err != nil {
+			continue
+		}
+		b.files = append(b.files, diskBufferFile{name: name, size: entry.Size(), createdAt: entry.ModTime()})
+		b.currentSize += entry.Size()
+		b.nextID = id + 1
+	}
+	updateDiskBufferTelemetry(int64(len(b.files)), b.currentSize)
+	if len(b.files) > 0 {
+		log.Infof("Reloaded %d logs payloads (%d bytes) from the disk buffer %s", len(b.files), b.currentSize, b.path)
+	}
+	b.makeRoomFor(0)
+	return nil
+}
+
+// writeFileSync writes data to a temporary file that is synced and renamed to filename,
+// so that a partially written payload is never replayed.
+func writeFileSync(filename string, data []byte) error {
+	tmpFilename := filename + diskBufferTmpExtension
+	f, err := os.OpenFile(tmpFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFilename, filename)
+	}
+	if err != nil {
+		os.Remove(tmpFilename) //nolint:errcheck
+	}
+	return err
+}
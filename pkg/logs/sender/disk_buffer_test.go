@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskBufferStoresPayloadsInOrder(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	b, err := NewDiskBuffer(path, 100, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, b.IsEmpty())
+
+	require.NoError(t, b.Store([]byte("a")))
+	require.NoError(t, b.Store([]byte("b")))
+	assert.False(t, b.IsEmpty())
+
+	payload, name, ok := b.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), payload)
+
+	// payloads are kept until they are removed
+	payload, _, _ = b.Peek()
+	assert.Equal(t, []byte("a"), payload)
+	b.Remove(name)
+
+	payload, name, ok = b.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), payload)
+	b.Remove(name)
+
+	_, _, ok = b.Peek()
+	assert.False(t, ok)
+	assert.True(t, b.IsEmpty())
+}
+
+func TestDiskBufferReloadsExistingPayloads(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	b, err := NewDiskBuffer(path, 100, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, b.Store([]byte("a")))
+	require.NoError(t, b.Store([]byte("b")))
+	// a payload partially written when the agent stopped
+	require.NoError(t, ioutil.WriteFile(filepath.Join(path, "00000000000000000002.payload.tmp"), []byte("c"), 0600))
+
+	b, err = NewDiskBuffer(path, 100, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, b.Store([]byte("d")))
+
+	for _, expected := range []string{"a", "b", "d"} {
+		payload, name, ok := b.Peek()
+		assert.True(t, ok)
+		assert.Equal(t, []byte(expected), payload)
+		b.Remove(name)
+	}
+	assert.True(t, b.IsEmpty())
+
+	files, err := ioutil.ReadDir(path)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDiskBufferDropsOldestPayloadsWhenFull(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	b, err := NewDiskBuffer(path, 10, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, b.Store([]byte("aaaa")))
+	require.NoError(t, b.Store([]byte("bbbb")))
+	require.NoError(t, b.Store([]byte("cccc")))
+	assert.NotNil(t, b.Store([]byte("payload too large")))
+
+	payload, name, ok := b.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bbbb"), payload)
+	b.Remove(name)
+	payload, _, _ = b.Peek()
+	assert.Equal(t, []byte("cccc"), payload)
+}
+
+func TestDiskBufferDropsOutdatedPayloads(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	b, err := NewDiskBuffer(path, 100, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, b.Store([]byte("a")))
+	require.NoError(t, b.Store([]byte("b")))
+	b.files[0].createdAt = time.Now().Add(-2 * time.Hour)
+
+	payload, _, ok := b.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), payload)
+}
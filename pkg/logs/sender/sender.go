@@ -8,6 +8,9 @@ package sender
 import (
 	"context"
 	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -27,24 +30,49 @@ type Sender struct {
 	outputChan   chan *message.Message
 	destinations *client.Destinations
 	strategy     Strategy
+	diskBuffer   *DiskBuffer
 	done         chan struct{}
+	stopReplay   chan struct{}
+	replayDone   chan struct{}
 	mu           sync.Mutex
+	// destinations are not safe for concurrent use,
+	// the payloads of the disk buffer are replayed concurrently with the new ones.
+	sendMu sync.Mutex
 }
 
+// Retry intervals when replaying the payloads of the disk buffer.
+const (
+	minReplayRetryInterval = time.Second
+	maxReplayRetryInterval = 30 * time.Second
+)
+
 // NewSender returns a new sender.
 func NewSender(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy) *Sender {
+	return NewSenderWithDiskBuffer(inputChan, outputChan, destinations, strategy, nil)
+}
+
+// NewSenderWithDiskBuffer returns a new sender storing on disk the payloads that can not be sent
+// to the main destination instead of retrying them in memory, the messages are only forwarded
+// to the next stage of the pipeline once sent or durably stored.
+func NewSenderWithDiskBuffer(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy, diskBuffer *DiskBuffer) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
 		strategy:     strategy,
+		diskBuffer:   diskBuffer,
 		done:         make(chan struct{}),
+		stopReplay:   make(chan struct{}),
+		replayDone:   make(chan struct{}),
 	}
 }
 
 // Start starts the sender.
 func (s *Sender) Start() {
 	go s.run()
+	if s.diskBuffer != nil {
+		go s.replay()
+	}
 }
 
 // Stop stops the sender,
@@ -52,6 +80,10 @@ func (s *Sender) Start() {
 func (s *Sender) Stop() {
 	close(s.inputChan)
 	<-s.done
+	if s.diskBuffer != nil {
+		close(s.stopReplay)
+		<-s.replayDone
+	}
 }
 
 // Flush sends synchronously the messages that this sender has to send.
@@ -68,14 +100,32 @@ func (s *Sender) run() {
 
 // send sends a payload to multiple destinations,
 // it will forever retry for the main destination unless the error is not retryable
-// and only try once for additionnal destinations.
+// or the payload can be stored in the disk buffer, and only try once for additionnal destinations.
 func (s *Sender) send(payload []byte) error {
+	if s.diskBuffer != nil && !s.diskBuffer.IsEmpty() {
+		// the main destination is not available yet,
+		// store the payload behind the ones waiting to be replayed to keep them in order.
+		err := s.diskBuffer.Store(payload)
+		if err == nil {
+			s.sendAdditionals(payload)
+			return nil
+		}
+		log.Warnf("Could not store payload in the disk buffer: %v", err)
+	}
+
 	for {
-		err := s.destinations.Main.Send(payload)
+		err := s.sendMain(payload)
 		if err != nil {
 			metrics.DestinationErrors.Add(1)
 			metrics.TlmDestinationErrors.Inc()
 			if _, ok := err.(*client.RetryableError); ok {
+				if s.diskBuffer != nil {
+					storeErr := s.diskBuffer.Store(payload)
+					if storeErr == nil {
+						break
+					}
+					log.Warnf("Could not store payload in the disk buffer, retrying in memory: %v", storeErr)
+				}
 				// could not send the payload because of a client issue,
 				// let's retry
 				continue
@@ -85,13 +135,68 @@ func (s *Sender) send(payload []byte) error {
 		break
 	}
 
+	s.sendAdditionals(payload)
+	return nil
+}
+
+// sendMain sends a payload to the main destination.
+func (s *Sender) sendMain(payload []byte) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.destinations.Main.Send(payload)
+}
+
+// sendAdditionals sends a payload to the additional destinations.
+func (s *Sender) sendAdditionals(payload []byte) {
 	for _, destination := range s.destinations.Additionals {
 		// send in the background so that the agent does not fall behind
 		// for the main destination
 		destination.SendAsync(payload)
 	}
+}
 
-	return nil
+// replay sends the payloads of the disk buffer to the main destination in the order they were stored,
+// it backs off while the destination is not available.
+func (s *Sender) replay() {
+	defer close(s.replayDone)
+	retryInterval := minReplayRetryInterval
+	for {
+		payload, name, ok := s.diskBuffer.Peek()
+		if !ok {
+			select {
+			case <-s.diskBuffer.Notify():
+				continue
+			case <-s.stopReplay:
+				return
+			}
+		}
+
+		err := s.sendMain(payload)
+		if err == nil {
+			s.diskBuffer.Remove(name)
+			retryInterval = minReplayRetryInterval
+			continue
+		}
+		if shouldStopSending(err) {
+			return
+		}
+		metrics.DestinationErrors.Add(1)
+		metrics.TlmDestinationErrors.Inc()
+		if _, ok := err.(*client.RetryableError); !ok {
+			log.Warnf("Could not send payload from the disk buffer, dropping it: %v", err)
+			s.diskBuffer.Remove(name)
+			continue
+		}
+
+		select {
+		case <-time.After(retryInterval):
+		case <-s.stopReplay:
+			return
+		}
+		if retryInterval *= 2; retryInterval > maxReplayRetryInterval {
+			retryInterval = maxReplayRetryInterval
+		}
+	}
 }
 
 // shouldStopSending returns true if a component should stop sending logs.
@@ -6,7 +6,12 @@
 package sender
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -87,3 +92,63 @@ func TestSenderNotBlockedByAdditional(t *testing.T) {
 	sender.Stop()
 	destinationsCtx.Stop()
 }
+
+// flakyDestination fails to send payloads until it is made available.
+type flakyDestination struct {
+	sync.Mutex
+	available bool
+	payloads  [][]byte
+}
+
+func (d *flakyDestination) Send(payload []byte) error {
+	d.Lock()
+	defer d.Unlock()
+	if !d.available {
+		return client.NewRetryableError(errors.New("intake unavailable"))
+	}
+	d.payloads = append(d.payloads, payload)
+	return nil
+}
+
+func (d *flakyDestination) SendAsync(payload []byte) {}
+
+func (d *flakyDestination) setAvailable() {
+	d.Lock()
+	defer d.Unlock()
+	d.available = true
+}
+
+func (d *flakyDestination) sent() [][]byte {
+	d.Lock()
+	defer d.Unlock()
+	return d.payloads
+}
+
+func TestSenderWithDiskBufferReplaysPayloadsInOrder(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(path)
+	diskBuffer, err := NewDiskBuffer(path, 1000, time.Hour)
+	assert.Nil(t, err)
+
+	source := config.NewLogSource("", &config.LogsConfig{})
+	input := make(chan *message.Message, 2)
+	output := make(chan *message.Message, 2)
+	destination := &flakyDestination{}
+
+	sender := NewSenderWithDiskBuffer(input, output, client.NewDestinations(destination, nil), StreamStrategy, diskBuffer)
+	sender.Start()
+
+	// messages are forwarded to the auditor once stored on disk
+	input <- newMessage([]byte("a"), source, "")
+	input <- newMessage([]byte("b"), source, "")
+	assert.Equal(t, []byte("a"), (<-output).Content)
+	assert.Equal(t, []byte("b"), (<-output).Content)
+	assert.Empty(t, destination.sent())
+
+	destination.setAvailable()
+	assert.Eventually(t, diskBuffer.IsEmpty, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, destination.sent())
+
+	sender.Stop()
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package rewrite renames, filters and retags the metric samples of the checks and DogStatsD
+// before their aggregation, following the rules of the `metric_rewrite_rules` setting.
+package rewrite
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Rewriter applies an ordered list of rules to the metric samples. It is safe for concurrent use.
+type Rewriter struct {
+	rules []*rule
+}
+
+type rule struct {
+	name       string
+	match      *regexp.Regexp
+	matchTags  map[string]*regexp.Regexp
+	drop       bool
+	rename     string
+	removeTags map[string]struct{}
+	renameTags map[string]string
+	mergeTags  []tagValuesMerge
+	addTags    []string
+}
+
+type tagValuesMerge struct {
+	tag   string
+	match *regexp.Regexp
+	value string
+}
+
+// NewRewriter validates and compiles the rules. It returns nil if there is no rule.
+func NewRewriter(configRules []config.MetricRewriteRule) (*Rewriter, error) {
+	if len(configRules) == 0 {
+		return nil, nil
+	}
+	r := &Rewriter{}
+	for i, configRule := range configRules {
+		name := configRule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		rl := &rule{
+			name:       name,
+			drop:       configRule.Drop,
+			rename:     configRule.Rename,
+			renameTags: configRule.RenameTags,
+			addTags:    configRule.AddTags,
+		}
+		var err error
+		if configRule.Match != "" {
+			if rl.match, err = compile(configRule.Match); err != nil {
+				return nil, fmt.Errorf("rule %s: invalid match: %v", name, err)
+			}
+		}
+		if len(configRule.MatchTags) > 0 {
+			rl.matchTags = make(map[string]*regexp.Regexp, len(configRule.MatchTags))
+			for tag, pattern := range configRule.MatchTags {
+				if rl.matchTags[tag], err = compile(pattern); err != nil {
+					return nil, fmt.Errorf("rule %s: invalid match of the tag %s: %v", name, tag, err)
+				}
+			}
+		}
+		if len(configRule.RemoveTags) > 0 {
+			rl.removeTags = make(map[string]struct{}, len(configRule.RemoveTags))
+			for _, tag := range configRule.RemoveTags {
+				rl.removeTags[tag] = struct{}{}
+			}
+		}
+		for _, merge := range configRule.MergeTagValues {
+			if merge.Tag == "" {
+				return nil, fmt.Errorf("rule %s: the tag of merge_tag_values is required", name)
+			}
+			re, err := compile(merge.Match)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid match of the values of the tag %s: %v", name, merge.Tag, err)
+			}
+			rl.mergeTags = append(rl.mergeTags, tagValuesMerge{tag: merge.Tag, match: re, value: merge.Value})
+		}
+		if rl.drop && (rl.rename != "" || rl.removeTags != nil || len(rl.renameTags) > 0 || rl.mergeTags != nil || len(rl.addTags) > 0) {
+			return nil, fmt.Errorf("rule %s: a rule dropping the samples cannot rewrite them", name)
+		}
+		r.rules = append(r.rules, rl)
+	}
+	return r, nil
+}
+
+// compile compiles a pattern matching a whole name or value
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// Rewrite applies the rules to the name and the tags of a sample, each rule being applied to
+// the output of the previous ones. It returns false if the sample is dropped. The tags slice is
+// not modified: a new slice is returned when a rule changes the tags.
+func (r *Rewriter) Rewrite(name string, tags []string) (string, []string, bool) {
+	copied := false
+	for _, rl := range r.rules {
+		var submatches []int
+		if rl.match != nil {
+			if submatches = rl.match.FindStringSubmatchIndex(name); submatches == nil {
+				continue
+			}
+		}
+		if !rl.matchesTags(tags) {
+			continue
+		}
+		if rl.drop {
+			return name, tags, false
+		}
+
+		if rl.removeTags != nil || len(rl.renameTags) > 0 || rl.mergeTags != nil || len(rl.addTags) > 0 {
+			if !copied {
+				tags = append(make([]string, 0, len(tags)+len(rl.addTags)), tags...)
+				copied = true
+			}
+			tags = rl.rewriteTags(tags)
+		}
+		if rl.rename != "" {
+			if rl.match != nil {
+				name = string(rl.match.ExpandString(nil, rl.rename, name, submatches))
+			} else {
+				name = rl.rename
+			}
+		}
+	}
+	return name, tags, true
+}
+
+// matchesTags returns whether every tag pattern of the rule matches the value of a tag
+func (rl *rule) matchesTags(tags []string) bool {
+	for key, re := range rl.matchTags {
+		found := false
+		for _, tag := range tags {
+			if k, v := splitTag(tag); k == key && re.MatchString(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// rewriteTags removes, renames, merges and adds tags in place
+func (rl *rule) rewriteTags(tags []string) []string {
+	n := 0
+	for _, tag := range tags {
+		key, value := splitTag(tag)
+		if _, found := rl.removeTags[key]; found {
+			continue
+		}
+		if newKey, found := rl.renameTags[key]; found {
+			key = newKey
+			tag = joinTag(key, value, strings.IndexByte(tag, ':') >= 0)
+		}
+		for _, merge := range rl.mergeTags {
+			if merge.tag != key {
+				continue
+			}
+			if submatches := merge.match.FindStringSubmatchIndex(value); submatches != nil {
+				value = string(merge.match.ExpandString(nil, merge.value, value, submatches))
+				tag = joinTag(key, value, true)
+			}
+		}
+		tags[n] = tag
+		n++
+	}
+	return append(tags[:n], rl.addTags...)
+}
+
+func splitTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func joinTag(key, value string, hasValue bool) string {
+	if !hasValue {
+		return key
+	}
+	return key + ":" + value
+}
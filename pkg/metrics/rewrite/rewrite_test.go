@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestNewRewriterEmpty(t *testing.T) {
+	r, err := NewRewriter(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestNewRewriterErrors(t *testing.T) {
+	for _, rules := range [][]config.MetricRewriteRule{
+		{{Match: "("}},
+		{{MatchTags: map[string]string{"env": "["}}},
+		{{MergeTagValues: []config.MetricTagValuesMerge{{Match: "2.."}}}},
+		{{MergeTagValues: []config.MetricTagValuesMerge{{Tag: "status", Match: "("}}}},
+		{{Match: "a", Drop: true, AddTags: []string{"b"}}},
+	} {
+		_, err := NewRewriter(rules)
+		assert.Error(t, err, "%+v", rules)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	r, err := NewRewriter([]config.MetricRewriteRule{
+		{Match: `debug\..*`, Drop: true},
+		{MatchTags: map[string]string{"env": "test|dev"}, Drop: true},
+		{
+			Match:          `legacy\.(\w+)\.(.*)`,
+			Rename:         "app.$2",
+			RemoveTags:     []string{"user_id"},
+			RenameTags:     map[string]string{"svc": "service", "canary": "is_canary"},
+			MergeTagValues: []config.MetricTagValuesMerge{{Tag: "status_code", Match: `([1-5])\d\d`, Value: "${1}xx"}},
+			AddTags:        []string{"source:legacy"},
+		},
+		{Match: `app\..*`, MatchTags: map[string]string{"service": "web"}, AddTags: []string{"team:frontend"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		tags         []string
+		expectedName string
+		expectedTags []string
+		dropped      bool
+	}{
+		{
+			name:    "debug.metric",
+			dropped: true,
+		},
+		{
+			name:    "system.load",
+			tags:    []string{"env:dev"},
+			dropped: true,
+		},
+		{
+			name:         "system.load",
+			tags:         []string{"env:devel"},
+			expectedName: "system.load",
+			expectedTags: []string{"env:devel"},
+		},
+		{
+			name:         "legacy.api.requests.count",
+			tags:         []string{"user_id:42", "svc:web", "status_code:201", "canary", "env:prod"},
+			expectedName: "app.requests.count",
+			expectedTags: []string{"service:web", "status_code:2xx", "is_canary", "env:prod", "source:legacy", "team:frontend"},
+		},
+		{
+			name:         "legacy.api.requests.count",
+			tags:         []string{"svc:db", "status_code:abc"},
+			expectedName: "app.requests.count",
+			expectedTags: []string{"service:db", "status_code:abc", "source:legacy"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tags := append([]string(nil), test.tags...)
+			name, newTags, keep := r.Rewrite(test.name, tags)
+			assert.Equal(t, test.tags, tags, "the input tags must not be modified")
+			if test.dropped {
+				assert.False(t, keep)
+				return
+			}
+			assert.True(t, keep)
+			assert.Equal(t, test.expectedName, name)
+			assert.Equal(t, test.expectedTags, newTags)
+		})
+	}
+}
+
+func TestRewriteRenameWithoutMatch(t *testing.T) {
+	r, err := NewRewriter([]config.MetricRewriteRule{
+		{MatchTags: map[string]string{"check": "old_check"}, Rename: "new.metric"},
+	})
+	require.NoError(t, err)
+
+	name, tags, keep := r.Rewrite("old.metric", []string{"check:old_check"})
+	assert.True(t, keep)
+	assert.Equal(t, "new.metric", name)
+	assert.Equal(t, []string{"check:old_check"}, tags)
+
+	name, _, _ = r.Rewrite("old.metric", []string{"check:other_check"})
+	assert.Equal(t, "old.metric", name)
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 )
 
@@ -95,6 +96,21 @@ func (series Series) Marshal() ([]byte, error) {
 	return proto.Marshal(payload)
 }
 
+// MarshalRemoteWrite adds the timeseries to a Prometheus remote-write builder
+func (series Series) MarshalRemoteWrite(b *remotewrite.Builder) {
+	for _, serie := range series {
+		tags := serie.Tags
+		if serie.Device != "" {
+			tags = append(tags[:len(tags):len(tags)], "device:"+serie.Device)
+		}
+		samples := make([]remotewrite.Sample, 0, len(serie.Points))
+		for _, p := range serie.Points {
+			samples = append(samples, remotewrite.Sample{Value: p.Value, Timestamp: int64(p.Ts * 1000)})
+		}
+		b.AddSeries(serie.Name, serie.Host, tags, samples)
+	}
+}
+
 // MarshalStrings converts the timeseries to a sorted slice of string slices
 func (series Series) MarshalStrings() ([]string, [][]string) {
 	var headers = []string{"Metric", "Type", "Timestamp", "Value", "Tags"}
@@ -19,8 +19,10 @@ import (
 
 	agentpayload "github.com/DataDog/agent-payload/gogen"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer/stream"
 	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -59,6 +61,37 @@ func TestMarshalSeries(t *testing.T) {
 	assert.Equal(t, newPayload.Samples[0].Points[1].Value, float64(12.12))
 }
 
+func TestMarshalRemoteWriteSeries(t *testing.T) {
+	series := Series{{
+		Name:   "system.disk.free",
+		Host:   "localHost",
+		Tags:   []string{"tag1", "fs:ext4"},
+		Device: "sda1",
+		Points: []Point{{Ts: 12345, Value: 21.21}, {Ts: 12355, Value: 22.5}},
+	}}
+	b := remotewrite.NewBuilder(nil)
+	series.MarshalRemoteWrite(b)
+	require.Equal(t, 1, b.Len())
+	payloads := b.Payloads(0)
+	require.Len(t, payloads, 1)
+
+	data, err := snappy.Decode(nil, *payloads[0])
+	require.NoError(t, err)
+	expected := remotewrite.WriteRequest{Timeseries: []remotewrite.TimeSeries{{
+		Labels: []remotewrite.Label{
+			{Name: "__name__", Value: "system_disk_free"},
+			{Name: "device", Value: "sda1"},
+			{Name: "fs", Value: "ext4"},
+			{Name: "host", Value: "localHost"},
+			{Name: "tag1", Value: "true"},
+		},
+		Samples: []remotewrite.Sample{{Value: 21.21, Timestamp: 12345000}, {Value: 22.5, Timestamp: 12355000}},
+	}}}
+	assert.Equal(t, expected.Marshal(), data)
+	// the tags of the serie are not modified
+	assert.Equal(t, []string{"tag1", "fs:ext4"}, series[0].Tags)
+}
+
 func TestPopulateDeviceField(t *testing.T) {
 	for _, tc := range []struct {
 		Tags           []string
@@ -10,6 +10,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/quantile"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer/stream"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/common"
@@ -268,6 +269,17 @@ func (sl SketchSeriesList) Marshal() ([]byte, error) {
 	return pb.Marshal()
 }
 
+// MarshalRemoteWrite adds the sketches to a Prometheus remote-write builder, as summaries.
+func (sl SketchSeriesList) MarshalRemoteWrite(b *remotewrite.Builder) {
+	for _, ss := range sl {
+		points := make([]remotewrite.SketchPoint, 0, len(ss.Points))
+		for _, p := range ss.Points {
+			points = append(points, remotewrite.SketchPoint{Ts: p.Ts, Sketch: p.Sketch})
+		}
+		b.AddSketches(ss.Name, ss.Host, ss.Tags, points)
+	}
+}
+
 // SplitPayload breaks the payload into times number of pieces
 func (sl SketchSeriesList) SplitPayload(times int) ([]marshaler.Marshaler, error) {
 	// Only break it down as much as possible
@@ -19,7 +19,6 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
-	"github.com/DataDog/datadog-agent/pkg/util/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -115,34 +114,44 @@ func fetchSecret(secretsHandle []string, origin string) (map[string]string, erro
 	log.Debugf("calling secret_backend_command with payload: '%s'", jsonPayload)
 	output, err := runCommand(string(jsonPayload))
 	if err != nil {
+		setSecretsError(secretsHandle, err)
 		return nil, err
 	}
 
 	secrets := map[string]Secret{}
 	err = json.Unmarshal(output, &secrets)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
+		err = fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
+		setSecretsError(secretsHandle, err)
+		return nil, err
 	}
 
 	res := map[string]string{}
 	for _, sec := range secretsHandle {
 		v, ok := secrets[sec]
 		if ok == false {
+			setSecretError(sec, errors.New("secret handle was not decrypted by the secret_backend_command"))
 			return nil, fmt.Errorf("secret handle '%s' was not decrypted by the secret_backend_command", sec)
 		}
 
 		if v.ErrorMsg != "" {
+			setSecretError(sec, errors.New(v.ErrorMsg))
 			return nil, fmt.Errorf("an error occurred while decrypting '%s': %s", sec, v.ErrorMsg)
 		}
 		if v.Value == "" {
+			setSecretError(sec, errors.New("decrypted secret is empty"))
 			return nil, fmt.Errorf("decrypted secret for '%s' is empty", sec)
 		}
 
-		// add it to the cache
-		secretCache[sec] = v.Value
-		// keep track of place where a handle was found
-		secretOrigin[sec] = common.NewStringSet(origin)
+		setSecret(sec, v.Value, origin)
 		res[sec] = v.Value
 	}
 	return res, nil
 }
+
+// setSecretsError keeps track of an error affecting all the handles sent to the secret_backend_command
+func setSecretsError(secretsHandle []string, err error) {
+	for _, sec := range secretsHandle {
+		setSecretError(sec, err)
+	}
+}
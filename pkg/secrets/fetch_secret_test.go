@@ -147,6 +147,7 @@ func TestFetchSecretExecError(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	runCommand = func(string) ([]byte, error) { return nil, fmt.Errorf("some error") }
@@ -158,6 +159,7 @@ func TestFetchSecretUnmarshalError(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	runCommand = func(string) ([]byte, error) { return []byte("{"), nil }
@@ -169,6 +171,7 @@ func TestFetchSecretMissingSecret(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	secrets := []string{"handle1", "handle2"}
@@ -183,6 +186,7 @@ func TestFetchSecretErrorForHandle(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	runCommand = func(string) ([]byte, error) {
@@ -191,12 +195,14 @@ func TestFetchSecretErrorForHandle(t *testing.T) {
 	_, err := fetchSecret([]string{"handle1"}, "test")
 	assert.NotNil(t, err)
 	assert.Equal(t, "an error occurred while decrypting 'handle1': some error", err.Error())
+	assert.Equal(t, map[string]string{"handle1": "some error"}, secretErrors)
 }
 
 func TestFetchSecretEmptyValue(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	runCommand = func(string) ([]byte, error) {
@@ -218,6 +224,7 @@ func TestFetchSecret(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 	}()
 
 	secrets := []string{"handle1", "handle2"}
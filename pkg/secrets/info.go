@@ -14,27 +14,38 @@ import (
 
 // SecretInfo export troubleshooting information about the decrypted secrets
 type SecretInfo struct {
-	ExecutablePath string
-	Rights         string
-	RightDetails   string
-	UnixOwner      string
-	UnixGroup      string
-	SecretsHandles map[string][]string
+	ExecutablePath  string
+	Rights          string
+	RightDetails    string
+	UnixOwner       string
+	UnixGroup       string
+	NativeResolvers []string
+	SecretsHandles  map[string][]string
+	SecretsErrors   map[string]string
 }
 
 // Print output a SecretInfo to a io.Writer
 func (si *SecretInfo) Print(w io.Writer) {
-	fmt.Fprintf(w, "=== Checking executable rights ===\n")
-	fmt.Fprintf(w, "Executable path: %s\n", si.ExecutablePath)
+	if si.ExecutablePath != "" {
+		fmt.Fprintf(w, "=== Checking executable rights ===\n")
+		fmt.Fprintf(w, "Executable path: %s\n", si.ExecutablePath)
 
-	fmt.Fprintf(w, "Check Rights: %s\n", si.Rights)
+		fmt.Fprintf(w, "Check Rights: %s\n", si.Rights)
 
-	fmt.Fprintf(w, "\nRights Detail:\n")
-	fmt.Fprintf(w, "%s\n", si.RightDetails)
+		fmt.Fprintf(w, "\nRights Detail:\n")
+		fmt.Fprintf(w, "%s\n", si.RightDetails)
 
-	if runtime.GOOS != "windows" {
-		fmt.Fprintf(w, "Owner username: %s\n", si.UnixOwner)
-		fmt.Fprintf(w, "Group name: %s\n", si.UnixGroup)
+		if runtime.GOOS != "windows" {
+			fmt.Fprintf(w, "Owner username: %s\n", si.UnixOwner)
+			fmt.Fprintf(w, "Group name: %s\n", si.UnixGroup)
+		}
+	} else {
+		fmt.Fprintf(w, "=== No secret_backend_command set ===\n")
+	}
+
+	if len(si.NativeResolvers) != 0 {
+		fmt.Fprintf(w, "\n=== Native resolvers ===\n")
+		fmt.Fprintf(w, "Enabled resolvers: %s\n", strings.Join(si.NativeResolvers, ", "))
 	}
 
 	fmt.Fprintf(w, "\n=== Secrets stats ===\n")
@@ -43,4 +54,12 @@ func (si *SecretInfo) Print(w io.Writer) {
 	for handle, origins := range si.SecretsHandles {
 		fmt.Fprintf(w, "- %s: from %s\n", handle, strings.Join(origins, ", "))
 	}
+
+	if len(si.SecretsErrors) != 0 {
+		fmt.Fprintf(w, "\n=== Secrets errors ===\n")
+		fmt.Fprintf(w, "Number of secrets in error: %d\n", len(si.SecretsErrors))
+		for handle, errMsg := range si.SecretsErrors {
+			fmt.Fprintf(w, "- %s: %s\n", handle, errMsg)
+		}
+	}
 }
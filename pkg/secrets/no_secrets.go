@@ -17,7 +17,8 @@ import (
 var SecretBackendOutputMaxSize = 1024 * 1024
 
 // Init placeholder when compiled without the 'secrets' build tag
-func Init(command string, arguments []string, timeout int, maxSize int, groupExecPerm bool) {}
+func Init(command string, arguments []string, timeout int, maxSize int, groupExecPerm bool, resolvers NativeResolversConfig) {
+}
 
 // Decrypt encrypted secrets are not available on windows
 func Decrypt(data []byte, origin string) ([]byte, error) {
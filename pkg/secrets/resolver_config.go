@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrets
+
+// NativeResolversConfig holds the configuration of the secret resolvers built
+// in the agent. A handle is resolved natively when it's prefixed by the name
+// of an enabled resolver, e.g. "ENC[file:/etc/secrets/password]".
+type NativeResolversConfig struct {
+	// Enabled is the list of the resolvers allowed to resolve secrets
+	Enabled   []string
+	K8sSecret K8sSecretResolverConfig
+	Vault     VaultResolverConfig
+}
+
+// K8sSecretResolverConfig configures the "k8s_secret" resolver, which reads
+// Kubernetes secrets from the API server with the service account of the agent
+type K8sSecretResolverConfig struct {
+	// APIServerURL defaults to the in-cluster API server address
+	APIServerURL string
+	TokenPath    string
+	CAPath       string
+}
+
+// VaultResolverConfig configures the "vault" resolver
+type VaultResolverConfig struct {
+	// Address defaults to the VAULT_ADDR environment variable
+	Address string
+	// AuthMethod is either "token" or "kubernetes"
+	AuthMethod string
+	// Token is read from TokenPath when empty, then from the VAULT_TOKEN
+	// environment variable
+	Token     string
+	TokenPath string
+	// Kubernetes auth method settings
+	KubernetesRole      string
+	KubernetesMount     string
+	KubernetesTokenPath string
+	CAPath              string
+}
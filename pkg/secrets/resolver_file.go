@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// resolveFile returns the content of the file at path, as the secret-helper
+// does for the files of its directory: the content is used as is.
+func resolveFile(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path '%s' is not absolute", path)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("secret file '%s' does not exist", path)
+		}
+		return "", err
+	}
+	if fi.IsDir() {
+		return "", fmt.Errorf("'%s' is a directory", path)
+	}
+	if fi.Size() > int64(SecretBackendOutputMaxSize) {
+		return "", fmt.Errorf("secret file '%s' exceeds max allowed size: %d bytes", path, SecretBackendOutputMaxSize)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFile(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	require.NoError(t, ioutil.WriteFile(path, []byte("password1"), 0600))
+
+	value, err := resolveFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+
+	_, err = resolveFile("password")
+	assert.EqualError(t, err, "path 'password' is not absolute")
+
+	_, err = resolveFile(filepath.Join(dir, "missing"))
+	assert.EqualError(t, err, "secret file '"+filepath.Join(dir, "missing")+"' does not exist")
+
+	_, err = resolveFile(dir)
+	assert.EqualError(t, err, "'"+dir+"' is a directory")
+
+	SecretBackendOutputMaxSize = 4
+	_, err = resolveFile(path)
+	assert.EqualError(t, err, "secret file '"+path+"' exceeds max allowed size: 4 bytes")
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// k8sSecretResolver reads a key of a Kubernetes secret, referenced as
+// <namespace>/<name>/<key>, from the API server
+type k8sSecretResolver struct {
+	cfg K8sSecretResolverConfig
+}
+
+func newK8sSecretResolver(cfg K8sSecretResolverConfig) *k8sSecretResolver {
+	return &k8sSecretResolver{cfg: cfg}
+}
+
+func (r *k8sSecretResolver) resolve(ref string) (string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("invalid reference '%s', expected <namespace>/<name>/<key>", ref)
+	}
+	namespace, name, key := parts[0], parts[1], parts[2]
+
+	apiServerURL, err := r.apiServerURL()
+	if err != nil {
+		return "", err
+	}
+	token, err := readTokenFile(r.cfg.TokenPath)
+	if err != nil {
+		return "", err
+	}
+	client, err := newResolverHTTPClient(r.cfg.CAPath)
+	if err != nil {
+		return "", err
+	}
+
+	secretURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", apiServerURL, url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequest(http.MethodGet, secretURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not query the API server: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(SecretBackendOutputMaxSize)))
+	if err != nil {
+		return "", fmt.Errorf("could not read the API server response: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Errors are returned as a Status object
+		var status struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &status); err == nil && status.Message != "" {
+			return "", fmt.Errorf("unexpected status code %d from the API server: %s", resp.StatusCode, status.Message)
+		}
+		return "", fmt.Errorf("unexpected status code %d from the API server", resp.StatusCode)
+	}
+
+	// The values are base64 encoded, which is decoded along with the []byte
+	var secret struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("could not unmarshal secret %s/%s: %s", namespace, name, err)
+	}
+	value, found := secret.Data[key]
+	if !found {
+		return "", fmt.Errorf("key '%s' not found in secret %s/%s", key, namespace, name)
+	}
+	return string(value), nil
+}
+
+// apiServerURL defaults to the address of the API server exposed to the pods
+func (r *k8sSecretResolver) apiServerURL() (string, error) {
+	if r.cfg.APIServerURL != "" {
+		return strings.TrimSuffix(r.cfg.APIServerURL, "/"), nil
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return "", errors.New("no API server URL set and not running in a Kubernetes pod")
+	}
+	return "https://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestToken(t *testing.T, token string) (string, func()) {
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte(token+"\n"), 0600))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestK8sSecretResolver(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	tokenPath, cleanup := writeTestToken(t, "sa-token")
+	defer cleanup()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/secrets/datadog":
+			// "cGFzc3dvcmQx" is "password1"
+			w.Write([]byte(`{"kind":"Secret","data":{"password":"cGFzc3dvcmQx"}}`)) //nolint:errcheck
+		case "/api/v1/namespaces/default/secrets/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"kind":"Status","message":"secrets \"forbidden\" is forbidden"}`)) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	resolver := newK8sSecretResolver(K8sSecretResolverConfig{APIServerURL: ts.URL + "/", TokenPath: tokenPath})
+
+	value, err := resolver.resolve("default/datadog/password")
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+
+	_, err = resolver.resolve("default/datadog/user")
+	assert.EqualError(t, err, "key 'user' not found in secret default/datadog")
+
+	_, err = resolver.resolve("default/forbidden/password")
+	assert.EqualError(t, err, `unexpected status code 403 from the API server: secrets "forbidden" is forbidden`)
+
+	_, err = resolver.resolve("default/missing/password")
+	assert.EqualError(t, err, "unexpected status code 404 from the API server")
+
+	for _, ref := range []string{"datadog/password", "default//password", "default/datadog/password/extra"} {
+		_, err = resolver.resolve(ref)
+		assert.EqualError(t, err, "invalid reference '"+ref+"', expected <namespace>/<name>/<key>")
+	}
+
+	resolver = newK8sSecretResolver(K8sSecretResolverConfig{APIServerURL: ts.URL, TokenPath: tokenPath + ".missing"})
+	_, err = resolver.resolve("default/datadog/password")
+	assert.Error(t, err)
+}
+
+func TestK8sSecretResolverAPIServerURL(t *testing.T) {
+	defer func(host, port string) {
+		os.Setenv("KUBERNETES_SERVICE_HOST", host)
+		os.Setenv("KUBERNETES_SERVICE_PORT", port)
+	}(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+
+	resolver := newK8sSecretResolver(K8sSecretResolverConfig{})
+
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	_, err := resolver.apiServerURL()
+	assert.EqualError(t, err, "no API server URL set and not running in a Kubernetes pod")
+
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	os.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	apiServerURL, err := resolver.apiServerURL()
+	require.NoError(t, err)
+	assert.Equal(t, "https://10.96.0.1:443", apiServerURL)
+}
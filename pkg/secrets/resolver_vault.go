@@ -0,0 +1,242 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authentication methods supported by the vault resolver
+const (
+	vaultTokenAuth      = "token"
+	vaultKubernetesAuth = "kubernetes"
+)
+
+// vaultResolver reads a key of a Vault secret, referenced as <path>#<key>.
+// Both KV version 1 and 2 secrets engines are supported, the path of the
+// secrets of the latter includes "data/" (e.g. secret/data/datadog#password).
+type vaultResolver struct {
+	cfg VaultResolverConfig
+
+	// token obtained with the kubernetes auth method, a zero expiration
+	// means that the token does not expire
+	m               sync.Mutex
+	token           string
+	tokenExpiration time.Time
+}
+
+// vaultStatusError is returned when Vault answers with an unexpected status code
+type vaultStatusError struct {
+	statusCode int
+	errors     []string
+}
+
+func (e *vaultStatusError) Error() string {
+	if len(e.errors) != 0 {
+		return fmt.Sprintf("unexpected status code %d from Vault: %s", e.statusCode, strings.Join(e.errors, ", "))
+	}
+	return fmt.Sprintf("unexpected status code %d from Vault", e.statusCode)
+}
+
+func newVaultResolver(cfg VaultResolverConfig) *vaultResolver {
+	return &vaultResolver{cfg: cfg}
+}
+
+func (r *vaultResolver) resolve(ref string) (string, error) {
+	idx := strings.LastIndex(ref, "#")
+	if idx <= 0 || idx == len(ref)-1 {
+		return "", fmt.Errorf("invalid reference '%s', expected <path>#<key>", ref)
+	}
+	path, key := strings.Trim(ref[:idx], "/"), ref[idx+1:]
+
+	address := r.cfg.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", errors.New("no Vault address set")
+	}
+	address = strings.TrimSuffix(address, "/")
+
+	client, err := newResolverHTTPClient(r.cfg.CAPath)
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := r.readSecret(client, address, path, &secret); err != nil {
+		return "", err
+	}
+
+	data := secret.Data
+	// KV version 2 secrets engines nest the key/value pairs with their metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, found := data["metadata"]; found {
+			data = nested
+		}
+	}
+	value, found := data[key]
+	if !found {
+		return "", fmt.Errorf("key '%s' not found in secret '%s'", key, path)
+	}
+	strValue, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("value of key '%s' in secret '%s' is not a string", key, path)
+	}
+	return strValue, nil
+}
+
+// readSecret reads the secret at path into out. A token obtained with the kubernetes auth
+// method that is denied by Vault (e.g. revoked before the end of its lease) is renewed once.
+func (r *vaultResolver) readSecret(client *http.Client, address string, path string, out interface{}) error {
+	token, err := r.getToken(client, address)
+	if err != nil {
+		return err
+	}
+	err = doVaultSecretRequest(client, address, path, token, out)
+	if statusErr, ok := err.(*vaultStatusError); ok && statusErr.statusCode == http.StatusForbidden && r.cfg.AuthMethod == vaultKubernetesAuth {
+		r.clearToken(token)
+		if token, err = r.getToken(client, address); err != nil {
+			return err
+		}
+		err = doVaultSecretRequest(client, address, path, token, out)
+	}
+	return err
+}
+
+// getToken returns the token to authenticate to Vault with
+func (r *vaultResolver) getToken(client *http.Client, address string) (string, error) {
+	switch r.cfg.AuthMethod {
+	case "", vaultTokenAuth:
+		if r.cfg.Token != "" {
+			return r.cfg.Token, nil
+		}
+		if r.cfg.TokenPath != "" {
+			return readTokenFile(r.cfg.TokenPath)
+		}
+		if token := os.Getenv("VAULT_TOKEN"); token != "" {
+			return token, nil
+		}
+		return "", errors.New("no Vault token set")
+	case vaultKubernetesAuth:
+		return r.kubernetesLogin(client, address)
+	default:
+		return "", fmt.Errorf("unknown Vault auth method '%s'", r.cfg.AuthMethod)
+	}
+}
+
+// kubernetesLogin exchanges the service account token of the agent for a
+// Vault token, which is reused until most of its lease has elapsed
+func (r *vaultResolver) kubernetesLogin(client *http.Client, address string) (string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.token != "" && (r.tokenExpiration.IsZero() || time.Now().Before(r.tokenExpiration)) {
+		return r.token, nil
+	}
+
+	if r.cfg.KubernetesRole == "" {
+		return "", errors.New("no Vault role set for the kubernetes auth method")
+	}
+	jwt, err := readTokenFile(r.cfg.KubernetesTokenPath)
+	if err != nil {
+		return "", err
+	}
+	mount := strings.Trim(r.cfg.KubernetesMount, "/")
+	if mount == "" {
+		mount = vaultKubernetesAuth
+	}
+
+	payload, err := json.Marshal(map[string]string{"role": r.cfg.KubernetesRole, "jwt": jwt})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, address+"/v1/auth/"+mount+"/login", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := doVaultRequest(client, req, &login); err != nil {
+		return "", fmt.Errorf("could not log in to Vault: %s", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", errors.New("could not log in to Vault: no token returned")
+	}
+
+	r.token = login.Auth.ClientToken
+	r.tokenExpiration = time.Time{}
+	// a zero lease duration is given to the tokens that do not expire
+	if login.Auth.LeaseDuration > 0 {
+		r.tokenExpiration = time.Now().Add(time.Duration(login.Auth.LeaseDuration) * time.Second * 4 / 5)
+	}
+	return r.token, nil
+}
+
+// clearToken forgets the token obtained with the kubernetes auth method, unless
+// it has already been renewed
+func (r *vaultResolver) clearToken(token string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.token == token {
+		r.token = ""
+	}
+}
+
+// doVaultSecretRequest reads the secret at path into out
+func doVaultSecretRequest(client *http.Client, address string, path string, token string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, address+"/v1/"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	return doVaultRequest(client, req, out)
+}
+
+// doVaultRequest sends a request to Vault and unmarshals its response into out
+func doVaultRequest(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not query Vault: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(SecretBackendOutputMaxSize)))
+	if err != nil {
+		return fmt.Errorf("could not read the Vault response: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(body, &vaultErr) //nolint:errcheck
+		return &vaultStatusError{statusCode: resp.StatusCode, errors: vaultErr.Errors}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("could not unmarshal the Vault response: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVaultServer serves the secrets of a KV version 1 and 2 secrets engine, and
+// tokens for the kubernetes auth method mounted on "k8s"
+type testVaultServer struct {
+	*httptest.Server
+	// leaseDuration of the tokens returned by the kubernetes auth method
+	leaseDuration int
+	logins        int
+	revoked       map[string]bool
+}
+
+func newTestVaultServer(t *testing.T) *testVaultServer {
+	s := &testVaultServer{leaseDuration: 3600, revoked: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/k8s/login" {
+			var login map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&login))
+			if login["role"] != "datadog" || login["jwt"] != "sa-token" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid role name"]}`)) //nolint:errcheck
+				return
+			}
+			s.logins++
+			fmt.Fprintf(w, `{"auth":{"client_token":"login-token-%d","lease_duration":%d}}`, s.logins, s.leaseDuration)
+			return
+		}
+
+		if token := r.Header.Get("X-Vault-Token"); s.revoked[token] || (token != "vault-token" && !strings.HasPrefix(token, "login-token-")) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`)) //nolint:errcheck
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/datadog":
+			// KV version 1
+			w.Write([]byte(`{"data":{"password":"password1","port":5432}}`)) //nolint:errcheck
+		case "/v1/secret/data/datadog":
+			// KV version 2
+			w.Write([]byte(`{"data":{"data":{"password":"password2"},"metadata":{"version":3}}}`)) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+		}
+	}))
+	return s
+}
+
+func newTestKubernetesVaultResolver(t *testing.T, address string, role string) *vaultResolver {
+	tokenPath, cleanup := writeTestToken(t, "sa-token")
+	t.Cleanup(cleanup)
+	return newVaultResolver(VaultResolverConfig{
+		Address:             address,
+		AuthMethod:          "kubernetes",
+		KubernetesRole:      role,
+		KubernetesMount:     "k8s",
+		KubernetesTokenPath: tokenPath,
+	})
+}
+
+func TestVaultResolverTokenAuth(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	ts := newTestVaultServer(t)
+	defer ts.Close()
+
+	resolver := newVaultResolver(VaultResolverConfig{Address: ts.URL, Token: "vault-token"})
+
+	value, err := resolver.resolve("secret/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+
+	value, err = resolver.resolve("/secret/data/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, "password2", value)
+
+	_, err = resolver.resolve("secret/datadog#user")
+	assert.EqualError(t, err, "key 'user' not found in secret 'secret/datadog'")
+
+	_, err = resolver.resolve("secret/datadog#port")
+	assert.EqualError(t, err, "value of key 'port' in secret 'secret/datadog' is not a string")
+
+	_, err = resolver.resolve("secret/missing#password")
+	assert.EqualError(t, err, "unexpected status code 404 from Vault")
+
+	for _, ref := range []string{"secret/datadog", "#password", "secret/datadog#"} {
+		_, err = resolver.resolve(ref)
+		assert.EqualError(t, err, "invalid reference '"+ref+"', expected <path>#<key>")
+	}
+
+	tokenPath, cleanup := writeTestToken(t, "wrong-token")
+	defer cleanup()
+	resolver = newVaultResolver(VaultResolverConfig{Address: ts.URL, TokenPath: tokenPath})
+	_, err = resolver.resolve("secret/datadog#password")
+	assert.EqualError(t, err, "unexpected status code 403 from Vault: permission denied")
+
+	resolver = newVaultResolver(VaultResolverConfig{Address: ts.URL, AuthMethod: "ldap"})
+	_, err = resolver.resolve("secret/datadog#password")
+	assert.EqualError(t, err, "unknown Vault auth method 'ldap'")
+}
+
+func TestVaultResolverKubernetesAuth(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	ts := newTestVaultServer(t)
+	defer ts.Close()
+
+	resolver := newTestKubernetesVaultResolver(t, ts.URL, "datadog")
+	value, err := resolver.resolve("secret/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+
+	// The token is reused until its lease is almost over
+	value, err = resolver.resolve("secret/data/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, "password2", value)
+	assert.Equal(t, 1, ts.logins)
+
+	resolver.tokenExpiration = time.Now().Add(-time.Second)
+	_, err = resolver.resolve("secret/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ts.logins)
+
+	resolver = newTestKubernetesVaultResolver(t, ts.URL, "other")
+	_, err = resolver.resolve("secret/datadog#password")
+	assert.EqualError(t, err, "could not log in to Vault: unexpected status code 400 from Vault: invalid role name")
+}
+
+func TestVaultResolverKubernetesAuthNoLease(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	ts := newTestVaultServer(t)
+	defer ts.Close()
+	// the tokens which do not expire have no lease
+	ts.leaseDuration = 0
+
+	resolver := newTestKubernetesVaultResolver(t, ts.URL, "datadog")
+	for i := 0; i < 2; i++ {
+		value, err := resolver.resolve("secret/datadog#password")
+		require.NoError(t, err)
+		assert.Equal(t, "password1", value)
+	}
+	assert.Equal(t, 1, ts.logins)
+	assert.True(t, resolver.tokenExpiration.IsZero())
+}
+
+func TestVaultResolverKubernetesAuthRevokedToken(t *testing.T) {
+	defer func(maxSize int) { SecretBackendOutputMaxSize = maxSize }(SecretBackendOutputMaxSize)
+	SecretBackendOutputMaxSize = 1024
+
+	ts := newTestVaultServer(t)
+	defer ts.Close()
+
+	resolver := newTestKubernetesVaultResolver(t, ts.URL, "datadog")
+	_, err := resolver.resolve("secret/datadog#password")
+	require.NoError(t, err)
+
+	// A token denied by Vault is renewed once
+	ts.revoked["login-token-1"] = true
+	value, err := resolver.resolve("secret/datadog#password")
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+	assert.Equal(t, 2, ts.logins)
+	assert.Equal(t, "login-token-2", resolver.token)
+
+	// The request is not retried more than once
+	ts.revoked["login-token-2"] = true
+	ts.revoked["login-token-3"] = true
+	_, err = resolver.resolve("secret/datadog#password")
+	assert.EqualError(t, err, "unexpected status code 403 from Vault: permission denied")
+	assert.Equal(t, 3, ts.logins)
+}
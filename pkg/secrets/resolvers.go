@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Names of the resolvers built in the agent, used as prefix of the handles
+const (
+	fileResolverName      = "file"
+	k8sSecretResolverName = "k8s_secret"
+	vaultResolverName     = "vault"
+)
+
+// secretResolver returns the secret referenced by ref in its backend
+type secretResolver func(ref string) (string, error)
+
+var nativeResolvers = map[string]secretResolver{}
+
+func initNativeResolvers(cfg NativeResolversConfig) {
+	nativeResolvers = map[string]secretResolver{}
+	for _, name := range cfg.Enabled {
+		switch name {
+		case fileResolverName:
+			nativeResolvers[name] = resolveFile
+		case k8sSecretResolverName:
+			nativeResolvers[name] = newK8sSecretResolver(cfg.K8sSecret).resolve
+		case vaultResolverName:
+			nativeResolvers[name] = newVaultResolver(cfg.Vault).resolve
+		default:
+			log.Warnf("Unknown secret resolver '%s', ignoring it", name)
+		}
+	}
+}
+
+// nativeResolverNames returns the sorted names of the enabled native resolvers
+func nativeResolverNames() []string {
+	names := make([]string, 0, len(nativeResolvers))
+	for name := range nativeResolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getNativeResolver returns the resolver enabled for the prefix of a handle,
+// along with the reference of the secret in its backend
+func getNativeResolver(handle string) (secretResolver, string, bool) {
+	idx := strings.Index(handle, ":")
+	if idx <= 0 {
+		return nil, "", false
+	}
+	resolver, found := nativeResolvers[handle[:idx]]
+	return resolver, handle[idx+1:], found
+}
+
+// resolveSecrets resolves the handles prefixed by the name of an enabled
+// native resolver and fetches the other ones with the secret_backend_command.
+// Every handle is attempted, the errors are reported together.
+func resolveSecrets(secretsHandle []string, origin string) (map[string]string, error) {
+	res := map[string]string{}
+	var errs []string
+	var commandHandles []string
+
+	for _, handle := range secretsHandle {
+		if _, found := res[handle]; found {
+			continue
+		}
+		resolver, ref, found := getNativeResolver(handle)
+		if !found {
+			commandHandles = append(commandHandles, handle)
+			continue
+		}
+
+		value, err := resolver(ref)
+		if err == nil && value == "" {
+			err = errors.New("decrypted secret is empty")
+		}
+		if err != nil {
+			setSecretError(handle, err)
+			errs = append(errs, fmt.Sprintf("an error occurred while decrypting '%s': %s", handle, err))
+			continue
+		}
+		setSecret(handle, value, origin)
+		res[handle] = value
+	}
+
+	if len(commandHandles) != 0 {
+		if secretBackendCommand == "" {
+			for _, handle := range commandHandles {
+				err := errors.New("no secret_backend_command set and no native resolver enabled for this handle")
+				setSecretError(handle, err)
+				errs = append(errs, fmt.Sprintf("could not decrypt '%s': %s", handle, err))
+			}
+		} else {
+			secrets, err := fetchSecret(commandHandles, origin)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			for handle, value := range secrets {
+				res[handle] = value
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return res, nil
+}
+
+// setSecret adds a decrypted secret to the cache
+func setSecret(handle, value, origin string) {
+	secretCache[handle] = value
+	// keep track of place where a handle was found
+	secretOrigin[handle] = common.NewStringSet(origin)
+	delete(secretErrors, handle)
+}
+
+// setSecretError keeps track of the last error for a handle, to be reported by GetDebugInfo
+func setSecretError(handle string, err error) {
+	secretErrors[handle] = err.Error()
+}
+
+// newResolverHTTPClient returns an HTTP client for the resolvers querying an
+// API, trusting the CA certificate at caPath if set
+func newResolverHTTPClient(caPath string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caPath != "" {
+		caCert, err := ioutil.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA certificate: %s", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate found in '%s'", caPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: certPool}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(secretBackendTimeout) * time.Second,
+	}, nil
+}
+
+// readTokenFile returns the token stored in a file. It's read at every use as
+// tokens like the ones of Kubernetes service accounts are rotated.
+func readTokenFile(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("no token file set")
+	}
+	token, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %s", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+)
+
+var testConfNative = []byte(`---
+instances:
+- password: ENC[file:/secrets/password]
+  user: ENC[user]
+`)
+
+func resetNativeResolvers() {
+	secretBackendCommand = ""
+	nativeResolvers = map[string]secretResolver{}
+	secretCache = map[string]string{}
+	secretOrigin = map[string]common.StringSet{}
+	secretErrors = map[string]string{}
+	runCommand = execCommand
+}
+
+func TestInitNativeResolvers(t *testing.T) {
+	defer resetNativeResolvers()
+
+	initNativeResolvers(NativeResolversConfig{Enabled: []string{"vault", "unknown", "file"}})
+	assert.Equal(t, []string{"file", "vault"}, nativeResolverNames())
+
+	_, ref, found := getNativeResolver("file:/secrets/password")
+	assert.True(t, found)
+	assert.Equal(t, "/secrets/password", ref)
+
+	// Handles are sent to the command when their resolver isn't enabled
+	_, _, found = getNativeResolver("k8s_secret:default/datadog/api_key")
+	assert.False(t, found)
+	_, _, found = getNativeResolver("password")
+	assert.False(t, found)
+	_, _, found = getNativeResolver(":password")
+	assert.False(t, found)
+}
+
+func TestResolveSecretsDispatch(t *testing.T) {
+	defer resetNativeResolvers()
+
+	secretBackendCommand = "some_command"
+	nativeResolvers = map[string]secretResolver{
+		"file": func(ref string) (string, error) {
+			assert.Equal(t, "/secrets/password", ref)
+			return "password1", nil
+		},
+	}
+	runCommand = func(payload string) ([]byte, error) {
+		assert.JSONEq(t, `{"version":"1.0","secrets":["user"]}`, payload)
+		return []byte(`{"user":{"value":"user1"}}`), nil
+	}
+
+	newConf, err := Decrypt(testConfNative, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "instances:\n- password: password1\n  user: user1\n", string(newConf))
+	assert.Equal(t, "password1", secretCache["file:/secrets/password"])
+	assert.Equal(t, []string{"test"}, secretOrigin["file:/secrets/password"].GetAll())
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	defer resetNativeResolvers()
+
+	nativeResolvers = map[string]secretResolver{
+		"file": func(ref string) (string, error) {
+			return "", nil
+		},
+	}
+
+	// Every handle is attempted, the errors are reported per handle
+	_, err := Decrypt(testConfNative, "test")
+	assert.EqualError(t, err, "an error occurred while decrypting 'file:/secrets/password': decrypted secret is empty; "+
+		"could not decrypt 'user': no secret_backend_command set and no native resolver enabled for this handle")
+	assert.Empty(t, secretCache)
+
+	info, err := GetDebugInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "", info.ExecutablePath)
+	assert.Equal(t, []string{"file"}, info.NativeResolvers)
+	assert.Equal(t, map[string]string{
+		"file:/secrets/password": "decrypted secret is empty",
+		"user":                   "no secret_backend_command set and no native resolver enabled for this handle",
+	}, info.SecretsErrors)
+
+	// The error is cleared once the secret is decrypted
+	nativeResolvers["file"] = func(ref string) (string, error) {
+		return "password1", nil
+	}
+	secretBackendCommand = "some_command"
+	runCommand = func(string) ([]byte, error) {
+		return []byte(`{"user":{"value":"user1"}}`), nil
+	}
+	_, err = Decrypt(testConfNative, "test")
+	require.NoError(t, err)
+
+	info, err = GetDebugInfo()
+	require.NoError(t, err)
+	assert.Empty(t, info.SecretsErrors)
+	assert.Len(t, info.SecretsHandles, 2)
+}
@@ -21,6 +21,8 @@ var (
 	secretCache map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
+	// last error for the handles that couldn't be decrypted
+	secretErrors map[string]string
 
 	secretBackendCommand               string
 	secretBackendArguments             []string
@@ -34,12 +36,13 @@ var (
 func init() {
 	secretCache = make(map[string]string)
 	secretOrigin = make(map[string]common.StringSet)
+	secretErrors = make(map[string]string)
 }
 
-// Init initializes the command and other options of the secrets package. Since
-// this package is used by the 'config' package to decrypt itself we can't
-// directly use it.
-func Init(command string, arguments []string, timeout int, maxSize int, groupExecPerm bool) {
+// Init initializes the command, the native resolvers and other options of the
+// secrets package. Since this package is used by the 'config' package to
+// decrypt itself we can't directly use it.
+func Init(command string, arguments []string, timeout int, maxSize int, groupExecPerm bool, resolvers NativeResolversConfig) {
 	secretBackendCommand = command
 	secretBackendArguments = arguments
 	secretBackendTimeout = timeout
@@ -48,6 +51,12 @@ func Init(command string, arguments []string, timeout int, maxSize int, groupExe
 	if secretBackendCommandAllowGroupExec {
 		log.Warnf("Agent configuration relax permissions constraint on the secret backend cmd, Group can read and exec")
 	}
+	initNativeResolvers(resolvers)
+}
+
+// isEnabled returns true if secrets can be decrypted by a command or a native resolver
+func isEnabled() bool {
+	return secretBackendCommand != "" || len(nativeResolvers) != 0
 }
 
 type walkerCallback func(string) (string, error)
@@ -124,12 +133,13 @@ func isEnc(str string) (bool, string) {
 }
 
 // testing purpose
-var secretFetcher = fetchSecret
+var secretFetcher = resolveSecrets
 
-// Decrypt replaces all encrypted secrets in data by executing
-// "secret_backend_command" once if all secrets aren't present in the cache.
+// Decrypt replaces all encrypted secrets in data by resolving the handles
+// prefixed by a native resolver and executing "secret_backend_command" once
+// for the other ones if all secrets aren't present in the cache.
 func Decrypt(data []byte, origin string) ([]byte, error) {
-	if data == nil || secretBackendCommand == "" {
+	if data == nil || !isEnabled() {
 		return data, nil
 	}
 
@@ -176,7 +186,7 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 		err = walk(&config, func(str string) (string, error) {
 			if ok, handle := isEnc(str); ok {
 				if secret, ok := secrets[handle]; ok {
-					log.Debugf("Secret '%s' was retrieved from executable", handle)
+					log.Debugf("Secret '%s' was retrieved from its backend", handle)
 					return secret, nil
 				}
 				// This should never happen since fetchSecret will return an error
@@ -199,15 +209,22 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
-	if secretBackendCommand == "" {
-		return nil, fmt.Errorf("No secret_backend_command set: secrets feature is not enabled")
+	if !isEnabled() {
+		return nil, fmt.Errorf("No secret_backend_command nor secret_backend_resolvers set: secrets feature is not enabled")
+	}
+	info := &SecretInfo{NativeResolvers: nativeResolverNames()}
+	if secretBackendCommand != "" {
+		info.ExecutablePath = secretBackendCommand
+		info.populateRights()
 	}
-	info := &SecretInfo{ExecutablePath: secretBackendCommand}
-	info.populateRights()
 
 	info.SecretsHandles = map[string][]string{}
 	for handle, originNames := range secretOrigin {
 		info.SecretsHandles[handle] = originNames.GetAll()
 	}
+	info.SecretsErrors = map[string]string{}
+	for handle, errMsg := range secretErrors {
+		info.SecretsErrors[handle] = errMsg
+	}
 	return info, nil
 }
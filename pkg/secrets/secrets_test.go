@@ -167,7 +167,7 @@ func TestWalkerComplex(t *testing.T) {
 }
 
 func TestDecryptNoCommand(t *testing.T) {
-	defer func() { secretFetcher = fetchSecret }()
+	defer func() { secretFetcher = resolveSecrets }()
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
 		return nil, fmt.Errorf("some error")
 	}
@@ -181,7 +181,7 @@ func TestDecryptSecretError(t *testing.T) {
 	secretBackendCommand = "some_command"
 	defer func() {
 		secretBackendCommand = ""
-		secretFetcher = fetchSecret
+		secretFetcher = resolveSecrets
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -203,7 +203,8 @@ func TestDecryptSecretStringMapStringWithDashValue(t *testing.T) {
 		secretBackendCommand = ""
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
-		secretFetcher = fetchSecret
+		secretErrors = map[string]string{}
+		secretFetcher = resolveSecrets
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -228,7 +229,8 @@ func TestDecryptSecretNoCache(t *testing.T) {
 		secretBackendCommand = ""
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
-		secretFetcher = fetchSecret
+		secretErrors = map[string]string{}
+		secretFetcher = resolveSecrets
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -258,7 +260,8 @@ func TestDecryptSecretPartialCache(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
-		secretFetcher = fetchSecret
+		secretErrors = map[string]string{}
+		secretFetcher = resolveSecrets
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -288,7 +291,8 @@ func TestDecryptSecretFullCache(t *testing.T) {
 	defer func() {
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
-		secretFetcher = fetchSecret
+		secretErrors = map[string]string{}
+		secretFetcher = resolveSecrets
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -308,6 +312,7 @@ func TestDebugInfo(t *testing.T) {
 		secretBackendCommand = ""
 		secretCache = map[string]string{}
 		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]string{}
 		runCommand = execCommand
 	}()
 
@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package remotewrite converts the metrics of the Agent to Prometheus remote-write requests.
+package remotewrite
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	metricNameLabel = "__name__"
+	hostLabel       = "host"
+	quantileLabel   = "quantile"
+)
+
+// Marshaler is an interface for metrics that are able to convert themselves to remote-write time series
+type Marshaler interface {
+	MarshalRemoteWrite(b *Builder)
+}
+
+// SketchPoint is a distribution of values at a timestamp, in seconds.
+type SketchPoint struct {
+	Ts     int64
+	Sketch *quantile.Sketch
+}
+
+// Builder accumulates remote-write time series and encodes them in requests.
+type Builder struct {
+	quantiles  []float64
+	timeseries []TimeSeries
+}
+
+// ParseQuantiles parses the quantiles set in the key of the configuration, skipping the invalid ones.
+func ParseQuantiles(key string, values []string) []float64 {
+	var quantiles []float64
+	for _, q := range values {
+		f, err := strconv.ParseFloat(q, 64)
+		if err != nil {
+			log.Errorf("Could not parse '%s' from '%s' (skipping): %s", q, key, err)
+			continue
+		}
+		if f < 0 || f > 1 {
+			log.Errorf("%s must be between 0 and 1: skipping %f", key, f)
+			continue
+		}
+		quantiles = append(quantiles, f)
+	}
+	return quantiles
+}
+
+// NewBuilder returns a Builder which converts the distributions to the given quantiles.
+func NewBuilder(quantiles []float64) *Builder {
+	return &Builder{quantiles: quantiles}
+}
+
+// AddSeries adds a time series. Its labels are the sanitized name of the metric, its host as the
+// "host" label and its tags, a tag without value being converted to a label with the "true" value.
+func (b *Builder) AddSeries(name, host string, tags []string, samples []Sample) {
+	if len(samples) == 0 {
+		return
+	}
+	b.timeseries = append(b.timeseries, TimeSeries{
+		Labels:  labels(sanitizeMetricName(name), host, tags, ""),
+		Samples: samples,
+	})
+}
+
+// AddSketches adds a distribution metric as a Prometheus summary: a series of each quantile with
+// the "quantile" label, and the "_sum" and "_count" series. Unlike the Prometheus summaries, the
+// sum and the count are not cumulative, they cover the interval of each point.
+func (b *Builder) AddSketches(name, host string, tags []string, points []SketchPoint) {
+	if len(points) == 0 {
+		return
+	}
+	name = sanitizeMetricName(name)
+	conf := quantile.Default()
+
+	for _, q := range b.quantiles {
+		samples := make([]Sample, 0, len(points))
+		for _, p := range points {
+			samples = append(samples, Sample{Value: p.Sketch.Quantile(conf, q), Timestamp: p.Ts * 1000})
+		}
+		b.timeseries = append(b.timeseries, TimeSeries{
+			Labels:  labels(name, host, tags, strconv.FormatFloat(q, 'f', -1, 64)),
+			Samples: samples,
+		})
+	}
+
+	sums := make([]Sample, 0, len(points))
+	counts := make([]Sample, 0, len(points))
+	for _, p := range points {
+		sums = append(sums, Sample{Value: p.Sketch.Basic.Sum, Timestamp: p.Ts * 1000})
+		counts = append(counts, Sample{Value: float64(p.Sketch.Basic.Cnt), Timestamp: p.Ts * 1000})
+	}
+	b.timeseries = append(b.timeseries,
+		TimeSeries{Labels: labels(name+"_sum", host, tags, ""), Samples: sums},
+		TimeSeries{Labels: labels(name+"_count", host, tags, ""), Samples: counts},
+	)
+}
+
+// Len returns the number of time series of the builder.
+func (b *Builder) Len() int {
+	return len(b.timeseries)
+}
+
+// TimeSeries returns the time series of the builder, in the order they were added.
+func (b *Builder) TimeSeries() []TimeSeries {
+	return b.timeseries
+}
+
+// Payloads encodes the time series in snappy compressed requests of at most maxSeries time series
+// and resets the builder.
+func (b *Builder) Payloads(maxSeries int) []*[]byte {
+	if maxSeries <= 0 {
+		maxSeries = len(b.timeseries)
+	}
+	var payloads []*[]byte
+	for start := 0; start < len(b.timeseries); start += maxSeries {
+		end := start + maxSeries
+		if end > len(b.timeseries) {
+			end = len(b.timeseries)
+		}
+		req := WriteRequest{Timeseries: b.timeseries[start:end]}
+		payload := snappy.Encode(nil, req.Marshal())
+		payloads = append(payloads, &payload)
+	}
+	b.timeseries = nil
+	return payloads
+}
+
+// labels returns the labels of a time series sorted by name, the values of the tags sharing the
+// same name are joined with commas. The quantile label is only added when q is not empty.
+func labels(name, host string, tags []string, q string) []Label {
+	ls := make([]Label, 0, len(tags)+3)
+	ls = append(ls, Label{Name: metricNameLabel, Value: name})
+	if host != "" {
+		ls = append(ls, Label{Name: hostLabel, Value: host})
+	}
+	if q != "" {
+		ls = append(ls, Label{Name: quantileLabel, Value: q})
+	}
+	for _, tag := range tags {
+		k, v := tag, "true"
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			k, v = tag[:i], tag[i+1:]
+		}
+		k = sanitizeLabelName(k)
+		if k == "" || v == "" || k == quantileLabel && q != "" {
+			continue
+		}
+		ls = append(ls, Label{Name: k, Value: v})
+	}
+	sort.SliceStable(ls, func(i, j int) bool { return ls[i].Name < ls[j].Name })
+
+	merged := ls[:0]
+	for _, l := range ls {
+		if n := len(merged); n > 0 && merged[n-1].Name == l.Name {
+			if !hasValue(merged[n-1].Value, l.Value) {
+				merged[n-1].Value += "," + l.Value
+			}
+			continue
+		}
+		merged = append(merged, l)
+	}
+	return merged
+}
+
+// hasValue reports whether v is one of the comma separated values of joined.
+func hasValue(joined, v string) bool {
+	for _, s := range strings.Split(joined, ",") {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeMetricName replaces the characters not allowed in a Prometheus metric name by underscores.
+func sanitizeMetricName(name string) string {
+	return sanitize(name, true)
+}
+
+// sanitizeLabelName replaces the characters not allowed in a Prometheus label name by underscores.
+// The names starting with "__", reserved by Prometheus, are prefixed with "tag".
+func sanitizeLabelName(name string) string {
+	name = sanitize(name, false)
+	if strings.HasPrefix(name, "__") {
+		name = "tag" + name
+	}
+	return name
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return name
+	}
+	var sb strings.Builder
+	sb.Grow(len(name) + 1)
+	if name[0] >= '0' && name[0] <= '9' {
+		sb.WriteByte('_')
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == ':' && allowColon:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+)
+
+func TestParseQuantiles(t *testing.T) {
+	assert.Equal(t, []float64{0.5, 0.99, 1}, ParseQuantiles("quantiles", []string{"0.5", "0.99", "foo", "1.5", "-1", "1"}))
+	assert.Empty(t, ParseQuantiles("quantiles", nil))
+}
+
+func TestSanitize(t *testing.T) {
+	for in, out := range map[string]string{
+		"system.load.1":   "system_load_1",
+		"ns:metric_total": "ns:metric_total",
+		"2xx.count":       "_2xx_count",
+		"é":               "__",
+		"":                "",
+	} {
+		assert.Equal(t, out, sanitizeMetricName(in), in)
+	}
+	for in, out := range map[string]string{
+		"kube_namespace": "kube_namespace",
+		"kube.namespace": "kube_namespace",
+		"a:b":            "a_b",
+		"__name__":       "tag__name__",
+		"9lives":         "_9lives",
+	} {
+		assert.Equal(t, out, sanitizeLabelName(in), in)
+	}
+}
+
+func TestAddSeries(t *testing.T) {
+	b := NewBuilder(nil)
+	b.AddSeries("system.load.1", "i-0a1b2c", []string{"env:prod", "role:db", "env:staging", "env:prod", "canary", "__name__:x", "empty:"}, []Sample{{Value: 1, Timestamp: 1000}})
+	b.AddSeries("no.samples", "", nil, nil)
+	require.Equal(t, 1, b.Len())
+	assert.Equal(t, []Label{
+		{Name: "__name__", Value: "system_load_1"},
+		{Name: "canary", Value: "true"},
+		{Name: "env", Value: "prod,staging"},
+		{Name: "host", Value: "i-0a1b2c"},
+		{Name: "role", Value: "db"},
+		{Name: "tag__name__", Value: "x"},
+	}, b.timeseries[0].Labels)
+	assert.Equal(t, []Sample{{Value: 1, Timestamp: 1000}}, b.timeseries[0].Samples)
+}
+
+func TestAddSketches(t *testing.T) {
+	sketch := &quantile.Sketch{}
+	for i := 1; i <= 100; i++ {
+		sketch.Insert(quantile.Default(), float64(i))
+	}
+	b := NewBuilder([]float64{0.5, 0.99})
+	b.AddSketches("http.latency", "", []string{"quantile:x", "service:web"}, []SketchPoint{{Ts: 10, Sketch: sketch}})
+	require.Equal(t, 4, b.Len())
+
+	assert.Equal(t, []Label{{Name: "__name__", Value: "http_latency"}, {Name: "quantile", Value: "0.5"}, {Name: "service", Value: "web"}}, b.timeseries[0].Labels)
+	assert.InEpsilon(t, 50, b.timeseries[0].Samples[0].Value, 0.05)
+	assert.Equal(t, int64(10000), b.timeseries[0].Samples[0].Timestamp)
+	assert.Equal(t, "0.99", b.timeseries[1].Labels[1].Value)
+	assert.InEpsilon(t, 99, b.timeseries[1].Samples[0].Value, 0.05)
+
+	assert.Equal(t, "http_latency_sum", b.timeseries[2].Labels[0].Value)
+	assert.Equal(t, []Sample{{Value: 5050, Timestamp: 10000}}, b.timeseries[2].Samples)
+	assert.Equal(t, "http_latency_count", b.timeseries[3].Labels[0].Value)
+	// the tags named "quantile" are only kept outside of the quantile series
+	assert.Equal(t, []Label{{Name: "__name__", Value: "http_latency_count"}, {Name: "quantile", Value: "x"}, {Name: "service", Value: "web"}}, b.timeseries[3].Labels)
+	assert.Equal(t, []Sample{{Value: 100, Timestamp: 10000}}, b.timeseries[3].Samples)
+}
+
+func TestPayloads(t *testing.T) {
+	b := NewBuilder(nil)
+	for i := 0; i < 5; i++ {
+		b.AddSeries("metric", "", nil, []Sample{{Value: float64(i), Timestamp: 1000}})
+	}
+	payloads := b.Payloads(2)
+	assert.Equal(t, 0, b.Len())
+	require.Len(t, payloads, 3)
+
+	var n int
+	for _, p := range payloads {
+		data, err := snappy.Decode(nil, *p)
+		require.NoError(t, err)
+		var req pbWriteRequest
+		require.NoError(t, proto.Unmarshal(data, &req))
+		n += len(req.Timeseries)
+	}
+	assert.Equal(t, 5, n)
+	assert.Empty(t, b.Payloads(2))
+}
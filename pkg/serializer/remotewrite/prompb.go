@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The types below are the subset of the Prometheus remote-write protocol (prompb/remote.proto and
+// prompb/types.proto) used by the Agent. They are encoded by hand with the protobuf wire format to
+// avoid depending on the Prometheus server module.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// WriteRequest is the body of a remote-write request.
+type WriteRequest struct {
+	Timeseries []TimeSeries // field 1
+}
+
+// TimeSeries is a series of samples identified by its labels.
+type TimeSeries struct {
+	Labels  []Label  // field 1, sorted by name
+	Samples []Sample // field 2, sorted by timestamp
+}
+
+// Label is a label of a time series.
+type Label struct {
+	Name  string // field 1
+	Value string // field 2
+}
+
+// Sample is a value of a time series at a timestamp, in milliseconds since the epoch.
+type Sample struct {
+	Value     float64 // field 1
+	Timestamp int64   // field 2
+}
+
+// Marshal encodes the request with the protobuf wire format.
+func (r *WriteRequest) Marshal() []byte {
+	return r.appendTo(make([]byte, 0, r.Size()))
+}
+
+// Size returns the size of the encoded request.
+func (r *WriteRequest) Size() int {
+	n := 0
+	for i := range r.Timeseries {
+		n += sizeBytesField(r.Timeseries[i].Size())
+	}
+	return n
+}
+
+func (r *WriteRequest) appendTo(b []byte) []byte {
+	for i := range r.Timeseries {
+		ts := &r.Timeseries[i]
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(ts.Size()))
+		b = ts.appendTo(b)
+	}
+	return b
+}
+
+// Size returns the size of the encoded time series.
+func (ts *TimeSeries) Size() int {
+	n := 0
+	for i := range ts.Labels {
+		n += sizeBytesField(ts.Labels[i].Size())
+	}
+	for i := range ts.Samples {
+		n += sizeBytesField(ts.Samples[i].Size())
+	}
+	return n
+}
+
+func (ts *TimeSeries) appendTo(b []byte) []byte {
+	for i := range ts.Labels {
+		l := &ts.Labels[i]
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(l.Size()))
+		b = l.appendTo(b)
+	}
+	for i := range ts.Samples {
+		s := &ts.Samples[i]
+		b = appendKey(b, 2, wireBytes)
+		b = appendVarint(b, uint64(s.Size()))
+		b = s.appendTo(b)
+	}
+	return b
+}
+
+// Size returns the size of the encoded label.
+func (l *Label) Size() int {
+	n := 0
+	if l.Name != "" {
+		n += sizeBytesField(len(l.Name))
+	}
+	if l.Value != "" {
+		n += sizeBytesField(len(l.Value))
+	}
+	return n
+}
+
+func (l *Label) appendTo(b []byte) []byte {
+	if l.Name != "" {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(len(l.Name)))
+		b = append(b, l.Name...)
+	}
+	if l.Value != "" {
+		b = appendKey(b, 2, wireBytes)
+		b = appendVarint(b, uint64(len(l.Value)))
+		b = append(b, l.Value...)
+	}
+	return b
+}
+
+// Size returns the size of the encoded sample.
+func (s *Sample) Size() int {
+	n := 0
+	if s.Value != 0 {
+		n += 1 + 8
+	}
+	if s.Timestamp != 0 {
+		n += 1 + sizeVarint(uint64(s.Timestamp))
+	}
+	return n
+}
+
+func (s *Sample) appendTo(b []byte) []byte {
+	if s.Value != 0 {
+		b = appendKey(b, 1, wireFixed64)
+		b = appendFixed64(b, math.Float64bits(s.Value))
+	}
+	if s.Timestamp != 0 {
+		b = appendKey(b, 2, wireVarint)
+		b = appendVarint(b, uint64(s.Timestamp))
+	}
+	return b
+}
+
+func appendKey(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field<<3|wireType))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func sizeVarint(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// sizeBytesField returns the size of a length-delimited field of n bytes, whose number is below 16.
+func sizeBytesField(n int) int {
+	return 1 + sizeVarint(uint64(n)) + n
+}
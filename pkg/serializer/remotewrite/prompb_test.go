@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The types below mirror the Prometheus remote-write messages, they are decoded with the
+// reflection based protobuf decoder to check the hand written encoder.
+
+type pbWriteRequest struct {
+	Timeseries []*pbTimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3"`
+}
+
+func (m *pbWriteRequest) Reset()         { *m = pbWriteRequest{} }
+func (m *pbWriteRequest) String() string { return proto.CompactTextString(m) }
+func (*pbWriteRequest) ProtoMessage()    {}
+
+type pbTimeSeries struct {
+	Labels  []*pbLabel  `protobuf:"bytes,1,rep,name=labels,proto3"`
+	Samples []*pbSample `protobuf:"bytes,2,rep,name=samples,proto3"`
+}
+
+func (m *pbTimeSeries) Reset()         { *m = pbTimeSeries{} }
+func (m *pbTimeSeries) String() string { return proto.CompactTextString(m) }
+func (*pbTimeSeries) ProtoMessage()    {}
+
+type pbLabel struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *pbLabel) Reset()         { *m = pbLabel{} }
+func (m *pbLabel) String() string { return proto.CompactTextString(m) }
+func (*pbLabel) ProtoMessage()    {}
+
+type pbSample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value,proto3"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp,proto3"`
+}
+
+func (m *pbSample) Reset()         { *m = pbSample{} }
+func (m *pbSample) String() string { return proto.CompactTextString(m) }
+func (*pbSample) ProtoMessage()    {}
+
+func TestWriteRequestMarshal(t *testing.T) {
+	req := &WriteRequest{Timeseries: []TimeSeries{
+		{
+			Labels: []Label{{Name: "__name__", Value: "system_load_1"}, {Name: "host", Value: "i-0a1b2c"}},
+			Samples: []Sample{
+				{Value: 1.5, Timestamp: 1617000000000},
+				{Value: 0, Timestamp: 1617000010000},
+				{Value: -3, Timestamp: -1},
+				{Value: math.Inf(1)},
+			},
+		},
+		{Labels: []Label{{Name: "__name__", Value: "empty"}, {Name: "env", Value: ""}}},
+	}}
+	data := req.Marshal()
+	assert.Len(t, data, req.Size())
+
+	var out pbWriteRequest
+	require.NoError(t, proto.Unmarshal(data, &out))
+	assert.Equal(t, pbWriteRequest{Timeseries: []*pbTimeSeries{
+		{
+			Labels: []*pbLabel{{Name: "__name__", Value: "system_load_1"}, {Name: "host", Value: "i-0a1b2c"}},
+			Samples: []*pbSample{
+				{Value: 1.5, Timestamp: 1617000000000},
+				{Timestamp: 1617000010000},
+				{Value: -3, Timestamp: -1},
+				{Value: math.Inf(1)},
+			},
+		},
+		{Labels: []*pbLabel{{Name: "__name__", Value: "empty"}, {Name: "env"}}},
+	}}, out)
+
+	assert.Empty(t, (&WriteRequest{}).Marshal())
+}
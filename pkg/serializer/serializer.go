@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 	"github.com/DataDog/datadog-agent/pkg/serializer/stream"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
@@ -106,6 +107,12 @@ type Serializer struct {
 	Forwarder             forwarder.Forwarder
 	orchestratorForwarder forwarder.Forwarder
 
+	// RemoteWriteForwarder, when set, receives the series and the sketches converted to
+	// Prometheus remote-write requests instead of the Forwarder.
+	RemoteWriteForwarder      *forwarder.RemoteWriteForwarder
+	remoteWriteQuantiles      []float64
+	remoteWriteMaxSeriesCount int
+
 	seriesJSONPayloadBuilder *stream.JSONPayloadBuilder
 
 	// Those variables allow users to blacklist any kind of payload
@@ -140,6 +147,8 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder forwarde
 		enableServiceChecksJSONStream: stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
 		enableEventsJSONStream:        stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
 		enableSketchProtobufStream:    stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
+		remoteWriteQuantiles:          remotewrite.ParseQuantiles("prometheus_remote_write.quantiles", config.Datadog.GetStringSlice("prometheus_remote_write.quantiles")),
+		remoteWriteMaxSeriesCount:     config.Datadog.GetInt("prometheus_remote_write.max_series_per_request"),
 	}
 
 	if !s.enableEvents {
@@ -293,6 +302,10 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 		return nil
 	}
 
+	if s.RemoteWriteForwarder != nil {
+		return s.sendRemoteWrite(series)
+	}
+
 	useV1API := !config.Datadog.GetBool("use_v2_api.series")
 
 	var seriesPayloads forwarder.Payloads
@@ -322,6 +335,10 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 		return nil
 	}
 
+	if s.RemoteWriteForwarder != nil {
+		return s.sendRemoteWrite(sketches)
+	}
+
 	if s.enableSketchProtobufStream {
 		payloads, err := sketches.MarshalSplitCompress(marshaler.DefaultBufferContext())
 		if err == nil {
@@ -340,6 +357,20 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 	return s.Forwarder.SubmitSketchSeries(splitSketches, extraHeaders)
 }
 
+// sendRemoteWrite converts a payload to Prometheus remote-write requests and sends them to the remote-write forwarder
+func (s *Serializer) sendRemoteWrite(payload interface{}) error {
+	m, ok := payload.(remotewrite.Marshaler)
+	if !ok {
+		return fmt.Errorf("dropping %T payload: it cannot be converted to remote-write time series", payload)
+	}
+	b := remotewrite.NewBuilder(s.remoteWriteQuantiles)
+	m.MarshalRemoteWrite(b)
+	if b.Len() == 0 {
+		return nil
+	}
+	return s.RemoteWriteForwarder.SubmitRemoteWrite(b.Payloads(s.remoteWriteMaxSeriesCount))
+}
+
 // SendMetadata serializes a metadata payload and sends it to the forwarder
 func (s *Serializer) SendMetadata(m marshaler.Marshaler) error {
 	return s.sendMetadata(m, s.Forwarder.SubmitMetadata)
@@ -9,8 +9,11 @@ package serializer
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +23,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/serializer/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/golang/snappy"
 )
 
 var initialContentEncoding = compression.ContentEncoding
@@ -368,6 +373,55 @@ func TestSendSketch(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+type testRemoteWritePayload struct {
+	testPayload
+}
+
+func (p *testRemoteWritePayload) MarshalRemoteWrite(b *remotewrite.Builder) {
+	b.AddSeries("test.metric", "host", []string{"env:test"}, []remotewrite.Sample{{Value: 1, Timestamp: 1000}})
+}
+
+func TestSendSeriesRemoteWrite(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	rw, err := forwarder.NewRemoteWriteForwarder(forwarder.NewOptions(nil), ts.URL+"/api/v1/write", nil)
+	require.NoError(t, err)
+	require.NoError(t, rw.Start())
+	defer rw.Stop()
+
+	// the default forwarder does not receive any series
+	f := &forwarder.MockedForwarder{}
+	s := NewSerializer(f, nil)
+	s.RemoteWriteForwarder = rw
+
+	require.NoError(t, s.SendSeries(&testRemoteWritePayload{}))
+	require.Error(t, s.SendSeries(&testPayload{}))
+	f.AssertExpectations(t)
+
+	select {
+	case body := <-bodies:
+		data, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		expected := remotewrite.WriteRequest{Timeseries: []remotewrite.TimeSeries{{
+			Labels: []remotewrite.Label{
+				{Name: "__name__", Value: "test_metric"},
+				{Name: "env", Value: "test"},
+				{Name: "host", Value: "host"},
+			},
+			Samples: []remotewrite.Sample{{Value: 1, Timestamp: 1000}},
+		}}}
+		assert.Equal(t, expected.Marshal(), data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no remote-write request received")
+	}
+}
+
 func TestSendMetadata(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	f.On("SubmitMetadata", jsonPayloads, jsonExtraHeadersWithCompression).Return(nil).Times(1)
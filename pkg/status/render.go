@@ -55,6 +55,7 @@ func FormatStatus(data []byte) (string, error) {
 	renderStatusTemplate(b, "/trace-agent.tmpl", stats["apmStats"])
 	renderStatusTemplate(b, "/aggregator.tmpl", aggregatorStats)
 	renderStatusTemplate(b, "/dogstatsd.tmpl", dogstatsdStats)
+	renderStatusTemplate(b, "/dogstatsd_origins.tmpl", stats["dogstatsdOriginStats"])
 	if config.Datadog.GetBool("cluster_agent.enabled") || config.Datadog.GetBool("cluster_checks.enabled") {
 		renderStatusTemplate(b, "/clusteragent.tmpl", dcaStats)
 	}
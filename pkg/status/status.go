@@ -338,6 +338,12 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	}
 	stats["dogstatsdStats"] = dogstatsdStats
 
+	if dogstatsdOriginsVar := expvar.Get("dogstatsd-origins"); dogstatsdOriginsVar != nil {
+		var dogstatsdOriginStats []interface{}
+		json.Unmarshal([]byte(dogstatsdOriginsVar.String()), &dogstatsdOriginStats) //nolint:errcheck
+		stats["dogstatsdOriginStats"] = dogstatsdOriginStats
+	}
+
 	pyLoaderData := expvar.Get("pyLoader")
 	if pyLoaderData != nil {
 		pyLoaderStatsJSON := []byte(pyLoaderData.String())
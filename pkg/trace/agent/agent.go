@@ -42,6 +42,7 @@ type Agent struct {
 	ErrorsSampler     *sampler.ErrorsSampler
 	ExceptionSampler  *sampler.ExceptionSampler
 	NoPrioritySampler *sampler.NoPrioritySampler
+	TailSampler       *sampler.TailSampler
 	EventProcessor    *event.Processor
 	TraceWriter       *writer.TraceWriter
 	StatsWriter       *writer.StatsWriter
@@ -83,6 +84,9 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		conf:              conf,
 		ctx:               ctx,
 	}
+	if conf.TailSampling.Enabled {
+		agnt.TailSampler = sampler.NewTailSampler(conf, agnt.writeTailSampled)
+	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt)
 	return agnt
 }
@@ -99,6 +103,9 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 
 	go a.TraceWriter.Run()
 	go a.StatsWriter.Run()
@@ -150,6 +157,10 @@ func (a *Agent) loop() {
 				log.Error(err)
 			}
 			a.Concentrator.Stop()
+			if a.TailSampler != nil {
+				// flushes the buffered traces, so it is stopped before the trace writer
+				a.TailSampler.Stop()
+			}
 			a.TraceWriter.Stop()
 			a.StatsWriter.Stop()
 			a.PrioritySampler.Stop()
@@ -327,7 +338,12 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 		return nil, false
 	}
 
-	sampled := a.runSamplers(pt, hasPriority)
+	var sampled bool
+	if a.TailSampler != nil {
+		sampled = a.runTailSampler(pt, hasPriority, priority)
+	} else {
+		sampled = a.runSamplers(pt, hasPriority)
+	}
 
 	events, numExtracted := a.EventProcessor.Process(pt.Root, pt.Trace)
 
@@ -337,6 +353,37 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 	return events, sampled
 }
 
+// runTailSampler buffers pt in the tail sampler, which writes it if a policy keeps it. The traces
+// kept by the user are always kept and the PrioritySampler still counts the traces to keep
+// computing the rates by service sent to the clients.
+func (a *Agent) runTailSampler(pt ProcessedTrace, hasPriority bool, priority sampler.SamplingPriority) bool {
+	if hasPriority {
+		a.PrioritySampler.Sample(pt.Trace, pt.Root, pt.Env, pt.ClientDroppedP0s)
+	}
+	if priority >= sampler.PriorityUserKeep {
+		return true
+	}
+	a.TailSampler.Add(pt.Trace)
+	return false
+}
+
+// writeTailSampled writes the traces kept by the tail sampler.
+func (a *Agent) writeTailSampled(traces []pb.Trace) {
+	ss := new(writer.SampledSpans)
+	for _, t := range traces {
+		ss.Traces = append(ss.Traces, traceutil.APITrace(t))
+		ss.Size += t.Msgsize()
+		ss.SpanCount += int64(len(t))
+		if ss.Size > writer.MaxPayloadSize {
+			a.TraceWriter.In <- ss
+			ss = new(writer.SampledSpans)
+		}
+	}
+	if ss.Size > 0 {
+		a.TraceWriter.In <- ss
+	}
+}
+
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
 // along with the sampling rate.
 func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) bool {
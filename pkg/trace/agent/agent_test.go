@@ -33,6 +33,7 @@ import (
 
 	"github.com/cihub/seelog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test to make sure that the joined effort of the quantizer and truncator, in that order, produce the
@@ -550,6 +551,59 @@ func TestClientComputedStats(t *testing.T) {
 	})
 }
 
+func TestTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "errors", Type: "error"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg)
+	require.NotNil(t, agnt.TailSampler)
+	agnt.TailSampler.Start()
+
+	newSpan := func(traceID uint64, err int32, priority sampler.SamplingPriority) *pb.Span {
+		return &pb.Span{
+			Service:  "serv1",
+			Name:     "op",
+			Resource: "res",
+			TraceID:  traceID,
+			SpanID:   traceID,
+			Start:    time.Now().Add(-time.Second).UnixNano(),
+			Duration: (100 * time.Millisecond).Nanoseconds(),
+			Error:    err,
+			Metrics:  map[string]float64{sampler.KeySamplingPriority: float64(priority)},
+		}
+	}
+	root := newSpan(1, 0, sampler.PriorityAutoKeep)
+	agnt.Process(&api.Payload{
+		Traces: pb.Traces{{root}, {newSpan(2, 1, sampler.PriorityAutoKeep)}, {newSpan(3, 0, sampler.PriorityUserKeep)}},
+		Source: agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+	})
+	// the traces kept by the user are written right away
+	select {
+	case ss := <-agnt.TraceWriter.In:
+		require.Len(t, ss.Traces, 1)
+		assert.Equal(t, uint64(3), ss.Traces[0].Spans[0].TraceID)
+	case <-time.After(time.Second):
+		t.Fatal("no traces written")
+	}
+	// the other traces are buffered until the end of their decision window
+	assert.Len(t, agnt.TraceWriter.In, 0)
+	// the priority sampler keeps counting the traces to compute the rates by service
+	assert.Contains(t, root.Metrics, "_sampling_priority_rate_v1")
+
+	agnt.TailSampler.Stop()
+	select {
+	case ss := <-agnt.TraceWriter.In:
+		require.Len(t, ss.Traces, 1)
+		assert.Equal(t, uint64(2), ss.Traces[0].Spans[0].TraceID)
+		assert.EqualValues(t, 1, ss.SpanCount)
+	case <-time.After(time.Second):
+		t.Fatal("no traces written")
+	}
+}
+
 func TestSampling(t *testing.T) {
 	for name, tt := range map[string]struct {
 		// hasErrors will be true if the input trace should have errors
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/grpc"
 
 	mainconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
@@ -71,6 +72,7 @@ type HTTPReceiver struct {
 	conf           *config.AgentConfig
 	dynConf        *sampler.DynamicConfig
 	server         *http.Server
+	grpcServer     *grpc.Server // OTLP/gRPC server, nil when disabled
 	statsProcessor StatsProcessor
 
 	debug               bool
@@ -182,6 +184,10 @@ func (r *HTTPReceiver) Start() {
 		log.Infof("Listening for traces on Windowes pipe %q. Security descriptor is %q", pipepath, secdec)
 	}
 
+	if r.conf.OTLPGRPCPort > 0 {
+		r.startOTLPGRPC()
+	}
+
 	go r.RateLimiter.Run()
 
 	go func() {
@@ -276,6 +282,9 @@ func (r *HTTPReceiver) Stop() error {
 	expiry := time.Now().Add(5 * time.Second) // give it 5 seconds
 	ctx, cancel := context.WithDeadline(context.Background(), expiry)
 	defer cancel()
+	if r.grpcServer != nil {
+		r.grpcServer.GracefulStop()
+	}
 	if err := r.server.Shutdown(ctx); err != nil {
 		return err
 	}
@@ -482,15 +491,18 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	atomic.AddInt64(&ts.TracesBytes, req.Body.(*LimitedReader).Count)
 	atomic.AddInt64(&ts.PayloadAccepted, 1)
 
-	payload := &Payload{
+	r.sendPayload(&Payload{
 		Source:                 ts,
 		Traces:                 traces,
 		ContainerTags:          getContainerTags(req.Header.Get(headerContainerID)),
 		ClientComputedTopLevel: req.Header.Get(headerComputedTopLevel) != "",
 		ClientComputedStats:    req.Header.Get(headerComputedStats) != "",
 		ClientDroppedP0s:       droppedTracesFromHeader(req.Header, ts),
-	}
+	})
+}
 
+// sendPayload sends the payload to the agent without blocking the caller.
+func (r *HTTPReceiver) sendPayload(payload *Payload) {
 	select {
 	case r.out <- payload:
 		// ok
@@ -508,6 +520,25 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	}
 }
 
+// acceptTraces sends the traces converted from a payload of the given size in bytes to the agent,
+// unless the rate limiter rejects them. It reports whether the traces were accepted.
+func (r *HTTPReceiver) acceptTraces(ts *info.TagStats, traces pb.Traces, size int64, containerTags string) bool {
+	if r.rateLimited(int64(len(traces))) {
+		atomic.AddInt64(&ts.PayloadRefused, 1)
+		return false
+	}
+	atomic.AddInt64(&ts.TracesReceived, int64(len(traces)))
+	atomic.AddInt64(&ts.TracesBytes, size)
+	atomic.AddInt64(&ts.PayloadAccepted, 1)
+
+	r.sendPayload(&Payload{
+		Source:        ts,
+		Traces:        traces,
+		ContainerTags: containerTags,
+	})
+	return true
+}
+
 func droppedTracesFromHeader(h http.Header, ts *info.TagStats) int64 {
 	var dropped int64
 	if v := h.Get(headerDroppedP0Traces); v != "" {
@@ -721,6 +752,47 @@ func tracesFromSpans(spans []pb.Span) pb.Traces {
 	return traces
 }
 
+// groupTraces groups the spans by trace, in the order in which the traces are first seen.
+func groupTraces(spans []*pb.Span) pb.Traces {
+	var traces pb.Traces
+	index := make(map[uint64]int)
+	for _, span := range spans {
+		i, ok := index[span.TraceID]
+		if !ok {
+			i = len(traces)
+			index[span.TraceID] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], span)
+	}
+	return traces
+}
+
+// spanKindType returns the Datadog span type of a span converted from another tracing format,
+// given its kind ("server", "client", ...): server spans are web requests, client spans are
+// database, cache or http calls depending on their semantic tags.
+func spanKindType(kind string, meta map[string]string) string {
+	switch kind {
+	case "server":
+		return "web"
+	case "client":
+		db := meta["db.system"]
+		if db == "" {
+			db = meta["db.type"]
+		}
+		switch db {
+		case "":
+			return "http"
+		case "redis", "memcached":
+			return "cache"
+		default:
+			return "db"
+		}
+	default:
+		return "custom"
+	}
+}
+
 // getContainerTag returns container and orchestrator tags belonging to containerID. If containerID
 // is empty or no tags are found, an empty string is returned.
 func getContainerTags(containerID string) string {
@@ -71,6 +71,18 @@ var endpoints = []endpoint{
 		Pattern: "/v0.5/stats",
 		Handler: func(r *HTTPReceiver) http.Handler { return http.HandlerFunc(r.handleStats) },
 	},
+	{
+		Pattern: "/v1/traces",
+		Handler: func(r *HTTPReceiver) http.Handler { return http.HandlerFunc(r.handleOTLPTraces) },
+	},
+	{
+		Pattern: "/api/v2/spans",
+		Handler: func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(zipkinVersion, r.handleZipkinSpans) },
+	},
+	{
+		Pattern: "/api/traces",
+		Handler: func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(jaegerVersion, r.handleJaegerTraces) },
+	},
 	{
 		Pattern: "/profiling/v1/input",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.profileProxyHandler() },
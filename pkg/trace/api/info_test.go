@@ -118,6 +118,9 @@ func TestInfoHandler(t *testing.T) {
 		"/v0.4/services",
 		"/v0.5/traces",
 		"/v0.5/stats",
+		"/v1/traces",
+		"/api/v2/spans",
+		"/api/traces",
 		"/profiling/v1/input"
 	],
 	"feature_flags": [
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/jaeger"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// jaegerVersion is the endpoint version reported in the stats of the Jaeger traces.
+const jaegerVersion Version = "jaeger-thrift"
+
+// handleJaegerTraces handles the Jaeger batches, encoded with the thrift binary protocol.
+func (r *HTTPReceiver) handleJaegerTraces(v Version, w http.ResponseWriter, req *http.Request) {
+	switch mediaType := getMediaType(req); mediaType {
+	case "application/x-thrift", "application/vnd.apache.thrift.binary":
+	default:
+		httpFormatError(w, v, fmt.Errorf("unsupported media type: %q", mediaType))
+		return
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	var batch *jaeger.Batch
+	_, err := io.Copy(buf, req.Body)
+	if err == nil {
+		batch, err = jaeger.UnmarshalBatch(buf.Bytes())
+	}
+	if err != nil {
+		httpDecodingError(err, []string{"handler:traces", fmt.Sprintf("v:%s", v)}, w)
+		log.Errorf("Cannot decode %s traces payload: %v", v, err)
+		return
+	}
+
+	process := batch.Process
+	if process == nil {
+		process = &jaeger.Process{}
+	}
+	ptags := make(map[string]string, len(process.Tags))
+	for _, tag := range process.Tags {
+		ptags[tag.Key] = jaegerTagString(tag)
+	}
+	ts := r.Stats.GetTagStats(info.Tags{
+		Lang:            req.Header.Get(headerLang),
+		TracerVersion:   ptags["jaeger.version"],
+		EndpointVersion: string(v),
+	})
+
+	spans := make([]*pb.Span, 0, len(batch.Spans))
+	for _, span := range batch.Spans {
+		spans = append(spans, convertJaegerSpan(span, process.ServiceName, ptags))
+	}
+	if len(spans) > 0 && !r.acceptTraces(ts, groupTraces(spans), int64(buf.Len()), "") {
+		w.WriteHeader(r.rateLimiterResponse)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// convertJaegerSpan converts a Jaeger span to a Datadog span. The process gives the service of
+// the span and its tags are added to the meta, the "span.kind" tag gives the type of the span
+// and its operation name.
+func convertJaegerSpan(in *jaeger.Span, service string, ptags map[string]string) *pb.Span {
+	span := &pb.Span{
+		Service:  service,
+		Resource: in.OperationName,
+		TraceID:  uint64(in.TraceIDLow),
+		SpanID:   uint64(in.SpanID),
+		ParentID: uint64(in.ParentSpanID),
+		Start:    in.StartTime * 1000,
+		Duration: in.Duration * 1000,
+		Meta:     make(map[string]string, len(ptags)+len(in.Tags)),
+		Metrics:  make(map[string]float64),
+	}
+	if span.Service == "" {
+		span.Service = unknownService
+	}
+	if span.ParentID == 0 {
+		// the parent of the spans of the recent clients is only given by their references
+		for _, ref := range in.References {
+			if ref.TraceIDLow == in.TraceIDLow && ref.TraceIDHigh == in.TraceIDHigh {
+				span.ParentID = uint64(ref.SpanID)
+				break
+			}
+		}
+	}
+	if in.TraceIDHigh != 0 {
+		span.Meta["jaeger.trace_id"] = fmt.Sprintf("%016x%016x", uint64(in.TraceIDHigh), uint64(in.TraceIDLow))
+	}
+	for k, v := range ptags {
+		span.Meta[k] = v
+	}
+	for _, tag := range in.Tags {
+		switch tag.VType {
+		case jaeger.TagTypeDouble:
+			span.Metrics[tag.Key] = tag.VDouble
+		case jaeger.TagTypeLong:
+			span.Metrics[tag.Key] = float64(tag.VLong)
+			if tag.Key == "http.status_code" {
+				// the stats are grouped by the status code found in the meta
+				span.Meta[tag.Key] = strconv.FormatInt(tag.VLong, 10)
+			}
+		default:
+			span.Meta[tag.Key] = jaegerTagString(tag)
+		}
+	}
+
+	kind := span.Meta["span.kind"]
+	if kind == "" {
+		kind = "internal"
+	}
+	span.Name = "jaeger." + kind
+	span.Type = spanKindType(kind, span.Meta)
+
+	if span.Meta["error"] == "true" {
+		span.Error = 1
+	}
+	for _, l := range in.Logs {
+		fields := make(map[string]string, len(l.Fields))
+		for _, f := range l.Fields {
+			fields[f.Key] = jaegerTagString(f)
+		}
+		if fields["event"] != "error" {
+			continue
+		}
+		span.Error = 1
+		if msg := fields["message"]; msg != "" {
+			span.Meta["error.msg"] = msg
+		} else if msg := fields["error.object"]; msg != "" {
+			span.Meta["error.msg"] = msg
+		}
+		if typ := fields["error.kind"]; typ != "" {
+			span.Meta["error.type"] = typ
+		}
+		if stack := fields["stack"]; stack != "" {
+			span.Meta["error.stack"] = stack
+		}
+	}
+	return span
+}
+
+// jaegerTagString returns the string representation of the value of a tag, binary values
+// are hex encoded.
+func jaegerTagString(tag *jaeger.Tag) string {
+	switch tag.VType {
+	case jaeger.TagTypeDouble:
+		return strconv.FormatFloat(tag.VDouble, 'f', -1, 64)
+	case jaeger.TagTypeBool:
+		return strconv.FormatBool(tag.VBool)
+	case jaeger.TagTypeLong:
+		return strconv.FormatInt(tag.VLong, 10)
+	case jaeger.TagTypeBinary:
+		return hex.EncodeToString(tag.VBinary)
+	default:
+		return tag.VStr
+	}
+}
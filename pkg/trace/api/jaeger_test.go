@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/jaeger"
+)
+
+func TestConvertJaegerSpan(t *testing.T) {
+	in := &jaeger.Span{
+		TraceIDLow:    12345,
+		TraceIDHigh:   1,
+		SpanID:        2,
+		OperationName: "GET /users",
+		References: []*jaeger.SpanRef{
+			{RefType: jaeger.SpanRefTypeFollowsFrom, TraceIDLow: 999, SpanID: 7},
+			{RefType: jaeger.SpanRefTypeChildOf, TraceIDLow: 12345, TraceIDHigh: 1, SpanID: 1},
+		},
+		StartTime: 1000,
+		Duration:  20,
+		Tags: []*jaeger.Tag{
+			{Key: "span.kind", VStr: "server"},
+			{Key: "http.status_code", VType: jaeger.TagTypeLong, VLong: 500},
+			{Key: "sampler.param", VType: jaeger.TagTypeDouble, VDouble: 0.5},
+			{Key: "error", VType: jaeger.TagTypeBool, VBool: true},
+			{Key: "payload", VType: jaeger.TagTypeBinary, VBinary: []byte{0xca, 0xfe}},
+		},
+		Logs: []*jaeger.Log{
+			{Fields: []*jaeger.Tag{{Key: "event", VStr: "cache miss"}}},
+			{Fields: []*jaeger.Tag{
+				{Key: "event", VStr: "error"},
+				{Key: "error.kind", VStr: "TimeoutError"},
+				{Key: "message", VStr: "deadline exceeded"},
+			}},
+		},
+	}
+	span := convertJaegerSpan(in, "users", map[string]string{"hostname": "host-1"})
+	assert.Equal(t, &pb.Span{
+		Service:  "users",
+		Name:     "jaeger.server",
+		Resource: "GET /users",
+		TraceID:  12345,
+		SpanID:   2,
+		ParentID: 1,
+		Start:    1000000,
+		Duration: 20000,
+		Error:    1,
+		Type:     "web",
+		Meta: map[string]string{
+			"hostname":         "host-1",
+			"span.kind":        "server",
+			"error":            "true",
+			"payload":          "cafe",
+			"jaeger.trace_id":  "00000000000000010000000000003039",
+			"error.type":       "TimeoutError",
+			"error.msg":        "deadline exceeded",
+			"http.status_code": "500",
+		},
+		Metrics: map[string]float64{
+			"http.status_code": 500,
+			"sampler.param":    0.5,
+		},
+	}, span)
+
+	// the parent span ID takes precedence over the references
+	span = convertJaegerSpan(&jaeger.Span{TraceIDLow: -1, SpanID: 3, ParentSpanID: 2, References: in.References}, "", nil)
+	assert.Equal(t, unknownService, span.Service)
+	assert.Equal(t, uint64(2), span.ParentID)
+	assert.Equal(t, uint64(0xffffffffffffffff), span.TraceID)
+	assert.Equal(t, "jaeger.internal", span.Name)
+	assert.Equal(t, "custom", span.Type)
+	assert.NotContains(t, span.Meta, "jaeger.trace_id")
+	assert.Equal(t, int32(0), span.Error)
+}
+
+// jaegerTestBatch returns a batch of two spans of the same trace, encoded with the thrift binary protocol.
+func jaegerTestBatch() []byte {
+	var buf bytes.Buffer
+	field := func(typ byte, id int16) {
+		buf.WriteByte(typ)
+		binary.Write(&buf, binary.BigEndian, id)
+	}
+	str := func(id int16, s string) {
+		field(11, id)
+		binary.Write(&buf, binary.BigEndian, int32(len(s)))
+		buf.WriteString(s)
+	}
+	i64 := func(id int16, v int64) {
+		field(10, id)
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+
+	field(12, 1) // process
+	str(1, "users")
+	buf.WriteByte(0)
+	field(15, 2) // spans
+	buf.WriteByte(12)
+	binary.Write(&buf, binary.BigEndian, int32(2))
+	for id := int64(1); id <= 2; id++ {
+		i64(1, 42)
+		i64(2, 0)
+		i64(3, id)
+		i64(4, id-1)
+		str(5, "op")
+		i64(8, 1000)
+		i64(9, 10)
+		buf.WriteByte(0)
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+func TestHandleJaegerTraces(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	handler := r.handleWithVersion(jaegerVersion, r.handleJaegerTraces)
+
+	req := httptest.NewRequest("POST", "/api/traces?format=jaeger.thrift", bytes.NewReader(jaegerTestBatch()))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	select {
+	case p := <-r.out:
+		require.Len(t, p.Traces, 1)
+		require.Len(t, p.Traces[0], 2)
+		assert.Equal(t, "users", p.Traces[0][0].Service)
+		assert.Equal(t, p.Traces[0][0].SpanID, p.Traces[0][1].ParentID)
+		assert.Equal(t, string(jaegerVersion), p.Source.EndpointVersion)
+	case <-time.After(time.Second):
+		t.Fatal("no payload received")
+	}
+
+	req = httptest.NewRequest("POST", "/api/traces", bytes.NewReader(jaegerTestBatch()))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	req = httptest.NewRequest("POST", "/api/traces", bytes.NewReader(jaegerTestBatch()[:10]))
+	req.Header.Set("Content-Type", "application/vnd.apache.thrift.binary")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
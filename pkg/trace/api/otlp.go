@@ -0,0 +1,305 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/otlp"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// otlpHTTPVersion and otlpGRPCVersion are the endpoint versions reported in the stats of the OTLP traces.
+	otlpHTTPVersion Version = "otlp-http"
+	otlpGRPCVersion Version = "otlp-grpc"
+
+	// unknownService is the service of the spans received without a service name.
+	unknownService = "unknown_service"
+)
+
+// handleOTLPTraces handles the OTLP/HTTP traces, encoded in protobuf.
+func (r *HTTPReceiver) handleOTLPTraces(w http.ResponseWriter, req *http.Request) {
+	if mediaType := getMediaType(req); mediaType != "application/x-protobuf" {
+		httpFormatError(w, otlpHTTPVersion, fmt.Errorf("unsupported media type: %q", mediaType))
+		return
+	}
+
+	rd := NewLimitedReader(req.Body, r.conf.MaxRequestBytes)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	var in otlp.ExportTraceServiceRequest
+	_, err := io.Copy(buf, rd)
+	if err == nil {
+		err = proto.Unmarshal(buf.Bytes(), &in)
+	}
+	if err != nil {
+		httpDecodingError(err, []string{"handler:traces", fmt.Sprintf("v:%s", otlpHTTPVersion)}, w)
+		log.Errorf("Cannot decode %s traces payload: %v", otlpHTTPVersion, err)
+		return
+	}
+
+	r.processOTLPRequest(otlpHTTPVersion, &in)
+
+	out, err := proto.Marshal(&otlp.ExportTraceServiceResponse{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(out) //nolint:errcheck
+}
+
+// otlpTraceService implements the OTLP/gRPC trace service.
+type otlpTraceService struct {
+	receiver *HTTPReceiver
+}
+
+// Export implements otlp.TraceServiceServer.
+func (s *otlpTraceService) Export(ctx context.Context, in *otlp.ExportTraceServiceRequest) (*otlp.ExportTraceServiceResponse, error) {
+	s.receiver.processOTLPRequest(otlpGRPCVersion, in)
+	return &otlp.ExportTraceServiceResponse{}, nil
+}
+
+// startOTLPGRPC starts the OTLP/gRPC server on the configured port.
+func (r *HTTPReceiver) startOTLPGRPC() {
+	addr := fmt.Sprintf("%s:%d", r.conf.ReceiverHost, r.conf.OTLPGRPCPort)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		killProcess("Error creating OTLP gRPC listener: %v", err)
+	}
+	r.grpcServer = grpc.NewServer(
+		grpc.CustomCodec(otlp.Codec),
+		grpc.MaxRecvMsgSize(int(r.conf.MaxRequestBytes)),
+	)
+	otlp.RegisterTraceServiceServer(r.grpcServer, &otlpTraceService{receiver: r})
+	go func() {
+		defer watchdog.LogOnPanic()
+		if err := r.grpcServer.Serve(ln); err != nil {
+			log.Errorf("OTLP gRPC server stopped: %v", err)
+		}
+	}()
+	log.Infof("Listening for OTLP traces at grpc://%s", addr)
+}
+
+// processOTLPRequest converts the resource spans of an OTLP request to traces and sends
+// them to the agent, a payload is created for each resource.
+func (r *HTTPReceiver) processOTLPRequest(v Version, in *otlp.ExportTraceServiceRequest) {
+	for _, rspans := range in.ResourceSpans {
+		r.processOTLPResourceSpans(v, rspans)
+	}
+}
+
+func (r *HTTPReceiver) processOTLPResourceSpans(v Version, rspans *otlp.ResourceSpans) {
+	rattr := make(map[string]string)
+	for _, kv := range rspans.GetResource().GetAttributes() {
+		rattr[kv.Key] = otlpValueString(kv.Value)
+	}
+	ts := r.Stats.GetTagStats(info.Tags{
+		Lang:            rattr["telemetry.sdk.language"],
+		TracerVersion:   rattr["telemetry.sdk.version"],
+		EndpointVersion: string(v),
+	})
+
+	var spans []*pb.Span
+	for _, libspans := range rspans.InstrumentationLibrarySpans {
+		lib := libspans.GetInstrumentationLibrary()
+		for _, span := range libspans.Spans {
+			spans = append(spans, convertOTLPSpan(span, lib, rattr))
+		}
+	}
+	if len(spans) == 0 {
+		return
+	}
+	r.acceptTraces(ts, groupTraces(spans), int64(proto.Size(rspans)), getContainerTags(rattr["container.id"]))
+}
+
+// convertOTLPSpan converts an OTLP span to a Datadog span. The resource attributes give the service,
+// env and version of the span, the kind of the span its type and the name of the library and the kind
+// its operation name.
+func convertOTLPSpan(in *otlp.Span, lib *otlp.InstrumentationLibrary, rattr map[string]string) *pb.Span {
+	span := &pb.Span{
+		Service:  unknownService,
+		Resource: in.Name,
+		TraceID:  otlpTraceID(in.TraceId),
+		SpanID:   otlpSpanID(in.SpanId),
+		ParentID: otlpSpanID(in.ParentSpanId),
+		Start:    int64(in.StartTimeUnixNano),
+		Meta:     make(map[string]string, len(rattr)+len(in.Attributes)),
+		Metrics:  make(map[string]float64),
+	}
+	if in.EndTimeUnixNano > in.StartTimeUnixNano {
+		span.Duration = int64(in.EndTimeUnixNano - in.StartTimeUnixNano)
+	}
+	for k, v := range rattr {
+		switch k {
+		case "service.name":
+			span.Service = v
+		case "deployment.environment":
+			span.Meta["env"] = v
+		case "service.version":
+			span.Meta["version"] = v
+		default:
+			span.Meta[k] = v
+		}
+	}
+	for _, kv := range in.Attributes {
+		switch v := kv.Value.GetValue().(type) {
+		case *otlp.AnyValue_IntValue:
+			span.Metrics[kv.Key] = float64(v.IntValue)
+			if kv.Key == "http.status_code" {
+				// the stats are grouped by the status code found in the meta
+				span.Meta[kv.Key] = strconv.FormatInt(v.IntValue, 10)
+			}
+		case *otlp.AnyValue_DoubleValue:
+			span.Metrics[kv.Key] = v.DoubleValue
+		default:
+			span.Meta[kv.Key] = otlpValueString(kv.Value)
+		}
+	}
+
+	kind := otlpSpanKindName(in.Kind)
+	span.Meta["span.kind"] = kind
+	span.Meta["otel.trace_id"] = hex.EncodeToString(in.TraceId)
+	if libName := lib.GetName(); libName != "" {
+		span.Name = libName + "." + kind
+		span.Meta["otel.library.name"] = libName
+		if libVersion := lib.GetVersion(); libVersion != "" {
+			span.Meta["otel.library.version"] = libVersion
+		}
+	} else {
+		span.Name = "opentelemetry." + kind
+	}
+	if method, route := span.Meta["http.method"], span.Meta["http.route"]; method != "" && route != "" {
+		span.Resource = method + " " + route
+	}
+	span.Type = spanKindType(kind, span.Meta)
+
+	if in.GetStatus().GetCode() == otlp.Status_STATUS_CODE_ERROR {
+		span.Error = 1
+		if msg := in.GetStatus().GetMessage(); msg != "" {
+			span.Meta["error.msg"] = msg
+		}
+	}
+	for _, event := range in.Events {
+		if event.Name != "exception" {
+			continue
+		}
+		for _, kv := range event.Attributes {
+			switch kv.Key {
+			case "exception.message":
+				span.Meta["error.msg"] = otlpValueString(kv.Value)
+			case "exception.type":
+				span.Meta["error.type"] = otlpValueString(kv.Value)
+			case "exception.stacktrace":
+				span.Meta["error.stack"] = otlpValueString(kv.Value)
+			}
+		}
+	}
+	return span
+}
+
+// otlpTraceID returns the lower 64 bits of a 128 bits OTLP trace ID, which are the bits
+// propagated by the Datadog tracers.
+func otlpTraceID(id []byte) uint64 {
+	if len(id) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(id[len(id)-8:])
+}
+
+// otlpSpanID returns the 64 bits OTLP span ID, or 0 if it is not set.
+func otlpSpanID(id []byte) uint64 {
+	if len(id) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(id)
+}
+
+// otlpSpanKindName returns the name of the kind of span, "internal" when unspecified.
+func otlpSpanKindName(kind otlp.Span_SpanKind) string {
+	switch kind {
+	case otlp.Span_SPAN_KIND_SERVER:
+		return "server"
+	case otlp.Span_SPAN_KIND_CLIENT:
+		return "client"
+	case otlp.Span_SPAN_KIND_PRODUCER:
+		return "producer"
+	case otlp.Span_SPAN_KIND_CONSUMER:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+// otlpValueString returns the string representation of an attribute value, arrays and
+// key-value lists are encoded in JSON.
+func otlpValueString(value *otlp.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *otlp.AnyValue_StringValue:
+		return v.StringValue
+	case *otlp.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *otlp.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *otlp.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'f', -1, 64)
+	case *otlp.AnyValue_BytesValue:
+		return hex.EncodeToString(v.BytesValue)
+	case *otlp.AnyValue_ArrayValue, *otlp.AnyValue_KvlistValue:
+		out, err := json.Marshal(otlpValueInterface(value))
+		if err != nil {
+			return ""
+		}
+		return string(out)
+	default:
+		return ""
+	}
+}
+
+// otlpValueInterface returns the Go representation of an attribute value.
+func otlpValueInterface(value *otlp.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *otlp.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, otlpValueInterface(item))
+		}
+		return values
+	case *otlp.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(v.KvlistValue.GetValues()))
+		for _, kv := range v.KvlistValue.GetValues() {
+			values[kv.Key] = otlpValueInterface(kv.Value)
+		}
+		return values
+	case *otlp.AnyValue_BoolValue:
+		return v.BoolValue
+	case *otlp.AnyValue_IntValue:
+		return v.IntValue
+	case *otlp.AnyValue_DoubleValue:
+		if math.IsNaN(v.DoubleValue) || math.IsInf(v.DoubleValue, 0) {
+			return nil
+		}
+		return v.DoubleValue
+	default:
+		return otlpValueString(value)
+	}
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/otlp"
+)
+
+func otlpString(k, v string) *otlp.KeyValue {
+	return &otlp.KeyValue{Key: k, Value: &otlp.AnyValue{Value: &otlp.AnyValue_StringValue{StringValue: v}}}
+}
+
+func otlpInt(k string, v int64) *otlp.KeyValue {
+	return &otlp.KeyValue{Key: k, Value: &otlp.AnyValue{Value: &otlp.AnyValue_IntValue{IntValue: v}}}
+}
+
+var (
+	otlpTestTraceID = []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x30, 0x39}
+	otlpTestRequest = &otlp.ExportTraceServiceRequest{
+		ResourceSpans: []*otlp.ResourceSpans{{
+			Resource: &otlp.Resource{Attributes: []*otlp.KeyValue{
+				otlpString("service.name", "checkout"),
+				otlpString("deployment.environment", "prod"),
+				otlpString("service.version", "1.2.3"),
+				otlpString("telemetry.sdk.language", "python"),
+				otlpString("host.name", "web-1"),
+			}},
+			InstrumentationLibrarySpans: []*otlp.InstrumentationLibrarySpans{{
+				InstrumentationLibrary: &otlp.InstrumentationLibrary{Name: "opentelemetry.instrumentation.flask", Version: "0.19b0"},
+				Spans: []*otlp.Span{
+					{
+						TraceId:           otlpTestTraceID,
+						SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, 1},
+						Name:              "HTTP GET",
+						Kind:              otlp.Span_SPAN_KIND_SERVER,
+						StartTimeUnixNano: 1000,
+						EndTimeUnixNano:   3000,
+						Attributes: []*otlp.KeyValue{
+							otlpString("http.method", "GET"),
+							otlpString("http.route", "/cart/<id>"),
+							otlpInt("http.status_code", 500),
+						},
+						Events: []*otlp.Span_Event{{
+							Name: "exception",
+							Attributes: []*otlp.KeyValue{
+								otlpString("exception.type", "KeyError"),
+								otlpString("exception.message", "'id'"),
+							},
+						}},
+						Status: &otlp.Status{Code: otlp.Status_STATUS_CODE_ERROR, Message: "internal error"},
+					},
+					{
+						TraceId:           otlpTestTraceID,
+						SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, 2},
+						ParentSpanId:      []byte{0, 0, 0, 0, 0, 0, 0, 1},
+						Name:              "GET",
+						Kind:              otlp.Span_SPAN_KIND_CLIENT,
+						StartTimeUnixNano: 1500,
+						EndTimeUnixNano:   2500,
+						Attributes:        []*otlp.KeyValue{otlpString("db.system", "redis")},
+					},
+				},
+			}},
+		}},
+	}
+)
+
+func TestConvertOTLPSpan(t *testing.T) {
+	rspans := otlpTestRequest.ResourceSpans[0]
+	rattr := make(map[string]string)
+	for _, kv := range rspans.Resource.Attributes {
+		rattr[kv.Key] = otlpValueString(kv.Value)
+	}
+	libspans := rspans.InstrumentationLibrarySpans[0]
+
+	span := convertOTLPSpan(libspans.Spans[0], libspans.InstrumentationLibrary, rattr)
+	assert.Equal(t, &pb.Span{
+		Service:  "checkout",
+		Name:     "opentelemetry.instrumentation.flask.server",
+		Resource: "GET /cart/<id>",
+		TraceID:  12345,
+		SpanID:   1,
+		Start:    1000,
+		Duration: 2000,
+		Error:    1,
+		Type:     "web",
+		Meta: map[string]string{
+			"env":                    "prod",
+			"version":                "1.2.3",
+			"telemetry.sdk.language": "python",
+			"host.name":              "web-1",
+			"http.method":            "GET",
+			"http.route":             "/cart/<id>",
+			"span.kind":              "server",
+			"otel.trace_id":          "ff000000000000000000000000003039",
+			"otel.library.name":      "opentelemetry.instrumentation.flask",
+			"otel.library.version":   "0.19b0",
+			"error.msg":              "'id'",
+			"error.type":             "KeyError",
+			"http.status_code":       "500",
+		},
+		Metrics: map[string]float64{"http.status_code": 500},
+	}, span)
+
+	span = convertOTLPSpan(libspans.Spans[1], nil, nil)
+	assert.Equal(t, unknownService, span.Service)
+	assert.Equal(t, "opentelemetry.client", span.Name)
+	assert.Equal(t, "GET", span.Resource)
+	assert.Equal(t, uint64(1), span.ParentID)
+	assert.Equal(t, "cache", span.Type)
+	assert.Equal(t, 0, int(span.Error))
+}
+
+func TestOTLPValueString(t *testing.T) {
+	value := &otlp.AnyValue{Value: &otlp.AnyValue_ArrayValue{ArrayValue: &otlp.ArrayValue{Values: []*otlp.AnyValue{
+		{Value: &otlp.AnyValue_StringValue{StringValue: "a"}},
+		{Value: &otlp.AnyValue_IntValue{IntValue: 1}},
+		{Value: &otlp.AnyValue_KvlistValue{KvlistValue: &otlp.KeyValueList{Values: []*otlp.KeyValue{
+			{Key: "ok", Value: &otlp.AnyValue{Value: &otlp.AnyValue_BoolValue{BoolValue: true}}},
+		}}}},
+	}}}}
+	assert.Equal(t, `["a",1,{"ok":true}]`, otlpValueString(value))
+	assert.Equal(t, "1.5", otlpValueString(&otlp.AnyValue{Value: &otlp.AnyValue_DoubleValue{DoubleValue: 1.5}}))
+	assert.Equal(t, "", otlpValueString(nil))
+}
+
+// assertOTLPTestPayload asserts that the payload contains the trace of otlpTestRequest.
+func assertOTLPTestPayload(t *testing.T, out chan *Payload, v Version) {
+	select {
+	case p := <-out:
+		require.Len(t, p.Traces, 1)
+		assert.Len(t, p.Traces[0], 2)
+		assert.Equal(t, "python", p.Source.Lang)
+		assert.Equal(t, string(v), p.Source.EndpointVersion)
+		assert.EqualValues(t, 1, p.Source.TracesReceived)
+	case <-time.After(time.Second):
+		t.Fatal("no payload received")
+	}
+}
+
+func TestHandleOTLPTraces(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	body, err := proto.Marshal(otlpTestRequest)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/v1/traces", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	r.handleOTLPTraces(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+	assertOTLPTestPayload(t, r.out, otlpHTTPVersion)
+
+	// JSON is not supported
+	req = httptest.NewRequest("POST", "/v1/traces", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	r.handleOTLPTraces(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	// invalid protobuf
+	req = httptest.NewRequest("POST", "/v1/traces", bytes.NewReader([]byte{0xff}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rec = httptest.NewRecorder()
+	r.handleOTLPTraces(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestOTLPGRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	conf := newTestReceiverConfig()
+	conf.OTLPGRPCPort = port
+	r := newTestReceiverFromConfig(conf)
+	r.startOTLPGRPC()
+	defer r.grpcServer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("localhost:%d", port), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithDefaultCallOptions(grpc.CallCustomCodec(otlp.Codec)))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var out otlp.ExportTraceServiceResponse
+	err = conn.Invoke(ctx, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", otlpTestRequest, &out)
+	require.NoError(t, err)
+	assertOTLPTestPayload(t, r.out, otlpGRPCVersion)
+}
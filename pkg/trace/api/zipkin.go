@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/zipkin"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// zipkinVersion is the endpoint version reported in the stats of the Zipkin traces.
+const zipkinVersion Version = "zipkin-v2"
+
+// zipkinSpan is a Zipkin v2 span, as encoded in JSON. The spans encoded in protobuf are
+// converted to it, their IDs being hex encoded.
+type zipkinSpan struct {
+	TraceID        string            `json:"traceId"`
+	ParentID       string            `json:"parentId"`
+	ID             string            `json:"id"`
+	Kind           string            `json:"kind"`
+	Name           string            `json:"name"`
+	Timestamp      uint64            `json:"timestamp"`
+	Duration       uint64            `json:"duration"`
+	LocalEndpoint  *zipkinEndpoint   `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint   `json:"remoteEndpoint"`
+	Tags           map[string]string `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int32  `json:"port"`
+}
+
+// handleZipkinSpans handles the Zipkin v2 spans, encoded in JSON or in protobuf.
+func (r *HTTPReceiver) handleZipkinSpans(v Version, w http.ResponseWriter, req *http.Request) {
+	mediaType := getMediaType(req)
+	if mediaType != "application/json" && mediaType != "application/x-protobuf" {
+		httpFormatError(w, v, fmt.Errorf("unsupported media type: %q", mediaType))
+		return
+	}
+	ts := r.tagStats(v, req)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	var spans []*pb.Span
+	_, err := io.Copy(buf, req.Body)
+	if err == nil {
+		spans, err = decodeZipkinSpans(mediaType, buf.Bytes())
+	}
+	if err != nil {
+		httpDecodingError(err, []string{"handler:traces", fmt.Sprintf("v:%s", v)}, w)
+		log.Errorf("Cannot decode %s traces payload: %v", v, err)
+		return
+	}
+
+	if len(spans) > 0 && !r.acceptTraces(ts, groupTraces(spans), int64(buf.Len()), "") {
+		w.WriteHeader(r.rateLimiterResponse)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeZipkinSpans decodes a list of Zipkin spans of the given media type and converts them
+// to Datadog spans.
+func decodeZipkinSpans(mediaType string, data []byte) ([]*pb.Span, error) {
+	var in []*zipkinSpan
+	if mediaType == "application/x-protobuf" {
+		var list zipkin.ListOfSpans
+		if err := proto.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		in = make([]*zipkinSpan, 0, len(list.Spans))
+		for _, span := range list.Spans {
+			in = append(in, zipkinSpanFromProto(span))
+		}
+	} else if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	spans := make([]*pb.Span, 0, len(in))
+	for _, zspan := range in {
+		if zspan == nil {
+			continue
+		}
+		span, err := convertZipkinSpan(zspan)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// zipkinSpanFromProto returns the JSON representation of a Zipkin protobuf span.
+func zipkinSpanFromProto(in *zipkin.Span) *zipkinSpan {
+	span := &zipkinSpan{
+		TraceID:        hex.EncodeToString(in.TraceId),
+		ParentID:       hex.EncodeToString(in.ParentId),
+		ID:             hex.EncodeToString(in.Id),
+		Name:           in.Name,
+		Timestamp:      in.Timestamp,
+		Duration:       in.Duration,
+		LocalEndpoint:  zipkinEndpointFromProto(in.LocalEndpoint),
+		RemoteEndpoint: zipkinEndpointFromProto(in.RemoteEndpoint),
+		Tags:           in.Tags,
+	}
+	switch in.Kind {
+	case zipkin.Span_CLIENT:
+		span.Kind = "CLIENT"
+	case zipkin.Span_SERVER:
+		span.Kind = "SERVER"
+	case zipkin.Span_PRODUCER:
+		span.Kind = "PRODUCER"
+	case zipkin.Span_CONSUMER:
+		span.Kind = "CONSUMER"
+	}
+	return span
+}
+
+func zipkinEndpointFromProto(in *zipkin.Endpoint) *zipkinEndpoint {
+	if in == nil {
+		return nil
+	}
+	e := &zipkinEndpoint{ServiceName: in.ServiceName, Port: in.Port}
+	if len(in.Ipv4) == net.IPv4len {
+		e.IPv4 = net.IP(in.Ipv4).String()
+	}
+	if len(in.Ipv6) == net.IPv6len {
+		e.IPv6 = net.IP(in.Ipv6).String()
+	}
+	return e
+}
+
+// convertZipkinSpan converts a Zipkin span to a Datadog span. The local endpoint gives the service
+// of the span, the kind its type and operation name, and the tags its meta.
+func convertZipkinSpan(in *zipkinSpan) (*pb.Span, error) {
+	traceID, err := zipkinID(in.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID %q: %v", in.TraceID, err)
+	}
+	spanID, err := zipkinID(in.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID %q: %v", in.ID, err)
+	}
+	var parentID uint64
+	if in.ParentID != "" {
+		if parentID, err = zipkinID(in.ParentID); err != nil {
+			return nil, fmt.Errorf("invalid parent ID %q: %v", in.ParentID, err)
+		}
+	}
+
+	span := &pb.Span{
+		Service:  unknownService,
+		Resource: in.Name,
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Start:    int64(in.Timestamp) * 1000,
+		Duration: int64(in.Duration) * 1000,
+		Meta:     make(map[string]string, len(in.Tags)+2),
+		Metrics:  make(map[string]float64),
+	}
+	if in.LocalEndpoint != nil && in.LocalEndpoint.ServiceName != "" {
+		span.Service = in.LocalEndpoint.ServiceName
+	}
+	for k, v := range in.Tags {
+		span.Meta[k] = v
+	}
+	if len(in.TraceID) > 16 {
+		span.Meta["zipkin.trace_id"] = strings.ToLower(in.TraceID)
+	}
+	if remote := in.RemoteEndpoint; remote != nil {
+		if remote.ServiceName != "" {
+			span.Meta["peer.service"] = remote.ServiceName
+		}
+		if host := remote.IPv4; host != "" || remote.IPv6 != "" {
+			if host == "" {
+				host = remote.IPv6
+			}
+			span.Meta["out.host"] = host
+		}
+		if remote.Port != 0 {
+			span.Meta["out.port"] = strconv.Itoa(int(remote.Port))
+		}
+	}
+
+	kind := strings.ToLower(in.Kind)
+	if kind == "" {
+		kind = "internal"
+	}
+	span.Meta["span.kind"] = kind
+	span.Name = "zipkin." + kind
+	span.Type = spanKindType(kind, span.Meta)
+
+	// the "error" tag is set on failed spans, its value being the error message if any
+	if msg, ok := in.Tags["error"]; ok {
+		span.Error = 1
+		if msg != "" && msg != "true" {
+			span.Meta["error.msg"] = msg
+		}
+	}
+	return span, nil
+}
+
+// zipkinID returns the lower 64 bits of a hex encoded Zipkin ID of 64 or 128 bits, which are the
+// bits propagated by the Datadog tracers.
+func zipkinID(id string) (uint64, error) {
+	if id == "" {
+		return 0, errors.New("missing ID")
+	}
+	if len(id) > 32 {
+		return 0, errors.New("ID longer than 128 bits")
+	}
+	if len(id) > 16 {
+		if _, err := strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+			return 0, err
+		}
+		id = id[len(id)-16:]
+	}
+	return strconv.ParseUint(id, 16, 64)
+}
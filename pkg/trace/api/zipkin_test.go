@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb/zipkin"
+)
+
+const zipkinTestJSON = `[
+	{
+		"traceId": "5af7183fb1d4cf5f463ac35c9f6413ad",
+		"id": "463ac35c9f6413ad",
+		"kind": "SERVER",
+		"name": "get /api",
+		"timestamp": 1556604172355737,
+		"duration": 1431,
+		"localEndpoint": {"serviceName": "backend", "ipv4": "192.168.99.1"},
+		"tags": {"http.method": "GET", "http.path": "/api", "error": "timeout"}
+	},
+	{
+		"traceId": "5af7183fb1d4cf5f463ac35c9f6413ad",
+		"parentId": "463ac35c9f6413ad",
+		"id": "72485a3953bb6124",
+		"kind": "CLIENT",
+		"name": "select",
+		"timestamp": 1556604172355800,
+		"duration": 1000,
+		"localEndpoint": {"serviceName": "backend"},
+		"remoteEndpoint": {"serviceName": "postgres", "ipv4": "10.0.0.2", "port": 5432},
+		"tags": {"db.type": "postgresql"}
+	},
+	{
+		"traceId": "000000000000abcd",
+		"id": "000000000000abcd",
+		"name": "job"
+	}
+]`
+
+func TestZipkinID(t *testing.T) {
+	for _, tt := range []struct {
+		in  string
+		out uint64
+		err bool
+	}{
+		{in: "463ac35c9f6413ad", out: 0x463ac35c9f6413ad},
+		{in: "abcd", out: 0xabcd},
+		{in: "5af7183fb1d4cf5f463ac35c9f6413ad", out: 0x463ac35c9f6413ad},
+		{in: "ffffffffffffffff", out: 0xffffffffffffffff},
+		{in: "", err: true},
+		{in: "xyz", err: true},
+		{in: "5af7183fb1d4cf5z463ac35c9f6413ad", err: true},
+		{in: "05af7183fb1d4cf5f463ac35c9f6413ad", err: true},
+	} {
+		id, err := zipkinID(tt.in)
+		if tt.err {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.out, id, tt.in)
+	}
+}
+
+func TestDecodeZipkinSpans(t *testing.T) {
+	spans, err := decodeZipkinSpans("application/json", []byte(zipkinTestJSON))
+	require.NoError(t, err)
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, &pb.Span{
+		Service:  "backend",
+		Name:     "zipkin.server",
+		Resource: "get /api",
+		TraceID:  0x463ac35c9f6413ad,
+		SpanID:   0x463ac35c9f6413ad,
+		Start:    1556604172355737000,
+		Duration: 1431000,
+		Error:    1,
+		Type:     "web",
+		Meta: map[string]string{
+			"http.method":     "GET",
+			"http.path":       "/api",
+			"error":           "timeout",
+			"error.msg":       "timeout",
+			"span.kind":       "server",
+			"zipkin.trace_id": "5af7183fb1d4cf5f463ac35c9f6413ad",
+		},
+		Metrics: map[string]float64{},
+	}, spans[0])
+
+	client := spans[1]
+	assert.Equal(t, spans[0].TraceID, client.TraceID)
+	assert.Equal(t, spans[0].SpanID, client.ParentID)
+	assert.Equal(t, "zipkin.client", client.Name)
+	assert.Equal(t, "db", client.Type)
+	assert.Equal(t, "postgres", client.Meta["peer.service"])
+	assert.Equal(t, "10.0.0.2", client.Meta["out.host"])
+	assert.Equal(t, "5432", client.Meta["out.port"])
+
+	local := spans[2]
+	assert.Equal(t, unknownService, local.Service)
+	assert.Equal(t, "zipkin.internal", local.Name)
+	assert.Equal(t, "custom", local.Type)
+	assert.NotContains(t, local.Meta, "zipkin.trace_id")
+
+	_, err = decodeZipkinSpans("application/json", []byte(`[{"traceId": "nothex", "id": "1"}]`))
+	assert.Error(t, err)
+	_, err = decodeZipkinSpans("application/json", []byte(`[{"traceId": "1"}]`))
+	assert.Error(t, err)
+}
+
+func TestDecodeZipkinSpansProto(t *testing.T) {
+	data, err := proto.Marshal(&zipkin.ListOfSpans{Spans: []*zipkin.Span{{
+		TraceId:       []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0x46, 0x3a, 0xc3, 0x5c, 0x9f, 0x64, 0x13, 0xad},
+		ParentId:      []byte{0, 0, 0, 0, 0, 0, 0, 1},
+		Id:            []byte{0, 0, 0, 0, 0, 0, 0, 2},
+		Kind:          zipkin.Span_CLIENT,
+		Name:          "get",
+		Timestamp:     1000,
+		Duration:      10,
+		LocalEndpoint: &zipkin.Endpoint{ServiceName: "frontend"},
+		RemoteEndpoint: &zipkin.Endpoint{
+			ServiceName: "cache",
+			Ipv4:        []byte{10, 0, 0, 3},
+			Port:        6379,
+		},
+		Tags: map[string]string{"db.type": "redis"},
+	}}})
+	require.NoError(t, err)
+
+	spans, err := decodeZipkinSpans("application/x-protobuf", data)
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "frontend", span.Service)
+	assert.Equal(t, "zipkin.client", span.Name)
+	assert.Equal(t, uint64(0x463ac35c9f6413ad), span.TraceID)
+	assert.Equal(t, uint64(2), span.SpanID)
+	assert.Equal(t, uint64(1), span.ParentID)
+	assert.Equal(t, int64(1000000), span.Start)
+	assert.Equal(t, int64(10000), span.Duration)
+	assert.Equal(t, "cache", span.Type)
+	assert.Equal(t, "10.0.0.3", span.Meta["out.host"])
+	assert.Equal(t, "5af7183fb1d4cf5f463ac35c9f6413ad", span.Meta["zipkin.trace_id"])
+}
+
+func TestHandleZipkinSpans(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	handler := r.handleWithVersion(zipkinVersion, r.handleZipkinSpans)
+
+	req := httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte(zipkinTestJSON)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	select {
+	case p := <-r.out:
+		require.Len(t, p.Traces, 2)
+		assert.Len(t, p.Traces[0], 2)
+		assert.Len(t, p.Traces[1], 1)
+		assert.Equal(t, string(zipkinVersion), p.Source.EndpointVersion)
+		assert.EqualValues(t, 2, p.Source.TracesReceived)
+	case <-time.After(time.Second):
+		t.Fatal("no payload received")
+	}
+
+	req = httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte(zipkinTestJSON)))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	req = httptest.NewRequest("POST", "/api/v2/spans", bytes.NewReader([]byte("{")))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -84,6 +84,100 @@ type JSONObfuscationConfig struct {
 	ObfuscateSQLValues []string `mapstructure:"obfuscate_sql_values"`
 }
 
+// Tail sampling policy types.
+const (
+	// TailPolicyError keeps the traces having a span with an error.
+	TailPolicyError = "error"
+	// TailPolicyLatency keeps the traces whose root span lasts at least a threshold.
+	TailPolicyLatency = "latency"
+	// TailPolicyTag keeps the traces having a span with a matching tag.
+	TailPolicyTag = "tag"
+	// TailPolicyRate keeps a rate of the traces of a service.
+	TailPolicyRate = "rate"
+)
+
+// TailSamplingConfig holds the configuration of the tail-based sampling, which buffers the spans
+// of the traces and decides whether to keep them once they are complete.
+type TailSamplingConfig struct {
+	// Enabled specifies whether the traces are sampled by the tail sampler instead of on arrival.
+	Enabled bool `mapstructure:"enabled"`
+
+	// DecisionWait is the time during which the spans of a trace are buffered before its sampling
+	// decision, counted from the arrival of its first span.
+	DecisionWait time.Duration `mapstructure:"-"`
+
+	// MaxBufferedSpans bounds the number of buffered spans. Beyond it, the oldest traces are decided
+	// before the end of their decision window.
+	MaxBufferedSpans int `mapstructure:"max_buffered_spans"`
+
+	// Policies lists the policies of the sampler, the traces matched by any of them are kept.
+	Policies []*TailSamplingPolicy `mapstructure:"policies"`
+}
+
+// TailSamplingPolicy specifies a tail sampling policy.
+type TailSamplingPolicy struct {
+	// Name identifies the policy in the telemetry, it defaults to its type.
+	Name string `mapstructure:"name"`
+
+	// Type is the type of the policy: "error", "latency", "tag" or "rate".
+	Type string `mapstructure:"type"`
+
+	// ThresholdMs is the duration, in milliseconds, from which the root spans are kept by a "latency" policy.
+	ThresholdMs int64 `mapstructure:"threshold_ms"`
+
+	// Key is the tag whose values are matched by a "tag" policy.
+	Key string `mapstructure:"key"`
+
+	// Pattern is the regexp matched by the values of the tag of a "tag" policy. Any value
+	// matches when it is empty.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-"`
+
+	// Service is the service of the root spans of the traces sampled by a "rate" policy. The policy
+	// applies to all services when it is empty.
+	Service string `mapstructure:"service"`
+
+	// Rate is the rate, between 0 and 1, of the traces kept by a "rate" policy.
+	Rate float64 `mapstructure:"rate"`
+}
+
+// compileTailSamplingPolicies validates the tail sampling policies and compiles the regular expressions
+// of the tag policies.
+func compileTailSamplingPolicies(policies []*TailSamplingPolicy) error {
+	for i, p := range policies {
+		switch p.Type {
+		case TailPolicyError:
+		case TailPolicyLatency:
+			if p.ThresholdMs <= 0 {
+				return fmt.Errorf("policy %d: latency policies must have a positive \"threshold_ms\"", i)
+			}
+		case TailPolicyTag:
+			if p.Key == "" {
+				return fmt.Errorf("policy %d: tag policies must have a \"key\"", i)
+			}
+			if p.Pattern != "" {
+				re, err := regexp.Compile(p.Pattern)
+				if err != nil {
+					return fmt.Errorf("policy %d: %s", i, err)
+				}
+				p.Re = re
+			}
+		case TailPolicyRate:
+			if p.Rate < 0 || p.Rate > 1 {
+				return fmt.Errorf("policy %d: the \"rate\" of rate policies must be between 0 and 1", i)
+			}
+		default:
+			return fmt.Errorf("policy %d: unknown type %q", i, p.Type)
+		}
+		if p.Name == "" {
+			p.Name = p.Type
+		}
+	}
+	return nil
+}
+
 // ReplaceRule specifies a replace rule.
 type ReplaceRule struct {
 	// Name specifies the name of the tag that the replace rule addresses. However,
@@ -207,6 +301,9 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.receiver_socket") {
 		c.ReceiverSocket = config.Datadog.GetString("apm_config.receiver_socket")
 	}
+	if config.Datadog.IsSet("apm_config.otlp_grpc_port") {
+		c.OTLPGRPCPort = config.Datadog.GetInt("apm_config.otlp_grpc_port")
+	}
 	if config.Datadog.IsSet("apm_config.connection_limit") {
 		c.ConnectionLimit = config.Datadog.GetInt("apm_config.connection_limit")
 	}
@@ -222,6 +319,19 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if k := "apm_config.ignore_resources"; config.Datadog.IsSet(k) {
 		c.Ignore["resource"] = config.Datadog.GetStringSlice(k)
 	}
+	if k := "apm_config.extra_aggregators"; config.Datadog.IsSet(k) {
+		for _, v := range config.Datadog.GetStringSlice(k) {
+			// the aggregators of the legacy configuration are separated by commas
+			for _, tag := range strings.Split(v, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					c.ExtraAggregators = append(c.ExtraAggregators, tag)
+				}
+			}
+		}
+	}
+	if k := "apm_config.extra_aggregators_max_cardinality"; config.Datadog.IsSet(k) {
+		c.ExtraAggregatorsMaxCardinality = config.Datadog.GetInt(k)
+	}
 	if k := "apm_config.max_payload_size"; config.Datadog.IsSet(k) {
 		c.MaxRequestBytes = config.Datadog.GetInt64(k)
 	}
@@ -278,6 +388,24 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		}
 	}
 
+	if config.Datadog.GetBool("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = true
+		if k := "apm_config.tail_sampling.decision_wait"; config.Datadog.IsSet(k) {
+			c.TailSampling.DecisionWait = getDuration(config.Datadog.GetInt(k))
+		}
+		if k := "apm_config.tail_sampling.max_buffered_spans"; config.Datadog.IsSet(k) {
+			c.TailSampling.MaxBufferedSpans = config.Datadog.GetInt(k)
+		}
+		if k := "apm_config.tail_sampling.policies"; config.Datadog.IsSet(k) {
+			if err := config.Datadog.UnmarshalKey(k, &c.TailSampling.Policies); err != nil {
+				osutil.Exitf("tail_sampling: bad format for %q: %s", k, err)
+			}
+		}
+		if err := compileTailSamplingPolicies(c.TailSampling.Policies); err != nil {
+			osutil.Exitf("tail_sampling: %s", err)
+		}
+	}
+
 	// undocumented
 	if config.Datadog.IsSet("apm_config.max_cpu_percent") {
 		c.MaxCPU = config.Datadog.GetFloat64("apm_config.max_cpu_percent") / 100
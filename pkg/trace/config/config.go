@@ -61,13 +61,15 @@ type AgentConfig struct {
 	Endpoints []*Endpoint
 
 	// Concentrator
-	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
-	ExtraAggregators []string
+	BucketInterval                 time.Duration // the size of our pre-aggregation per bucket
+	ExtraAggregators               []string      // span tags added to the dimensions of the stats
+	ExtraAggregatorsMaxCardinality int           // maximum number of values of each extra aggregator per bucket, 0 for no limit
 
 	// Sampler configuration
 	ExtraSampleRate float64
 	TargetTPS       float64
 	MaxEPS          float64
+	TailSampling    *TailSamplingConfig
 
 	// Receiver
 	ReceiverHost    string
@@ -76,6 +78,7 @@ type AgentConfig struct {
 	ConnectionLimit int    // for rate-limiting, how many unique connections to allow in a lease period (30s)
 	ReceiverTimeout int
 	MaxRequestBytes int64 // specifies the maximum allowed request size for incoming trace payloads
+	OTLPGRPCPort    int   // if not 0, OTLP traces are accepted over gRPC on this port
 
 	// Writers
 	SynchronousFlushing     bool // Mode where traces are only submitted when FlushAsync is called, used for Serverless Extension
@@ -140,11 +143,16 @@ func New() *AgentConfig {
 		DefaultEnv: "none",
 		Endpoints:  []*Endpoint{{Host: "https://trace.agent.datadoghq.com"}},
 
-		BucketInterval: time.Duration(10) * time.Second,
+		BucketInterval:                 time.Duration(10) * time.Second,
+		ExtraAggregatorsMaxCardinality: 100,
 
 		ExtraSampleRate: 1.0,
 		TargetTPS:       10,
 		MaxEPS:          200,
+		TailSampling: &TailSamplingConfig{
+			DecisionWait:     10 * time.Second,
+			MaxBufferedSpans: 100000,
+		},
 
 		ReceiverHost:    "localhost",
 		ReceiverPort:    8126,
@@ -207,6 +207,18 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
 
+	assert.Equal(&TailSamplingConfig{
+		Enabled:          true,
+		DecisionWait:     30 * time.Second,
+		MaxBufferedSpans: 5000,
+		Policies: []*TailSamplingPolicy{
+			{Name: "errors", Type: "error"},
+			{Name: "latency", Type: "latency", ThresholdMs: 500},
+			{Name: "vip", Type: "tag", Key: "customer.tier", Pattern: "^gold$", Re: regexp.MustCompile("^gold$")},
+			{Name: "web", Type: "rate", Service: "web", Rate: 0.1},
+		},
+	}, c.TailSampling)
+
 	o := c.Obfuscation
 	assert.NotNil(o)
 	assert.True(o.ES.Enabled)
@@ -220,6 +232,23 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(c.Obfuscation.Memcached.Enabled)
 }
 
+func TestCompileTailSamplingPolicies(t *testing.T) {
+	for _, p := range []*TailSamplingPolicy{
+		{Type: "latency"},
+		{Type: "tag"},
+		{Type: "tag", Key: "k", Pattern: "("},
+		{Type: "rate", Rate: 1.5},
+		{Type: "unknown"},
+	} {
+		assert.Error(t, compileTailSamplingPolicies([]*TailSamplingPolicy{p}), p.Type)
+	}
+
+	p := &TailSamplingPolicy{Type: "tag", Key: "k", Pattern: "^v"}
+	assert.NoError(t, compileTailSamplingPolicies([]*TailSamplingPolicy{p}))
+	assert.Equal(t, "tag", p.Name)
+	assert.True(t, p.Re.MatchString("value"))
+}
+
 func TestUndocumentedYamlConfig(t *testing.T) {
 	defer cleanConfig()()
 	origcfg := config.Datadog
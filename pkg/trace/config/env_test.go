@@ -9,6 +9,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/cihub/seelog"
@@ -162,6 +163,18 @@ func TestLoadEnv(t *testing.T) {
 		})
 	}
 
+	env = "DD_APM_OTLP_GRPC_PORT"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, "4317")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(4317, cfg.OTLPGRPCPort)
+	})
+
 	env = "DD_DOGSTATSD_PORT"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
@@ -265,6 +278,40 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(cfg.RequireTags, []*Tag{{K: "important1", V: ""}, {K: "important2", V: "value1"}})
 	})
 
+	env = "DD_APM_EXTRA_AGGREGATORS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, "peer.service db.instance")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		err = os.Setenv("DD_APM_EXTRA_AGGREGATORS_MAX_CARDINALITY", "50")
+		assert.NoError(err)
+		defer os.Unsetenv("DD_APM_EXTRA_AGGREGATORS_MAX_CARDINALITY")
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]string{"peer.service", "db.instance"}, cfg.ExtraAggregators)
+		assert.Equal(50, cfg.ExtraAggregatorsMaxCardinality)
+	})
+
+	env = "DD_APM_TAIL_SAMPLING_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, "true")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		err = os.Setenv("DD_APM_TAIL_SAMPLING_DECISION_WAIT", "5")
+		assert.NoError(err)
+		defer os.Unsetenv("DD_APM_TAIL_SAMPLING_DECISION_WAIT")
+		cfg, err := Load("./testdata/site_default.yaml")
+		assert.NoError(err)
+		assert.True(cfg.TailSampling.Enabled)
+		assert.Equal(5*time.Second, cfg.TailSampling.DecisionWait)
+		assert.Equal(100000, cfg.TailSampling.MaxBufferedSpans)
+		assert.Empty(cfg.TailSampling.Policies)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REJECT"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package jaeger contains the Jaeger thrift model received by the trace-agent
+// (https://github.com/jaegertracing/jaeger-idl/blob/master/thrift/jaeger.thrift)
+// and its decoder for the thrift binary protocol.
+package jaeger
+
+// TagType is the type of the value of a tag.
+type TagType int32
+
+// Tag types
+const (
+	TagTypeString TagType = 0
+	TagTypeDouble TagType = 1
+	TagTypeBool   TagType = 2
+	TagTypeLong   TagType = 3
+	TagTypeBinary TagType = 4
+)
+
+// SpanRefType is the type of reference between two spans.
+type SpanRefType int32
+
+// Span reference types
+const (
+	SpanRefTypeChildOf     SpanRefType = 0
+	SpanRefTypeFollowsFrom SpanRefType = 1
+)
+
+// Tag is a typed key-value pair.
+type Tag struct {
+	Key     string
+	VType   TagType
+	VStr    string
+	VDouble float64
+	VBool   bool
+	VLong   int64
+	VBinary []byte
+}
+
+// Log is a timed event with fields.
+type Log struct {
+	Timestamp int64
+	Fields    []*Tag
+}
+
+// SpanRef is a reference from a span to another span.
+type SpanRef struct {
+	RefType     SpanRefType
+	TraceIDLow  int64
+	TraceIDHigh int64
+	SpanID      int64
+}
+
+// Span is a named unit of work, its start time and duration are in microseconds.
+type Span struct {
+	TraceIDLow    int64
+	TraceIDHigh   int64
+	SpanID        int64
+	ParentSpanID  int64
+	OperationName string
+	References    []*SpanRef
+	Flags         int32
+	StartTime     int64
+	Duration      int64
+	Tags          []*Tag
+	Logs          []*Log
+}
+
+// Process describes the traced process.
+type Process struct {
+	ServiceName string
+	Tags        []*Tag
+}
+
+// Batch is a collection of spans reported by a process.
+type Batch struct {
+	Process *Process
+	Spans   []*Span
+}
@@ -0,0 +1,365 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package jaeger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// thrift binary protocol types
+const (
+	typeStop   = 0
+	typeBool   = 2
+	typeByte   = 3
+	typeDouble = 4
+	typeI16    = 6
+	typeI32    = 8
+	typeI64    = 10
+	typeString = 11
+	typeStruct = 12
+	typeMap    = 13
+	typeSet    = 14
+	typeList   = 15
+)
+
+// maxDepth bounds the nesting of the skipped structures.
+const maxDepth = 32
+
+var errShortBuffer = errors.New("thrift: unexpected end of payload")
+
+// UnmarshalBatch decodes a batch encoded with the thrift binary protocol, as sent
+// by the Jaeger clients to the /api/traces endpoint of the collector.
+func UnmarshalBatch(data []byte) (*Batch, error) {
+	d := &decoder{buf: data}
+	batch := &Batch{}
+	err := d.readStruct(func(id int16, typ byte) (bool, error) {
+		switch {
+		case id == 1 && typ == typeStruct:
+			batch.Process = &Process{}
+			return true, d.readProcess(batch.Process)
+		case id == 2 && typ == typeList:
+			return true, d.readList(typeStruct, func() error {
+				span := &Span{}
+				batch.Spans = append(batch.Spans, span)
+				return d.readSpan(span)
+			})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// decoder reads the thrift binary protocol from a buffer.
+type decoder struct {
+	buf []byte
+	off int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.buf)-d.off < n {
+		return nil, errShortBuffer
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) readI16() (int16, error) {
+	b, err := d.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *decoder) readI32() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (d *decoder) readI64() (int64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func (d *decoder) readDouble() (float64, error) {
+	v, err := d.readI64()
+	return math.Float64frombits(uint64(v)), err
+}
+
+func (d *decoder) readBinary() ([]byte, error) {
+	n, err := d.readI32()
+	if err != nil {
+		return nil, err
+	}
+	return d.next(int(n))
+}
+
+func (d *decoder) readString() (string, error) {
+	b, err := d.readBinary()
+	return string(b), err
+}
+
+// readStruct reads the fields of a struct until its end, field is called for each of them
+// and reports whether it read the field, the fields it did not read are skipped.
+func (d *decoder) readStruct(field func(id int16, typ byte) (bool, error)) error {
+	for {
+		typ, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		if typ == typeStop {
+			return nil
+		}
+		id, err := d.readI16()
+		if err != nil {
+			return err
+		}
+		read, err := field(id, typ)
+		if err != nil {
+			return err
+		}
+		if !read {
+			if err := d.skip(typ, 0); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readList reads a list whose elements are of the expected type, elem is called for each of them.
+func (d *decoder) readList(expected byte, elem func() error) error {
+	typ, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	n, err := d.readI32()
+	if err != nil {
+		return err
+	}
+	if typ != expected {
+		return fmt.Errorf("thrift: unexpected list element type %d", typ)
+	}
+	// each element takes at least one byte, which bounds the size of invalid lists
+	if n < 0 || int(n) > len(d.buf)-d.off {
+		return errShortBuffer
+	}
+	for i := int32(0); i < n; i++ {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skip skips a value of the given type.
+func (d *decoder) skip(typ byte, depth int) error {
+	if depth > maxDepth {
+		return errors.New("thrift: maximum nesting depth exceeded")
+	}
+	var err error
+	switch typ {
+	case typeBool, typeByte:
+		_, err = d.next(1)
+	case typeI16:
+		_, err = d.next(2)
+	case typeI32:
+		_, err = d.next(4)
+	case typeDouble, typeI64:
+		_, err = d.next(8)
+	case typeString:
+		_, err = d.readBinary()
+	case typeStruct:
+		err = d.readStruct(func(id int16, typ byte) (bool, error) {
+			return true, d.skip(typ, depth+1)
+		})
+	case typeMap:
+		var ktyp, vtyp byte
+		var n int32
+		if ktyp, err = d.readByte(); err != nil {
+			return err
+		}
+		if vtyp, err = d.readByte(); err != nil {
+			return err
+		}
+		if n, err = d.readI32(); err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(d.buf)-d.off {
+			return errShortBuffer
+		}
+		for i := int32(0); i < n && err == nil; i++ {
+			if err = d.skip(ktyp, depth+1); err == nil {
+				err = d.skip(vtyp, depth+1)
+			}
+		}
+	case typeSet, typeList:
+		var etyp byte
+		var n int32
+		if etyp, err = d.readByte(); err != nil {
+			return err
+		}
+		if n, err = d.readI32(); err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(d.buf)-d.off {
+			return errShortBuffer
+		}
+		for i := int32(0); i < n && err == nil; i++ {
+			err = d.skip(etyp, depth+1)
+		}
+	default:
+		err = fmt.Errorf("thrift: unknown type %d", typ)
+	}
+	return err
+}
+
+func (d *decoder) readProcess(p *Process) error {
+	return d.readStruct(func(id int16, typ byte) (bool, error) {
+		switch {
+		case id == 1 && typ == typeString:
+			var err error
+			p.ServiceName, err = d.readString()
+			return true, err
+		case id == 2 && typ == typeList:
+			return true, d.readTags(&p.Tags)
+		}
+		return false, nil
+	})
+}
+
+func (d *decoder) readTags(tags *[]*Tag) error {
+	return d.readList(typeStruct, func() error {
+		tag := &Tag{}
+		*tags = append(*tags, tag)
+		return d.readTag(tag)
+	})
+}
+
+func (d *decoder) readTag(t *Tag) error {
+	return d.readStruct(func(id int16, typ byte) (bool, error) {
+		var err error
+		switch {
+		case id == 1 && typ == typeString:
+			t.Key, err = d.readString()
+		case id == 2 && typ == typeI32:
+			var v int32
+			v, err = d.readI32()
+			t.VType = TagType(v)
+		case id == 3 && typ == typeString:
+			t.VStr, err = d.readString()
+		case id == 4 && typ == typeDouble:
+			t.VDouble, err = d.readDouble()
+		case id == 5 && typ == typeBool:
+			var b byte
+			b, err = d.readByte()
+			t.VBool = b != 0
+		case id == 6 && typ == typeI64:
+			t.VLong, err = d.readI64()
+		case id == 7 && typ == typeString:
+			t.VBinary, err = d.readBinary()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func (d *decoder) readSpan(s *Span) error {
+	return d.readStruct(func(id int16, typ byte) (bool, error) {
+		var err error
+		switch {
+		case id == 1 && typ == typeI64:
+			s.TraceIDLow, err = d.readI64()
+		case id == 2 && typ == typeI64:
+			s.TraceIDHigh, err = d.readI64()
+		case id == 3 && typ == typeI64:
+			s.SpanID, err = d.readI64()
+		case id == 4 && typ == typeI64:
+			s.ParentSpanID, err = d.readI64()
+		case id == 5 && typ == typeString:
+			s.OperationName, err = d.readString()
+		case id == 6 && typ == typeList:
+			err = d.readList(typeStruct, func() error {
+				ref := &SpanRef{}
+				s.References = append(s.References, ref)
+				return d.readSpanRef(ref)
+			})
+		case id == 7 && typ == typeI32:
+			s.Flags, err = d.readI32()
+		case id == 8 && typ == typeI64:
+			s.StartTime, err = d.readI64()
+		case id == 9 && typ == typeI64:
+			s.Duration, err = d.readI64()
+		case id == 10 && typ == typeList:
+			err = d.readTags(&s.Tags)
+		case id == 11 && typ == typeList:
+			err = d.readList(typeStruct, func() error {
+				l := &Log{}
+				s.Logs = append(s.Logs, l)
+				return d.readLog(l)
+			})
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func (d *decoder) readSpanRef(r *SpanRef) error {
+	return d.readStruct(func(id int16, typ byte) (bool, error) {
+		var err error
+		switch {
+		case id == 1 && typ == typeI32:
+			var v int32
+			v, err = d.readI32()
+			r.RefType = SpanRefType(v)
+		case id == 2 && typ == typeI64:
+			r.TraceIDLow, err = d.readI64()
+		case id == 3 && typ == typeI64:
+			r.TraceIDHigh, err = d.readI64()
+		case id == 4 && typ == typeI64:
+			r.SpanID, err = d.readI64()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func (d *decoder) readLog(l *Log) error {
+	return d.readStruct(func(id int16, typ byte) (bool, error) {
+		switch {
+		case id == 1 && typ == typeI64:
+			var err error
+			l.Timestamp, err = d.readI64()
+			return true, err
+		case id == 2 && typ == typeList:
+			return true, d.readTags(&l.Fields)
+		}
+		return false, nil
+	})
+}
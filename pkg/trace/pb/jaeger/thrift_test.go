@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package jaeger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encoder writes the thrift binary protocol, it is used to build the test payloads.
+type encoder struct{ bytes.Buffer }
+
+func (e *encoder) field(typ byte, id int16) {
+	e.WriteByte(typ)
+	binary.Write(&e.Buffer, binary.BigEndian, id)
+}
+
+func (e *encoder) stop() { e.WriteByte(typeStop) }
+
+func (e *encoder) i32(id int16, v int32) {
+	e.field(typeI32, id)
+	binary.Write(&e.Buffer, binary.BigEndian, v)
+}
+
+func (e *encoder) i64(id int16, v int64) {
+	e.field(typeI64, id)
+	binary.Write(&e.Buffer, binary.BigEndian, v)
+}
+
+func (e *encoder) double(id int16, v float64) {
+	e.field(typeDouble, id)
+	binary.Write(&e.Buffer, binary.BigEndian, math.Float64bits(v))
+}
+
+func (e *encoder) bool(id int16, v bool) {
+	e.field(typeBool, id)
+	if v {
+		e.WriteByte(1)
+	} else {
+		e.WriteByte(0)
+	}
+}
+
+func (e *encoder) string(id int16, v string) {
+	e.field(typeString, id)
+	binary.Write(&e.Buffer, binary.BigEndian, int32(len(v)))
+	e.WriteString(v)
+}
+
+func (e *encoder) list(id int16, typ byte, n int) {
+	e.field(typeList, id)
+	e.WriteByte(typ)
+	binary.Write(&e.Buffer, binary.BigEndian, int32(n))
+}
+
+func testBatch() []byte {
+	var e encoder
+	// process
+	e.field(typeStruct, 1)
+	e.string(1, "web")
+	e.list(2, typeStruct, 1)
+	e.string(1, "hostname")
+	e.i32(2, int32(TagTypeString))
+	e.string(3, "host-1")
+	e.stop()
+	e.stop()
+	// spans
+	e.list(2, typeStruct, 1)
+	e.i64(1, 42)
+	e.i64(2, -1)
+	e.i64(3, 2)
+	e.i64(4, 0)
+	e.string(5, "GET /users")
+	e.list(6, typeStruct, 1)
+	e.i32(1, int32(SpanRefTypeChildOf))
+	e.i64(2, 42)
+	e.i64(3, -1)
+	e.i64(4, 1)
+	e.stop()
+	e.i32(7, 1)
+	e.i64(8, 1000)
+	e.i64(9, 20)
+	e.list(10, typeStruct, 3)
+	e.string(1, "span.kind")
+	e.i32(2, int32(TagTypeString))
+	e.string(3, "server")
+	e.stop()
+	e.string(1, "ratio")
+	e.i32(2, int32(TagTypeDouble))
+	e.double(4, 0.5)
+	e.stop()
+	e.string(1, "error")
+	e.i32(2, int32(TagTypeBool))
+	e.bool(5, true)
+	e.stop()
+	e.list(11, typeStruct, 1)
+	e.i64(1, 1010)
+	e.list(2, typeStruct, 1)
+	e.string(1, "event")
+	e.string(3, "error")
+	e.stop()
+	e.stop()
+	// unknown field, skipped by the decoder
+	e.field(typeMap, 99)
+	e.WriteByte(typeString)
+	e.WriteByte(typeI32)
+	binary.Write(&e.Buffer, binary.BigEndian, int32(1))
+	binary.Write(&e.Buffer, binary.BigEndian, int32(1))
+	e.WriteString("k")
+	binary.Write(&e.Buffer, binary.BigEndian, int32(7))
+	e.stop()
+	e.stop()
+	return e.Bytes()
+}
+
+func TestUnmarshalBatch(t *testing.T) {
+	batch, err := UnmarshalBatch(testBatch())
+	require.NoError(t, err)
+	assert.Equal(t, &Batch{
+		Process: &Process{
+			ServiceName: "web",
+			Tags:        []*Tag{{Key: "hostname", VType: TagTypeString, VStr: "host-1"}},
+		},
+		Spans: []*Span{{
+			TraceIDLow:    42,
+			TraceIDHigh:   -1,
+			SpanID:        2,
+			OperationName: "GET /users",
+			References:    []*SpanRef{{RefType: SpanRefTypeChildOf, TraceIDLow: 42, TraceIDHigh: -1, SpanID: 1}},
+			Flags:         1,
+			StartTime:     1000,
+			Duration:      20,
+			Tags: []*Tag{
+				{Key: "span.kind", VType: TagTypeString, VStr: "server"},
+				{Key: "ratio", VType: TagTypeDouble, VDouble: 0.5},
+				{Key: "error", VType: TagTypeBool, VBool: true},
+			},
+			Logs: []*Log{{
+				Timestamp: 1010,
+				Fields:    []*Tag{{Key: "event", VStr: "error"}},
+			}},
+		}},
+	}, batch)
+}
+
+func TestUnmarshalBatchInvalid(t *testing.T) {
+	data := testBatch()
+	for i := 0; i < len(data); i++ {
+		_, err := UnmarshalBatch(data[:i])
+		assert.Error(t, err, "truncated at %d bytes", i)
+	}
+
+	// list larger than the payload
+	var e encoder
+	e.list(2, typeStruct, math.MaxInt32)
+	_, err := UnmarshalBatch(e.Bytes())
+	assert.Error(t, err)
+
+	// unknown type
+	_, err = UnmarshalBatch([]byte{42, 0, 1})
+	assert.Error(t, err)
+}
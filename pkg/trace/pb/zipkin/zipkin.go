@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package zipkin contains the Zipkin v2 protobuf messages received by the trace-agent, as
+// described in zipkin.proto. The messages only carry the protobuf struct tags of protoc-gen-gogo
+// and are unmarshaled by the table-driven codec of gogo/protobuf.
+package zipkin
+
+import (
+	proto "github.com/gogo/protobuf/proto"
+)
+
+// Span_Kind is the kind of a span.
+type Span_Kind int32
+
+// Span kinds
+const (
+	Span_SPAN_KIND_UNSPECIFIED Span_Kind = 0
+	Span_CLIENT                Span_Kind = 1
+	Span_SERVER                Span_Kind = 2
+	Span_PRODUCER              Span_Kind = 3
+	Span_CONSUMER              Span_Kind = 4
+)
+
+// Span is a single-host view of an operation.
+type Span struct {
+	TraceId        []byte            `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	ParentId       []byte            `protobuf:"bytes,2,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Id             []byte            `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Kind           Span_Kind         `protobuf:"varint,4,opt,name=kind,proto3,enum=zipkin.proto3.Span_Kind" json:"kind,omitempty"`
+	Name           string            `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Timestamp      uint64            `protobuf:"fixed64,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Duration       uint64            `protobuf:"varint,7,opt,name=duration,proto3" json:"duration,omitempty"`
+	LocalEndpoint  *Endpoint         `protobuf:"bytes,8,opt,name=local_endpoint,json=localEndpoint,proto3" json:"local_endpoint,omitempty"`
+	RemoteEndpoint *Endpoint         `protobuf:"bytes,9,opt,name=remote_endpoint,json=remoteEndpoint,proto3" json:"remote_endpoint,omitempty"`
+	Annotations    []*Annotation     `protobuf:"bytes,10,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	Tags           map[string]string `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Debug          bool              `protobuf:"varint,12,opt,name=debug,proto3" json:"debug,omitempty"`
+	Shared         bool              `protobuf:"varint,13,opt,name=shared,proto3" json:"shared,omitempty"`
+}
+
+func (m *Span) Reset()         { *m = Span{} }
+func (m *Span) String() string { return proto.CompactTextString(m) }
+func (*Span) ProtoMessage()    {}
+
+// Endpoint is the network context of a node in the service graph.
+type Endpoint struct {
+	ServiceName string `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Ipv4        []byte `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6        []byte `protobuf:"bytes,3,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	Port        int32  `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+}
+
+func (m *Endpoint) Reset()         { *m = Endpoint{} }
+func (m *Endpoint) String() string { return proto.CompactTextString(m) }
+func (*Endpoint) ProtoMessage()    {}
+
+// Annotation associates an event that explains latency with a timestamp.
+type Annotation struct {
+	Timestamp uint64 `protobuf:"fixed64,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value     string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Annotation) Reset()         { *m = Annotation{} }
+func (m *Annotation) String() string { return proto.CompactTextString(m) }
+func (*Annotation) ProtoMessage()    {}
+
+// ListOfSpans is the payload of the /api/v2/spans endpoint in protobuf.
+type ListOfSpans struct {
+	Spans []*Span `protobuf:"bytes,1,rep,name=spans,proto3" json:"spans,omitempty"`
+}
+
+func (m *ListOfSpans) Reset()         { *m = ListOfSpans{} }
+func (m *ListOfSpans) String() string { return proto.CompactTextString(m) }
+func (*ListOfSpans) ProtoMessage()    {}
@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// tailDecisionsLimit bounds the number of sampling decisions remembered to handle the spans
+	// arriving after the decision on their trace.
+	tailDecisionsLimit = 100000
+	// tailMaxCheckPeriod is the maximum period at which the expired traces are decided.
+	tailMaxCheckPeriod = time.Second
+	// tailReportPeriod is the period at which the telemetry of the sampler is reported.
+	tailReportPeriod = 10 * time.Second
+)
+
+// tailTrace holds the spans of a trace buffered by the TailSampler.
+type tailTrace struct {
+	spans   pb.Trace
+	arrival time.Time
+}
+
+// TailSampler samples complete traces. It buffers the spans of each trace for a decision window,
+// counted from the arrival of its first span, then keeps the trace if any of the configured
+// policies matches it. The kept traces are passed to the flush function, along with the spans
+// of these traces arriving after the decision.
+type TailSampler struct {
+	conf  *config.TailSamplingConfig
+	flush func([]pb.Trace)
+
+	mu     sync.Mutex
+	traces map[uint64]*tailTrace
+	queue  []uint64 // IDs of the buffered traces, in arrival order
+	spans  int      // number of buffered spans
+
+	decisions map[uint64]bool
+	decided   []uint64 // ring of the IDs of the decisions, in decision order
+	next      int      // position of the oldest decision in the ring once it is full
+
+	// telemetry, reset on each report
+	kept    map[string]int64
+	dropped int64
+	early   int64
+	late    int64
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// NewTailSampler returns a TailSampler configured by conf, which passes the kept traces to flush.
+func NewTailSampler(conf *config.AgentConfig, flush func([]pb.Trace)) *TailSampler {
+	return &TailSampler{
+		conf:      conf.TailSampling,
+		flush:     flush,
+		traces:    make(map[uint64]*tailTrace),
+		decisions: make(map[uint64]bool),
+		kept:      make(map[string]int64),
+		exit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start starts deciding the traces at the end of their window.
+func (s *TailSampler) Start() {
+	period := tailMaxCheckPeriod
+	if w := s.conf.DecisionWait; w > 0 && w < period {
+		period = w
+	}
+	go func() {
+		defer close(s.done)
+		check := time.NewTicker(period)
+		defer check.Stop()
+		report := time.NewTicker(tailReportPeriod)
+		defer report.Stop()
+		for {
+			select {
+			case now := <-check.C:
+				s.expire(now)
+			case <-report.C:
+				s.report()
+			case <-s.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the sampler and decides all the buffered traces without waiting for the end of their window.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+	s.mu.Lock()
+	var kept []pb.Trace
+	for len(s.queue) > 0 {
+		if t := s.decideOldest(); t != nil {
+			kept = append(kept, t)
+		}
+	}
+	s.mu.Unlock()
+	if len(kept) > 0 {
+		s.flush(kept)
+	}
+	s.report()
+}
+
+// Add buffers the spans of a trace chunk. The spans of the traces already decided are flushed
+// right away if their trace was kept and dropped otherwise.
+func (s *TailSampler) Add(t pb.Trace) {
+	s.add(time.Now(), t)
+}
+
+func (s *TailSampler) add(now time.Time, t pb.Trace) {
+	if len(t) == 0 {
+		return
+	}
+	id := t[0].TraceID
+	var kept []pb.Trace
+
+	s.mu.Lock()
+	if keep, ok := s.decisions[id]; ok {
+		s.late += int64(len(t))
+		s.mu.Unlock()
+		if keep {
+			s.flush([]pb.Trace{t})
+		}
+		return
+	}
+	tt, ok := s.traces[id]
+	if !ok {
+		tt = &tailTrace{arrival: now}
+		s.traces[id] = tt
+		s.queue = append(s.queue, id)
+	}
+	tt.spans = append(tt.spans, t...)
+	s.spans += len(t)
+	for s.spans > s.conf.MaxBufferedSpans && len(s.queue) > 0 {
+		// too many buffered spans: decide the oldest traces without waiting for the end of their window
+		s.early++
+		if t := s.decideOldest(); t != nil {
+			kept = append(kept, t)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(kept) > 0 {
+		s.flush(kept)
+	}
+}
+
+// expire decides the traces whose window ended at now.
+func (s *TailSampler) expire(now time.Time) {
+	var kept []pb.Trace
+	s.mu.Lock()
+	for len(s.queue) > 0 && !s.traces[s.queue[0]].arrival.Add(s.conf.DecisionWait).After(now) {
+		if t := s.decideOldest(); t != nil {
+			kept = append(kept, t)
+		}
+	}
+	s.mu.Unlock()
+	if len(kept) > 0 {
+		s.flush(kept)
+	}
+}
+
+// decideOldest removes the oldest buffered trace, decides whether it is kept and returns it if so.
+// It must be called with the lock held and at least one buffered trace.
+func (s *TailSampler) decideOldest() pb.Trace {
+	id := s.queue[0]
+	s.queue = s.queue[1:]
+	t := s.traces[id].spans
+	delete(s.traces, id)
+	s.spans -= len(t)
+
+	policy, keep := s.decide(t)
+	s.remember(id, keep)
+	if !keep {
+		s.dropped++
+		return nil
+	}
+	s.kept[policy]++
+	return t
+}
+
+// decide returns the name of the first policy matching the trace t, and whether there is one.
+func (s *TailSampler) decide(t pb.Trace) (string, bool) {
+	root := traceutil.GetRoot(t)
+	for _, p := range s.conf.Policies {
+		if matchTailPolicy(p, t, root) {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// remember records the decision on the trace id, forgetting the oldest decision once there are
+// tailDecisionsLimit of them. It must be called with the lock held.
+func (s *TailSampler) remember(id uint64, keep bool) {
+	if len(s.decided) < tailDecisionsLimit {
+		s.decided = append(s.decided, id)
+	} else {
+		delete(s.decisions, s.decided[s.next])
+		s.decided[s.next] = id
+		s.next = (s.next + 1) % tailDecisionsLimit
+	}
+	s.decisions[id] = keep
+}
+
+// matchTailPolicy reports whether the policy p matches the trace t, whose root span is root.
+func matchTailPolicy(p *config.TailSamplingPolicy, t pb.Trace, root *pb.Span) bool {
+	switch p.Type {
+	case config.TailPolicyError:
+		return traceHasError(t)
+	case config.TailPolicyLatency:
+		return root.Duration >= p.ThresholdMs*int64(time.Millisecond)
+	case config.TailPolicyTag:
+		for _, span := range t {
+			v, ok := span.Meta[p.Key]
+			if ok && (p.Re == nil || p.Re.MatchString(v)) {
+				return true
+			}
+		}
+		return false
+	case config.TailPolicyRate:
+		if p.Service != "" && root.Service != p.Service {
+			return false
+		}
+		return SampleByRate(root.TraceID, p.Rate)
+	}
+	return false
+}
+
+// traceHasError reports whether a span of the trace t has an error.
+func traceHasError(t pb.Trace) bool {
+	for _, span := range t {
+		if span.Error != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TailSampler) report() {
+	s.mu.Lock()
+	traces, spans := len(s.traces), s.spans
+	kept := s.kept
+	dropped, early, late := s.dropped, s.early, s.late
+	s.kept = make(map[string]int64, len(kept))
+	s.dropped, s.early, s.late = 0, 0, 0
+	s.mu.Unlock()
+
+	metrics.Gauge("datadog.trace_agent.tail_sampler.traces_buffered", float64(traces), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.spans_buffered", float64(spans), nil, 1)
+	for policy, n := range kept {
+		metrics.Count("datadog.trace_agent.tail_sampler.kept", n, []string{"policy:" + policy}, 1)
+	}
+	metrics.Count("datadog.trace_agent.tail_sampler.dropped", dropped, nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.early_decisions", early, nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.late_spans", late, nil, 1)
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tailSink collects the traces flushed by a TailSampler.
+type tailSink struct {
+	mu     sync.Mutex
+	traces []pb.Trace
+}
+
+func (s *tailSink) flush(traces []pb.Trace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traces = append(s.traces, traces...)
+}
+
+func (s *tailSink) flushed() []pb.Trace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.traces
+}
+
+func newTestTailSampler(maxSpans int, policies ...*config.TailSamplingPolicy) (*TailSampler, *tailSink) {
+	conf := config.New()
+	conf.TailSampling = &config.TailSamplingConfig{
+		Enabled:          true,
+		DecisionWait:     10 * time.Second,
+		MaxBufferedSpans: maxSpans,
+		Policies:         policies,
+	}
+	sink := &tailSink{}
+	return NewTailSampler(conf, sink.flush), sink
+}
+
+func TestMatchTailPolicy(t *testing.T) {
+	root := &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Duration: int64(200 * time.Millisecond)}
+	child := &pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Service: "db", Meta: map[string]string{"customer.tier": "gold"}}
+	trace := pb.Trace{root, child}
+
+	for name, tt := range map[string]struct {
+		policy *config.TailSamplingPolicy
+		match  bool
+	}{
+		"error":            {&config.TailSamplingPolicy{Type: "error"}, false},
+		"latency-below":    {&config.TailSamplingPolicy{Type: "latency", ThresholdMs: 500}, false},
+		"latency-above":    {&config.TailSamplingPolicy{Type: "latency", ThresholdMs: 200}, true},
+		"tag-any-value":    {&config.TailSamplingPolicy{Type: "tag", Key: "customer.tier"}, true},
+		"tag-pattern":      {&config.TailSamplingPolicy{Type: "tag", Key: "customer.tier", Re: regexp.MustCompile("^gold$")}, true},
+		"tag-no-match":     {&config.TailSamplingPolicy{Type: "tag", Key: "customer.tier", Re: regexp.MustCompile("^silver$")}, false},
+		"tag-missing":      {&config.TailSamplingPolicy{Type: "tag", Key: "tenant"}, false},
+		"rate-all":         {&config.TailSamplingPolicy{Type: "rate", Rate: 1}, true},
+		"rate-none":        {&config.TailSamplingPolicy{Type: "rate", Rate: 0}, false},
+		"rate-service":     {&config.TailSamplingPolicy{Type: "rate", Service: "web", Rate: 1}, true},
+		"rate-not-service": {&config.TailSamplingPolicy{Type: "rate", Service: "db", Rate: 1}, false},
+	} {
+		assert.Equal(t, tt.match, matchTailPolicy(tt.policy, trace, root), name)
+	}
+
+	child.Error = 1
+	assert.True(t, matchTailPolicy(&config.TailSamplingPolicy{Type: "error"}, trace, root))
+}
+
+func TestTailSamplerDecisionWindow(t *testing.T) {
+	assert := assert.New(t)
+	s, sink := newTestTailSampler(1000, &config.TailSamplingPolicy{Name: "errors", Type: "error"})
+	now := time.Now()
+
+	// the spans of the trace 1 arrive in two chunks, the error being in the second one
+	s.add(now, pb.Trace{{TraceID: 1, SpanID: 1}})
+	s.add(now, pb.Trace{{TraceID: 2, SpanID: 3}})
+	s.add(now.Add(5*time.Second), pb.Trace{{TraceID: 1, SpanID: 2, ParentID: 1, Error: 1}})
+
+	s.expire(now.Add(9 * time.Second))
+	assert.Empty(sink.flushed())
+	assert.Len(s.traces, 2)
+	assert.Equal(3, s.spans)
+
+	s.expire(now.Add(10 * time.Second))
+	require.Len(t, sink.flushed(), 1)
+	assert.Len(sink.flushed()[0], 2)
+	assert.Empty(s.traces)
+	assert.Equal(0, s.spans)
+	assert.EqualValues(1, s.kept["errors"])
+	assert.EqualValues(1, s.dropped)
+
+	// the late spans follow the decision on their trace
+	s.add(now.Add(11*time.Second), pb.Trace{{TraceID: 1, SpanID: 4, ParentID: 1}})
+	s.add(now.Add(11*time.Second), pb.Trace{{TraceID: 2, SpanID: 5, ParentID: 3}})
+	require.Len(t, sink.flushed(), 2)
+	assert.Equal(uint64(4), sink.flushed()[1][0].SpanID)
+	assert.Empty(s.traces)
+	assert.EqualValues(2, s.late)
+}
+
+func TestTailSamplerMaxBufferedSpans(t *testing.T) {
+	assert := assert.New(t)
+	s, sink := newTestTailSampler(3, &config.TailSamplingPolicy{Name: "all", Type: "rate", Rate: 1})
+	now := time.Now()
+
+	s.add(now, pb.Trace{{TraceID: 1, SpanID: 1}, {TraceID: 1, SpanID: 2}})
+	s.add(now, pb.Trace{{TraceID: 2, SpanID: 3}})
+	assert.Empty(sink.flushed())
+
+	// the oldest trace is decided early to stay within the limit
+	s.add(now, pb.Trace{{TraceID: 3, SpanID: 4}})
+	require.Len(t, sink.flushed(), 1)
+	assert.Equal(uint64(1), sink.flushed()[0][0].TraceID)
+	assert.Equal(2, s.spans)
+	assert.EqualValues(1, s.early)
+	assert.Equal([]uint64{2, 3}, s.queue)
+}
+
+func TestTailSamplerDecisionsLimit(t *testing.T) {
+	s, _ := newTestTailSampler(1000)
+	for id := uint64(0); id < tailDecisionsLimit+10; id++ {
+		s.remember(id, false)
+	}
+	assert.Len(t, s.decisions, tailDecisionsLimit)
+	assert.NotContains(t, s.decisions, uint64(9))
+	assert.Contains(t, s.decisions, uint64(10))
+	assert.Contains(t, s.decisions, uint64(tailDecisionsLimit+9))
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s, sink := newTestTailSampler(1000, &config.TailSamplingPolicy{Name: "all", Type: "rate", Rate: 1})
+	s.Start()
+	s.Add(pb.Trace{{TraceID: 1, SpanID: 1}})
+	s.Add(pb.Trace{{TraceID: 2, SpanID: 2}})
+	s.Stop()
+	assert.Len(t, sink.flushed(), 2)
+	assert.Empty(t, s.traces)
+}
@@ -15,6 +15,9 @@ const (
 	tagVersion    = "version"
 	tagOrigin     = "_dd.origin"
 	tagSynthetics = "synthetics"
+
+	// tagValueOverflow replaces the values of an extra aggregator once its cardinality limit is reached.
+	tagValueOverflow = "_overflow"
 )
 
 // Aggregation contains all the dimension on which we aggregate statistics
@@ -29,6 +32,8 @@ type Aggregation struct {
 	StatusCode uint32
 	Version    string
 	Synthetics bool
+	// PeerTags are the comma-separated "key:value" tags of the extra aggregators set on the span.
+	PeerTags string
 }
 
 func getStatusCode(s *pb.Span) uint32 {
@@ -62,3 +67,55 @@ func NewAggregationFromSpan(s *pb.Span, env string, agentHostname string) Aggreg
 		Synthetics: synthetics,
 	}
 }
+
+// extraAggregators computes the extra dimensions of the stats of the spans from the values of
+// their configured tags. The number of distinct values of each tag is limited to maxCardinality
+// until reset is called, the spans with new values beyond it are aggregated in an overflow bucket.
+type extraAggregators struct {
+	tags           []string
+	maxCardinality int
+	values         map[string]map[string]struct{}
+}
+
+func newExtraAggregators(tags []string, maxCardinality int) *extraAggregators {
+	e := &extraAggregators{
+		tags:           tags,
+		maxCardinality: maxCardinality,
+	}
+	e.reset()
+	return e
+}
+
+// reset forgets the values seen for each tag.
+func (e *extraAggregators) reset() {
+	e.values = make(map[string]map[string]struct{}, len(e.tags))
+	for _, tag := range e.tags {
+		e.values[tag] = make(map[string]struct{})
+	}
+}
+
+// peerTags returns the comma-separated "key:value" tags of the extra aggregators set on the span,
+// in the order of the configuration.
+func (e *extraAggregators) peerTags(s *pb.Span) string {
+	var b strings.Builder
+	for _, tag := range e.tags {
+		v := traceutil.GetMetaDefault(s, tag, "")
+		if v == "" {
+			continue
+		}
+		values := e.values[tag]
+		if _, ok := values[v]; !ok {
+			if e.maxCardinality > 0 && len(values) >= e.maxCardinality {
+				v = tagValueOverflow
+			} else {
+				values[v] = struct{}{}
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		// normalizing replaces the commas of the values
+		b.WriteString(traceutil.NormalizeTag(tag + ":" + v))
+	}
+	return b.String()
+}
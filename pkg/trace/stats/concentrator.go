@@ -43,6 +43,8 @@ type Concentrator struct {
 	mu            sync.Mutex
 	agentEnv      string
 	agentHostname string
+	// extraAggregators computes the extra dimensions of the stats, it is nil when none is configured.
+	extraAggregators *extraAggregators
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -62,6 +64,9 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
 	}
+	if len(conf.ExtraAggregators) > 0 {
+		c.extraAggregators = newExtraAggregators(conf.ExtraAggregators, conf.ExtraAggregatorsMaxCardinality)
+	}
 	return &c
 }
 
@@ -150,7 +155,13 @@ func (c *Concentrator) addNow(i *Input) {
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
 			c.buckets[btime] = b
 		}
-		b.HandleSpan(s, env, c.agentHostname)
+		if c.extraAggregators == nil {
+			b.HandleSpan(s, env, c.agentHostname)
+			continue
+		}
+		aggr := NewAggregationFromSpan(s.Span, env, c.agentHostname)
+		aggr.PeerTags = c.extraAggregators.peerTags(s.Span)
+		b.add(s, aggr)
 	}
 }
 
@@ -183,6 +194,10 @@ func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 		log.Debugf("update oldestTs to %d", newOldestTs)
 		c.oldestTs = newOldestTs
 	}
+	if c.extraAggregators != nil {
+		// the cardinality of the extra aggregators is limited per flush
+		c.extraAggregators.reset()
+	}
 	c.mu.Unlock()
 	sb := make([]pb.ClientStatsPayload, 0, len(m))
 	for k, s := range m {
@@ -8,6 +8,7 @@ package stats
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -256,6 +257,50 @@ func TestConcentratorStatsTotals(t *testing.T) {
 	})
 }
 
+// TestConcentratorExtraAggregators tests that the spans are grouped by their extra aggregators
+// and that the values above the cardinality limit fall into an overflow group.
+func TestConcentratorExtraAggregators(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	cfg := config.AgentConfig{
+		BucketInterval:                 time.Duration(testBucketInterval),
+		DefaultEnv:                     "env",
+		Hostname:                       "hostname",
+		ExtraAggregators:               []string{"peer.service", "tenant"},
+		ExtraAggregatorsMaxCardinality: 1,
+	}
+	c := NewConcentrator(&cfg, make(chan pb.StatsPayload), now)
+	alignedNow := alignTs(now.UnixNano(), c.bsize)
+	c.oldestTs = alignedNow - int64(c.bufferLen)*c.bsize
+
+	withMeta := func(s *pb.Span, meta map[string]string) *pb.Span {
+		s.Meta = meta
+		return s
+	}
+	trace := pb.Trace{
+		withMeta(testSpan(1, 0, 10, 2, "A1", "resource1", 0), map[string]string{"tenant": "a", "peer.service": "db"}),
+		withMeta(testSpan(2, 0, 20, 2, "A1", "resource1", 0), map[string]string{"tenant": "a", "peer.service": "db"}),
+		withMeta(testSpan(3, 0, 30, 2, "A1", "resource1", 0), map[string]string{"tenant": "b", "peer.service": "db"}),
+		testSpan(4, 0, 40, 2, "A1", "resource1", 0),
+	}
+	traceutil.ComputeTopLevel(trace)
+	c.addNow(&Input{Env: "none", Trace: NewWeightedTrace(trace, traceutil.GetRoot(trace))})
+
+	stats := c.flushNow(alignedNow)
+	if !assert.Len(stats.Stats, 1) || !assert.Len(stats.Stats[0].Stats, 1) {
+		t.FailNow()
+	}
+	hitsByTags := make(map[string]uint64)
+	for _, gs := range stats.Stats[0].Stats[0].Stats {
+		hitsByTags[strings.Join(gs.PeerTags, ",")] += gs.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"peer.service:db,tenant:a":         2,
+		"peer.service:db,tenant:_overflow": 1,
+		"":                                 1,
+	}, hitsByTags)
+}
+
 // TestConcentratorStatsCounts tests exhaustively each stats bucket, over multiple time buckets.
 func TestConcentratorStatsCounts(t *testing.T) {
 	assert := assert.New(t)
@@ -73,9 +73,18 @@ func (s *groupedStats) export(k statsKey) (pb.ClientGroupedStats, error) {
 		OkSummary:      okSummary,
 		ErrorSummary:   errSummary,
 		Synthetics:     k.aggr.Synthetics,
+		PeerTags:       splitPeerTags(k.aggr.PeerTags),
 	}, nil
 }
 
+// splitPeerTags returns the list of tags of the comma-separated peer tags of an aggregation.
+func splitPeerTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
 func newGroupedStats() *groupedStats {
 	okSketch, err := ddsketch.LogCollapsingLowestDenseDDSketch(relativeAccuracy, maxNumBins)
 	if err != nil {
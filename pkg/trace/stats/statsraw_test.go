@@ -41,6 +41,27 @@ func TestGrainWithExtraTags(t *testing.T) {
 	}, aggr)
 }
 
+func TestExtraAggregatorsPeerTags(t *testing.T) {
+	assert := assert.New(t)
+	e := newExtraAggregators([]string{"peer.service", "tenant"}, 2)
+	span := func(meta map[string]string) *pb.Span { return &pb.Span{Meta: meta} }
+
+	assert.Equal("", e.peerTags(span(nil)))
+	assert.Equal("peer.service:postgres,tenant:a", e.peerTags(span(map[string]string{"tenant": "a", "peer.service": "postgres", "other": "x"})))
+	assert.Equal("tenant:b_c", e.peerTags(span(map[string]string{"tenant": "b,c"})))
+	// the cardinality limit of tenant is reached, known values are kept
+	assert.Equal("tenant:_overflow", e.peerTags(span(map[string]string{"tenant": "d"})))
+	assert.Equal("tenant:a", e.peerTags(span(map[string]string{"tenant": "a"})))
+
+	e.reset()
+	assert.Equal("tenant:d", e.peerTags(span(map[string]string{"tenant": "d"})))
+
+	unlimited := newExtraAggregators([]string{"tenant"}, 0)
+	for i := 0; i < 10; i++ {
+		assert.NotEqual("tenant:_overflow", unlimited.peerTags(span(map[string]string{"tenant": string(rune('a' + i))})))
+	}
+}
+
 func BenchmarkHandleSpanRandom(b *testing.B) {
 	sb := NewRawBucket(0, 1e9)
 	b.ResetTimer()
@@ -9,6 +9,7 @@ package containerd
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -35,6 +36,7 @@ var (
 
 // ContainerdItf is the interface implementing a subset of methods that leverage the Containerd api.
 type ContainerdItf interface {
+	Container(id string) (containerd.Container, error)
 	Containers() ([]containerd.Container, error)
 	GetEvents() containerd.EventService
 	Info(ctn containerd.Container) (containers.Container, error)
@@ -43,6 +45,7 @@ type ContainerdItf interface {
 	Metadata() (containerd.Version, error)
 	Namespace() string
 	TaskMetrics(ctn containerd.Container) (*types.Metric, error)
+	TaskPid(ctn containerd.Container) (uint32, error)
 	TaskPids(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 }
 
@@ -142,6 +145,14 @@ func (c *ContainerdUtil) Containers() ([]containerd.Container, error) {
 	return c.cl.Containers(ctxNamespace)
 }
 
+// Container interfaces with the containerd api to get a Container by its ID.
+func (c *ContainerdUtil) Container(id string) (containerd.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+	return c.cl.LoadContainer(ctxNamespace, id)
+}
+
 // ImageSize interfaces with the containerd api to get the size of an image
 func (c *ContainerdUtil) ImageSize(ctn containerd.Container) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
@@ -187,6 +198,28 @@ func (c *ContainerdUtil) TaskMetrics(ctn containerd.Container) (*types.Metric, e
 	return t.Metrics(ctxNamespace)
 }
 
+// TaskPid interfaces with the containerd api to get the pid of the init process of a running container
+func (c *ContainerdUtil) TaskPid(ctn containerd.Container) (uint32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+
+	t, errTask := ctn.Task(ctxNamespace, nil)
+	if errTask != nil {
+		return 0, errTask
+	}
+
+	status, err := t.Status(ctxNamespace)
+	if err != nil {
+		return 0, err
+	}
+	if status.Status != containerd.Running {
+		return 0, fmt.Errorf("task of container %s is %s", ctn.ID(), status.Status)
+	}
+
+	return t.Pid(), nil
+}
+
 // TaskPids interfaces with the containerd api to get the pids from a container
 func (c *ContainerdUtil) TaskPids(ctn containerd.Container) ([]containerd.ProcessInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
@@ -17,6 +17,7 @@ const (
 	RuntimeNameContainerd string = "containerd"
 	RuntimeNameCRIO       string = "cri-o"
 	RuntimeNameGarden     string = "garden"
+	RuntimeNamePodman     string = "podman"
 )
 
 // Supported container states
@@ -183,6 +183,12 @@ func getKubeDynamicClient(timeout time.Duration) (dynamic.Interface, error) {
 	return dynamic.NewForConfig(clientConfig)
 }
 
+// NewDynamicClient returns a client to access custom resources, use a zero timeout for informers
+// to allow long watch.
+func NewDynamicClient(timeout time.Duration) (dynamic.Interface, error) {
+	return getKubeDynamicClient(timeout)
+}
+
 func getWPAInformerFactory() (dynamic_informer.DynamicSharedInformerFactory, error) {
 	// default to 300s
 	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2017-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package autoscalers
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// DatadogBackend evaluates the queries against the Datadog metrics query API
+	DatadogBackend = "datadog"
+	// PrometheusBackend evaluates the queries against a PromQL-compatible HTTP API
+	PrometheusBackend = "prometheus"
+
+	metricsBackendConfig = "external_metrics_provider.backend"
+)
+
+// MetricsBackend evaluates the queries of the external metrics.
+// The returned map is indexed by query, a query missing from it has no data.
+// A non-nil error along with an empty map means that all the queries failed.
+type MetricsBackend interface {
+	QueryExternalMetric(queries []string) (map[string]Point, error)
+}
+
+// GetMetricsBackendName returns the name of the configured metrics backend
+func GetMetricsBackendName() string {
+	if backend := config.Datadog.GetString(metricsBackendConfig); backend != "" {
+		return backend
+	}
+	return DatadogBackend
+}
+
+// NewMetricsBackend returns the metrics backend set in `external_metrics_provider.backend`
+func NewMetricsBackend() (MetricsBackend, error) {
+	switch backend := GetMetricsBackendName(); backend {
+	case DatadogBackend:
+		datadogCl, err := NewDatadogClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewProcessor(datadogCl), nil
+	case PrometheusBackend:
+		return NewPrometheusClient()
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q, valid values are %q and %q", backend, DatadogBackend, PrometheusBackend)
+	}
+}
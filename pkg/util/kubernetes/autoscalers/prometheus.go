@@ -0,0 +1,304 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2017-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package autoscalers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utilserror "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	le "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxConcurrentPrometheusQueries limits the number of queries sent at the same time,
+	// as the PromQL API evaluates a single query per request.
+	maxConcurrentPrometheusQueries = 10
+	prometheusQueryEndpoint        = "/api/v1/query"
+	prometheusURLConfig            = "external_metrics_provider.prometheus.url"
+	prometheusBearerTokenConfig    = "external_metrics_provider.prometheus.bearer_token_file"
+	prometheusTimeoutConfig        = "external_metrics_provider.prometheus.timeout"
+)
+
+var (
+	promRequests = telemetry.NewCounterWithOpts("", "prometheus_requests",
+		[]string{"status", le.JoinLeaderLabel}, "Counter of requests made to the Prometheus query API",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+)
+
+// PrometheusClient evaluates the queries of the external metrics against a PromQL-compatible HTTP API.
+type PrometheusClient struct {
+	queryURL        string
+	bearerTokenFile string
+	client          *http.Client
+}
+
+// prometheusResponse is the response of the instant query endpoint of the PromQL API
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// prometheusSample is a [<unix timestamp in s>, "<value>"] pair
+type prometheusSample []interface{}
+
+type prometheusSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  prometheusSample  `json:"value"`
+}
+
+// NewPrometheusClient returns a new PrometheusClient configured with `external_metrics_provider.prometheus.*`
+func NewPrometheusClient() (*PrometheusClient, error) {
+	endpoint := config.Datadog.GetString(prometheusURLConfig)
+	if endpoint == "" {
+		return nil, fmt.Errorf("%s is required to use the %s metrics backend", prometheusURLConfig, PrometheusBackend)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q", prometheusURLConfig, endpoint)
+	}
+
+	return &PrometheusClient{
+		queryURL:        strings.TrimSuffix(endpoint, "/") + prometheusQueryEndpoint,
+		bearerTokenFile: config.Datadog.GetString(prometheusBearerTokenConfig),
+		client: &http.Client{
+			Transport: httputils.CreateHTTPTransport(),
+			Timeout:   time.Duration(config.Datadog.GetInt64(prometheusTimeoutConfig)) * time.Second,
+		},
+	}, nil
+}
+
+// QueryExternalMetric evaluates the queries and returns the resulting points, indexed by query.
+// A query evaluating to several series or to a non-numeric value yields an invalid point,
+// a query evaluating to an empty vector yields no point.
+func (p *PrometheusClient) QueryExternalMetric(queries []string) (map[string]Point, error) {
+	processed := make(map[string]Point, len(queries))
+	if len(queries) == 0 {
+		return processed, nil
+	}
+
+	token, err := p.bearerToken()
+	if err != nil {
+		return processed, err
+	}
+
+	var (
+		lock    sync.Mutex
+		errors  []error
+		waitAll sync.WaitGroup
+	)
+	sem := make(chan struct{}, maxConcurrentPrometheusQueries)
+	waitAll.Add(len(queries))
+	for _, q := range queries {
+		sem <- struct{}{}
+		go func(query string) {
+			defer func() {
+				<-sem
+				waitAll.Done()
+			}()
+			point, found, err := p.queryPrometheus(query, token)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errors = append(errors, err)
+			}
+			if found {
+				processed[query] = point
+			}
+		}(q)
+	}
+	waitAll.Wait()
+
+	log.Debugf("Processed %d queries against %s", len(queries), p.queryURL)
+	return processed, utilserror.NewAggregate(errors)
+}
+
+func (p *PrometheusClient) bearerToken() (string, error) {
+	if p.bearerTokenFile == "" {
+		return "", nil
+	}
+	// The token is read at every refresh as it may be rotated
+	token, err := ioutil.ReadFile(p.bearerTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s: %v", prometheusBearerTokenConfig, err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// queryPrometheus evaluates a single query, found is false when the query has no data.
+// The instant queries stamp their result with the evaluation time, even though they look
+// back up to 5 minutes for the samples of each series, so the timestamp of the point is
+// the time of the sample returned by timestamp(<query>) for a staleness check to be possible.
+// Scalars and series computed by functions or aggregations have no sample time, they keep
+// the evaluation time.
+func (p *PrometheusClient) queryPrometheus(query, token string) (point Point, found bool, err error) {
+	promResp, err := p.evaluate(query, token)
+	if err != nil {
+		return point, false, err
+	}
+	if promResp.Status != "success" {
+		// The query itself is invalid, flag it as such rather than missing
+		now := time.Now().Unix()
+		return Point{Timestamp: now}, true, fmt.Errorf("error while executing query %s: %s: %s", query, promResp.ErrorType, promResp.Error)
+	}
+
+	var sample prometheusSample
+	switch promResp.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(promResp.Data.Result, &sample); err != nil {
+			return point, false, fmt.Errorf("invalid scalar result for query %s: %v", query, err)
+		}
+	case "vector":
+		var series []prometheusSeries
+		if err := json.Unmarshal(promResp.Data.Result, &series); err != nil {
+			return point, false, fmt.Errorf("invalid vector result for query %s: %v", query, err)
+		}
+		if len(series) == 0 {
+			log.Debugf("No data for query: %s", query)
+			return point, false, nil
+		}
+		// We are not able to determine which value we should take for Autoscaling
+		if len(series) > 1 {
+			log.Warnf("Multiple Series found for query: %s. Please change your query to return a single Serie. Results will be flagged as invalid", query)
+			return Point{Timestamp: time.Now().Unix()}, true, nil
+		}
+		sample = series[0].Value
+	default:
+		log.Warnf("Unsupported result type %q for query: %s. Please change your query to return a scalar or an instant vector. Results will be flagged as invalid", promResp.Data.ResultType, query)
+		return Point{Timestamp: time.Now().Unix()}, true, nil
+	}
+
+	point, err = sample.toPoint()
+	if err != nil {
+		log.Warnf("Invalid value for query: %s: %v. Results will be flagged as invalid", query, err)
+		return Point{Timestamp: time.Now().Unix()}, true, nil
+	}
+
+	if promResp.Data.ResultType == "vector" {
+		point.Timestamp, err = p.sampleTimestamp(query, token)
+		if err != nil {
+			// Without the sample time, the staleness of the point is unknown
+			return Point{Timestamp: time.Now().Unix()}, true, fmt.Errorf("error while retrieving the sample time of query %s: %v", query, err)
+		}
+	}
+
+	// Prometheus submissions on the processed external metrics
+	metricsEval.Set(point.Value, query, le.JoinLeaderValue)
+	metricsDelay.Set(float64(time.Now().Unix()-point.Timestamp), query, le.JoinLeaderValue)
+
+	return point, true, nil
+}
+
+// sampleTimestamp returns the time of the sample of a query evaluating to a single series
+func (p *PrometheusClient) sampleTimestamp(query, token string) (int64, error) {
+	promResp, err := p.evaluate("timestamp("+query+")", token)
+	if err != nil {
+		return 0, err
+	}
+	if promResp.Status != "success" {
+		return 0, fmt.Errorf("%s: %s", promResp.ErrorType, promResp.Error)
+	}
+	var series []prometheusSeries
+	if promResp.Data.ResultType == "vector" {
+		if err := json.Unmarshal(promResp.Data.Result, &series); err != nil {
+			return 0, err
+		}
+	}
+	if len(series) != 1 {
+		return 0, fmt.Errorf("expected a single series, got %d", len(series))
+	}
+	timestamp, err := series[0].Value.toPoint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(timestamp.Value), nil
+}
+
+// evaluate sends an instant query to the PromQL API. An error is returned when no
+// PromQL response is received, the errors of the query are set in the response.
+func (p *PrometheusClient) evaluate(query, token string) (*prometheusResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, p.queryURL, strings.NewReader(url.Values{"query": {query}}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		promRequests.Inc("error", le.JoinLeaderValue)
+		return nil, fmt.Errorf("error while executing query %s: %v", query, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		promRequests.Inc("error", le.JoinLeaderValue)
+		return nil, fmt.Errorf("error while reading the result of query %s: %v", query, err)
+	}
+
+	var promResp prometheusResponse
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		promRequests.Inc("error", le.JoinLeaderValue)
+		return nil, fmt.Errorf("unexpected response to query %s, status code %d: %v", query, resp.StatusCode, err)
+	}
+	if promResp.Status != "success" {
+		promRequests.Inc("error", le.JoinLeaderValue)
+	} else {
+		promRequests.Inc("success", le.JoinLeaderValue)
+	}
+	return &promResp, nil
+}
+
+func (s prometheusSample) toPoint() (Point, error) {
+	if len(s) != 2 {
+		return Point{}, fmt.Errorf("malformed sample %v", []interface{}(s))
+	}
+	ts, ok := s[0].(float64)
+	if !ok {
+		return Point{}, fmt.Errorf("malformed timestamp %v", s[0])
+	}
+	raw, ok := s[1].(string)
+	if !ok {
+		return Point{}, fmt.Errorf("malformed value %v", s[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Point{}, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Point{}, fmt.Errorf("non-finite value %s", raw)
+	}
+
+	return Point{
+		Value:     value,
+		Timestamp: int64(ts),
+		Valid:     true,
+	}, nil
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2017-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package autoscalers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func newTestPrometheusClient(t *testing.T, url string) *PrometheusClient {
+	mockConfig := config.Mock()
+	mockConfig.Set(prometheusURLConfig, url)
+	p, err := NewPrometheusClient()
+	require.NoError(t, err)
+	return p
+}
+
+func TestNewPrometheusClient(t *testing.T) {
+	mockConfig := config.Mock()
+
+	mockConfig.Set(prometheusURLConfig, "")
+	_, err := NewPrometheusClient()
+	assert.Error(t, err)
+
+	mockConfig.Set(prometheusURLConfig, "prometheus:9090")
+	_, err = NewPrometheusClient()
+	assert.Error(t, err)
+
+	mockConfig.Set(prometheusURLConfig, "http://prometheus:9090/")
+	p, err := NewPrometheusClient()
+	require.NoError(t, err)
+	assert.Equal(t, "http://prometheus:9090/api/v1/query", p.queryURL)
+}
+
+func TestNewMetricsBackend(t *testing.T) {
+	mockConfig := config.Mock()
+
+	mockConfig.Set(metricsBackendConfig, "unknown")
+	_, err := NewMetricsBackend()
+	assert.Error(t, err)
+
+	mockConfig.Set(metricsBackendConfig, PrometheusBackend)
+	mockConfig.Set(prometheusURLConfig, "http://prometheus:9090")
+	backend, err := NewMetricsBackend()
+	require.NoError(t, err)
+	assert.IsType(t, &PrometheusClient{}, backend)
+}
+
+func TestPrometheusQueryExternalMetric(t *testing.T) {
+	now := time.Now().Unix()
+	responses := map[string]string{
+		"single": fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d.123,"42.5"]}]}}`, now),
+		// the sample time of the single series
+		"timestamp(single)": fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d.123,"%d.5"]}]}}`, now, now-5),
+		"scalar":            fmt.Sprintf(`{"status":"success","data":{"resultType":"scalar","result":[%d,"3"]}}`, now-60),
+		"empty":             `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"multiple": fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1"},"value":[%d,"1"]},{"metric":{"a":"2"},"value":[%d,"2"]}]}}`,
+			now, now),
+		"nan":    fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"NaN"]}]}}`, now),
+		"matrix": `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		query := r.FormValue("query")
+		if resp, found := responses[query]; found {
+			w.Write([]byte(resp)) //nolint:errcheck
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`)) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("token\n") //nolint:errcheck
+	tokenFile.Close()
+
+	p := newTestPrometheusClient(t, ts.URL)
+	p.bearerTokenFile = tokenFile.Name()
+
+	points, err := p.QueryExternalMetric([]string{"single", "scalar", "empty", "multiple", "nan", "matrix", "invalid("})
+	assert.EqualError(t, err, "error while executing query invalid(: bad_data: parse error")
+
+	assert.Equal(t, Point{Value: 42.5, Timestamp: now - 5, Valid: true}, points["single"])
+	assert.Equal(t, Point{Value: 3, Timestamp: now - 60, Valid: true}, points["scalar"])
+	assert.NotContains(t, points, "empty")
+	for _, query := range []string{"multiple", "nan", "matrix", "invalid("} {
+		require.Contains(t, points, query)
+		assert.False(t, points[query].Valid, query)
+		assert.WithinDuration(t, time.Now(), time.Unix(points[query].Timestamp, 0), time.Minute, query)
+	}
+
+	points, err = p.QueryExternalMetric(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, points)
+}
+
+func TestPrometheusQueryExternalMetricSampleTime(t *testing.T) {
+	now := time.Now().Unix()
+	// The instant queries are stamped with the evaluation time, even when they
+	// return a sample scraped minutes ago
+	responses := map[string]string{
+		"old":             fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"1"]}]}}`, now),
+		"timestamp(old)":  fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"%d"]}]}}`, now, now-240),
+		"gone":            fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%d,"2"]}]}}`, now),
+		"timestamp(gone)": `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[r.FormValue("query")])) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	p := newTestPrometheusClient(t, ts.URL)
+
+	points, err := p.QueryExternalMetric([]string{"old"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{Value: 1, Timestamp: now - 240, Valid: true}, points["old"])
+
+	// Without the sample time, the point is flagged as invalid
+	points, err = p.QueryExternalMetric([]string{"gone"})
+	assert.EqualError(t, err, "error while retrieving the sample time of query gone: expected a single series, got 0")
+	require.Contains(t, points, "gone")
+	assert.False(t, points["gone"].Valid)
+}
+
+func TestPrometheusQueryExternalMetricGlobalError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	ts.Close()
+
+	p := newTestPrometheusClient(t, ts.URL)
+	points, err := p.QueryExternalMetric([]string{"a", "b"})
+	assert.Error(t, err)
+	assert.Empty(t, points)
+
+	p.bearerTokenFile = "/does/not/exist"
+	points, err = p.QueryExternalMetric([]string{"a"})
+	assert.Error(t, err)
+	assert.Empty(t, points)
+}
@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// The libpod API is served by podman >= 2.0, its version is independent from the podman version
+	apiBaseURL = "http://podman/v1.0.0/libpod"
+
+	// ContainerStartEvent is the action of the event sent when a container starts
+	ContainerStartEvent = "start"
+	// ContainerDiedEvent is the action of the event sent when the main process of a container exits
+	ContainerDiedEvent = "died"
+)
+
+var (
+	globalClient *Client
+	once         sync.Once
+)
+
+// Client is a client of the libpod REST API served by the podman socket
+type Client struct {
+	socketPath   string
+	queryTimeout time.Duration
+	httpClient   *http.Client
+}
+
+// ContainerSummary is the subset of a container of the libpod list endpoint used by the agent
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Image  string            `json:"Image"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Container is the subset of the libpod inspect payload used by the agent
+type Container struct {
+	ID        string `json:"Id"`
+	Name      string `json:"Name"`
+	ImageName string `json:"ImageName"`
+	State     struct {
+		Running bool `json:"Running"`
+		Pid     int  `json:"Pid"`
+	} `json:"State"`
+	Config struct {
+		Hostname string            `json:"Hostname"`
+		Labels   map[string]string `json:"Labels"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		IPAddress string `json:"IPAddress"`
+		// Ports are indexed by `<port>/<protocol>`
+		Ports    map[string][]HostPort `json:"Ports"`
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// HostPort is a port of the host a container port is published on
+type HostPort struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// ContainerEvent is a container lifecycle event of the libpod events endpoint
+type ContainerEvent struct {
+	Action string     `json:"Action"`
+	Actor  EventActor `json:"Actor"`
+}
+
+// EventActor is the object an event is about
+type EventActor struct {
+	ID string `json:"ID"`
+}
+
+// GetClient returns a Client for the socket set in `podman_socket_path`
+func GetClient() *Client {
+	once.Do(func() {
+		globalClient = NewClient(
+			config.Datadog.GetString("podman_socket_path"),
+			config.Datadog.GetDuration("podman_query_timeout")*time.Second,
+		)
+	})
+	return globalClient
+}
+
+// NewClient returns a Client for the given unix socket
+func NewClient(socketPath string, queryTimeout time.Duration) *Client {
+	return &Client{
+		socketPath:   socketPath,
+		queryTimeout: queryTimeout,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// ListContainers returns the running containers
+func (c *Client) ListContainers() ([]ContainerSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+
+	var containers []ContainerSummary
+	err := c.get(ctx, "/containers/json", &containers)
+	return containers, err
+}
+
+// InspectContainer returns the details of a container
+func (c *Client) InspectContainer(id string) (*Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+
+	container := &Container{}
+	if err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/json", container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// SubscribeToContainerEvents streams the container events with the given actions until the
+// context is cancelled. The error channel receives a single value when the stream ends.
+func (c *Client) SubscribeToContainerEvents(ctx context.Context, actions ...string) (<-chan ContainerEvent, <-chan error) {
+	events := make(chan ContainerEvent)
+	errs := make(chan error, 1)
+
+	filters, _ := json.Marshal(map[string][]string{
+		"type":  {"container"},
+		"event": actions,
+	})
+	resp, err := c.do(ctx, "/events", url.Values{"stream": {"true"}, "filters": {string(filters)}})
+	if err != nil {
+		errs <- err
+		return events, errs
+	}
+
+	go func() {
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var ev ContainerEvent
+			if err := decoder.Decode(&ev); err != nil {
+				errs <- fmt.Errorf("podman event stream interrupted: %v", err)
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	resp, err := c.do(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) do(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := apiBaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error while querying the podman API on %s: %v", c.socketPath, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		// Errors are returned as {"cause": "...", "message": "...", "response": <code>}
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = string(body)
+		}
+		return nil, fmt.Errorf("unexpected status code %d for %s: %s", resp.StatusCode, path, apiErr.Message)
+	}
+	return resp, nil
+}
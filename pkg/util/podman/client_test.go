@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build !windows
+
+package podman
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) (*Client, func()) {
+	dir, err := ioutil.TempDir("", "podman")
+	require.NoError(t, err)
+	socketPath := filepath.Join(dir, "podman.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Listener.Close()
+	ts.Listener = l
+	ts.Start()
+
+	return NewClient(socketPath, time.Second), func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestListContainers(t *testing.T) {
+	client, cleanup := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.0.0/libpod/containers/json", r.URL.Path)
+		w.Write([]byte(`[{"Id":"abc","Image":"docker.io/library/redis:latest","Names":["redis"],"Labels":{"foo":"bar"},"State":"running"}]`)) //nolint:errcheck
+	}))
+	defer cleanup()
+
+	containers, err := client.ListContainers()
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerSummary{{
+		ID:     "abc",
+		Image:  "docker.io/library/redis:latest",
+		Names:  []string{"redis"},
+		Labels: map[string]string{"foo": "bar"},
+	}}, containers)
+}
+
+func TestInspectContainer(t *testing.T) {
+	client, cleanup := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0.0/libpod/containers/abc/json" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"cause":"no such container","message":"no container with name or ID \"def\" found: no such container","response":404}`)) //nolint:errcheck
+			return
+		}
+		w.Write([]byte(`{
+			"Id": "abc",
+			"Name": "redis",
+			"ImageName": "docker.io/library/redis:latest",
+			"State": {"Status": "running", "Running": true, "Pid": 1234},
+			"Config": {"Hostname": "abc", "Labels": {"foo": "bar"}},
+			"NetworkSettings": {
+				"IPAddress": "10.88.0.5",
+				"Ports": {"6379/tcp": [{"HostIp": "", "HostPort": "6379"}]},
+				"Networks": {"podman": {"IPAddress": "10.88.0.5"}}
+			}
+		}`)) //nolint:errcheck
+	}))
+	defer cleanup()
+
+	ctn, err := client.InspectContainer("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", ctn.ID)
+	assert.Equal(t, "redis", ctn.Name)
+	assert.Equal(t, "docker.io/library/redis:latest", ctn.ImageName)
+	assert.True(t, ctn.State.Running)
+	assert.Equal(t, 1234, ctn.State.Pid)
+	assert.Equal(t, "abc", ctn.Config.Hostname)
+	assert.Equal(t, map[string]string{"foo": "bar"}, ctn.Config.Labels)
+	assert.Equal(t, "10.88.0.5", ctn.NetworkSettings.Networks["podman"].IPAddress)
+	assert.Equal(t, []HostPort{{HostPort: "6379"}}, ctn.NetworkSettings.Ports["6379/tcp"])
+
+	_, err = client.InspectContainer("def")
+	assert.EqualError(t, err, `unexpected status code 404 for /containers/def/json: no container with name or ID "def" found: no such container`)
+}
+
+func TestSubscribeToContainerEvents(t *testing.T) {
+	client, cleanup := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.0.0/libpod/events", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("stream"))
+		assert.JSONEq(t, `{"type":["container"],"event":["start","died"]}`, r.URL.Query().Get("filters"))
+		w.Write([]byte(`{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"image":"redis"}}}` + "\n")) //nolint:errcheck
+		w.Write([]byte(`{"Type":"container","Action":"died","Actor":{"ID":"abc","Attributes":{"image":"redis"}}}` + "\n"))  //nolint:errcheck
+	}))
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.SubscribeToContainerEvents(ctx, ContainerStartEvent, ContainerDiedEvent)
+
+	for _, action := range []string{ContainerStartEvent, ContainerDiedEvent} {
+		select {
+		case ev := <-events:
+			assert.Equal(t, action, ev.Action)
+			assert.Equal(t, "abc", ev.Actor.ID)
+		case err := <-errs:
+			require.FailNow(t, "unexpected error", err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for events")
+		}
+	}
+
+	// The stream ends with the response
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for the end of the stream")
+	}
+}
@@ -37,6 +37,18 @@ func (tb *TagsBuilder) SortUniq() {
 	tb.data = SortUniqInPlace(tb.data)
 }
 
+// Filter removes in place the tags for which keep returns false
+func (tb *TagsBuilder) Filter(keep func(tag string) bool) {
+	n := 0
+	for _, tag := range tb.data {
+		if keep(tag) {
+			tb.data[n] = tag
+			n++
+		}
+	}
+	tb.data = tb.data[:n]
+}
+
 // Reset resets the size of the builder to 0 without discaring the internal
 // buffer
 func (tb *TagsBuilder) Reset() {
@@ -44,6 +44,17 @@ func TestTagsBuilderSortUniq(t *testing.T) {
 	assert.Equal(t, []string{"a", "b", "c"}, tb.data)
 }
 
+func TestTagsBuilderFilter(t *testing.T) {
+	tb := NewTagsBuilder()
+
+	tb.Append("a:1", "b:2", "a:3", "c")
+	tb.Filter(func(tag string) bool { return tag[0] != 'a' })
+	assert.Equal(t, []string{"b:2", "c"}, tb.data)
+
+	tb.Filter(func(string) bool { return false })
+	assert.Equal(t, []string{}, tb.data)
+}
+
 func TestTagsBuilderReset(t *testing.T) {
 	tb := NewTagsBuilder()
 